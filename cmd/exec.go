@@ -0,0 +1,413 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/history"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit statuses of the exec command
+const (
+	execExitAllOK   = 0 // The command succeeded on every host
+	execExitSomeErr = 1 // The command failed or could not run on at least one host
+	execExitError   = 2 // The command could not be run at all
+)
+
+var (
+	// execConcurrency limits the number of hosts the command runs on at once
+	execConcurrency int
+	// execTimeout is the time the command may run on each host
+	execTimeout time.Duration
+	// execGroup prints the output of each host in one block once it finishes
+	execGroup bool
+	// execOutputDir is the directory the output of each host is written to
+	execOutputDir string
+	// execTagsOnly matches the queries against tags only
+	execTagsOnly bool
+	// execAll runs the command on every host
+	execAll bool
+)
+
+// execSSHBinary is the ssh client run for each host
+var execSSHBinary = "ssh"
+
+var execCmd = &cobra.Command{
+	Use:   "exec <host|query...> -- <command...>",
+	Short: "Run a command on every host matching a query",
+	Long: `Run a command on several hosts at once and summarise the exit codes.
+
+The arguments before -- are host names or search queries matching host names,
+hostnames and tags (only tags with --tags). The arguments after -- are the
+command to run. Use --all to run the command on every host.
+
+Each line of output is prefixed with the host it comes from, or with --group
+the output of each host is printed in one block once it finishes. A summary
+of the exit codes follows. With --output-dir the output of each host is also
+written to <dir>/<host>.log.
+
+The command runs without a terminal and ssh never prompts for a password
+(BatchMode), so hosts you cannot log in to with a key fail right away.
+
+The exit status is 0 when the command succeeded on every host, 1 when it
+failed or could not run on at least one host and 2 when it could not be run.
+
+Examples:
+  sshm exec web -- uptime
+  sshm exec --tags prod -j 20 -- sudo systemctl is-active nginx
+  sshm exec db --group --timeout 30s -- df -h /var
+  sshm exec --all --output-dir ./out -- cat /etc/os-release`,
+	Args: cobra.ArbitraryArgs,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if cmd.ArgsLenAtDash() >= 0 {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		return RootCmd.ValidArgsFunction(cmd, nil, toComplete)
+	},
+	Run: runExec,
+}
+
+// execResult is the outcome of the command on one host
+type execResult struct {
+	HostName string
+	ExitCode int // -1 when the command did not exit on its own
+	Start    time.Time
+	Duration time.Duration
+	TimedOut bool
+	Err      error // Why the command could not run, if it could not
+}
+
+func runExec(cmd *cobra.Command, args []string) {
+	queries, command := splitExecArgs(args, cmd.ArgsLenAtDash())
+	if len(command) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no command given (use: sshm exec <query> -- <command>)")
+		os.Exit(execExitError)
+	}
+	if len(queries) == 0 && !execAll {
+		fmt.Fprintln(os.Stderr, "Error: no host query given (use --all to run the command on every host)")
+		os.Exit(execExitError)
+	}
+
+	var hosts []config.SSHHost
+	var err error
+
+	if configFile != "" {
+		hosts, err = config.ParseSSHConfigFile(configFile)
+	} else {
+		hosts, err = config.ParseSSHConfig()
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading SSH config file: %v\n", err)
+		os.Exit(execExitError)
+	}
+
+	targets := selectTargets(hosts, queries, execTagsOnly)
+	if len(targets) == 0 {
+		fmt.Fprintf(os.Stderr, "No hosts found matching '%s'.\n", strings.Join(queries, " "))
+		os.Exit(execExitError)
+	}
+
+	if execOutputDir != "" {
+		if err := os.MkdirAll(execOutputDir, 0755); err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating output directory: %v\n", err)
+			os.Exit(execExitError)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	names := make([]string, 0, len(targets))
+	for _, host := range targets {
+		names = append(names, host.Name)
+	}
+
+	results := runOnHosts(ctx, os.Stdout, os.Stderr, names, command)
+	fmt.Fprintln(os.Stdout)
+	writeExecSummary(os.Stdout, results)
+
+	if historyManager, err := history.NewHistoryManager(configFile); err == nil {
+		for _, session := range execSessions(results, command) {
+			recordSession(historyManager, session)
+		}
+	}
+
+	os.Exit(execExitCode(results))
+}
+
+// splitExecArgs splits the arguments into the host queries before -- and
+// the command after it. dash is the index of the first argument after --,
+// or -1 when there is none.
+func splitExecArgs(args []string, dash int) ([]string, []string) {
+	if dash < 0 {
+		return args, nil
+	}
+	return args[:dash], args[dash:]
+}
+
+// execSSHArgs returns the arguments of the ssh client running the command on a host
+func execSSHArgs(hostName string, command []string) []string {
+	var args []string
+	if configFile != "" {
+		args = append(args, "-F", configFile)
+	}
+	// Never prompt: the command runs on many hosts at once without a terminal
+	args = append(args, "-T", "-o", "BatchMode=yes", hostName, "--")
+	return append(args, command...)
+}
+
+// runOnHosts runs the command on every host, at most execConcurrency at
+// once, writing their output to stdout and stderr. It returns the results in
+// host order.
+func runOnHosts(ctx context.Context, stdout, stderr io.Writer, hostNames []string, command []string) []execResult {
+	limit := execConcurrency
+	if limit <= 0 {
+		limit = len(hostNames)
+	}
+	slots := make(chan struct{}, max(limit, 1))
+
+	width := 0
+	for _, name := range hostNames {
+		width = max(width, len(name))
+	}
+
+	// Hosts write their output one line, or one block, at a time
+	var outputMutex sync.Mutex
+	results := make([]execResult, len(hostNames))
+	var wg sync.WaitGroup
+	for i, name := range hostNames {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			var out, errOut io.Writer
+			var group bytes.Buffer
+			var prefixed []*prefixWriter
+			if execGroup {
+				out, errOut = &group, &group
+			} else {
+				prefix := fmt.Sprintf("%-*s | ", width, name)
+				prefixed = []*prefixWriter{
+					{w: stdout, prefix: prefix, mutex: &outputMutex},
+					{w: stderr, prefix: prefix, mutex: &outputMutex},
+				}
+				out, errOut = prefixed[0], prefixed[1]
+			}
+
+			var logFile *os.File
+			if execOutputDir != "" {
+				var err error
+				logFile, err = os.Create(filepath.Join(execOutputDir, execLogName(name)))
+				if err != nil {
+					outputMutex.Lock()
+					fmt.Fprintf(stderr, "Warning: could not write the output of %s: %v\n", name, err)
+					outputMutex.Unlock()
+				} else {
+					defer logFile.Close()
+					if execGroup {
+						// A single writer keeps stdout and stderr in order
+						out = io.MultiWriter(&group, logFile)
+						errOut = out
+					} else {
+						out, errOut = io.MultiWriter(out, logFile), io.MultiWriter(errOut, logFile)
+					}
+				}
+			}
+
+			results[i] = runOnHost(ctx, name, command, out, errOut)
+
+			outputMutex.Lock()
+			defer outputMutex.Unlock()
+			for _, w := range prefixed {
+				w.flush()
+			}
+			if execGroup {
+				fmt.Fprintf(stdout, "── %s (%s) ──\n", name, describeExecResult(results[i]))
+				stdout.Write(group.Bytes())
+				if group.Len() > 0 && !bytes.HasSuffix(group.Bytes(), []byte("\n")) {
+					fmt.Fprintln(stdout)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// runOnHost runs the command on a host, giving up after execTimeout
+func runOnHost(ctx context.Context, hostName string, command []string, stdout, stderr io.Writer) execResult {
+	if execTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, execTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	sshCmd := exec.CommandContext(ctx, execSSHBinary, execSSHArgs(hostName, command)...)
+	sshCmd.Stdout = stdout
+	sshCmd.Stderr = stderr
+	// Do not wait for processes the remote command left holding the output
+	sshCmd.WaitDelay = time.Second
+
+	err := sshCmd.Run()
+	result := execResult{HostName: hostName, ExitCode: -1, Start: start, Duration: time.Since(start)}
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.TimedOut = true
+	case ctx.Err() != nil:
+		result.Err = errors.New("interrupted")
+	case err == nil:
+		result.ExitCode = 0
+	case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+		result.ExitCode = exitErr.ExitCode()
+	default:
+		result.Err = err
+	}
+	return result
+}
+
+// execSessions returns the sessions to log for the results of a command
+func execSessions(results []execResult, command []string) []history.Session {
+	sessions := make([]history.Session, 0, len(results))
+	for _, result := range results {
+		session := history.NewSession(result.HostName, history.SessionCommand, configFile)
+		session.Command = strings.Join(command, " ")
+		session.Start = result.Start
+		session.End = result.Start.Add(result.Duration)
+		session.Duration = result.Duration.Seconds()
+		session.ExitCode = result.ExitCode
+		if result.TimedOut || result.Err != nil {
+			session.Error = describeExecResult(result)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// execLogName returns the name of the file the output of a host is written to
+func execLogName(hostName string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(hostName) + ".log"
+}
+
+// describeExecResult describes the outcome of the command on a host
+func describeExecResult(result execResult) string {
+	switch {
+	case result.TimedOut:
+		return fmt.Sprintf("timed out after %s", execTimeout)
+	case result.Err != nil:
+		return result.Err.Error()
+	case result.ExitCode == 0:
+		return "ok"
+	case result.ExitCode == 255:
+		// ssh exits with 255 when it cannot connect or log in
+		return "ssh failed (exit 255)"
+	}
+	return fmt.Sprintf("exit %d", result.ExitCode)
+}
+
+// writeExecSummary writes the exit code of each host as a table followed by a count
+func writeExecSummary(w io.Writer, results []execResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tEXIT\tDURATION\tRESULT")
+
+	ok := 0
+	for _, result := range results {
+		exitCode := "-"
+		if result.ExitCode >= 0 {
+			exitCode = fmt.Sprintf("%d", result.ExitCode)
+		}
+		if result.ExitCode == 0 {
+			ok++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", result.HostName, exitCode, result.Duration.Round(time.Millisecond), describeExecResult(result))
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\n%d/%d host(s) succeeded\n", ok, len(results))
+}
+
+// execExitCode returns the exit status for the results of the command
+func execExitCode(results []execResult) int {
+	if len(results) == 0 {
+		return execExitError
+	}
+	for _, result := range results {
+		if result.ExitCode != 0 {
+			return execExitSomeErr
+		}
+	}
+	return execExitAllOK
+}
+
+// prefixWriter writes each complete line with a prefix. Lines are written
+// while holding mutex, so that lines of different hosts do not interleave.
+type prefixWriter struct {
+	w       io.Writer
+	prefix  string
+	mutex   *sync.Mutex
+	partial []byte // Start of a line whose end was not written yet
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	data := append(p.partial, b...)
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		p.partial = data
+		return len(b), nil
+	}
+
+	var out bytes.Buffer
+	for _, line := range bytes.SplitAfter(data[:end+1], []byte("\n")) {
+		if len(line) > 0 {
+			out.WriteString(p.prefix)
+			out.Write(line)
+		}
+	}
+	p.partial = append([]byte(nil), data[end+1:]...)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if _, err := p.w.Write(out.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// flush writes the last line when it does not end with a newline.
+// The caller holds the mutex.
+func (p *prefixWriter) flush() {
+	if len(p.partial) > 0 {
+		fmt.Fprintf(p.w, "%s%s\n", p.prefix, p.partial)
+		p.partial = nil
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(execCmd)
+
+	execCmd.Flags().IntVarP(&execConcurrency, "concurrency", "j", 10, "Maximum number of hosts the command runs on at once (0 for no limit)")
+	execCmd.Flags().DurationVar(&execTimeout, "timeout", 0, "Time the command may run on each host (0 for no limit)")
+	execCmd.Flags().BoolVarP(&execGroup, "group", "g", false, "Print the output of each host in one block once it finishes")
+	execCmd.Flags().StringVarP(&execOutputDir, "output-dir", "o", "", "Also write the output of each host to <dir>/<host>.log")
+	execCmd.Flags().BoolVar(&execTagsOnly, "tags", false, "Match the queries against tags only")
+	execCmd.Flags().BoolVar(&execAll, "all", false, "Run the command on every host")
+}
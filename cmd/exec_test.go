@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExecCommandRegistration(t *testing.T) {
+	found := false
+	for _, cmd := range RootCmd.Commands() {
+		if cmd.Name() == "exec" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("Exec command not found in root command")
+	}
+
+	for _, name := range []string{"concurrency", "timeout", "group", "output-dir", "tags", "all"} {
+		if execCmd.Flags().Lookup(name) == nil {
+			t.Errorf("Expected --%s flag to be defined", name)
+		}
+	}
+}
+
+func TestSplitExecArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		dash        int
+		wantQueries []string
+		wantCommand []string
+	}{
+		{"queries and command", []string{"web", "db", "uptime", "-p"}, 2, []string{"web", "db"}, []string{"uptime", "-p"}},
+		{"no query", []string{"uptime"}, 0, []string{}, []string{"uptime"}},
+		{"no dash", []string{"web"}, -1, []string{"web"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries, command := splitExecArgs(tt.args, tt.dash)
+			if !reflect.DeepEqual(queries, tt.wantQueries) || !reflect.DeepEqual(command, tt.wantCommand) {
+				t.Errorf("splitExecArgs() = %v, %v, want %v, %v", queries, command, tt.wantQueries, tt.wantCommand)
+			}
+		})
+	}
+}
+
+func TestExecSSHArgs(t *testing.T) {
+	defer func(previous string) { configFile = previous }(configFile)
+
+	configFile = ""
+	want := []string{"-T", "-o", "BatchMode=yes", "web", "--", "df", "-h"}
+	if got := execSSHArgs("web", []string{"df", "-h"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("execSSHArgs() = %v, want %v", got, want)
+	}
+
+	configFile = "/tmp/ssh_config"
+	if got := execSSHArgs("web", []string{"uptime"}); got[0] != "-F" || got[1] != "/tmp/ssh_config" {
+		t.Errorf("execSSHArgs() = %v, want the config file first", got)
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var output bytes.Buffer
+	w := &prefixWriter{w: &output, prefix: "web | ", mutex: &sync.Mutex{}}
+
+	w.Write([]byte("first\nsec"))
+	w.Write([]byte("ond\nthi"))
+	if got := output.String(); got != "web | first\nweb | second\n" {
+		t.Errorf("Expected complete lines only, got %q", got)
+	}
+
+	w.flush()
+	if got := output.String(); !strings.HasSuffix(got, "web | thi\n") {
+		t.Errorf("Expected flush() to write the last line, got %q", got)
+	}
+}
+
+// fakeSSH installs a script standing in for ssh: it prints the host it runs
+// on, and fails on "broken" and hangs on "slow"
+func fakeSSH(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ssh is a shell script")
+	}
+
+	script := `#!/bin/sh
+host=$4
+case "$host" in
+broken) echo "command not found" >&2; exit 127 ;;
+slow) exec sleep 10 ;;
+esac
+echo "hello from $host"
+printf "no newline"
+`
+	path := filepath.Join(t.TempDir(), "ssh")
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := execSSHBinary
+	execSSHBinary = path
+	t.Cleanup(func() { execSSHBinary = previous })
+}
+
+// setExecFlags sets the exec flags for the duration of a test
+func setExecFlags(t *testing.T, group bool, timeout time.Duration, outputDir string) {
+	t.Helper()
+
+	previousGroup, previousTimeout, previousDir := execGroup, execTimeout, execOutputDir
+	execGroup, execTimeout, execOutputDir = group, timeout, outputDir
+	t.Cleanup(func() { execGroup, execTimeout, execOutputDir = previousGroup, previousTimeout, previousDir })
+}
+
+func TestRunOnHosts(t *testing.T) {
+	fakeSSH(t)
+	outputDir := t.TempDir()
+	setExecFlags(t, false, 500*time.Millisecond, outputDir)
+
+	var stdout, stderr bytes.Buffer
+	results := runOnHosts(context.Background(), &stdout, &stderr, []string{"web", "broken", "slow"}, []string{"uptime"})
+
+	for _, expected := range []string{"web    | hello from web\n", "web    | no newline\n"} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("Expected stdout to contain %q, got:\n%s", expected, stdout.String())
+		}
+	}
+	if !strings.Contains(stderr.String(), "broken | command not found\n") {
+		t.Errorf("Expected prefixed stderr, got:\n%s", stderr.String())
+	}
+
+	if results[0].ExitCode != 0 || results[1].ExitCode != 127 || !results[2].TimedOut {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+	if got := execExitCode(results); got != execExitSomeErr {
+		t.Errorf("execExitCode() = %d, want %d", got, execExitSomeErr)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "web.log"))
+	if err != nil || string(data) != "hello from web\nno newline" {
+		t.Errorf("Expected the output of web in web.log, got %q (%v)", data, err)
+	}
+
+	var summary bytes.Buffer
+	writeExecSummary(&summary, results)
+	for _, expected := range []string{"HOST", "exit 127", "timed out after 500ms", "1/3 host(s) succeeded"} {
+		if !strings.Contains(summary.String(), expected) {
+			t.Errorf("Expected summary to contain %q, got:\n%s", expected, summary.String())
+		}
+	}
+}
+
+func TestRunOnHostsGrouped(t *testing.T) {
+	fakeSSH(t)
+	setExecFlags(t, true, 0, "")
+
+	var stdout, stderr bytes.Buffer
+	results := runOnHosts(context.Background(), &stdout, &stderr, []string{"web", "db"}, []string{"uptime"})
+
+	for _, host := range []string{"web", "db"} {
+		block := "── " + host + " (ok) ──\nhello from " + host + "\nno newline\n"
+		if !strings.Contains(stdout.String(), block) {
+			t.Errorf("Expected a block for %s, got:\n%s", host, stdout.String())
+		}
+	}
+	if got := execExitCode(results); got != execExitAllOK {
+		t.Errorf("execExitCode() = %d, want %d", got, execExitAllOK)
+	}
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/history"
+
+	"github.com/spf13/cobra"
+)
+
+// forwardNoShell sets up the forwards without running a remote shell
+var forwardNoShell bool
+
+var forwardCmd = &cobra.Command{
+	Use:   "forward <host> [profile]",
+	Short: "Set up the port forwards of a saved profile",
+	Long: `Connect to a host with the port forwards of a saved profile.
+
+Profiles are named sets of local (-L), remote (-R) and dynamic (-D) forwards
+saved per host from the port forwarding form of the TUI (key f). Without a
+profile name, the profiles saved for the host are listed.
+
+Examples:
+  sshm forward db1                # List the profiles of db1
+  sshm forward db1 postgres       # Connect with the forwards of the postgres profile
+  sshm forward -N k8s k8s-api     # Only forward, without a remote shell`,
+	Args: cobra.RangeArgs(1, 2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			return RootCmd.ValidArgsFunction(cmd, nil, toComplete)
+		case 1:
+			historyManager, err := history.NewHistoryManager(configFile)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			var names []string
+			for _, profile := range historyManager.GetPortForwardProfiles(args[0]) {
+				if strings.HasPrefix(profile.Name, toComplete) {
+					names = append(names, profile.Name)
+				}
+			}
+			return names, cobra.ShellCompDirectiveNoFileComp
+		default:
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+	},
+	Run: runForward,
+}
+
+func runForward(cmd *cobra.Command, args []string) {
+	hostName := args[0]
+
+	var hostFound bool
+	var err error
+
+	if configFile != "" {
+		hostFound, err = config.QuickHostExistsInFile(hostName, configFile)
+	} else {
+		hostFound, err = config.QuickHostExists(hostName)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error checking SSH config: %v\n", err)
+		os.Exit(1)
+	}
+	if !hostFound {
+		fmt.Fprintf(os.Stderr, "Error: Host '%s' not found in SSH configuration.\n", hostName)
+		os.Exit(1)
+	}
+
+	historyManager, err := history.NewHistoryManager(configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading history: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(args) == 1 {
+		writeForwardProfiles(os.Stdout, hostName, historyManager.GetPortForwardProfiles(hostName))
+		return
+	}
+
+	profile, exists := historyManager.GetPortForwardProfile(hostName, args[1])
+	if !exists {
+		fmt.Fprintf(os.Stderr, "Error: no port forwarding profile '%s' for %s (use 'sshm forward %s' to list them).\n", args[1], hostName, hostName)
+		os.Exit(1)
+	}
+
+	sshArgs, err := forwardSSHArgs(hostName, profile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid forward in profile '%s': %v\n", profile.Name, err)
+		os.Exit(1)
+	}
+
+	if err := historyManager.MarkPortForwardProfileUsed(hostName, profile.Name); err != nil {
+		fmt.Printf("Warning: Could not record profile use: %v\n", err)
+	}
+	if err := historyManager.RecordConnection(hostName); err != nil {
+		fmt.Printf("Warning: Could not record connection history: %v\n", err)
+	}
+
+	fmt.Printf("Connecting to %s with %s...\n", hostName, profile.Summary())
+
+	session := history.NewSession(hostName, history.SessionForward, configFile)
+	session.Forwards = profile.Summary()
+
+	sshCmd := exec.Command("ssh", sshArgs...)
+	sshCmd.Stdin = os.Stdin
+	sshCmd.Stdout = os.Stdout
+	sshCmd.Stderr = os.Stderr
+
+	err = sshCmd.Run()
+	session.Finish(err)
+	recordSession(historyManager, session)
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
+				os.Exit(status.ExitStatus())
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Error executing SSH command: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// forwardSSHArgs returns the arguments of the ssh client setting up the forwards of a profile
+func forwardSSHArgs(hostName string, profile history.PortForwardProfile) ([]string, error) {
+	forwards, err := profile.SSHArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	var args []string
+	if configFile != "" {
+		args = append(args, "-F", configFile)
+	}
+	if forwardNoShell {
+		args = append(args, "-N")
+	}
+	args = append(args, forwards...)
+	return append(args, hostName), nil
+}
+
+// writeForwardProfiles writes the port forwarding profiles of a host as a table
+func writeForwardProfiles(w io.Writer, hostName string, profiles []history.PortForwardProfile) {
+	if len(profiles) == 0 {
+		fmt.Fprintf(w, "No port forwarding profile saved for %s (save one from the TUI with f).\n", hostName)
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROFILE\tFORWARDS\tLAST USED")
+	for _, profile := range profiles {
+		lastUsed := "-"
+		if !profile.LastUsed.IsZero() {
+			lastUsed = profile.LastUsed.Format(time.DateTime)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", profile.Name, profile.Summary(), lastUsed)
+	}
+	tw.Flush()
+}
+
+func init() {
+	RootCmd.AddCommand(forwardCmd)
+
+	forwardCmd.Flags().BoolVarP(&forwardNoShell, "no-shell", "N", false, "Only set up the forwards, without running a remote shell")
+}
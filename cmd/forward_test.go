@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/history"
+)
+
+func TestForwardCommandRegistration(t *testing.T) {
+	found := false
+	for _, cmd := range RootCmd.Commands() {
+		if cmd.Name() == "forward" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("Forward command not found in root command")
+	}
+
+	if forwardCmd.Flags().Lookup("no-shell") == nil {
+		t.Error("Expected --no-shell flag to be defined")
+	}
+}
+
+func TestForwardSSHArgs(t *testing.T) {
+	defer func(previousConfig string, previousNoShell bool) {
+		configFile, forwardNoShell = previousConfig, previousNoShell
+	}(configFile, forwardNoShell)
+
+	profile := history.PortForwardProfile{
+		Name: "grafana",
+		Forwards: []history.PortForwardConfig{
+			{Type: "local", LocalPort: "3000", RemotePort: "3000"},
+			{Type: "dynamic", LocalPort: "1080", BindAddress: "127.0.0.1"},
+		},
+	}
+
+	configFile, forwardNoShell = "/tmp/ssh_config", true
+	want := []string{"-F", "/tmp/ssh_config", "-N", "-L", "3000:localhost:3000", "-D", "127.0.0.1:1080", "monitoring"}
+	got, err := forwardSSHArgs("monitoring", profile)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("forwardSSHArgs() = %v, %v, want %v", got, err, want)
+	}
+
+	profile.Forwards = append(profile.Forwards, history.PortForwardConfig{Type: "remote", LocalPort: "9000"})
+	if _, err := forwardSSHArgs("monitoring", profile); err == nil {
+		t.Error("Expected an error for a remote forward without local port")
+	}
+}
+
+func TestWriteForwardProfiles(t *testing.T) {
+	var output bytes.Buffer
+	writeForwardProfiles(&output, "db1", nil)
+	if !strings.Contains(output.String(), "No port forwarding profile saved for db1") {
+		t.Errorf("Expected a message when there is no profile, got %q", output.String())
+	}
+
+	output.Reset()
+	writeForwardProfiles(&output, "db1", []history.PortForwardProfile{
+		{Name: "postgres", Forwards: []history.PortForwardConfig{{Type: "local", LocalPort: "5432", RemoteHost: "db", RemotePort: "5432"}}},
+	})
+	for _, expected := range []string{"PROFILE", "postgres", "-L 5432:db:5432"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, output.String())
+		}
+	}
+}
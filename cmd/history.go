@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/history"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// historyFormat defines the output format (table, json)
+	historyFormat string
+	// historyKind lists only the sessions of one kind
+	historyKind string
+	// historySince lists only the sessions started since a date or for a duration
+	historySince string
+	// historyFailed lists only the sessions that failed
+	historyFailed bool
+	// historyLimit is the number of sessions listed, 0 for all
+	historyLimit int
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history [host]",
+	Short: "List the SSH sessions started with sshm",
+	Long: `List the SSH sessions started with sshm, most recent first, with their
+duration and exit status.
+
+Shells, commands and port forwards started from the TUI or the command line
+are logged once they end, along with the SSH config file they were read from.
+
+--since takes a date (2006-01-02), a number of days (7d) or a duration (12h).
+
+Examples:
+  sshm history                    # The last 20 sessions
+  sshm history web -n 0           # Every session with web
+  sshm history --kind command --failed
+  sshm history --since 7d --format json`,
+	Args: cobra.MaximumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return RootCmd.ValidArgsFunction(cmd, nil, toComplete)
+	},
+	Run: runHistory,
+}
+
+func runHistory(cmd *cobra.Command, args []string) {
+	switch historyFormat {
+	case "table", "json":
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid format '%s' (expected table or json)\n", historyFormat)
+		os.Exit(1)
+	}
+
+	filter, err := newSessionFilter(args, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	historyManager, err := history.NewHistoryManager(configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading history: %v\n", err)
+		os.Exit(1)
+	}
+
+	sessions, err := historyManager.GetSessions(filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading history: %v\n", err)
+		os.Exit(1)
+	}
+
+	if historyFormat == "json" {
+		writeSessionsJSON(os.Stdout, sessions)
+	} else {
+		writeSessions(os.Stdout, sessions)
+	}
+}
+
+// newSessionFilter builds the filter of the sessions listed from the arguments and flags
+func newSessionFilter(args []string, now time.Time) (history.SessionFilter, error) {
+	filter := history.SessionFilter{
+		FailedOnly: historyFailed,
+		Limit:      historyLimit,
+	}
+	if len(args) > 0 {
+		filter.HostName = args[0]
+	}
+
+	if historyKind != "" {
+		for _, kind := range history.SessionKinds {
+			if string(kind) == historyKind {
+				filter.Kind = kind
+			}
+		}
+		if filter.Kind == "" {
+			return filter, fmt.Errorf("invalid kind '%s' (expected interactive, command or forward)", historyKind)
+		}
+	}
+
+	if historySince != "" {
+		since, err := parseSince(historySince, now)
+		if err != nil {
+			return filter, err
+		}
+		filter.Since = since
+	}
+
+	if historyLimit < 0 {
+		return filter, fmt.Errorf("--limit must not be negative")
+	}
+	return filter, nil
+}
+
+// parseSince parses the value of --since: a date, a number of days or a duration
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if duration, err := time.ParseDuration(value); err == nil && duration >= 0 {
+		return now.Add(-duration), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since '%s' (expected a date like 2006-01-02, days like 7d or a duration like 12h)", value)
+}
+
+// recordSession logs a finished session, warning when it could not be
+func recordSession(historyManager *history.HistoryManager, session history.Session) {
+	if historyManager == nil {
+		return
+	}
+	if err := historyManager.RecordSession(session); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not record connection history: %v\n", err)
+	}
+}
+
+// writeSessions writes the sessions as a table
+func writeSessions(w io.Writer, sessions []history.Session) {
+	if len(sessions) == 0 {
+		fmt.Fprintln(w, "No session found.")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "START\tHOST\tKIND\tDURATION\tEXIT\tDETAILS")
+	for _, session := range sessions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			session.Start.Local().Format("2006-01-02 15:04"),
+			session.HostName,
+			session.Kind,
+			session.Elapsed().Round(time.Second),
+			describeSessionExit(session),
+			orDash(sessionDetails(session)))
+	}
+	tw.Flush()
+}
+
+// writeSessionsJSON writes the sessions as a JSON array
+func writeSessionsJSON(w io.Writer, sessions []history.Session) {
+	if sessions == nil {
+		sessions = []history.Session{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(sessions)
+}
+
+// describeSessionExit describes how a session ended
+func describeSessionExit(session history.Session) string {
+	if session.Error != "" {
+		return session.Error
+	}
+	if session.ExitCode < 0 {
+		return "killed"
+	}
+	return strconv.Itoa(session.ExitCode)
+}
+
+// sessionDetails returns the command or the forwards of a session
+func sessionDetails(session history.Session) string {
+	if session.Kind == history.SessionForward {
+		return session.Forwards
+	}
+	return session.Command
+}
+
+func init() {
+	RootCmd.AddCommand(historyCmd)
+
+	historyCmd.Flags().StringVarP(&historyFormat, "format", "f", "table", "Output format (table, json)")
+	historyCmd.Flags().StringVar(&historyKind, "kind", "", "List only the sessions of a kind (interactive, command, forward)")
+	historyCmd.Flags().StringVar(&historySince, "since", "", "List only the sessions started since a date (2006-01-02) or for a while (7d, 12h)")
+	historyCmd.Flags().BoolVar(&historyFailed, "failed", false, "List only the sessions that failed")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of sessions listed, 0 for all")
+}
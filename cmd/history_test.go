@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/history"
+)
+
+func TestHistoryCommandRegistration(t *testing.T) {
+	found := false
+	for _, cmd := range RootCmd.Commands() {
+		if cmd.Name() == "history" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Fatal("History command not found in root command")
+	}
+
+	for _, name := range []string{"format", "kind", "since", "failed", "limit"} {
+		if historyCmd.Flags().Lookup(name) == nil {
+			t.Errorf("Expected --%s flag to be defined", name)
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2024-03-01", time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local), false},
+		{"7d", now.AddDate(0, 0, -7), false},
+		{"12h", now.Add(-12 * time.Hour), false},
+		{"90m", now.Add(-90 * time.Minute), false},
+		{"yesterday", time.Time{}, true},
+		{"-3d", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseSince(tt.value, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSince(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseSince(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSessionFilter(t *testing.T) {
+	defer func() { historyKind, historyLimit = "", 20 }()
+
+	historyKind, historyLimit = "command", 5
+	filter, err := newSessionFilter([]string{"web"}, time.Now())
+	if err != nil {
+		t.Fatalf("newSessionFilter() error = %v", err)
+	}
+	if filter.HostName != "web" || filter.Kind != history.SessionCommand || filter.Limit != 5 {
+		t.Errorf("Unexpected filter %+v", filter)
+	}
+
+	historyKind = "shell"
+	if _, err := newSessionFilter(nil, time.Now()); err == nil || !strings.Contains(err.Error(), "invalid kind") {
+		t.Errorf("Expected an invalid kind error, got %v", err)
+	}
+}
+
+func TestWriteSessions(t *testing.T) {
+	start := time.Date(2024, 3, 10, 9, 30, 0, 0, time.Local)
+	sessions := []history.Session{
+		{HostName: "web", Kind: history.SessionCommand, Command: "uptime", Start: start, Duration: 1.2, ExitCode: 0},
+		{HostName: "db", Kind: history.SessionForward, Forwards: "-L 5432:localhost:5432", Start: start, Duration: 3725, ExitCode: 255},
+		{HostName: "db", Kind: history.SessionInteractive, Start: start, ExitCode: -1, Error: "ssh not found"},
+	}
+
+	var output bytes.Buffer
+	writeSessions(&output, sessions)
+	for _, want := range []string{"START", "2024-03-10 09:30", "uptime", "1s", "-L 5432:localhost:5432", "1h2m5s", "255", "ssh not found"} {
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("Expected %q in the table, got:\n%s", want, output.String())
+		}
+	}
+
+	output.Reset()
+	writeSessions(&output, nil)
+	if !strings.Contains(output.String(), "No session found") {
+		t.Errorf("Expected a message when there is no session, got %q", output.String())
+	}
+
+	output.Reset()
+	writeSessionsJSON(&output, nil)
+	var decoded []history.Session
+	if err := json.Unmarshal(output.Bytes(), &decoded); err != nil || decoded == nil {
+		t.Errorf("Expected an empty JSON array, got %q (%v)", output.String(), err)
+	}
+}
+
+func TestExecSessions(t *testing.T) {
+	start := time.Now().Add(-time.Minute)
+	results := []execResult{
+		{HostName: "web", ExitCode: 0, Start: start, Duration: 2 * time.Second},
+		{HostName: "db", ExitCode: -1, Start: start, Err: errors.New("interrupted")},
+	}
+
+	sessions := execSessions(results, []string{"df", "-h"})
+	if len(sessions) != 2 {
+		t.Fatalf("Expected a session per host, got %d", len(sessions))
+	}
+	if s := sessions[0]; s.Kind != history.SessionCommand || s.Command != "df -h" || s.Duration != 2 || !s.End.Equal(start.Add(2*time.Second)) {
+		t.Errorf("Unexpected session %+v", s)
+	}
+	if s := sessions[1]; s.ExitCode != -1 || s.Error != "interrupted" {
+		t.Errorf("Expected the failure to be logged, got %+v", s)
+	}
+}
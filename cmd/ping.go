@@ -0,0 +1,573 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit statuses of the ping command
+const (
+	pingExitAllUp   = 0 // Every target is reachable
+	pingExitSomeOff = 1 // At least one target is down
+	pingExitError   = 2 // The targets could not be checked
+
+	// pingExitInterrupted is the status when interrupted before every target
+	// was checked, the usual status of a command stopped by SIGINT
+	pingExitInterrupted = 130
+)
+
+var (
+	// pingFormat defines the output format (table, json, csv)
+	pingFormat string
+	// pingConcurrency limits the number of hosts checked at once
+	pingConcurrency int
+	// pingJumpConcurrency limits the number of checks crossing the same jump host at once
+	pingJumpConcurrency int
+	// pingTimeout is the connection timeout of each check
+	pingTimeout time.Duration
+	// pingWatch re-checks the hosts until interrupted
+	pingWatch bool
+	// pingInterval is the delay between two rounds of checks in watch mode
+	pingInterval time.Duration
+	// pingAuth also checks which authentication methods the reachable hosts accept
+	pingAuth bool
+)
+
+var pingCmd = &cobra.Command{
+	Use:   "ping [host|query...]",
+	Short: "Check the SSH connectivity of hosts",
+	Long: `Check whether your SSH hosts are reachable, the same way the TUI does.
+
+Each argument is a host name or a search query matching host names, hostnames
+and tags. Without arguments every host is checked. Hosts behind a ProxyJump or
+a ProxyCommand are checked through it.
+
+The exit status is 0 when every host is up, 1 when at least one host is down,
+2 when the hosts could not be checked and 130 when interrupted before every
+host was checked.
+
+With --watch, the hosts are checked again every --interval and only the state
+transitions are reported, until interrupted.
+
+With --auth, the authentication methods offered by the reachable hosts are
+listed, and the keys of your agent and IdentityFile are offered to find out
+whether one of them is accepted. Keys are never used to sign and no password
+is sent, so no session is opened.
+
+Examples:
+  sshm ping                       # Check every host
+  sshm ping web db                # Check the hosts matching "web" or "db"
+  sshm ping prod -j 20 --timeout 3s
+  sshm ping --format json prod    # Output results in JSON format
+  sshm ping --auth web            # Check whether web accepts your keys
+  sshm ping --watch --interval 30s prod`,
+	Args: cobra.ArbitraryArgs,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return RootCmd.ValidArgsFunction(cmd, nil, toComplete)
+	},
+	Run: runPing,
+}
+
+// pingRecord is a check result as written in JSON and CSV output
+type pingRecord struct {
+	Name               string    `json:"name"`
+	Hostname           string    `json:"hostname"`
+	Status             string    `json:"status"`
+	Result             string    `json:"result"`
+	LatencyMs          int64     `json:"latency_ms"`
+	ResolvedIP         string    `json:"resolved_ip,omitempty"`
+	ServerVersion      string    `json:"server_version,omitempty"`
+	HostKeyStatus      string    `json:"host_key_status,omitempty"`
+	HostKeyType        string    `json:"host_key_type,omitempty"`
+	HostKeyFingerprint string    `json:"host_key_fingerprint,omitempty"`
+	FailedHop          string    `json:"failed_hop,omitempty"`
+	Error              string    `json:"error,omitempty"`
+	CheckedAt          time.Time `json:"checked_at"`
+
+	// Authentication probe outcome, set with --auth only
+	Auth        string   `json:"auth,omitempty"`
+	AuthMethods []string `json:"auth_methods,omitempty"`
+	AcceptedKey string   `json:"accepted_key,omitempty"`
+
+	// Previous is the status before a transition, set in watch mode only
+	Previous string `json:"previous_status,omitempty"`
+}
+
+// pingCSVHeader is the header row of CSV output
+var pingCSVHeader = []string{"name", "hostname", "status", "result", "latency_ms", "resolved_ip", "server_version",
+	"host_key_status", "host_key_type", "host_key_fingerprint", "failed_hop", "error", "checked_at", "previous_status"}
+
+// pingAuthCSVHeader is appended to the header row with --auth
+var pingAuthCSVHeader = []string{"auth", "auth_methods", "accepted_key"}
+
+func runPing(cmd *cobra.Command, args []string) {
+	switch pingFormat {
+	case "table", "json", "csv":
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid format '%s' (expected table, json or csv)\n", pingFormat)
+		os.Exit(pingExitError)
+	}
+
+	if pingWatch && pingInterval <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --interval must be positive")
+		os.Exit(pingExitError)
+	}
+
+	if pingWatch && pingAuth {
+		fmt.Fprintln(os.Stderr, "Error: --auth cannot be used with --watch")
+		os.Exit(pingExitError)
+	}
+
+	var hosts []config.SSHHost
+	var err error
+
+	if configFile != "" {
+		hosts, err = config.ParseSSHConfigFile(configFile)
+	} else {
+		hosts, err = config.ParseSSHConfig()
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading SSH config file: %v\n", err)
+		os.Exit(pingExitError)
+	}
+
+	targets := selectTargets(hosts, args, false)
+	if len(targets) == 0 {
+		if len(args) == 0 {
+			fmt.Fprintln(os.Stderr, "No SSH hosts found in your configuration file.")
+		} else {
+			fmt.Fprintf(os.Stderr, "No hosts found matching '%s'.\n", strings.Join(args, " "))
+		}
+		os.Exit(pingExitError)
+	}
+
+	pm := connectivity.NewPingManager(pingTimeout)
+	pm.SetHosts(hosts)
+	pm.SetConcurrency(pingConcurrency)
+	pm.SetJumpHostConcurrency(pingJumpConcurrency)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var results []*connectivity.HostPingResult
+	if pingWatch {
+		results = watchPing(ctx, os.Stdout, pm, targets)
+	} else {
+		results = checkHosts(ctx, pm, targets)
+
+		var auths map[string]*connectivity.AuthProbeResult
+		if pingAuth {
+			auths = probeHostsAuth(ctx, pm, targets, results)
+		}
+		writePingResults(os.Stdout, targets, results, auths)
+		if len(results) < len(targets) {
+			fmt.Fprintf(os.Stderr, "Interrupted: %d/%d host(s) checked\n", len(results), len(targets))
+		}
+	}
+
+	os.Exit(pingExitCode(results, len(targets)))
+}
+
+// selectTargets returns the hosts named or matched by the arguments, in
+// configuration order. An argument naming a host exactly selects only that
+// host; other arguments are search queries, matching only tags when tagsOnly
+// is set. Without arguments every host is selected.
+func selectTargets(hosts []config.SSHHost, args []string, tagsOnly bool) []config.SSHHost {
+	if len(args) == 0 {
+		return hosts
+	}
+
+	selected := make(map[string]bool)
+	for _, arg := range args {
+		exact := false
+		for _, host := range hosts {
+			if host.Name == arg {
+				selected[host.Name] = true
+				exact = true
+			}
+		}
+		if exact {
+			continue
+		}
+		for _, host := range filterHosts(hosts, arg, tagsOnly, false) {
+			selected[host.Name] = true
+		}
+	}
+
+	var targets []config.SSHHost
+	for _, host := range hosts {
+		if selected[host.Name] {
+			targets = append(targets, host)
+			delete(selected, host.Name) // A host defined in several files is checked once
+		}
+	}
+	return targets
+}
+
+// checkHosts checks every target and returns the results in target order.
+// Targets left unchecked because ctx was cancelled have no result.
+func checkHosts(ctx context.Context, pm *connectivity.PingManager, targets []config.SSHHost) []*connectivity.HostPingResult {
+	byName := make(map[string]*connectivity.HostPingResult, len(targets))
+	for result := range pm.PingAllHosts(ctx, targets) {
+		byName[result.HostName] = result
+	}
+
+	results := make([]*connectivity.HostPingResult, 0, len(targets))
+	for _, host := range targets {
+		if result, ok := byName[host.Name]; ok {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// probeHostsAuth probes the authentication of the targets found online, at
+// most pingConcurrency at once, and returns the outcomes by host name
+func probeHostsAuth(ctx context.Context, pm *connectivity.PingManager, targets []config.SSHHost, results []*connectivity.HostPingResult) map[string]*connectivity.AuthProbeResult {
+	online := make(map[string]bool, len(results))
+	for _, result := range results {
+		online[result.HostName] = result.Status == connectivity.StatusOnline
+	}
+
+	limit := pingConcurrency
+	if limit <= 0 {
+		limit = len(targets)
+	}
+	slots := make(chan struct{}, max(limit, 1))
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	auths := make(map[string]*connectivity.AuthProbeResult)
+	for _, host := range targets {
+		if !online[host.Name] {
+			continue
+		}
+
+		wg.Add(1)
+		go func(host config.SSHHost) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			auth := pm.ProbeAuth(ctx, host)
+			mutex.Lock()
+			auths[host.Name] = auth
+			mutex.Unlock()
+		}(host)
+	}
+	wg.Wait()
+	return auths
+}
+
+// watchPing checks the targets every pingInterval until ctx is cancelled,
+// reporting the results of the first round and then the state transitions.
+// It returns the last result of each target.
+func watchPing(ctx context.Context, w io.Writer, pm *connectivity.PingManager, targets []config.SSHHost) []*connectivity.HostPingResult {
+	hostnames := pingHostnames(targets)
+	previous := make(map[string]*connectivity.HostPingResult)
+	var last []*connectivity.HostPingResult
+
+	var csvWriter *csv.Writer
+	if pingFormat == "csv" {
+		csvWriter = csv.NewWriter(w)
+		csvWriter.Write(pingCSVHeader)
+		csvWriter.Flush()
+	}
+
+	for round := 0; ; round++ {
+		results := checkHosts(ctx, pm, targets)
+		if ctx.Err() != nil {
+			break
+		}
+
+		if round == 0 && pingFormat == "table" {
+			writePingTable(w, hostnames, results, nil)
+			fmt.Fprintf(w, "\nWatching %d host(s) every %s, press Ctrl+C to stop\n", len(targets), pingInterval)
+		} else {
+			for _, change := range pingTransitions(previous, results) {
+				record := newPingRecord(change.result, hostnames[change.result.HostName])
+				record.Previous = change.previous
+
+				switch pingFormat {
+				case "json":
+					// One JSON object per line, so that the output can be streamed
+					line, _ := json.Marshal(record)
+					fmt.Fprintln(w, string(line))
+				case "csv":
+					csvWriter.Write(record.csvRow())
+					csvWriter.Flush()
+				default:
+					fmt.Fprintln(w, formatPingTransition(change))
+				}
+			}
+		}
+
+		for _, result := range results {
+			previous[result.HostName] = result
+		}
+		last = results
+
+		select {
+		case <-ctx.Done():
+			return last
+		case <-time.After(pingInterval):
+		}
+	}
+	return last
+}
+
+// pingTransition is a change of status of a host between two rounds of checks
+type pingTransition struct {
+	previous string // Status before the change, "unknown" for the first check
+	result   *connectivity.HostPingResult
+}
+
+// pingTransitions returns the results whose status or outcome differs from
+// the previous result of the same host
+func pingTransitions(previous map[string]*connectivity.HostPingResult, results []*connectivity.HostPingResult) []pingTransition {
+	var changes []pingTransition
+	for _, result := range results {
+		before, ok := previous[result.HostName]
+		switch {
+		case !ok:
+			changes = append(changes, pingTransition{previous: connectivity.StatusUnknown.String(), result: result})
+		case before.Status != result.Status || before.Kind != result.Kind:
+			changes = append(changes, pingTransition{previous: describePingResult(before), result: result})
+		}
+	}
+	return changes
+}
+
+// formatPingTransition formats a transition for table output
+func formatPingTransition(change pingTransition) string {
+	line := fmt.Sprintf("%s  %s  %s → %s",
+		change.result.CheckedAt.Format("2006-01-02 15:04:05"),
+		change.result.HostName,
+		change.previous,
+		describePingResult(change.result))
+	if change.result.Status == connectivity.StatusOffline && change.result.Error != nil {
+		line += ": " + change.result.Error.Error()
+	}
+	return line
+}
+
+// describePingResult describes a result by its status and, when it adds
+// information, its outcome (e.g. "offline (connection refused)")
+func describePingResult(result *connectivity.HostPingResult) string {
+	if result.Kind == connectivity.KindNone || result.Kind == connectivity.KindAuthRequired {
+		return result.Status.String()
+	}
+	return fmt.Sprintf("%s (%s)", result.Status, result.Kind)
+}
+
+// writePingResults writes the results in the selected output format, along
+// with the authentication probe outcomes when auths is not nil
+func writePingResults(w io.Writer, targets []config.SSHHost, results []*connectivity.HostPingResult, auths map[string]*connectivity.AuthProbeResult) {
+	hostnames := pingHostnames(targets)
+
+	switch pingFormat {
+	case "json":
+		writePingJSON(w, hostnames, results, auths)
+	case "csv":
+		writePingCSV(w, hostnames, results, auths)
+	default:
+		writePingTable(w, hostnames, results, auths)
+	}
+}
+
+// pingHostnames maps the name of each target to its hostname
+func pingHostnames(targets []config.SSHHost) map[string]string {
+	hostnames := make(map[string]string, len(targets))
+	for _, host := range targets {
+		hostname := host.Hostname
+		if hostname == "" {
+			hostname = host.Name
+		}
+		hostnames[host.Name] = hostname
+	}
+	return hostnames
+}
+
+// writePingTable writes the results as an aligned table followed by a
+// summary, with an AUTH column when auths is not nil
+func writePingTable(w io.Writer, hostnames map[string]string, results []*connectivity.HostPingResult, auths map[string]*connectivity.AuthProbeResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := "NAME\tHOSTNAME\tSTATUS\tRESULT\tLATENCY\tADDRESS\tSERVER"
+	if auths != nil {
+		header += "\tAUTH"
+	}
+	fmt.Fprintln(tw, header)
+
+	up := 0
+	for _, result := range results {
+		if result.Status == connectivity.StatusOnline {
+			up++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s",
+			result.HostName,
+			hostnames[result.HostName],
+			result.Status,
+			result.Kind,
+			fmt.Sprintf("%dms", result.Duration.Milliseconds()),
+			orDash(result.ResolvedIP),
+			orDash(result.ServerVersion))
+		if auths != nil {
+			fmt.Fprintf(tw, "\t%s", describeAuth(auths[result.HostName]))
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+
+	// Explain the failures below the table, where long messages do not break the alignment
+	for _, result := range results {
+		if result.Status == connectivity.StatusOffline && result.Error != nil {
+			fmt.Fprintf(w, "%s: %v\n", result.HostName, result.Error)
+		}
+		if auth := auths[result.HostName]; auth != nil && auth.Error != nil {
+			fmt.Fprintf(w, "%s: authentication not checked: %v\n", result.HostName, auth.Error)
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d/%d host(s) up\n", up, len(results))
+}
+
+// writePingJSON writes the results as a JSON array
+func writePingJSON(w io.Writer, hostnames map[string]string, results []*connectivity.HostPingResult, auths map[string]*connectivity.AuthProbeResult) {
+	records := make([]pingRecord, 0, len(results))
+	for _, result := range results {
+		record := newPingRecord(result, hostnames[result.HostName])
+		record.setAuth(auths[result.HostName])
+		records = append(records, record)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(records)
+}
+
+// writePingCSV writes the results as CSV with a header row, and the
+// authentication columns when auths is not nil
+func writePingCSV(w io.Writer, hostnames map[string]string, results []*connectivity.HostPingResult, auths map[string]*connectivity.AuthProbeResult) {
+	csvWriter := csv.NewWriter(w)
+	header := pingCSVHeader
+	if auths != nil {
+		header = append(header[:len(header):len(header)], pingAuthCSVHeader...)
+	}
+	csvWriter.Write(header)
+	for _, result := range results {
+		record := newPingRecord(result, hostnames[result.HostName])
+		row := record.csvRow()
+		if auths != nil {
+			record.setAuth(auths[result.HostName])
+			row = append(row, record.Auth, strings.Join(record.AuthMethods, " "), record.AcceptedKey)
+		}
+		csvWriter.Write(row)
+	}
+	csvWriter.Flush()
+}
+
+// newPingRecord converts a check result for JSON and CSV output
+func newPingRecord(result *connectivity.HostPingResult, hostname string) pingRecord {
+	record := pingRecord{
+		Name:               result.HostName,
+		Hostname:           hostname,
+		Status:             result.Status.String(),
+		Result:             result.Kind.String(),
+		LatencyMs:          result.Duration.Milliseconds(),
+		ResolvedIP:         result.ResolvedIP,
+		ServerVersion:      result.ServerVersion,
+		HostKeyType:        result.HostKeyType,
+		HostKeyFingerprint: result.HostKeyFingerprint,
+		FailedHop:          result.FailedHop,
+		CheckedAt:          result.CheckedAt,
+	}
+	if result.HostKeyStatus != connectivity.HostKeyUnchecked {
+		record.HostKeyStatus = result.HostKeyStatus.String()
+	}
+	if result.Error != nil && result.Status == connectivity.StatusOffline {
+		record.Error = result.Error.Error()
+	}
+	return record
+}
+
+// setAuth fills the authentication fields of the record from a probe outcome
+func (r *pingRecord) setAuth(auth *connectivity.AuthProbeResult) {
+	if auth == nil || auth.Error != nil {
+		return
+	}
+	r.Auth = auth.Status.String()
+	r.AuthMethods = auth.Methods
+	r.AcceptedKey = auth.AcceptedKey
+}
+
+// describeAuth describes the outcome of an authentication probe for table output
+func describeAuth(auth *connectivity.AuthProbeResult) string {
+	if auth == nil || auth.Error != nil {
+		return "-"
+	}
+	if auth.Status == connectivity.AuthKeyAccepted {
+		return fmt.Sprintf("%s (%s)", auth.Status, auth.AcceptedKey)
+	}
+	return auth.Status.String()
+}
+
+// csvRow returns the record as a CSV row matching pingCSVHeader
+func (r pingRecord) csvRow() []string {
+	return []string{
+		r.Name, r.Hostname, r.Status, r.Result, strconv.FormatInt(r.LatencyMs, 10), r.ResolvedIP, r.ServerVersion,
+		r.HostKeyStatus, r.HostKeyType, r.HostKeyFingerprint, r.FailedHop, r.Error, r.CheckedAt.Format(time.RFC3339), r.Previous,
+	}
+}
+
+// pingExitCode returns the exit status for the results of the checks of
+// targets hosts. Hosts left unchecked have no result.
+func pingExitCode(results []*connectivity.HostPingResult, targets int) int {
+	switch {
+	case len(results) < targets:
+		return pingExitInterrupted
+	case len(results) == 0:
+		return pingExitError
+	}
+	for _, result := range results {
+		if result.Status != connectivity.StatusOnline {
+			return pingExitSomeOff
+		}
+	}
+	return pingExitAllUp
+}
+
+// orDash returns value, or "-" if it is empty
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func init() {
+	RootCmd.AddCommand(pingCmd)
+
+	pingCmd.Flags().StringVarP(&pingFormat, "format", "f", "table", "Output format (table, json, csv)")
+	pingCmd.Flags().IntVarP(&pingConcurrency, "concurrency", "j", 10, "Maximum number of hosts checked at once (0 for no limit)")
+	pingCmd.Flags().IntVar(&pingJumpConcurrency, "jump-concurrency", config.GetDefaultConnectivityConfig().JumpHostConcurrency, "Maximum number of checks crossing the same jump host at once (0 for no limit)")
+	pingCmd.Flags().DurationVar(&pingTimeout, "timeout", 5*time.Second, "Connection timeout of each check")
+	pingCmd.Flags().BoolVarP(&pingWatch, "watch", "w", false, "Check the hosts again every interval and report state transitions")
+	pingCmd.Flags().DurationVar(&pingInterval, "interval", 30*time.Second, "Delay between two rounds of checks in watch mode")
+	pingCmd.Flags().BoolVar(&pingAuth, "auth", false, "Check which authentication methods the reachable hosts accept")
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+)
+
+func TestPingCommandRegistration(t *testing.T) {
+	found := false
+	for _, cmd := range RootCmd.Commands() {
+		if cmd.Name() == "ping" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("Ping command not found in root command")
+	}
+
+	for _, name := range []string{"format", "concurrency", "jump-concurrency", "timeout", "watch", "interval", "auth"} {
+		if pingCmd.Flags().Lookup(name) == nil {
+			t.Errorf("Expected --%s flag to be defined", name)
+		}
+	}
+}
+
+func TestSelectTargets(t *testing.T) {
+	hosts := []config.SSHHost{
+		{Name: "web", Hostname: "web.example.com"},
+		{Name: "web-2", Hostname: "web2.example.com", Tags: []string{"prod"}},
+		{Name: "db", Hostname: "db.example.com", Tags: []string{"prod"}},
+		{Name: "cache", Hostname: "cache.internal"},
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		tagsOnly bool
+		want     []string
+	}{
+		{"no arguments", nil, false, []string{"web", "web-2", "db", "cache"}},
+		{"exact name", []string{"web"}, false, []string{"web"}},
+		{"query", []string{"prod"}, false, []string{"web-2", "db"}},
+		{"name and query", []string{"cache", "db"}, false, []string{"db", "cache"}},
+		{"hostname query", []string{"internal"}, false, []string{"cache"}},
+		{"tags only", []string{"web"}, true, []string{"web"}},
+		{"tag query", []string{"pro"}, true, []string{"web-2", "db"}},
+		{"no match", []string{"nothing"}, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, host := range selectTargets(hosts, tt.args, tt.tagsOnly) {
+				got = append(got, host.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectTargets(%v) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+// testPingResults returns an online and an offline result
+func testPingResults() []*connectivity.HostPingResult {
+	checkedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return []*connectivity.HostPingResult{
+		{
+			HostName: "web", Status: connectivity.StatusOnline, Kind: connectivity.KindAuthRequired,
+			Duration: 42 * time.Millisecond, CheckedAt: checkedAt, ResolvedIP: "10.0.0.1",
+			ServerVersion: "SSH-2.0-OpenSSH_9.6", HostKeyStatus: connectivity.HostKeyKnown,
+		},
+		{
+			HostName: "db", Status: connectivity.StatusOffline, Kind: connectivity.KindTCPRefused,
+			Duration: 3 * time.Millisecond, CheckedAt: checkedAt, ResolvedIP: "10.0.0.2",
+			Error: errors.New("connection refused"),
+		},
+	}
+}
+
+func TestPingExitCode(t *testing.T) {
+	results := testPingResults()
+
+	if got := pingExitCode(results[:1], 1); got != pingExitAllUp {
+		t.Errorf("pingExitCode(all up) = %d, want %d", got, pingExitAllUp)
+	}
+	if got := pingExitCode(results, 2); got != pingExitSomeOff {
+		t.Errorf("pingExitCode(one down) = %d, want %d", got, pingExitSomeOff)
+	}
+	if got := pingExitCode(results[:1], 2); got != pingExitInterrupted {
+		t.Errorf("pingExitCode(one unchecked) = %d, want %d", got, pingExitInterrupted)
+	}
+	if got := pingExitCode(nil, 0); got != pingExitError {
+		t.Errorf("pingExitCode(no result) = %d, want %d", got, pingExitError)
+	}
+}
+
+func TestCheckHostsInterrupted(t *testing.T) {
+	// A server accepting connections without ever answering, so that its check
+	// is still running when the context is cancelled
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	hostname, port, _ := net.SplitHostPort(listener.Addr().String())
+	targets := []config.SSHHost{{Name: "stalled", Hostname: hostname, Port: port}}
+	pm := connectivity.NewPingManager(5 * time.Second)
+	pm.SetHosts(targets)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	results := checkHosts(ctx, pm, targets)
+	if len(results) != 0 {
+		t.Fatalf("Expected no result for the interrupted check, got %d", len(results))
+	}
+	if got := pingExitCode(results, len(targets)); got != pingExitInterrupted {
+		t.Errorf("pingExitCode() = %d, want %d", got, pingExitInterrupted)
+	}
+}
+
+func TestWritePingOutput(t *testing.T) {
+	hostnames := map[string]string{"web": "web.example.com", "db": "db.example.com"}
+	results := testPingResults()
+
+	var table bytes.Buffer
+	writePingTable(&table, hostnames, results, nil)
+	for _, expected := range []string{"NAME", "web.example.com", "auth required", "SSH-2.0-OpenSSH_9.6", "db: connection refused", "1/2 host(s) up"} {
+		if !strings.Contains(table.String(), expected) {
+			t.Errorf("Expected table output to contain %q, got:\n%s", expected, table.String())
+		}
+	}
+
+	var jsonOutput bytes.Buffer
+	writePingJSON(&jsonOutput, hostnames, results, nil)
+	var records []pingRecord
+	if err := json.Unmarshal(jsonOutput.Bytes(), &records); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	if len(records) != 2 || records[1].Result != "connection refused" || records[1].Error != "connection refused" || records[0].HostKeyStatus != "known" {
+		t.Errorf("Unexpected JSON records: %+v", records)
+	}
+
+	var csvOutput bytes.Buffer
+	writePingCSV(&csvOutput, hostnames, results, nil)
+	lines := strings.Split(strings.TrimSpace(csvOutput.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and 2 CSV rows, got %d lines", len(lines))
+	}
+	if !strings.HasPrefix(lines[2], "db,db.example.com,offline,connection refused,3,10.0.0.2") {
+		t.Errorf("Unexpected CSV row: %s", lines[2])
+	}
+}
+
+func TestWritePingOutputWithAuth(t *testing.T) {
+	hostnames := map[string]string{"web": "web.example.com", "db": "db.example.com"}
+	results := testPingResults()
+	auths := map[string]*connectivity.AuthProbeResult{
+		"web": {HostName: "web", Status: connectivity.AuthKeyAccepted, Methods: []string{"publickey", "password"}, AcceptedKey: "~/.ssh/id_ed25519"},
+	}
+
+	var table bytes.Buffer
+	writePingTable(&table, hostnames, results, auths)
+	for _, expected := range []string{"AUTH", "key ok (~/.ssh/id_ed25519)"} {
+		if !strings.Contains(table.String(), expected) {
+			t.Errorf("Expected table output to contain %q, got:\n%s", expected, table.String())
+		}
+	}
+
+	var jsonOutput bytes.Buffer
+	writePingJSON(&jsonOutput, hostnames, results, auths)
+	var records []pingRecord
+	if err := json.Unmarshal(jsonOutput.Bytes(), &records); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	if len(records) != 2 || records[0].Auth != "key ok" || len(records[0].AuthMethods) != 2 || records[1].Auth != "" {
+		t.Errorf("Unexpected JSON records: %+v", records)
+	}
+
+	var csvOutput bytes.Buffer
+	writePingCSV(&csvOutput, hostnames, results, auths)
+	lines := strings.Split(strings.TrimSpace(csvOutput.String()), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], ",auth,auth_methods,accepted_key") {
+		t.Fatalf("Unexpected CSV output:\n%s", csvOutput.String())
+	}
+	if !strings.HasSuffix(lines[1], ",key ok,publickey password,~/.ssh/id_ed25519") {
+		t.Errorf("Unexpected CSV row: %s", lines[1])
+	}
+}
+
+func TestPingTransitions(t *testing.T) {
+	results := testPingResults()
+
+	// Every host changes from unknown on the first round
+	changes := pingTransitions(map[string]*connectivity.HostPingResult{}, results)
+	if len(changes) != 2 || changes[0].previous != "unknown" {
+		t.Fatalf("Expected 2 transitions from unknown, got %+v", changes)
+	}
+
+	previous := map[string]*connectivity.HostPingResult{"web": results[0], "db": results[1]}
+
+	// Same state: no transition
+	if changes := pingTransitions(previous, results); len(changes) != 0 {
+		t.Errorf("Expected no transition, got %+v", changes)
+	}
+
+	// db comes back up
+	up := *results[1]
+	up.Status = connectivity.StatusOnline
+	up.Kind = connectivity.KindAuthRequired
+	up.Error = nil
+	changes = pingTransitions(previous, []*connectivity.HostPingResult{results[0], &up})
+	if len(changes) != 1 || changes[0].result.HostName != "db" {
+		t.Fatalf("Expected a transition for db, got %+v", changes)
+	}
+	if got := formatPingTransition(changes[0]); !strings.Contains(got, "db  offline (connection refused) → online") {
+		t.Errorf("formatPingTransition() = %q", got)
+	}
+}
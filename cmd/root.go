@@ -168,7 +168,7 @@ func connectToHost(hostName string, remoteCommand []string) {
 		os.Exit(1)
 	}
 
-	historyManager, err := history.NewHistoryManager()
+	historyManager, err := history.NewHistoryManager(configFile)
 	if err != nil {
 		fmt.Printf("Warning: Could not initialize connection history: %v\n", err)
 	} else {
@@ -196,12 +196,20 @@ func connectToHost(hostName string, remoteCommand []string) {
 		fmt.Printf("Connecting to %s...\n", hostName)
 	}
 
+	session := history.NewSession(hostName, history.SessionInteractive, configFile)
+	if len(remoteCommand) > 0 {
+		session.Kind = history.SessionCommand
+		session.Command = strings.Join(remoteCommand, " ")
+	}
+
 	sshCmd := exec.Command("ssh", args...)
 	sshCmd.Stdin = os.Stdin
 	sshCmd.Stdout = os.Stdout
 	sshCmd.Stderr = os.Stderr
 
 	err = sshCmd.Run()
+	session.Finish(err)
+	recordSession(historyManager, session)
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
 			if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/history"
+	"github.com/Gu1llaum-3/sshm/internal/tunnel"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// tunnelAutoRestart restarts the tunnel with a backoff when it drops
+	tunnelAutoRestart bool
+	// tunnelStopAll stops every tunnel
+	tunnelStopAll bool
+)
+
+// tunnelStartWait is how long starting a tunnel waits for its forwards to be set up
+const tunnelStartWait = 10 * time.Second
+
+var tunnelCmd = &cobra.Command{
+	Use:   "tunnel",
+	Short: "Manage port forwarding tunnels running in the background",
+	Long: `Manage port forwarding tunnels running in the background.
+
+A tunnel is an ssh process setting up the forwards of a saved port forwarding
+profile (see 'sshm forward') without a remote shell. It keeps running after
+sshm exits, watched over by a supervisor process. With --auto-restart, a
+tunnel that drops is started again, waiting longer each time it drops again
+soon after starting (up to one minute).
+
+Examples:
+  sshm tunnel start db1 postgres --auto-restart
+  sshm tunnel ls
+  sshm tunnel restart db1-postgres
+  sshm tunnel stop db1-postgres
+  sshm tunnel stop --all`,
+}
+
+var tunnelStartCmd = &cobra.Command{
+	Use:   "start <host> <profile>",
+	Short: "Start the forwards of a saved profile in the background",
+	Args:  cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return forwardCmd.ValidArgsFunction(cmd, args, toComplete)
+	},
+	Run: runTunnelStart,
+}
+
+var tunnelListCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "List the tunnels with their health",
+	Args:    cobra.NoArgs,
+	Run:     runTunnelList,
+}
+
+var tunnelStopCmd = &cobra.Command{
+	Use:               "stop <tunnel...>",
+	Short:             "Stop tunnels",
+	ValidArgsFunction: completeTunnelIDs,
+	Run:               runTunnelStop,
+}
+
+var tunnelRestartCmd = &cobra.Command{
+	Use:               "restart <tunnel...>",
+	Short:             "Restart tunnels",
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeTunnelIDs,
+	Run:               runTunnelRestart,
+}
+
+// tunnelSuperviseCmd is run in the background by the tunnel manager
+var tunnelSuperviseCmd = &cobra.Command{
+	Use:    "supervise <dir> <tunnel>",
+	Short:  "Run and watch over the ssh process of a tunnel",
+	Hidden: true,
+	Args:   cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := tunnel.Supervise(ctx, args[0], args[1], "ssh"); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func runTunnelStart(cmd *cobra.Command, args []string) {
+	hostName, profileName := args[0], args[1]
+
+	var hostFound bool
+	var err error
+	if configFile != "" {
+		hostFound, err = config.QuickHostExistsInFile(hostName, configFile)
+	} else {
+		hostFound, err = config.QuickHostExists(hostName)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error checking SSH config: %v\n", err)
+		os.Exit(1)
+	}
+	if !hostFound {
+		fmt.Fprintf(os.Stderr, "Error: Host '%s' not found in SSH configuration.\n", hostName)
+		os.Exit(1)
+	}
+
+	historyManager, err := history.NewHistoryManager(configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading history: %v\n", err)
+		os.Exit(1)
+	}
+	profile, exists := historyManager.GetPortForwardProfile(hostName, profileName)
+	if !exists {
+		fmt.Fprintf(os.Stderr, "Error: no port forwarding profile '%s' for %s (use 'sshm forward %s' to list them).\n", profileName, hostName, hostName)
+		os.Exit(1)
+	}
+
+	manager := newTunnelManager()
+	t, err := manager.Start(hostName, profile, configFile, tunnelAutoRestart, tunnelStartWait)
+	if err != nil {
+		if t != nil {
+			fmt.Fprintf(os.Stderr, "Error: tunnel %s: %v\n", t.ID, err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		os.Exit(1)
+	}
+
+	_ = historyManager.MarkPortForwardProfileUsed(hostName, profile.Name)
+	fmt.Printf("Tunnel %s started (%s, pid %d)\n", t.ID, t.Summary(), t.PID)
+}
+
+func runTunnelList(cmd *cobra.Command, args []string) {
+	tunnels, err := newTunnelManager().List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing tunnels: %v\n", err)
+		os.Exit(1)
+	}
+	writeTunnels(os.Stdout, tunnels, checkTunnels(tunnels))
+}
+
+func runTunnelStop(cmd *cobra.Command, args []string) {
+	manager := newTunnelManager()
+
+	ids := args
+	if tunnelStopAll {
+		tunnels, err := manager.List()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing tunnels: %v\n", err)
+			os.Exit(1)
+		}
+		ids = nil
+		for _, t := range tunnels {
+			ids = append(ids, t.ID)
+		}
+	} else if len(ids) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no tunnel given (use --all to stop every tunnel)")
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, id := range ids {
+		if err := manager.Stop(id); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Tunnel %s stopped\n", id)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func runTunnelRestart(cmd *cobra.Command, args []string) {
+	manager := newTunnelManager()
+
+	failed := false
+	for _, id := range args {
+		t, err := manager.Restart(id, tunnelStartWait)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: tunnel %s: %v\n", id, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Tunnel %s restarted (pid %d)\n", t.ID, t.PID)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// newTunnelManager returns the manager of the tunnels, exiting when it cannot be created
+func newTunnelManager() *tunnel.Manager {
+	manager, err := tunnel.NewDefaultManager()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return manager
+}
+
+// completeTunnelIDs completes the IDs of the tunnels
+func completeTunnelIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	manager, err := tunnel.NewDefaultManager()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	tunnels, err := manager.List()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var ids []string
+	for _, t := range tunnels {
+		ids = append(ids, t.ID)
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+// checkTunnels checks the health of the tunnels
+func checkTunnels(tunnels []*tunnel.Tunnel) []tunnel.Health {
+	health := make([]tunnel.Health, len(tunnels))
+	for i, t := range tunnels {
+		health[i] = t.CheckHealth(time.Second)
+	}
+	return health
+}
+
+// writeTunnels writes the tunnels and their health as a table
+func writeTunnels(w io.Writer, tunnels []*tunnel.Tunnel, health []tunnel.Health) {
+	if len(tunnels) == 0 {
+		fmt.Fprintln(w, "No tunnel running (start one with 'sshm tunnel start <host> <profile>').")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TUNNEL\tHOST\tFORWARDS\tPID\tUPTIME\tRESTARTS\tHEALTH")
+
+	healthy := 0
+	for i, t := range tunnels {
+		pid := "-"
+		if t.PID != 0 {
+			pid = strconv.Itoa(t.PID)
+		}
+		restarts := strconv.Itoa(t.Restarts)
+		if t.AutoRestart {
+			restarts += " (auto)"
+		}
+		if health[i].OK {
+			healthy++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", t.ID, t.HostName, t.Summary(), pid, tunnel.FormatUptime(t.Uptime()), restarts, health[i])
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\n%d/%d tunnel(s) healthy\n", healthy, len(tunnels))
+}
+
+func init() {
+	RootCmd.AddCommand(tunnelCmd)
+	tunnelCmd.AddCommand(tunnelStartCmd, tunnelListCmd, tunnelStopCmd, tunnelRestartCmd, tunnelSuperviseCmd)
+
+	tunnelStartCmd.Flags().BoolVar(&tunnelAutoRestart, "auto-restart", false, "Restart the tunnel with a backoff when it drops")
+	tunnelStopCmd.Flags().BoolVar(&tunnelStopAll, "all", false, "Stop every tunnel")
+}
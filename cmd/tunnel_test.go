@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/history"
+	"github.com/Gu1llaum-3/sshm/internal/tunnel"
+)
+
+func TestTunnelCommandRegistration(t *testing.T) {
+	found := false
+	for _, cmd := range RootCmd.Commands() {
+		if cmd.Name() == "tunnel" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Fatal("Tunnel command not found in root command")
+	}
+
+	subcommands := map[string]bool{}
+	for _, cmd := range tunnelCmd.Commands() {
+		subcommands[cmd.Name()] = true
+	}
+	for _, name := range []string{"start", "ls", "stop", "restart", "supervise"} {
+		if !subcommands[name] {
+			t.Errorf("Expected tunnel %s subcommand to be defined", name)
+		}
+	}
+	if !tunnelSuperviseCmd.Hidden {
+		t.Error("Expected tunnel supervise to be hidden")
+	}
+
+	if tunnelStartCmd.Flags().Lookup("auto-restart") == nil {
+		t.Error("Expected --auto-restart flag to be defined")
+	}
+	if tunnelStopCmd.Flags().Lookup("all") == nil {
+		t.Error("Expected --all flag to be defined")
+	}
+}
+
+func TestWriteTunnels(t *testing.T) {
+	var output bytes.Buffer
+	writeTunnels(&output, nil, nil)
+	if !strings.Contains(output.String(), "No tunnel running") {
+		t.Errorf("Expected a message when there is no tunnel, got %q", output.String())
+	}
+
+	output.Reset()
+	tunnels := []*tunnel.Tunnel{
+		{ID: "db1-postgres", HostName: "db1", PID: 4242, Restarts: 2, AutoRestart: true, Forwards: []history.PortForwardConfig{{Type: "local", LocalPort: "15432", RemotePort: "5432"}}},
+		{ID: "web", HostName: "web", State: tunnel.StateFailed, Forwards: []history.PortForwardConfig{{Type: "dynamic", LocalPort: "1080"}}},
+	}
+	health := []tunnel.Health{{OK: true}, {Problem: "failed: Permission denied"}}
+	writeTunnels(&output, tunnels, health)
+
+	for _, expected := range []string{"TUNNEL", "db1-postgres", "-L 15432:localhost:5432", "4242", "2 (auto)", "healthy", "failed: Permission denied", "1/2 tunnel(s) healthy"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, output.String())
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package config
+
+// Column identifiers for the host table
+const (
+	ColumnName        = "name"
+	ColumnHostname    = "hostname"
+	ColumnUser        = "user"
+	ColumnPort        = "port"
+	ColumnProxyJump   = "proxy_jump"
+	ColumnSourceFile  = "source_file"
+	ColumnConnections = "connections"
+	ColumnLatency     = "latency"
+	ColumnTags        = "tags"
+	ColumnDescription = "description"
+	ColumnLastLogin   = "last_login"
+	ColumnHealth      = "health"
+)
+
+// columnAliases maps alternative column names to their canonical identifier
+var columnAliases = map[string]string{
+	"labels":    ColumnTags,
+	"proxyjump": ColumnProxyJump,
+	"file":      ColumnSourceFile,
+	"count":     ColumnConnections,
+	"ping":      ColumnLatency,
+	"sparkline": ColumnHealth,
+}
+
+// TableConfig represents the host table configuration
+type TableConfig struct {
+	// Columns lists the table columns in display order
+	Columns []ColumnConfig `json:"columns"`
+}
+
+// ColumnConfig represents a single table column and its width policy
+type ColumnConfig struct {
+	// ID identifies the column (name, hostname, user, port, proxy_jump, source_file,
+	// connections, latency, health, tags, description, last_login)
+	ID string `json:"id"`
+
+	// Hidden hides the column without losing its position
+	Hidden bool `json:"hidden,omitempty"`
+
+	// Width fixes the column width; 0 sizes the column from its content
+	Width int `json:"width,omitempty"`
+
+	// MinWidth is the width the column never shrinks below (0 = column default)
+	MinWidth int `json:"min_width,omitempty"`
+
+	// MaxWidth caps the width of the column (0 = column default)
+	MaxWidth int `json:"max_width,omitempty"`
+}
+
+// GetDefaultTableConfig returns the default table configuration
+func GetDefaultTableConfig() TableConfig {
+	return TableConfig{
+		Columns: []ColumnConfig{
+			{ID: ColumnName},
+			{ID: ColumnHostname},
+			{ID: ColumnTags},
+			{ID: ColumnLastLogin},
+		},
+	}
+}
+
+// NormalizeColumnID returns the canonical identifier for a column name
+func NormalizeColumnID(id string) string {
+	if canonical, ok := columnAliases[id]; ok {
+		return canonical
+	}
+	return id
+}
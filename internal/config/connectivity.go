@@ -0,0 +1,116 @@
+package config
+
+import "time"
+
+// Policies for the checks run when the TUI starts
+const (
+	RefreshNever  = "never"  // Only show the cached results
+	RefreshStale  = "stale"  // Check the hosts without a recent result
+	RefreshAlways = "always" // Check every host
+)
+
+// ConnectivityConfig represents the limits of the connectivity checks
+type ConnectivityConfig struct {
+	// Concurrency is the maximum number of hosts checked at once
+	Concurrency int `json:"concurrency"`
+
+	// JumpHostConcurrency is the maximum number of checks crossing the same jump host at once
+	JumpHostConcurrency int `json:"jump_host_concurrency"`
+
+	// RefreshOnStartup controls the checks run when the TUI starts, the
+	// results of the previous run being shown meanwhile: never, stale or always
+	RefreshOnStartup string `json:"refresh_on_startup,omitempty"`
+
+	// StaleAfter is the age from which a result is shown as stale (e.g. "15m")
+	StaleAfter string `json:"stale_after,omitempty"`
+
+	// Monitor re-checks the hosts in the background while the TUI runs
+	Monitor MonitorConfig `json:"monitor"`
+}
+
+// MonitorConfig represents the background health monitor of the TUI
+type MonitorConfig struct {
+	// Enabled starts the monitor with the TUI; it can also be toggled with M
+	Enabled bool `json:"enabled"`
+
+	// Interval is the delay between two rounds of checks (e.g. "1m")
+	Interval string `json:"interval,omitempty"`
+
+	// Jitter is the maximum random delay added to each interval, so that
+	// several sshm instances do not check the same hosts in step
+	Jitter string `json:"jitter,omitempty"`
+
+	// HistorySize is the number of samples kept per host
+	HistorySize int `json:"history_size,omitempty"`
+
+	// HistoryTTL is how long samples are kept (e.g. "24h")
+	HistoryTTL string `json:"history_ttl,omitempty"`
+
+	// PinnedHosts are the hosts whose state changes are announced
+	PinnedHosts []string `json:"pinned_hosts,omitempty"`
+
+	// Bell rings the terminal bell when a pinned host changes state,
+	// in addition to the message flashed above the search bar
+	Bell bool `json:"bell,omitempty"`
+}
+
+// GetDefaultConnectivityConfig returns the default connectivity check limits
+func GetDefaultConnectivityConfig() ConnectivityConfig {
+	return ConnectivityConfig{
+		Concurrency:         16,
+		JumpHostConcurrency: 4,
+		RefreshOnStartup:    RefreshNever,
+		StaleAfter:          "15m",
+		Monitor:             GetDefaultMonitorConfig(),
+	}
+}
+
+// StaleAfterDuration returns the age from which a result is shown as stale
+func (c ConnectivityConfig) StaleAfterDuration() time.Duration {
+	return parseDurationOr(c.StaleAfter, 15*time.Minute)
+}
+
+// GetDefaultMonitorConfig returns the default health monitor configuration
+func GetDefaultMonitorConfig() MonitorConfig {
+	return MonitorConfig{
+		Interval:    "1m",
+		Jitter:      "10s",
+		HistorySize: 60,
+		HistoryTTL:  "24h",
+	}
+}
+
+// IntervalDuration returns the delay between two rounds of checks
+func (c MonitorConfig) IntervalDuration() time.Duration {
+	return parseDurationOr(c.Interval, time.Minute)
+}
+
+// JitterDuration returns the maximum random delay added to each interval
+func (c MonitorConfig) JitterDuration() time.Duration {
+	return parseDurationOr(c.Jitter, 10*time.Second)
+}
+
+// HistoryTTLDuration returns how long samples are kept
+func (c MonitorConfig) HistoryTTLDuration() time.Duration {
+	return parseDurationOr(c.HistoryTTL, 24*time.Hour)
+}
+
+// IsPinned reports whether the state changes of a host are announced
+func (c MonitorConfig) IsPinned(hostName string) bool {
+	for _, pinned := range c.PinnedHosts {
+		if pinned == hostName {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDurationOr parses a duration such as "30s", falling back to
+// fallback when it is empty, invalid or negative
+func parseDurationOr(value string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// forwardKeywords maps the lower-cased ssh_config keywords setting up port
+// forwards to their usual spelling
+var forwardKeywords = map[string]string{
+	"localforward":   "LocalForward",
+	"remoteforward":  "RemoteForward",
+	"dynamicforward": "DynamicForward",
+}
+
+// TunnelHostSuffix is appended to the name of a host to name the alias
+// setting up its forwards without a session
+const TunnelHostSuffix = "-tunnel"
+
+// SplitForwardOptions separates the LocalForward, RemoteForward and
+// DynamicForward directives from the other options of a host, both in
+// config format. Forward keywords are given their usual spelling and
+// separated from their value by a space.
+func SplitForwardOptions(options string) (forwards []string, rest string) {
+	var others []string
+	for _, line := range strings.Split(options, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		keyword, value := splitOption(line)
+		if spelling, ok := forwardKeywords[strings.ToLower(keyword)]; ok {
+			forwards = append(forwards, spelling+" "+value)
+			continue
+		}
+		others = append(others, line)
+	}
+	return forwards, strings.Join(others, "\n")
+}
+
+// JoinForwardOptions appends forward directives to host options in config
+// format, leaving out the directives given twice
+func JoinForwardOptions(options string, forwards []string) string {
+	lines := []string{}
+	if options = strings.TrimSpace(options); options != "" {
+		lines = append(lines, options)
+	}
+
+	seen := make(map[string]bool)
+	for _, forward := range forwards {
+		if seen[forward] {
+			continue
+		}
+		seen[forward] = true
+		lines = append(lines, forward)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// AddHostForwards adds forward directives to the block of a host in the
+// file defining it, skipping the ones the host already has
+func AddHostForwards(host SSHHost, forwards []string) error {
+	if host.SourceFile == "" {
+		return fmt.Errorf("config file of host '%s' is unknown", host.Name)
+	}
+	existing, rest := SplitForwardOptions(host.Options)
+	host.Options = JoinForwardOptions(rest, append(existing, forwards...))
+	return UpdateSSHHostInFile(host.Name, host, host.SourceFile)
+}
+
+// NewTunnelHost returns an alias connecting to the same server as host that
+// only sets up the given forwards, without a shell or a remote command
+// (SessionType none, OpenSSH 8.7 or later)
+func NewTunnelHost(host SSHHost, forwards []string) SSHHost {
+	hostname := host.Hostname
+	if hostname == "" {
+		hostname = host.Name
+	}
+
+	// Keep the connection options of the host but not its own forwards
+	_, rest := SplitForwardOptions(host.Options)
+	var options []string
+	for _, line := range strings.Split(rest, "\n") {
+		keyword, _ := splitOption(line)
+		switch strings.ToLower(keyword) {
+		case "", "sessiontype", "exitonforwardfailure":
+			continue
+		}
+		options = append(options, line)
+	}
+	options = append(options, "SessionType none", "ExitOnForwardFailure yes")
+
+	return SSHHost{
+		Name:         host.Name + TunnelHostSuffix,
+		Hostname:     hostname,
+		User:         host.User,
+		Port:         host.Port,
+		Identity:     host.Identity,
+		ProxyJump:    host.ProxyJump,
+		ProxyCommand: host.ProxyCommand,
+		Options:      JoinForwardOptions(strings.Join(options, "\n"), forwards),
+		Description:  "Port forwards of " + host.Name,
+		Tags:         host.Tags,
+	}
+}
+
+// IsTunnelHost reports whether a host only sets up forwards (SessionType none)
+func IsTunnelHost(host SSHHost) bool {
+	for _, line := range strings.Split(host.Options, "\n") {
+		keyword, value := splitOption(line)
+		if strings.EqualFold(keyword, "SessionType") && strings.EqualFold(value, "none") {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveTunnelHost adds a tunnel alias made by NewTunnelHost to a config file.
+// If the alias already exists, the new forwards are added to it.
+func SaveTunnelHost(tunnel SSHHost, configPath string) error {
+	existing, err := GetSSHHostFromFile(tunnel.Name, configPath)
+	if err != nil {
+		return AddSSHHostToFile(tunnel, configPath)
+	}
+	if !IsTunnelHost(*existing) {
+		return fmt.Errorf("host '%s' already exists and is not a tunnel (SessionType none)", tunnel.Name)
+	}
+
+	forwards, _ := SplitForwardOptions(tunnel.Options)
+	return AddHostForwards(*existing, forwards)
+}
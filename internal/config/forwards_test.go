@@ -0,0 +1,158 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitForwardOptions(t *testing.T) {
+	options := "Compression yes\nlocalforward 8080   localhost:80\nDynamicForward 1080\nServerAliveInterval 60"
+
+	forwards, rest := SplitForwardOptions(options)
+	wantForwards := []string{"LocalForward 8080 localhost:80", "DynamicForward 1080"}
+	if !reflect.DeepEqual(forwards, wantForwards) {
+		t.Errorf("forwards = %v, want %v", forwards, wantForwards)
+	}
+	if rest != "Compression yes\nServerAliveInterval 60" {
+		t.Errorf("rest = %q", rest)
+	}
+
+	joined := JoinForwardOptions(rest, append(forwards, "DynamicForward 1080"))
+	if joined != "Compression yes\nServerAliveInterval 60\nLocalForward 8080 localhost:80\nDynamicForward 1080" {
+		t.Errorf("JoinForwardOptions() = %q", joined)
+	}
+	if got := JoinForwardOptions("", []string{"DynamicForward 1080"}); got != "DynamicForward 1080" {
+		t.Errorf("JoinForwardOptions() without options = %q", got)
+	}
+}
+
+func TestSplitForwardOptionsSyntax(t *testing.T) {
+	tests := []struct {
+		name    string
+		options string
+		want    []string
+	}{
+		{"equals sign", "LocalForward=8080 localhost:80", []string{"LocalForward 8080 localhost:80"}},
+		{"spaced equals sign", "RemoteForward = 9000 localhost:3000", []string{"RemoteForward 9000 localhost:3000"}},
+		{"equals sign before value", "DynamicForward= 1080", []string{"DynamicForward 1080"}},
+		{"tabs", "LocalForward\t8080\tlocalhost:80", []string{"LocalForward 8080 localhost:80"}},
+		{"single argument", "LocalForward=8080:localhost:80", []string{"LocalForward 8080:localhost:80"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forwards, rest := SplitForwardOptions("Compression=yes\n" + tt.options)
+			if !reflect.DeepEqual(forwards, tt.want) {
+				t.Errorf("forwards = %q, want %q", forwards, tt.want)
+			}
+			if rest != "Compression=yes" {
+				t.Errorf("rest = %q, want the other options only", rest)
+			}
+		})
+	}
+}
+
+func TestNewTunnelHostOptionSyntax(t *testing.T) {
+	host := SSHHost{
+		Name:    "db1",
+		Options: "Compression=yes\nLocalForward=5432 localhost:5432\nSessionType\tdefault\nExitOnForwardFailure=no",
+	}
+
+	tunnel := NewTunnelHost(host, []string{"LocalForward 15432 localhost:5432"})
+	want := "Compression=yes\nSessionType none\nExitOnForwardFailure yes\nLocalForward 15432 localhost:5432"
+	if tunnel.Options != want {
+		t.Errorf("Options = %q, want %q", tunnel.Options, want)
+	}
+	if !IsTunnelHost(SSHHost{Options: "SessionType=none"}) {
+		t.Error("Expected SessionType=none to make a tunnel host")
+	}
+}
+
+// writeForwardsConfig writes a config file with a db1 host and keeps the
+// backups out of the real configuration directory
+func writeForwardsConfig(t *testing.T) string {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	configFile := filepath.Join(t.TempDir(), "config")
+	content := `# Tags: prod
+Host db1
+    HostName db1.example.com
+    User admin
+    Compression yes
+    LocalForward 5432 localhost:5432
+`
+	if err := os.WriteFile(configFile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return configFile
+}
+
+func TestAddHostForwards(t *testing.T) {
+	configFile := writeForwardsConfig(t)
+	host, err := GetSSHHostFromFile("db1", configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AddHostForwards(*host, []string{"LocalForward 5432 localhost:5432", "DynamicForward 1080"}); err != nil {
+		t.Fatalf("AddHostForwards() error = %v", err)
+	}
+
+	host, err = GetSSHHostFromFile("db1", configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forwards, rest := SplitForwardOptions(host.Options)
+	if !reflect.DeepEqual(forwards, []string{"LocalForward 5432 localhost:5432", "DynamicForward 1080"}) {
+		t.Errorf("Expected the new forward to be added once, got %v", forwards)
+	}
+	if rest != "Compression yes" || host.User != "admin" || !reflect.DeepEqual(host.Tags, []string{"prod"}) {
+		t.Errorf("Expected the rest of the host to be kept, got %+v", host)
+	}
+}
+
+func TestSaveTunnelHost(t *testing.T) {
+	configFile := writeForwardsConfig(t)
+	host, err := GetSSHHostFromFile("db1", configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tunnel := NewTunnelHost(*host, []string{"LocalForward 15432 localhost:5432"})
+	if tunnel.Name != "db1-tunnel" || tunnel.Hostname != "db1.example.com" || !IsTunnelHost(tunnel) {
+		t.Fatalf("Unexpected tunnel host %+v", tunnel)
+	}
+	if forwards, _ := SplitForwardOptions(tunnel.Options); !reflect.DeepEqual(forwards, []string{"LocalForward 15432 localhost:5432"}) {
+		t.Errorf("Expected only the given forwards, got %v", forwards)
+	}
+	if err := SaveTunnelHost(tunnel, configFile); err != nil {
+		t.Fatalf("SaveTunnelHost() error = %v", err)
+	}
+
+	// Saving it again adds the forwards to the existing alias
+	again := NewTunnelHost(*host, []string{"DynamicForward 1080"})
+	if err := SaveTunnelHost(again, configFile); err != nil {
+		t.Fatalf("SaveTunnelHost() again error = %v", err)
+	}
+
+	saved, err := GetSSHHostFromFile("db1-tunnel", configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forwards, rest := SplitForwardOptions(saved.Options)
+	if !reflect.DeepEqual(forwards, []string{"LocalForward 15432 localhost:5432", "DynamicForward 1080"}) {
+		t.Errorf("forwards = %v", forwards)
+	}
+	if rest != "Compression yes\nSessionType none\nExitOnForwardFailure yes" || saved.User != "admin" {
+		t.Errorf("Unexpected tunnel host %+v", saved)
+	}
+
+	// A host that is not a tunnel is not changed
+	if err := SaveTunnelHost(SSHHost{Name: "db1", Options: "SessionType none"}, configFile); err == nil {
+		t.Error("Expected an error replacing a host that is not a tunnel")
+	}
+}
@@ -18,7 +18,12 @@ type KeyBindings struct {
 
 // AppConfig represents the main application configuration
 type AppConfig struct {
-	KeyBindings KeyBindings `json:"key_bindings"`
+	KeyBindings  KeyBindings        `json:"key_bindings"`
+	Theme        ThemeConfig        `json:"theme"`
+	Table        TableConfig        `json:"table"`
+	Layout       LayoutConfig       `json:"layout"`
+	Session      SessionConfig      `json:"session"`
+	Connectivity ConnectivityConfig `json:"connectivity"`
 }
 
 // GetDefaultKeyBindings returns the default key bindings configuration
@@ -32,7 +37,10 @@ func GetDefaultKeyBindings() KeyBindings {
 // GetDefaultAppConfig returns the default application configuration
 func GetDefaultAppConfig() AppConfig {
 	return AppConfig{
-		KeyBindings: GetDefaultKeyBindings(),
+		KeyBindings:  GetDefaultKeyBindings(),
+		Theme:        GetDefaultThemeConfig(),
+		Table:        GetDefaultTableConfig(),
+		Connectivity: GetDefaultConnectivityConfig(),
 	}
 }
 
@@ -125,6 +133,46 @@ func mergeWithDefaults(config AppConfig) AppConfig {
 		config.KeyBindings.QuitKeys = defaults.KeyBindings.QuitKeys
 	}
 
+	// If no theme is selected, use the default
+	if config.Theme.Name == "" {
+		config.Theme.Name = defaults.Theme.Name
+	}
+
+	// If no columns are configured, use the default layout
+	if len(config.Table.Columns) == 0 {
+		config.Table.Columns = defaults.Table.Columns
+	}
+
+	// If connectivity limits are missing, use the defaults
+	if config.Connectivity.Concurrency <= 0 {
+		config.Connectivity.Concurrency = defaults.Connectivity.Concurrency
+	}
+	if config.Connectivity.JumpHostConcurrency <= 0 {
+		config.Connectivity.JumpHostConcurrency = defaults.Connectivity.JumpHostConcurrency
+	}
+
+	if config.Connectivity.RefreshOnStartup == "" {
+		config.Connectivity.RefreshOnStartup = defaults.Connectivity.RefreshOnStartup
+	}
+	if config.Connectivity.StaleAfter == "" {
+		config.Connectivity.StaleAfter = defaults.Connectivity.StaleAfter
+	}
+
+	// Fill in the unset monitor settings
+	monitor := &config.Connectivity.Monitor
+	if monitor.Interval == "" {
+		monitor.Interval = defaults.Connectivity.Monitor.Interval
+	}
+	if monitor.Jitter == "" {
+		monitor.Jitter = defaults.Connectivity.Monitor.Jitter
+	}
+	if monitor.HistorySize <= 0 {
+		monitor.HistorySize = defaults.Connectivity.Monitor.HistorySize
+	}
+	if monitor.HistoryTTL == "" {
+		monitor.HistoryTTL = defaults.Connectivity.Monitor.HistoryTTL
+	}
+
 	return config
 }
 
@@ -143,4 +191,4 @@ func (kb *KeyBindings) ShouldQuitOnKey(key string) bool {
 	}
 
 	return false
-}
\ No newline at end of file
+}
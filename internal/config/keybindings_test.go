@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestDefaultKeyBindings(t *testing.T) {
@@ -129,6 +130,57 @@ func TestMergeWithDefaults(t *testing.T) {
 	}
 }
 
+func TestMergeWithDefaultsTheme(t *testing.T) {
+	// Missing theme name falls back to the default
+	merged := mergeWithDefaults(AppConfig{})
+	if merged.Theme.Name != DefaultThemeName {
+		t.Errorf("Expected theme %q, got %q", DefaultThemeName, merged.Theme.Name)
+	}
+
+	// An explicit theme and custom themes are preserved
+	bold := true
+	custom := AppConfig{
+		Theme: ThemeConfig{
+			Name: "mine",
+			CustomThemes: map[string]CustomTheme{
+				"mine": {
+					Base:   "light",
+					Colors: ThemeColors{Primary: "#FF00FF"},
+					Styles: map[string]StyleOverride{"header": {Bold: &bold}},
+				},
+			},
+		},
+	}
+	merged = mergeWithDefaults(custom)
+	if merged.Theme.Name != "mine" {
+		t.Errorf("Expected theme 'mine', got %q", merged.Theme.Name)
+	}
+	if merged.Theme.CustomThemes["mine"].Colors.Primary != "#FF00FF" {
+		t.Error("Custom theme colors should be preserved")
+	}
+}
+
+func TestMergeWithDefaultsConnectivity(t *testing.T) {
+	// Missing limits fall back to the defaults
+	merged := mergeWithDefaults(AppConfig{})
+	defaults := GetDefaultConnectivityConfig()
+	if merged.Connectivity.Concurrency != defaults.Concurrency || merged.Connectivity.JumpHostConcurrency != defaults.JumpHostConcurrency {
+		t.Errorf("Expected default connectivity limits, got %+v", merged.Connectivity)
+	}
+	if merged.Connectivity.RefreshOnStartup != RefreshNever || merged.Connectivity.StaleAfterDuration() != 15*time.Minute {
+		t.Errorf("Expected the default startup policy, got %+v", merged.Connectivity)
+	}
+	if merged.Connectivity.Monitor.Interval != "1m" || merged.Connectivity.Monitor.HistorySize != 60 {
+		t.Errorf("Expected default monitor settings, got %+v", merged.Connectivity.Monitor)
+	}
+
+	// Explicit limits are preserved
+	merged = mergeWithDefaults(AppConfig{Connectivity: ConnectivityConfig{Concurrency: 64, JumpHostConcurrency: 1}})
+	if merged.Connectivity.Concurrency != 64 || merged.Connectivity.JumpHostConcurrency != 1 {
+		t.Errorf("Expected limits 64/1, got %+v", merged.Connectivity)
+	}
+}
+
 func TestSaveAndLoadAppConfigIntegration(t *testing.T) {
 	// Create a temporary directory for testing
 	tempDir, err := os.MkdirTemp("", "sshm_test")
@@ -178,4 +230,17 @@ func TestSaveAndLoadAppConfigIntegration(t *testing.T) {
 	if len(loadedConfig.KeyBindings.QuitKeys) != 1 || loadedConfig.KeyBindings.QuitKeys[0] != "q" {
 		t.Errorf("Expected quit keys to be ['q'], got %v", loadedConfig.KeyBindings.QuitKeys)
 	}
-}
\ No newline at end of file
+}
+func TestMonitorConfigDurations(t *testing.T) {
+	monitor := MonitorConfig{Interval: "30s", Jitter: "invalid", HistoryTTL: "-1h"}
+
+	if got := monitor.IntervalDuration(); got != 30*time.Second {
+		t.Errorf("IntervalDuration() = %v, want 30s", got)
+	}
+	if got := monitor.JitterDuration(); got != 10*time.Second {
+		t.Errorf("JitterDuration() = %v, want the default 10s", got)
+	}
+	if got := monitor.HistoryTTLDuration(); got != 24*time.Hour {
+		t.Errorf("HistoryTTLDuration() = %v, want the default 24h", got)
+	}
+}
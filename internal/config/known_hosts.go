@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// GetOption returns the value of an SSH option stored in the Options of a
+// host (e.g. "UserKnownHostsFile"), matching the keyword case-insensitively.
+// The second value reports whether the option is set.
+func (h SSHHost) GetOption(keyword string) (string, bool) {
+	for _, line := range strings.Split(h.Options, "\n") {
+		key, value := splitOption(line)
+		if key != "" && strings.EqualFold(key, keyword) {
+			return strings.Trim(value, `"`), true
+		}
+	}
+	return "", false
+}
+
+// splitOption splits a line of options in config format into its keyword
+// and its value, separated by whitespace or "=" (e.g. "Keyword = value").
+// Whitespace in the value is collapsed.
+func splitOption(line string) (keyword, value string) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", ""
+	}
+
+	keyword, value = fields[0], strings.Join(fields[1:], " ")
+	if eq := strings.Index(keyword, "="); eq >= 0 {
+		value = keyword[eq+1:] + " " + value
+		keyword = keyword[:eq]
+	}
+	return keyword, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(value), "="))
+}
+
+// GetDefaultUserKnownHostsFiles returns the user known hosts files ssh reads by default
+func GetDefaultUserKnownHostsFiles() []string {
+	sshDir, err := GetSSHDirectory()
+	if err != nil {
+		return nil
+	}
+	return []string{
+		filepath.Join(sshDir, "known_hosts"),
+		filepath.Join(sshDir, "known_hosts2"),
+	}
+}
+
+// GetDefaultGlobalKnownHostsFiles returns the system-wide known hosts files ssh reads by default
+func GetDefaultGlobalKnownHostsFiles() []string {
+	dir := "/etc/ssh"
+	if runtime.GOOS == "windows" {
+		programData := os.Getenv("ProgramData")
+		if programData == "" {
+			programData = `C:\ProgramData`
+		}
+		dir = filepath.Join(programData, "ssh")
+	}
+	return []string{
+		filepath.Join(dir, "ssh_known_hosts"),
+		filepath.Join(dir, "ssh_known_hosts2"),
+	}
+}
+
+// GetKnownHostsFiles returns the known hosts files used to verify the key
+// of a host: its UserKnownHostsFile and GlobalKnownHostsFile options if set,
+// or the defaults. "none" disables a list. Files may not exist.
+func GetKnownHostsFiles(host SSHHost) []string {
+	var files []string
+	for _, option := range []struct {
+		keyword  string
+		defaults []string
+	}{
+		{"UserKnownHostsFile", GetDefaultUserKnownHostsFiles()},
+		{"GlobalKnownHostsFile", GetDefaultGlobalKnownHostsFiles()},
+	} {
+		value, ok := host.GetOption(option.keyword)
+		if !ok {
+			files = append(files, option.defaults...)
+			continue
+		}
+		if strings.EqualFold(value, "none") {
+			continue
+		}
+		for _, file := range strings.Fields(value) {
+			files = append(files, ExpandHomePath(file))
+		}
+	}
+	return files
+}
+
+// GetHostKeyName returns the name the key of a host is recorded under in
+// known hosts files: its HostKeyAlias if set, or its hostname
+func GetHostKeyName(host SSHHost) string {
+	if alias, ok := host.GetOption("HostKeyAlias"); ok && alias != "" {
+		return alias
+	}
+	if host.Hostname != "" {
+		return host.Hostname
+	}
+	return host.Name
+}
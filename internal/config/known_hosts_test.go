@@ -0,0 +1,86 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSSHHostGetOption(t *testing.T) {
+	host := SSHHost{Options: "StrictHostKeyChecking no\nUserKnownHostsFile = ~/.ssh/work_hosts\nhostkeyalias \"db\""}
+
+	tests := []struct {
+		keyword string
+		want    string
+		wantOk  bool
+	}{
+		{"StrictHostKeyChecking", "no", true},
+		{"userknownhostsfile", "~/.ssh/work_hosts", true},
+		{"HostKeyAlias", "db", true},
+		{"GlobalKnownHostsFile", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := host.GetOption(tt.keyword)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("GetOption(%q) = %q, %v, want %q, %v", tt.keyword, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func TestGetKnownHostsFiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	defaults := append(GetDefaultUserKnownHostsFiles(), GetDefaultGlobalKnownHostsFiles()...)
+
+	tests := []struct {
+		name    string
+		options string
+		want    []string
+	}{
+		{"defaults", "", defaults},
+		{
+			"user override",
+			"UserKnownHostsFile ~/.ssh/work_hosts /tmp/other_hosts",
+			append([]string{filepath.Join(home, ".ssh", "work_hosts"), "/tmp/other_hosts"}, GetDefaultGlobalKnownHostsFiles()...),
+		},
+		{
+			"disabled lists",
+			"UserKnownHostsFile none\nGlobalKnownHostsFile none",
+			nil,
+		},
+		{
+			"global override",
+			"GlobalKnownHostsFile /etc/ssh/extra_hosts",
+			append(GetDefaultUserKnownHostsFiles(), "/etc/ssh/extra_hosts"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GetKnownHostsFiles(SSHHost{Name: "web", Options: tt.options})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetKnownHostsFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetHostKeyName(t *testing.T) {
+	tests := []struct {
+		host SSHHost
+		want string
+	}{
+		{SSHHost{Name: "web", Hostname: "10.0.0.5"}, "10.0.0.5"},
+		{SSHHost{Name: "web"}, "web"},
+		{SSHHost{Name: "web", Hostname: "10.0.0.5", Options: "HostKeyAlias web.internal"}, "web.internal"},
+	}
+
+	for _, tt := range tests {
+		if got := GetHostKeyName(tt.host); got != tt.want {
+			t.Errorf("GetHostKeyName(%+v) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
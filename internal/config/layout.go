@@ -0,0 +1,10 @@
+package config
+
+// LayoutConfig represents the layout of the main list view
+type LayoutConfig struct {
+	// ShowPreview shows a preview pane with the selected host's details next to the table
+	ShowPreview bool `json:"show_preview"`
+
+	// PreviewWidth fixes the width of the preview pane; 0 sizes it from the terminal width
+	PreviewWidth int `json:"preview_width,omitempty"`
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// JumpHop represents a single hop of a ProxyJump chain
+type JumpHop struct {
+	// Spec is the hop as written in the ProxyJump directive (e.g. "admin@bastion:2222")
+	Spec string
+
+	// Alias is the configured host the hop refers to, or nil if it is not a configured alias
+	Alias *SSHHost
+
+	// Hostname, User and Port are the effective connection parameters of the hop
+	Hostname string
+	User     string
+	Port     string
+}
+
+// Name returns the name used to display the hop
+func (h JumpHop) Name() string {
+	if h.Alias != nil {
+		return h.Alias.Name
+	}
+	return h.Hostname
+}
+
+// Address returns the host:port address of the hop
+func (h JumpHop) Address() string {
+	port := h.Port
+	if port == "" {
+		port = "22"
+	}
+	return net.JoinHostPort(h.Hostname, port)
+}
+
+// ParseProxyJump splits a ProxyJump value into its hops, in connection order.
+// It does not resolve aliases; use ResolveProxyJumpChain for that.
+func ParseProxyJump(value string) []JumpHop {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "none") {
+		return nil
+	}
+
+	var hops []JumpHop
+	for _, spec := range strings.Split(value, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		hops = append(hops, parseJumpSpec(spec))
+	}
+	return hops
+}
+
+// parseJumpSpec parses a single [user@]host[:port] jump specification
+func parseJumpSpec(spec string) JumpHop {
+	hop := JumpHop{Spec: spec}
+
+	hostPart := strings.TrimPrefix(spec, "ssh://")
+	if at := strings.LastIndex(hostPart, "@"); at >= 0 {
+		hop.User = hostPart[:at]
+		hostPart = hostPart[at+1:]
+	}
+
+	if host, port, err := net.SplitHostPort(hostPart); err == nil {
+		hop.Hostname = host
+		hop.Port = port
+	} else {
+		// No port, possibly a bracketed IPv6 literal
+		hop.Hostname = strings.Trim(hostPart, "[]")
+	}
+
+	return hop
+}
+
+// ResolveProxyJumpChain returns the complete jump chain needed to reach a host,
+// in connection order. Hops that refer to configured aliases are resolved to the
+// alias' hostname, user and port, and the alias' own ProxyJump is followed
+// recursively. An error is returned if the chain contains a loop.
+func ResolveProxyJumpChain(host SSHHost, hosts []SSHHost) ([]JumpHop, error) {
+	byName := make(map[string]*SSHHost, len(hosts))
+	for i := range hosts {
+		if _, exists := byName[hosts[i].Name]; !exists {
+			byName[hosts[i].Name] = &hosts[i]
+		}
+	}
+
+	return resolveJumpChain(host, byName, map[string]bool{host.Name: true})
+}
+
+// resolveJumpChain resolves the ProxyJump chain of a host, tracking visited aliases
+func resolveJumpChain(host SSHHost, byName map[string]*SSHHost, visiting map[string]bool) ([]JumpHop, error) {
+	var chain []JumpHop
+
+	for _, hop := range ParseProxyJump(host.ProxyJump) {
+		alias, ok := byName[hop.Hostname]
+		if !ok {
+			chain = append(chain, hop)
+			continue
+		}
+
+		if visiting[alias.Name] {
+			return nil, fmt.Errorf("ProxyJump loop detected at '%s'", alias.Name)
+		}
+
+		// The alias' own jump hosts must be crossed first
+		visiting[alias.Name] = true
+		parent, err := resolveJumpChain(*alias, byName, visiting)
+		delete(visiting, alias.Name)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, parent...)
+
+		hop.Alias = alias
+		if alias.Hostname != "" {
+			hop.Hostname = alias.Hostname
+		}
+		if hop.User == "" {
+			hop.User = alias.User
+		}
+		if hop.Port == "" {
+			hop.Port = alias.Port
+		}
+		chain = append(chain, hop)
+	}
+
+	return chain, nil
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseProxyJump(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected []JumpHop
+	}{
+		{"", nil},
+		{"none", nil},
+		{"bastion", []JumpHop{{Spec: "bastion", Hostname: "bastion"}}},
+		{"admin@bastion:2222", []JumpHop{{Spec: "admin@bastion:2222", Hostname: "bastion", User: "admin", Port: "2222"}}},
+		{"[2001:db8::1]:22", []JumpHop{{Spec: "[2001:db8::1]:22", Hostname: "2001:db8::1", Port: "22"}}},
+		{"jump1, user@jump2", []JumpHop{
+			{Spec: "jump1", Hostname: "jump1"},
+			{Spec: "user@jump2", Hostname: "jump2", User: "user"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			hops := ParseProxyJump(tt.value)
+			if len(hops) != len(tt.expected) {
+				t.Fatalf("ParseProxyJump(%q) returned %d hops, want %d", tt.value, len(hops), len(tt.expected))
+			}
+			for i, hop := range hops {
+				if hop != tt.expected[i] {
+					t.Errorf("hop %d = %+v, want %+v", i, hop, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestResolveProxyJumpChain(t *testing.T) {
+	hosts := []SSHHost{
+		{Name: "edge", Hostname: "edge.example.com", User: "ops"},
+		{Name: "bastion", Hostname: "10.0.0.1", Port: "2222", ProxyJump: "edge"},
+		{Name: "db", Hostname: "10.0.1.5", ProxyJump: "bastion,root@other.example.com"},
+		{Name: "loop-a", ProxyJump: "loop-b"},
+		{Name: "loop-b", ProxyJump: "loop-a"},
+	}
+
+	chain, err := ResolveProxyJumpChain(hosts[2], hosts)
+	if err != nil {
+		t.Fatalf("ResolveProxyJumpChain() error = %v", err)
+	}
+
+	var names []string
+	for _, hop := range chain {
+		names = append(names, hop.Name())
+	}
+	if got := strings.Join(names, " -> "); got != "edge -> bastion -> other.example.com" {
+		t.Errorf("Unexpected chain: %s", got)
+	}
+
+	if chain[1].Address() != "10.0.0.1:2222" {
+		t.Errorf("Expected bastion to be resolved to its hostname and port, got %s", chain[1].Address())
+	}
+	if chain[0].User != "ops" || chain[2].User != "root" {
+		t.Errorf("Unexpected hop users: %q, %q", chain[0].User, chain[2].User)
+	}
+	if chain[2].Alias != nil {
+		t.Error("Unconfigured hop should not have an alias")
+	}
+
+	if _, err := ResolveProxyJumpChain(hosts[3], hosts); err == nil {
+		t.Error("Expected an error for a ProxyJump loop")
+	}
+}
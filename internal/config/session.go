@@ -0,0 +1,8 @@
+package config
+
+// SessionConfig represents the behaviour of SSH sessions started from the TUI
+type SessionConfig struct {
+	// ReturnToList returns to the host list when an SSH session or port forwarding
+	// ends, instead of exiting sshm
+	ReturnToList bool `json:"return_to_list"`
+}
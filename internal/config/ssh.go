@@ -23,6 +23,7 @@ type SSHHost struct {
 	Options       string
 	RemoteCommand string // Command to execute after SSH connection
 	RequestTTY    string // Request TTY (yes, no, force, auto)
+	Description   string // Free-form description from a "# Description:" comment in the host block
 	Tags          []string
 	SourceFile    string // Path to the config file where this host is defined
 	LineNumber    int    // Line number in the source file where this host block starts (1-indexed)
@@ -96,6 +97,16 @@ func GetSSHDirectory() (string, error) {
 	return filepath.Join(homeDir, ".ssh"), nil
 }
 
+// ExpandHomePath expands a leading ~ in a path to the home directory
+func ExpandHomePath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
 // ensureSSHDirectory creates the .ssh directory with appropriate permissions
 func ensureSSHDirectory() error {
 	sshDir, err := GetSSHDirectory()
@@ -240,6 +251,14 @@ func parseSSHConfigFileWithProcessedFiles(configPath string, processedFiles map[
 			continue
 		}
 
+		// Check for description comment inside a host block
+		if strings.HasPrefix(line, "# Description:") {
+			if currentHost != nil {
+				currentHost.Description = strings.TrimSpace(strings.TrimPrefix(line, "# Description:"))
+			}
+			continue
+		}
+
 		// Ignore other comments
 		if strings.HasPrefix(line, "#") {
 			continue
@@ -545,6 +564,49 @@ func AddSSHHost(host SSHHost) error {
 	return AddSSHHostToFile(host, configPath)
 }
 
+// hostBlockLines returns the lines of a host block declaring the Host
+// patterns: the tags comment, the Host line, the description comment and
+// the directives of the host
+func hostBlockLines(patterns string, host SSHHost) []string {
+	var lines []string
+	if len(host.Tags) > 0 {
+		lines = append(lines, "# Tags: "+strings.Join(host.Tags, ", "))
+	}
+	lines = append(lines, "Host "+patterns)
+	if host.Description != "" {
+		lines = append(lines, "    # Description: "+host.Description)
+	}
+	lines = append(lines, "    HostName "+host.Hostname)
+	if host.User != "" {
+		lines = append(lines, "    User "+host.User)
+	}
+	if host.Port != "" && host.Port != "22" {
+		lines = append(lines, "    Port "+host.Port)
+	}
+	if host.Identity != "" {
+		lines = append(lines, "    IdentityFile "+formatSSHConfigValue(host.Identity))
+	}
+	if host.ProxyJump != "" {
+		lines = append(lines, "    ProxyJump "+host.ProxyJump)
+	}
+	if host.ProxyCommand != "" {
+		lines = append(lines, "    ProxyCommand="+host.ProxyCommand)
+	}
+	if host.RemoteCommand != "" {
+		lines = append(lines, "    RemoteCommand "+host.RemoteCommand)
+	}
+	if host.RequestTTY != "" {
+		lines = append(lines, "    RequestTTY "+host.RequestTTY)
+	}
+	// Write SSH options
+	for _, option := range strings.Split(host.Options, "\n") {
+		if option = strings.TrimSpace(option); option != "" {
+			lines = append(lines, "    "+option)
+		}
+	}
+	return lines
+}
+
 // AddSSHHostToFile adds a new SSH host to a specific config file
 func AddSSHHostToFile(host SSHHost, configPath string) error {
 	configMutex.Lock()
@@ -579,89 +641,13 @@ func AddSSHHostToFile(host SSHHost, configPath string) error {
 		return err
 	}
 
-	// Write tags if present
-	if len(host.Tags) > 0 {
-		_, err = file.WriteString("# Tags: " + strings.Join(host.Tags, ", ") + "\n")
-		if err != nil {
-			return err
-		}
-	}
-
 	// Write host configuration
-	_, err = file.WriteString(fmt.Sprintf("Host %s\n", host.Name))
-	if err != nil {
-		return err
-	}
-
-	_, err = file.WriteString(fmt.Sprintf("    HostName %s\n", host.Hostname))
-	if err != nil {
-		return err
-	}
-
-	if host.User != "" {
-		_, err = file.WriteString(fmt.Sprintf("    User %s\n", host.User))
-		if err != nil {
-			return err
-		}
-	}
-
-	if host.Port != "" && host.Port != "22" {
-		_, err = file.WriteString(fmt.Sprintf("    Port %s\n", host.Port))
-		if err != nil {
-			return err
-		}
-	}
-
-	if host.Identity != "" {
-		_, err = file.WriteString(fmt.Sprintf("    IdentityFile %s\n", formatSSHConfigValue(host.Identity)))
-		if err != nil {
-			return err
-		}
-	}
-
-	if host.ProxyJump != "" {
-		_, err = file.WriteString(fmt.Sprintf("    ProxyJump %s\n", host.ProxyJump))
-		if err != nil {
-			return err
-		}
-	}
-
-	if host.ProxyCommand != "" {
-		_, err = file.WriteString(fmt.Sprintf("    ProxyCommand=%s\n", host.ProxyCommand))
-		if err != nil {
-			return err
-		}
-	}
-
-	if host.RemoteCommand != "" {
-		_, err = file.WriteString(fmt.Sprintf("    RemoteCommand %s\n", host.RemoteCommand))
-		if err != nil {
+	for _, line := range hostBlockLines(host.Name, host) {
+		if _, err := file.WriteString(line + "\n"); err != nil {
 			return err
 		}
 	}
 
-	if host.RequestTTY != "" {
-		_, err = file.WriteString(fmt.Sprintf("    RequestTTY %s\n", host.RequestTTY))
-		if err != nil {
-			return err
-		}
-	}
-
-	// Write SSH options
-	if host.Options != "" {
-		// Split options by newlines and write each one
-		options := strings.Split(host.Options, "\n")
-		for _, option := range options {
-			option = strings.TrimSpace(option)
-			if option != "" {
-				_, err = file.WriteString(fmt.Sprintf("    %s\n", option))
-				if err != nil {
-					return err
-				}
-			}
-		}
-	}
-
 	return nil
 }
 
@@ -1077,42 +1063,7 @@ func UpdateSSHHostInFile(oldName string, newHost SSHHost, configPath string) err
 
 						// Add the new host as a separate entry
 						newLines = append(newLines, "")
-						if len(newHost.Tags) > 0 {
-							newLines = append(newLines, "# Tags: "+strings.Join(newHost.Tags, ", "))
-						}
-						newLines = append(newLines, "Host "+newHost.Name)
-						newLines = append(newLines, "    HostName "+newHost.Hostname)
-						if newHost.User != "" {
-							newLines = append(newLines, "    User "+newHost.User)
-						}
-						if newHost.Port != "" && newHost.Port != "22" {
-							newLines = append(newLines, "    Port "+newHost.Port)
-						}
-						if newHost.Identity != "" {
-							newLines = append(newLines, "    IdentityFile "+formatSSHConfigValue(newHost.Identity))
-						}
-						if newHost.ProxyJump != "" {
-							newLines = append(newLines, "    ProxyJump "+newHost.ProxyJump)
-						}
-						if newHost.ProxyCommand != "" {
-							newLines = append(newLines, "    ProxyCommand="+newHost.ProxyCommand)
-						}
-						if newHost.RemoteCommand != "" {
-							newLines = append(newLines, "    RemoteCommand "+newHost.RemoteCommand)
-						}
-						if newHost.RequestTTY != "" {
-							newLines = append(newLines, "    RequestTTY "+newHost.RequestTTY)
-						}
-						// Write SSH options
-						if newHost.Options != "" {
-							options := strings.Split(newHost.Options, "\n")
-							for _, option := range options {
-								option = strings.TrimSpace(option)
-								if option != "" {
-									newLines = append(newLines, "    "+option)
-								}
-							}
-						}
+						newLines = append(newLines, hostBlockLines(newHost.Name, newHost)...)
 						newLines = append(newLines, "")
 
 						continue
@@ -1134,42 +1085,7 @@ func UpdateSSHHostInFile(oldName string, newHost SSHHost, configPath string) err
 						if len(newLines) > 0 && strings.TrimSpace(newLines[len(newLines)-1]) != "" {
 							newLines = append(newLines, "")
 						}
-						if len(newHost.Tags) > 0 {
-							newLines = append(newLines, "# Tags: "+strings.Join(newHost.Tags, ", "))
-						}
-						newLines = append(newLines, "Host "+newHost.Name)
-						newLines = append(newLines, "    HostName "+newHost.Hostname)
-						if newHost.User != "" {
-							newLines = append(newLines, "    User "+newHost.User)
-						}
-						if newHost.Port != "" && newHost.Port != "22" {
-							newLines = append(newLines, "    Port "+newHost.Port)
-						}
-						if newHost.Identity != "" {
-							newLines = append(newLines, "    IdentityFile "+formatSSHConfigValue(newHost.Identity))
-						}
-						if newHost.ProxyJump != "" {
-							newLines = append(newLines, "    ProxyJump "+newHost.ProxyJump)
-						}
-						if newHost.ProxyCommand != "" {
-							newLines = append(newLines, "    ProxyCommand="+newHost.ProxyCommand)
-						}
-						if newHost.RemoteCommand != "" {
-							newLines = append(newLines, "    RemoteCommand "+newHost.RemoteCommand)
-						}
-						if newHost.RequestTTY != "" {
-							newLines = append(newLines, "    RequestTTY "+newHost.RequestTTY)
-						}
-						// Write SSH options
-						if newHost.Options != "" {
-							options := strings.Split(newHost.Options, "\n")
-							for _, option := range options {
-								option = strings.TrimSpace(option)
-								if option != "" {
-									newLines = append(newLines, "    "+option)
-								}
-							}
-						}
+						newLines = append(newLines, hostBlockLines(newHost.Name, newHost)...)
 
 						// Add empty line after the host configuration for separation
 						newLines = append(newLines, "")
@@ -1227,42 +1143,7 @@ func UpdateSSHHostInFile(oldName string, newHost SSHHost, configPath string) err
 
 					// Add the new host as a separate entry
 					newLines = append(newLines, "")
-					if len(newHost.Tags) > 0 {
-						newLines = append(newLines, "# Tags: "+strings.Join(newHost.Tags, ", "))
-					}
-					newLines = append(newLines, "Host "+newHost.Name)
-					newLines = append(newLines, "    HostName "+newHost.Hostname)
-					if newHost.User != "" {
-						newLines = append(newLines, "    User "+newHost.User)
-					}
-					if newHost.Port != "" && newHost.Port != "22" {
-						newLines = append(newLines, "    Port "+newHost.Port)
-					}
-					if newHost.Identity != "" {
-						newLines = append(newLines, "    IdentityFile "+formatSSHConfigValue(newHost.Identity))
-					}
-					if newHost.ProxyJump != "" {
-						newLines = append(newLines, "    ProxyJump "+newHost.ProxyJump)
-					}
-					if newHost.ProxyCommand != "" {
-						newLines = append(newLines, "    ProxyCommand="+newHost.ProxyCommand)
-					}
-					if newHost.RemoteCommand != "" {
-						newLines = append(newLines, "    RemoteCommand "+newHost.RemoteCommand)
-					}
-					if newHost.RequestTTY != "" {
-						newLines = append(newLines, "    RequestTTY "+newHost.RequestTTY)
-					}
-					// Write SSH options
-					if newHost.Options != "" {
-						options := strings.Split(newHost.Options, "\n")
-						for _, option := range options {
-							option = strings.TrimSpace(option)
-							if option != "" {
-								newLines = append(newLines, "    "+option)
-							}
-						}
-					}
+					newLines = append(newLines, hostBlockLines(newHost.Name, newHost)...)
 					newLines = append(newLines, "")
 
 					continue
@@ -1284,42 +1165,7 @@ func UpdateSSHHostInFile(oldName string, newHost SSHHost, configPath string) err
 					if len(newLines) > 0 && strings.TrimSpace(newLines[len(newLines)-1]) != "" {
 						newLines = append(newLines, "")
 					}
-					if len(newHost.Tags) > 0 {
-						newLines = append(newLines, "# Tags: "+strings.Join(newHost.Tags, ", "))
-					}
-					newLines = append(newLines, "Host "+newHost.Name)
-					newLines = append(newLines, "    HostName "+newHost.Hostname)
-					if newHost.User != "" {
-						newLines = append(newLines, "    User "+newHost.User)
-					}
-					if newHost.Port != "" && newHost.Port != "22" {
-						newLines = append(newLines, "    Port "+newHost.Port)
-					}
-					if newHost.Identity != "" {
-						newLines = append(newLines, "    IdentityFile "+formatSSHConfigValue(newHost.Identity))
-					}
-					if newHost.ProxyJump != "" {
-						newLines = append(newLines, "    ProxyJump "+newHost.ProxyJump)
-					}
-					if newHost.ProxyCommand != "" {
-						newLines = append(newLines, "    ProxyCommand="+newHost.ProxyCommand)
-					}
-					if newHost.RemoteCommand != "" {
-						newLines = append(newLines, "    RemoteCommand "+newHost.RemoteCommand)
-					}
-					if newHost.RequestTTY != "" {
-						newLines = append(newLines, "    RequestTTY "+newHost.RequestTTY)
-					}
-					// Write SSH options
-					if newHost.Options != "" {
-						options := strings.Split(newHost.Options, "\n")
-						for _, option := range options {
-							option = strings.TrimSpace(option)
-							if option != "" {
-								newLines = append(newLines, "    "+option)
-							}
-						}
-					}
+					newLines = append(newLines, hostBlockLines(newHost.Name, newHost)...)
 
 					// Add empty line after the host configuration for separation
 					newLines = append(newLines, "")
@@ -1820,48 +1666,8 @@ func UpdateMultiHostBlock(originalHosts, newHosts []string, commonProperties SSH
 						newLines = append(newLines, "")
 					}
 
-					// Add tags if present
-					if len(commonProperties.Tags) > 0 {
-						newLines = append(newLines, "# Tags: "+strings.Join(commonProperties.Tags, ", "))
-					}
-
-					// Add Host line with new host names
-					newLines = append(newLines, "Host "+strings.Join(newHosts, " "))
-
-					// Add common properties
-					newLines = append(newLines, "    HostName "+commonProperties.Hostname)
-					if commonProperties.User != "" {
-						newLines = append(newLines, "    User "+commonProperties.User)
-					}
-					if commonProperties.Port != "" && commonProperties.Port != "22" {
-						newLines = append(newLines, "    Port "+commonProperties.Port)
-					}
-					if commonProperties.Identity != "" {
-						newLines = append(newLines, "    IdentityFile "+formatSSHConfigValue(commonProperties.Identity))
-					}
-					if commonProperties.ProxyJump != "" {
-						newLines = append(newLines, "    ProxyJump "+commonProperties.ProxyJump)
-					}
-					if commonProperties.ProxyCommand != "" {
-						newLines = append(newLines, "    ProxyCommand="+commonProperties.ProxyCommand)
-					}
-					if commonProperties.RemoteCommand != "" {
-						newLines = append(newLines, "    RemoteCommand "+commonProperties.RemoteCommand)
-					}
-					if commonProperties.RequestTTY != "" {
-						newLines = append(newLines, "    RequestTTY "+commonProperties.RequestTTY)
-					}
-
-					// Write SSH options
-					if commonProperties.Options != "" {
-						options := strings.Split(commonProperties.Options, "\n")
-						for _, option := range options {
-							option = strings.TrimSpace(option)
-							if option != "" {
-								newLines = append(newLines, "    "+option)
-							}
-						}
-					}
+					// Add the block with the new host names
+					newLines = append(newLines, hostBlockLines(strings.Join(newHosts, " "), commonProperties)...)
 
 					// Add empty line after the block
 					newLines = append(newLines, "")
@@ -1909,48 +1715,8 @@ func UpdateMultiHostBlock(originalHosts, newHosts []string, commonProperties SSH
 					newLines = append(newLines, "")
 				}
 
-				// Add tags if present
-				if len(commonProperties.Tags) > 0 {
-					newLines = append(newLines, "# Tags: "+strings.Join(commonProperties.Tags, ", "))
-				}
-
-				// Add Host line with new host names
-				newLines = append(newLines, "Host "+strings.Join(newHosts, " "))
-
-				// Add common properties
-				newLines = append(newLines, "    HostName "+commonProperties.Hostname)
-				if commonProperties.User != "" {
-					newLines = append(newLines, "    User "+commonProperties.User)
-				}
-				if commonProperties.Port != "" && commonProperties.Port != "22" {
-					newLines = append(newLines, "    Port "+commonProperties.Port)
-				}
-				if commonProperties.Identity != "" {
-					newLines = append(newLines, "    IdentityFile "+formatSSHConfigValue(commonProperties.Identity))
-				}
-				if commonProperties.ProxyJump != "" {
-					newLines = append(newLines, "    ProxyJump "+commonProperties.ProxyJump)
-				}
-				if commonProperties.ProxyCommand != "" {
-					newLines = append(newLines, "    ProxyCommand="+commonProperties.ProxyCommand)
-				}
-				if commonProperties.RemoteCommand != "" {
-					newLines = append(newLines, "    RemoteCommand "+commonProperties.RemoteCommand)
-				}
-				if commonProperties.RequestTTY != "" {
-					newLines = append(newLines, "    RequestTTY "+commonProperties.RequestTTY)
-				}
-
-				// Write SSH options
-				if commonProperties.Options != "" {
-					options := strings.Split(commonProperties.Options, "\n")
-					for _, option := range options {
-						option = strings.TrimSpace(option)
-						if option != "" {
-							newLines = append(newLines, "    "+option)
-						}
-					}
-				}
+				// Add the block with the new host names
+				newLines = append(newLines, hostBlockLines(strings.Join(newHosts, " "), commonProperties)...)
 
 				// Add empty line after the block
 				newLines = append(newLines, "")
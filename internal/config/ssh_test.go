@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
@@ -1780,3 +1781,86 @@ Host "quoted1" "quoted2"
 		}
 	}
 }
+
+func TestParseSSHConfigWithDescription(t *testing.T) {
+	tempDir := t.TempDir()
+
+	configFile := filepath.Join(tempDir, "config")
+	configContent := `# Tags: prod
+Host api
+    # Description: Public API gateway
+    HostName api.example.com
+
+Host worker
+    HostName worker.example.com
+`
+
+	if err := os.WriteFile(configFile, []byte(configContent), 0600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	hosts, err := ParseSSHConfigFile(configFile)
+	if err != nil {
+		t.Fatalf("ParseSSHConfigFile() error = %v", err)
+	}
+
+	hostMap := make(map[string]SSHHost)
+	for _, host := range hosts {
+		hostMap[host.Name] = host
+	}
+
+	if hostMap["api"].Description != "Public API gateway" {
+		t.Errorf("Expected description 'Public API gateway', got %q", hostMap["api"].Description)
+	}
+	if hostMap["worker"].Description != "" {
+		t.Errorf("Expected no description for worker, got %q", hostMap["worker"].Description)
+	}
+
+	// The description must survive an update of the host
+	updated := hostMap["api"]
+	updated.Hostname = "api2.example.com"
+	if err := UpdateSSHHostInFile("api", updated, configFile); err != nil {
+		t.Fatalf("UpdateSSHHostInFile() error = %v", err)
+	}
+
+	host, err := GetSSHHostFromFile("api", configFile)
+	if err != nil {
+		t.Fatalf("GetSSHHostFromFile() error = %v", err)
+	}
+	if host.Description != "Public API gateway" {
+		t.Errorf("Expected description to be preserved, got %q", host.Description)
+	}
+	if host.Hostname != "api2.example.com" {
+		t.Errorf("Expected updated hostname, got %q", host.Hostname)
+	}
+}
+
+func TestHostBlockLines(t *testing.T) {
+	host := SSHHost{
+		Hostname:    "web.example.com",
+		User:        "deploy",
+		Port:        "2222",
+		Description: "Public web server",
+		Tags:        []string{"prod", "web"},
+		Options:     "Compression yes\n\nServerAliveInterval 60",
+	}
+
+	want := []string{
+		"# Tags: prod, web",
+		"Host web1 web2",
+		"    # Description: Public web server",
+		"    HostName web.example.com",
+		"    User deploy",
+		"    Port 2222",
+		"    Compression yes",
+		"    ServerAliveInterval 60",
+	}
+	if got := hostBlockLines("web1 web2", host); !reflect.DeepEqual(got, want) {
+		t.Errorf("hostBlockLines() = %q, want %q", got, want)
+	}
+
+	want = []string{"Host db", "    HostName db.example.com"}
+	if got := hostBlockLines("db", SSHHost{Hostname: "db.example.com", Port: "22"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("hostBlockLines() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,61 @@
+package config
+
+// DefaultThemeName is the theme used when none is configured. It picks the dark
+// or light built-in theme based on the terminal background.
+const DefaultThemeName = "auto"
+
+// ThemeConfig represents the colour theme configuration
+type ThemeConfig struct {
+	// Name of the active theme: "auto", a built-in theme or a custom theme name
+	Name string `json:"name"`
+
+	// CustomThemes holds user-defined themes, keyed by name
+	CustomThemes map[string]CustomTheme `json:"custom_themes,omitempty"`
+}
+
+// CustomTheme describes a user-defined theme in config.json
+type CustomTheme struct {
+	// Base is the built-in theme this theme starts from (default: "dark")
+	Base string `json:"base,omitempty"`
+
+	// Colors overrides the palette of the base theme
+	Colors ThemeColors `json:"colors,omitempty"`
+
+	// Styles overrides individual UI styles, keyed by style name (e.g. "header", "selected")
+	Styles map[string]StyleOverride `json:"styles,omitempty"`
+}
+
+// ThemeColors represents the palette of a theme. Empty values keep the base theme colour.
+// Values accept anything lipgloss understands: ANSI numbers ("240") or hex ("#00ADD8").
+type ThemeColors struct {
+	Primary            string `json:"primary,omitempty"`
+	Secondary          string `json:"secondary,omitempty"`
+	Accent             string `json:"accent,omitempty"`
+	Error              string `json:"error,omitempty"`
+	Warning            string `json:"warning,omitempty"`
+	Success            string `json:"success,omitempty"`
+	Text               string `json:"text,omitempty"`
+	Muted              string `json:"muted,omitempty"`
+	SelectedForeground string `json:"selected_foreground,omitempty"`
+	TitleForeground    string `json:"title_foreground,omitempty"`
+}
+
+// StyleOverride represents the attributes of a single UI style that can be overridden.
+// Nil booleans and empty colours keep the value from the theme.
+type StyleOverride struct {
+	Foreground       string `json:"foreground,omitempty"`
+	Background       string `json:"background,omitempty"`
+	BorderForeground string `json:"border_foreground,omitempty"`
+	Bold             *bool  `json:"bold,omitempty"`
+	Italic           *bool  `json:"italic,omitempty"`
+	Faint            *bool  `json:"faint,omitempty"`
+	Underline        *bool  `json:"underline,omitempty"`
+	Reverse          *bool  `json:"reverse,omitempty"`
+}
+
+// GetDefaultThemeConfig returns the default theme configuration
+func GetDefaultThemeConfig() ThemeConfig {
+	return ThemeConfig{
+		Name: DefaultThemeName,
+	}
+}
@@ -0,0 +1,241 @@
+package connectivity
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// AuthStatus summarises whether we can log in to a host without a password
+type AuthStatus int
+
+const (
+	AuthUnchecked    AuthStatus = iota // The probe did not get as far as authentication
+	AuthKeyAccepted                    // One of our keys is accepted
+	AuthKeyRejected                    // The server accepts keys, but none of ours
+	AuthNoKeys                         // The server accepts keys, but we have none to offer
+	AuthPasswordOnly                   // The server only offers password or keyboard-interactive authentication
+	AuthNoMethod                       // The server offers no method we support
+)
+
+func (s AuthStatus) String() string {
+	switch s {
+	case AuthKeyAccepted:
+		return "key ok"
+	case AuthKeyRejected:
+		return "key rejected"
+	case AuthNoKeys:
+		return "no key to try"
+	case AuthPasswordOnly:
+		return "password only"
+	case AuthNoMethod:
+		return "no supported method"
+	}
+	return "not checked"
+}
+
+// Authentication methods recorded by the probe
+const (
+	authPublicKey           = "publickey"
+	authPassword            = "password"
+	authKeyboardInteractive = "keyboard-interactive"
+)
+
+// AuthProbeResult is the outcome of an authentication probe
+type AuthProbeResult struct {
+	HostName    string
+	Status      AuthStatus
+	Methods     []string // Methods offered by the server among publickey, password and keyboard-interactive
+	AcceptedKey string   // Key the server accepts: its file, or "agent" and its comment
+	Keys        int      // Number of keys we can offer
+	Error       error    // Why the probe could not complete, if it did not
+	CheckedAt   time.Time
+}
+
+// errProbeDone aborts authentication once the probe has learned what it needs,
+// so that no password is sent and no session is opened
+var errProbeDone = errors.New("authentication probe done")
+
+// authKey is a public key we may authenticate with, and where it comes from
+type authKey struct {
+	key    ssh.PublicKey
+	source string
+}
+
+// probeSigner offers a public key to the server without ever signing: the
+// client only signs once the server accepted the key, which is all the probe
+// needs to know. It implements AlgorithmSigner so that RSA keys are offered
+// with SHA-2 signatures, as ssh does.
+type probeSigner struct {
+	authKey
+	accepted *string
+}
+
+func (s probeSigner) PublicKey() ssh.PublicKey { return s.key }
+
+func (s probeSigner) Sign(rand io.Reader, data []byte) (*ssh.Signature, error) {
+	*s.accepted = s.source
+	return nil, errProbeDone
+}
+
+func (s probeSigner) SignWithAlgorithm(rand io.Reader, data []byte, algorithm string) (*ssh.Signature, error) {
+	return s.Sign(rand, data)
+}
+
+// ProbeAuth finds out which authentication methods a host offers and whether
+// it accepts one of our keys: the agent keys (unless IdentitiesOnly is set)
+// and the configured or default key files. Keys are only offered, never used
+// to sign, and no password is sent, so the probe never logs in.
+func (pm *PingManager) ProbeAuth(ctx context.Context, host config.SSHHost) *AuthProbeResult {
+	result := &AuthProbeResult{HostName: host.Name}
+	defer func() { result.CheckedAt = time.Now() }()
+
+	address, port := hostAddress(host)
+	timeout := pm.timeout * time.Duration(1+len(config.ParseProxyJump(host.ProxyJump)))
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	r, err := pm.dialRoute(probeCtx, host, address)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	defer r.Close()
+
+	keys := authKeys(host)
+	result.Keys = len(keys)
+
+	// The client goes on with the next method when one fails, so every
+	// method offered by the server is recorded in a single handshake
+	offer := func(method string) {
+		if !slices.Contains(result.Methods, method) {
+			result.Methods = append(result.Methods, method)
+		}
+	}
+	auth := []ssh.AuthMethod{
+		ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			offer(authPublicKey)
+			signers := make([]ssh.Signer, 0, len(keys))
+			for _, key := range keys {
+				signers = append(signers, probeSigner{key, &result.AcceptedKey})
+			}
+			return signers, nil
+		}),
+		ssh.PasswordCallback(func() (string, error) {
+			offer(authPassword)
+			return "", errProbeDone
+		}),
+		ssh.KeyboardInteractive(func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+			offer(authKeyboardInteractive)
+			return nil, errProbeDone
+		}),
+	}
+
+	hostKey := newHostKeyCheck(host, port)
+	clientConfig := &ssh.ClientConfig{
+		User:              hopUser(config.JumpHop{User: host.User}),
+		Auth:              auth,
+		HostKeyCallback:   hostKey.verify,
+		HostKeyAlgorithms: hostKey.algorithms(),
+		Timeout:           pm.timeout,
+	}
+
+	sshConn, _, _, err := handshake(probeCtx, r.conn, address, clientConfig)
+	if sshConn != nil {
+		// The server let us in without authentication
+		sshConn.Close()
+	}
+	if err != nil && !errors.Is(err, errProbeDone) && !isAuthFailure(err) {
+		result.Error = err
+		return result
+	}
+
+	passwordOffered := slices.Contains(result.Methods, authPassword) || slices.Contains(result.Methods, authKeyboardInteractive)
+	switch {
+	case result.AcceptedKey != "":
+		result.Status = AuthKeyAccepted
+	case slices.Contains(result.Methods, authPublicKey) && len(keys) == 0:
+		result.Status = AuthNoKeys
+	case slices.Contains(result.Methods, authPublicKey):
+		result.Status = AuthKeyRejected
+	case passwordOffered:
+		result.Status = AuthPasswordOnly
+	default:
+		result.Status = AuthNoMethod
+	}
+	return result
+}
+
+// isAuthFailure reports whether a handshake failed because no method succeeded
+func isAuthFailure(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "unable to authenticate")
+}
+
+// authKeys returns the public keys ssh would offer to a host: the agent
+// keys unless IdentitiesOnly is set, then the configured or default key
+// files. The public key of a file is read from its .pub companion, or from
+// the private key when it is not protected by a passphrase.
+func authKeys(host config.SSHHost) []authKey {
+	var keys []authKey
+	seen := make(map[string]bool)
+	add := func(key ssh.PublicKey, source string) {
+		if fingerprint := ssh.FingerprintSHA256(key); !seen[fingerprint] {
+			seen[fingerprint] = true
+			keys = append(keys, authKey{key: key, source: source})
+		}
+	}
+
+	identitiesOnly, _ := host.GetOption("IdentitiesOnly")
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" && !strings.EqualFold(identitiesOnly, "yes") {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			if agentKeys, err := agent.NewClient(conn).List(); err == nil {
+				for _, key := range agentKeys {
+					add(key, "agent: "+key.Comment)
+				}
+			}
+			conn.Close()
+		}
+	}
+
+	for _, path := range identityFiles(config.JumpHop{Alias: &host}) {
+		if key := readPublicKey(path); key != nil {
+			add(key, path)
+		}
+	}
+	return keys
+}
+
+// readPublicKey reads the public key of a key file, or returns nil
+func readPublicKey(path string) ssh.PublicKey {
+	if data, err := os.ReadFile(path + ".pub"); err == nil {
+		if key, _, _, _, err := ssh.ParseAuthorizedKey(data); err == nil {
+			return key
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	signer, err := ssh.ParsePrivateKey(data)
+	if err == nil {
+		return signer.PublicKey()
+	}
+
+	// Keys protected by a passphrase may still carry their public key
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) && missing.PublicKey != nil {
+		return missing.PublicKey
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package connectivity
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestProbeAuth(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	keyPath, publicKey := writeTestKey(t)
+	_, otherKey := writeTestKey(t)
+
+	// The server must never see a signature: the probe only asks whether keys would be accepted
+	signed := false
+	keyServerConfig := func(authorized ssh.PublicKey) *ssh.ServerConfig {
+		return &ssh.ServerConfig{
+			PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+				if string(key.Marshal()) == string(authorized.Marshal()) {
+					return nil, nil
+				}
+				return nil, errors.New("unauthorized key")
+			},
+			PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+				signed = true
+				return nil, errors.New("wrong password")
+			},
+			AuthLogCallback: func(conn ssh.ConnMetadata, method string, err error) {
+				if method == authPublicKey && err == nil {
+					signed = true
+				}
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		server      *ssh.ServerConfig
+		identity    string
+		wantStatus  AuthStatus
+		wantMethods []string
+	}{
+		{"key accepted", keyServerConfig(publicKey), keyPath, AuthKeyAccepted, []string{authPublicKey, authPassword}},
+		{"key rejected", keyServerConfig(otherKey), keyPath, AuthKeyRejected, []string{authPublicKey, authPassword}},
+		{"no key", keyServerConfig(publicKey), filepath.Join(t.TempDir(), "missing"), AuthNoKeys, []string{authPublicKey, authPassword}},
+		{"password only", passwordServerConfig(), keyPath, AuthPasswordOnly, []string{authPassword}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			signed = false
+			hostname, port := splitTestAddress(t, startTestSSHServer(t, tt.server))
+
+			pm := NewPingManager(2 * time.Second)
+			host := config.SSHHost{Name: "test", Hostname: hostname, Port: port, Identity: tt.identity}
+			result := pm.ProbeAuth(context.Background(), host)
+
+			if result.Error != nil {
+				t.Fatalf("ProbeAuth() error = %v", result.Error)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("ProbeAuth() status = %v, want %v", result.Status, tt.wantStatus)
+			}
+			if !slices.Equal(result.Methods, tt.wantMethods) {
+				t.Errorf("ProbeAuth() methods = %v, want %v", result.Methods, tt.wantMethods)
+			}
+			if tt.wantStatus == AuthKeyAccepted && result.AcceptedKey != keyPath {
+				t.Errorf("ProbeAuth() accepted key = %q, want %q", result.AcceptedKey, keyPath)
+			}
+			if signed {
+				t.Error("ProbeAuth() authenticated with the server")
+			}
+		})
+	}
+}
+
+func TestProbeAuth_Unreachable(t *testing.T) {
+	hostname, port := splitTestAddress(t, closedTestPort(t))
+
+	pm := NewPingManager(time.Second)
+	result := pm.ProbeAuth(context.Background(), config.SSHHost{Name: "test", Hostname: hostname, Port: port})
+	if result.Error == nil || result.Status != AuthUnchecked {
+		t.Errorf("ProbeAuth() = %v, %v, want an error", result.Status, result.Error)
+	}
+}
+
+func TestReadPublicKey(t *testing.T) {
+	keyPath, publicKey := writeTestKey(t)
+
+	if key := readPublicKey(keyPath); key == nil || ssh.FingerprintSHA256(key) != ssh.FingerprintSHA256(publicKey) {
+		t.Errorf("readPublicKey() of a private key = %v, want %v", key, publicKey)
+	}
+
+	// The .pub companion is preferred, as for keys protected by a passphrase
+	_, otherKey := writeTestKey(t)
+	if err := os.WriteFile(keyPath+".pub", ssh.MarshalAuthorizedKey(otherKey), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if key := readPublicKey(keyPath); key == nil || ssh.FingerprintSHA256(key) != ssh.FingerprintSHA256(otherKey) {
+		t.Errorf("readPublicKey() with a .pub file = %v, want %v", key, otherKey)
+	}
+
+	if key := readPublicKey(filepath.Join(t.TempDir(), "missing")); key != nil {
+		t.Errorf("readPublicKey() of a missing file = %v, want nil", key)
+	}
+}
@@ -0,0 +1,158 @@
+package connectivity
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/history"
+)
+
+// cachedResult is the on-disk representation of a check result. Status,
+// kind and host key status are stored as numbers: new values are only
+// ever appended to their enumerations.
+type cachedResult struct {
+	HostName           string        `json:"host_name"`
+	Kind               ResultKind    `json:"kind"`
+	Error              string        `json:"error,omitempty"`
+	Duration           time.Duration `json:"duration"`
+	CheckedAt          time.Time     `json:"checked_at"`
+	FailedHop          string        `json:"failed_hop,omitempty"`
+	ResolvedIP         string        `json:"resolved_ip,omitempty"`
+	ServerVersion      string        `json:"server_version,omitempty"`
+	HostKeyStatus      HostKeyStatus `json:"host_key_status,omitempty"`
+	HostKeyType        string        `json:"host_key_type,omitempty"`
+	HostKeyFingerprint string        `json:"host_key_fingerprint,omitempty"`
+}
+
+// pingCacheFile is the on-disk representation of the ping cache: the results
+// of each SSH config file, keyed by its canonical path, so that hosts with the
+// same name in different config files are not mixed up. Results written
+// before config files were told apart are dropped.
+type pingCacheFile struct {
+	ConfigFiles map[string][]cachedResult `json:"config_files"`
+}
+
+// GetPingCachePath returns the path of the file check results are cached in
+func GetPingCachePath() (string, error) {
+	configDir, err := config.GetSSHMConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "ping_cache.json"), nil
+}
+
+// SaveResults writes the completed check results of the hosts of configFile
+// to path, so that the next run can show them before checking the hosts
+// again. The results other sshm processes cached for other hosts or config
+// files are kept.
+func (pm *PingManager) SaveResults(path, configFile string) error {
+	pm.mutex.RLock()
+	results := make(map[string]cachedResult, len(pm.results))
+	for _, result := range pm.results {
+		if result.Kind == KindNone || result.CheckedAt.IsZero() {
+			continue
+		}
+		cached := cachedResult{
+			HostName:           result.HostName,
+			Kind:               result.Kind,
+			Duration:           result.Duration,
+			CheckedAt:          result.CheckedAt,
+			FailedHop:          result.FailedHop,
+			ResolvedIP:         result.ResolvedIP,
+			ServerVersion:      result.ServerVersion,
+			HostKeyStatus:      result.HostKeyStatus,
+			HostKeyType:        result.HostKeyType,
+			HostKeyFingerprint: result.HostKeyFingerprint,
+		}
+		if result.Error != nil {
+			cached.Error = result.Error.Error()
+		}
+		results[result.HostName] = cached
+	}
+	pm.mutex.RUnlock()
+
+	configFile = history.CanonicalConfigFile(configFile)
+	return history.UpdateFile(path, func(data []byte) ([]byte, error) {
+		// A cache that cannot be parsed is replaced
+		var file pingCacheFile
+		if data != nil && json.Unmarshal(data, &file) != nil {
+			file = pingCacheFile{}
+		}
+		if file.ConfigFiles == nil {
+			file.ConfigFiles = make(map[string][]cachedResult)
+		}
+
+		// Keep the newest result of each host
+		for _, cached := range file.ConfigFiles[configFile] {
+			if current, exists := results[cached.HostName]; !exists || cached.CheckedAt.After(current.CheckedAt) {
+				results[cached.HostName] = cached
+			}
+		}
+		merged := make([]cachedResult, 0, len(results))
+		for _, cached := range results {
+			merged = append(merged, cached)
+		}
+		sort.Slice(merged, func(i, j int) bool { return merged[i].HostName < merged[j].HostName })
+		file.ConfigFiles[configFile] = merged
+
+		return json.MarshalIndent(file, "", "  ")
+	})
+}
+
+// LoadResults reads the results of the hosts of configFile cached in path,
+// skipping those older than maxAge and those of hosts checked since. Loaded
+// results are marked as cached. A missing file is not an error.
+func (pm *PingManager) LoadResults(path, configFile string, maxAge time.Duration) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var file pingCacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	for _, cached := range file.ConfigFiles[history.CanonicalConfigFile(configFile)] {
+		if cached.HostName == "" || cached.Kind == KindNone {
+			continue
+		}
+		if maxAge > 0 && time.Since(cached.CheckedAt) > maxAge {
+			continue
+		}
+		if _, exists := pm.results[cached.HostName]; exists {
+			continue
+		}
+
+		result := &HostPingResult{
+			HostName:           cached.HostName,
+			Status:             cached.Kind.Status(),
+			Kind:               cached.Kind,
+			Duration:           cached.Duration,
+			CheckedAt:          cached.CheckedAt,
+			FailedHop:          cached.FailedHop,
+			ResolvedIP:         cached.ResolvedIP,
+			ServerVersion:      cached.ServerVersion,
+			HostKeyStatus:      cached.HostKeyStatus,
+			HostKeyType:        cached.HostKeyType,
+			HostKeyFingerprint: cached.HostKeyFingerprint,
+			Cached:             true,
+		}
+		if cached.Error != "" {
+			result.Error = errors.New(cached.Error)
+		}
+		pm.results[cached.HostName] = result
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package connectivity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+func TestPingManager_SaveAndLoadResults(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sshm", "ping_cache.json")
+	configFile := filepath.Join(dir, "config")
+
+	pm := NewPingManager(time.Second)
+	pm.updateStatus(&HostPingResult{
+		HostName: "web", Status: StatusOnline, Kind: KindAuthRequired,
+		Duration: 12 * time.Millisecond, CheckedAt: time.Now(), ServerVersion: "SSH-2.0-OpenSSH_9.6",
+		HostKeyStatus: HostKeyKnown, HostKeyType: "ssh-ed25519",
+	})
+	pm.updateStatus(&HostPingResult{
+		HostName: "db", Status: StatusOffline, Kind: KindProxyFailed,
+		Error: errors.New("via bastion: connection refused"), FailedHop: "bastion", CheckedAt: time.Now(),
+	})
+	pm.updateStatus(&HostPingResult{HostName: "old", Status: StatusOnline, Kind: KindSSHReady, CheckedAt: time.Now().Add(-48 * time.Hour)})
+	pm.updateStatus(&HostPingResult{HostName: "pending", Status: StatusConnecting})
+
+	if err := pm.SaveResults(path, configFile); err != nil {
+		t.Fatalf("SaveResults() error = %v", err)
+	}
+
+	loaded := NewPingManager(time.Second)
+	loaded.updateStatus(&HostPingResult{HostName: "db", Status: StatusOnline, Kind: KindSSHReady, CheckedAt: time.Now()})
+	if err := loaded.LoadResults(path, configFile, 24*time.Hour); err != nil {
+		t.Fatalf("LoadResults() error = %v", err)
+	}
+
+	web, ok := loaded.GetResult("web")
+	if !ok {
+		t.Fatal("Expected the result of web to be loaded")
+	}
+	if !web.Cached || web.Status != StatusOnline || web.Duration != 12*time.Millisecond ||
+		web.ServerVersion != "SSH-2.0-OpenSSH_9.6" || web.HostKeyStatus != HostKeyKnown {
+		t.Errorf("Unexpected cached result: %+v", web)
+	}
+
+	if db, _ := loaded.GetResult("db"); db.Cached || db.Status != StatusOnline {
+		t.Errorf("Expected the newer result of db to be kept, got %+v", db)
+	}
+	if _, ok := loaded.GetResult("old"); ok {
+		t.Error("Expected results older than maxAge to be skipped")
+	}
+	if _, ok := loaded.GetResult("pending"); ok {
+		t.Error("Expected checks in progress not to be cached")
+	}
+
+	// Errors survive the round trip
+	other := NewPingManager(time.Second)
+	if err := other.LoadResults(path, configFile, 0); err != nil {
+		t.Fatal(err)
+	}
+	if db, _ := other.GetResult("db"); db.Error == nil || db.Error.Error() != "via bastion: connection refused" || db.FailedHop != "bastion" {
+		t.Errorf("Expected the error of db to be loaded, got %+v", db)
+	}
+}
+
+func TestPingManager_ResultsByConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ping_cache.json")
+	defaultConfig := filepath.Join(dir, "config")
+	otherConfig := filepath.Join(dir, "other_config")
+
+	first := NewPingManager(time.Second)
+	first.updateStatus(&HostPingResult{
+		HostName: "web", Status: StatusOnline, Kind: KindSSHReady, CheckedAt: time.Now(),
+		ResolvedIP: "10.0.0.1", HostKeyStatus: HostKeyKnown, HostKeyFingerprint: "SHA256:first",
+	})
+	first.updateStatus(&HostPingResult{HostName: "db", Status: StatusOnline, Kind: KindSSHReady, CheckedAt: time.Now()})
+
+	second := NewPingManager(time.Second)
+	second.updateStatus(&HostPingResult{HostName: "web", Status: StatusOffline, Kind: KindTCPRefused, CheckedAt: time.Now(), ResolvedIP: "10.0.0.2"})
+
+	third := NewPingManager(time.Second)
+	third.updateStatus(&HostPingResult{HostName: "cache", Status: StatusOnline, Kind: KindSSHReady, CheckedAt: time.Now()})
+
+	// Each manager saves after the others, none of them drops their results
+	if err := first.SaveResults(path, defaultConfig); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.SaveResults(path, otherConfig); err != nil {
+		t.Fatal(err)
+	}
+	if err := third.SaveResults(path, defaultConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewPingManager(time.Second)
+	if err := loaded.LoadResults(path, defaultConfig, 0); err != nil {
+		t.Fatal(err)
+	}
+	if web, ok := loaded.GetResult("web"); !ok || web.ResolvedIP != "10.0.0.1" || web.HostKeyFingerprint != "SHA256:first" {
+		t.Errorf("Expected the result of web in the default config, got %+v", web)
+	}
+	for _, name := range []string{"db", "cache"} {
+		if _, ok := loaded.GetResult(name); !ok {
+			t.Errorf("Expected the result of %s to be kept", name)
+		}
+	}
+
+	other := NewPingManager(time.Second)
+	if err := other.LoadResults(path, otherConfig, 0); err != nil {
+		t.Fatal(err)
+	}
+	if web, ok := other.GetResult("web"); !ok || web.Status != StatusOffline || web.ResolvedIP != "10.0.0.2" {
+		t.Errorf("Expected the result of web in the other config, got %+v", web)
+	}
+	if _, ok := other.GetResult("db"); ok {
+		t.Error("Expected the results of the default config not to be loaded for the other config")
+	}
+}
+
+func TestPingManager_ConcurrentSaves(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ping_cache.json")
+	configFile := filepath.Join(dir, "config")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		pm := NewPingManager(time.Second)
+		pm.updateStatus(&HostPingResult{HostName: fmt.Sprintf("host%d", i), Status: StatusOnline, Kind: KindSSHReady, CheckedAt: time.Now()})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- pm.SaveResults(path, configFile)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("SaveResults() error = %v", err)
+		}
+	}
+
+	loaded := NewPingManager(time.Second)
+	if err := loaded.LoadResults(path, configFile, 0); err != nil {
+		t.Fatalf("LoadResults() error = %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, ok := loaded.GetResult(fmt.Sprintf("host%d", i)); !ok {
+			t.Errorf("Expected the result of host%d to be kept", i)
+		}
+	}
+}
+
+func TestPingManager_CheckReplacesCachedResult(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ping_cache.json")
+	configFile := filepath.Join(dir, "config")
+
+	host, port := splitTestAddress(t, closedTestPort(t))
+	target := config.SSHHost{Name: "web", Hostname: host, Port: port}
+
+	pm := NewPingManager(time.Second)
+	pm.updateStatus(&HostPingResult{HostName: "web", Status: StatusOnline, Kind: KindSSHReady, CheckedAt: time.Now()})
+	if err := pm.SaveResults(path, configFile); err != nil {
+		t.Fatal(err)
+	}
+
+	fresh := NewPingManager(time.Second)
+	if err := fresh.LoadResults(path, configFile, 0); err != nil {
+		t.Fatal(err)
+	}
+	result := fresh.PingHost(context.Background(), target)
+	if result.Cached || result.Kind != KindTCPRefused {
+		t.Errorf("Expected a fresh result, got %+v", result)
+	}
+}
@@ -0,0 +1,75 @@
+package connectivity
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Client is an authenticated SSH connection to a host, along with the jump
+// host connections or ProxyCommand it goes through
+type Client struct {
+	*ssh.Client
+	route *route
+}
+
+// Close closes the connection to the host and the route leading to it
+func (c *Client) Close() error {
+	err := c.Client.Close()
+	c.route.Close()
+	return err
+}
+
+// Connect opens an authenticated SSH connection to a host, through its jump
+// hosts or ProxyCommand. Only the SSH agent and key files without a
+// passphrase are used, and the host key must already be known: there is no
+// terminal to ask for a password or to confirm a new key.
+func (pm *PingManager) Connect(ctx context.Context, host config.SSHHost) (*Client, error) {
+	address, port := hostAddress(host)
+	timeout := pm.timeout * time.Duration(1+len(config.ParseProxyJump(host.ProxyJump)))
+	connectCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	r, err := pm.dialRoute(connectCtx, host, address)
+	if err != nil {
+		return nil, err
+	}
+
+	auth, agentConn := authMethods(config.JumpHop{Alias: &host})
+	if agentConn != nil {
+		// The agent is only needed to authenticate
+		defer agentConn.Close()
+	}
+
+	hostKey := newHostKeyCheck(host, port)
+	clientConfig := &ssh.ClientConfig{
+		User: hopUser(config.JumpHop{User: host.User}),
+		Auth: auth,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if err := hostKey.verify(hostname, remote, key); err != nil {
+				return err
+			}
+			if status, _, _ := hostKey.result(); status != HostKeyKnown {
+				return fmt.Errorf("host key of %s is not known, connect once with ssh to check and accept it", host.Name)
+			}
+			return nil
+		},
+		HostKeyAlgorithms: hostKey.algorithms(),
+		Timeout:           pm.timeout,
+	}
+
+	sshConn, chans, reqs, err := handshake(connectCtx, r.conn, address, clientConfig)
+	if err != nil {
+		r.Close()
+		if isAuthFailure(err) {
+			return nil, fmt.Errorf("authentication failed (only the SSH agent and keys without a passphrase can be used): %w", err)
+		}
+		return nil, err
+	}
+	return &Client{Client: ssh.NewClient(sshConn, chans, reqs), route: r}, nil
+}
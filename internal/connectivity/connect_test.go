@@ -0,0 +1,88 @@
+package connectivity
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+func TestConnect(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	keyPath, publicKey := writeTestKey(t)
+	_, otherKey := writeTestKey(t)
+	hostKey := testHostKey(t)
+
+	// The forwarded connections reach this server
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer echo.Close()
+	go func() {
+		for {
+			conn, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	hostname, port := splitTestAddress(t, testSSHServer(t, publicKey, hostKey))
+	otherHostname, otherPort := splitTestAddress(t, testSSHServer(t, otherKey, hostKey))
+
+	tests := []struct {
+		name      string
+		hostname  string
+		port      string
+		known     bool
+		wantError string
+	}{
+		{"connected", hostname, port, true, ""},
+		{"unknown host key", hostname, port, false, "is not known"},
+		{"key rejected", otherHostname, otherPort, true, "authentication failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			if tt.known {
+				writeKnownHosts(t, home, knownHostsLine(tt.hostname, tt.port, hostKey.PublicKey()))
+			}
+
+			pm := NewPingManager(2 * time.Second)
+			host := config.SSHHost{Name: "test", Hostname: tt.hostname, Port: tt.port, Identity: keyPath}
+			client, err := pm.Connect(context.Background(), host)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("Connect() error = %v, want %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Connect() error = %v", err)
+			}
+			defer client.Close()
+
+			conn, err := client.Dial("tcp", echo.Addr().String())
+			if err != nil {
+				t.Fatalf("Dial() through the connection error = %v", err)
+			}
+			defer conn.Close()
+			conn.Write([]byte("hello"))
+			reply := make([]byte, 5)
+			if _, err := io.ReadFull(conn, reply); err != nil || string(reply) != "hello" {
+				t.Errorf("Expected the connection to be forwarded, got %q (%v)", reply, err)
+			}
+		})
+	}
+}
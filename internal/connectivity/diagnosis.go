@@ -0,0 +1,167 @@
+package connectivity
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ResultKind describes the outcome of a connectivity check in more detail than its status
+type ResultKind int
+
+const (
+	KindNone            ResultKind = iota // Not checked yet
+	KindSSHReady                          // The server accepted the connection without authentication
+	KindAuthRequired                      // The SSH handshake succeeded and the server asks for authentication
+	KindHostKeyChanged                    // The host key does not match the known_hosts entries or is revoked
+	KindHandshakeFailed                   // The server speaks SSH but the key exchange failed
+	KindDNSFailure                        // The hostname could not be resolved
+	KindTCPRefused                        // The connection to the SSH port was refused
+	KindTCPTimeout                        // The connection or the handshake timed out
+	KindUnreachable                       // The host could not be reached for another network reason
+	KindNotSSH                            // The server did not send an SSH banner
+	KindProxyFailed                       // A jump host or the ProxyCommand failed
+)
+
+func (k ResultKind) String() string {
+	switch k {
+	case KindSSHReady:
+		return "SSH ready"
+	case KindAuthRequired:
+		return "auth required"
+	case KindHostKeyChanged:
+		return "host key changed"
+	case KindHandshakeFailed:
+		return "handshake failed"
+	case KindDNSFailure:
+		return "DNS failure"
+	case KindTCPRefused:
+		return "connection refused"
+	case KindTCPTimeout:
+		return "timeout"
+	case KindUnreachable:
+		return "unreachable"
+	case KindNotSSH:
+		return "not an SSH server"
+	case KindProxyFailed:
+		return "proxy failed"
+	}
+	return "unknown"
+}
+
+// Status returns the status of a host for a check with this outcome:
+// the host is online whenever an SSH server answered
+func (k ResultKind) Status() PingStatus {
+	switch k {
+	case KindNone:
+		return StatusUnknown
+	case KindSSHReady, KindAuthRequired, KindHostKeyChanged, KindHandshakeFailed:
+		return StatusOnline
+	}
+	return StatusOffline
+}
+
+// classifyError returns the kind of a failure to reach the SSH port of a host
+func classifyError(err error) ResultKind {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var channelErr *ssh.OpenChannelError
+	var hopErr *HopError
+
+	switch {
+	case err == nil:
+		return KindNone
+	case errors.As(err, &dnsErr):
+		return KindDNSFailure
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return KindTCPRefused
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return KindTCPTimeout
+	case errors.As(err, &channelErr):
+		// The last jump host could not connect to the target
+		return KindUnreachable
+	case errors.As(err, &hopErr):
+		return KindProxyFailed
+	}
+	return KindUnreachable
+}
+
+// resolvedIP returns the IP address a connection or a failed dial was made to
+func resolvedIP(conn net.Conn, err error) string {
+	var addr net.Addr
+	if conn != nil {
+		addr = conn.RemoteAddr()
+	} else {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) {
+			addr = opErr.Addr
+		}
+	}
+
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok && tcpAddr.IP != nil {
+		return tcpAddr.IP.String()
+	}
+	return ""
+}
+
+// maxBannerBytes is the number of bytes the server may send before its
+// version string, as allowed by RFC 4253
+const maxBannerBytes = 255
+
+// bannerConn records the first bytes sent by the server to find its version string
+type bannerConn struct {
+	net.Conn
+
+	mutex    sync.Mutex
+	received []byte
+}
+
+func (c *bannerConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+
+	c.mutex.Lock()
+	if room := maxBannerBytes - len(c.received); room > 0 {
+		c.received = append(c.received, b[:min(n, room)]...)
+	}
+	c.mutex.Unlock()
+
+	return n, err
+}
+
+// serverVersion returns the SSH version string sent by the server, if any
+func (c *bannerConn) serverVersion() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, line := range bytes.Split(c.received, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte("SSH-")) {
+			return strings.TrimRight(string(line), "\r")
+		}
+	}
+	return ""
+}
+
+// classifyHandshake returns the kind of a check whose SSH handshake ended with err
+func classifyHandshake(err error, version string, hostKey *hostKeyCheck) ResultKind {
+	hostKey.mutex.Lock()
+	defer hostKey.mutex.Unlock()
+
+	switch {
+	case hostKey.status == HostKeyChanged, hostKey.status == HostKeyRevoked:
+		return KindHostKeyChanged
+	case err == nil:
+		return KindSSHReady
+	case hostKey.seen:
+		// The key exchange is over, only the authentication failed
+		return KindAuthRequired
+	case version == "":
+		return KindNotSSH
+	}
+	return KindHandshakeFailed
+}
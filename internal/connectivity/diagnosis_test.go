@@ -0,0 +1,145 @@
+package connectivity
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// closedTestPort returns the address of a local port nothing listens on
+func closedTestPort(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	return address
+}
+
+// testHTTPServer starts a server answering every connection like a web server
+func testHTTPServer(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("HTTP/1.1 400 Bad Request\r\nContent-Length: 0\r\n\r\n"))
+			conn.Close()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestPingHost_ResultKinds(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // No known_hosts file
+
+	tests := []struct {
+		name       string
+		address    string
+		wantKind   ResultKind
+		wantStatus PingStatus
+		wantSSH    bool // A server version is expected
+	}{
+		{"auth required", testSSHServer(t, nil), KindAuthRequired, StatusOnline, true},
+		{"no authentication", startTestSSHServer(t, &ssh.ServerConfig{NoClientAuth: true}), KindSSHReady, StatusOnline, true},
+		{"connection refused", closedTestPort(t), KindTCPRefused, StatusOffline, false},
+		{"not an SSH server", testHTTPServer(t), KindNotSSH, StatusOffline, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hostname, port := splitTestAddress(t, tt.address)
+			host := config.SSHHost{Name: "test", Hostname: hostname, Port: port}
+
+			pm := NewPingManager(2 * time.Second)
+			result := pm.PingHost(context.Background(), host)
+
+			if result.Kind != tt.wantKind {
+				t.Errorf("PingHost() kind = %v, want %v (error: %v)", result.Kind, tt.wantKind, result.Error)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("PingHost() status = %v, want %v", result.Status, tt.wantStatus)
+			}
+			if result.ResolvedIP != "127.0.0.1" {
+				t.Errorf("PingHost() resolved IP = %q, want %q", result.ResolvedIP, "127.0.0.1")
+			}
+			if hasVersion := strings.HasPrefix(result.ServerVersion, "SSH-2.0-"); hasVersion != tt.wantSSH {
+				t.Errorf("PingHost() server version = %q", result.ServerVersion)
+			}
+		})
+	}
+}
+
+// timeoutError is a network error reporting a timeout
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ResultKind
+	}{
+		{"nil", nil, KindNone},
+		{"dns", &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}}, KindDNSFailure},
+		{"dial timeout", &net.OpError{Op: "dial", Err: timeoutError{}}, KindTCPTimeout},
+		{"context deadline", context.DeadlineExceeded, KindTCPTimeout},
+		{"jump host cannot reach target", &HopError{Hop: "bastion", Err: &ssh.OpenChannelError{Reason: ssh.ConnectionFailed}}, KindUnreachable},
+		{"jump host authentication", &HopError{Hop: "bastion", Err: errors.New("ssh: unable to authenticate")}, KindProxyFailed},
+		{"other", errors.New("network is unreachable"), KindUnreachable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyError(tt.err); got != tt.want {
+				t.Errorf("classifyError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResultKind_Status(t *testing.T) {
+	tests := []struct {
+		kind ResultKind
+		want PingStatus
+	}{
+		{KindNone, StatusUnknown},
+		{KindSSHReady, StatusOnline},
+		{KindAuthRequired, StatusOnline},
+		{KindHostKeyChanged, StatusOnline},
+		{KindHandshakeFailed, StatusOnline},
+		{KindDNSFailure, StatusOffline},
+		{KindTCPRefused, StatusOffline},
+		{KindTCPTimeout, StatusOffline},
+		{KindNotSSH, StatusOffline},
+		{KindProxyFailed, StatusOffline},
+	}
+
+	for _, tt := range tests {
+		if got := tt.kind.Status(); got != tt.want {
+			t.Errorf("%v.Status() = %v, want %v", tt.kind, got, tt.want)
+		}
+	}
+}
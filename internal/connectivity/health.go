@@ -0,0 +1,248 @@
+package connectivity
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/history"
+)
+
+// HealthSample is the outcome of one completed connectivity check
+type HealthSample struct {
+	Time    time.Time     `json:"time"`
+	Online  bool          `json:"online"`
+	Latency time.Duration `json:"latency"`
+}
+
+// HealthHistory keeps the last samples of each host, to follow latency and
+// uptime over time. Samples older than the TTL are dropped.
+type HealthHistory struct {
+	path       string
+	configFile string // Canonical path of the SSH config file of the hosts
+	size       int
+	ttl        time.Duration
+
+	mutex   sync.RWMutex
+	samples map[string][]HealthSample // Oldest first, at most size per host
+}
+
+// healthHistoryFile is the on-disk representation of the health history: the
+// samples of the hosts of each SSH config file, keyed by its canonical path,
+// so that hosts with the same name in different config files are not mixed up
+type healthHistoryFile struct {
+	ConfigFiles map[string]map[string][]HealthSample `json:"config_files"`
+
+	// Hosts are the samples written before config files were told apart.
+	// They are moved to the default SSH config when loaded.
+	Hosts map[string][]HealthSample `json:"hosts,omitempty"`
+}
+
+// migrate moves the samples written before config files were told apart to
+// the default SSH config
+func (f *healthHistoryFile) migrate() {
+	if f.ConfigFiles == nil {
+		f.ConfigFiles = make(map[string]map[string][]HealthSample)
+	}
+	if len(f.Hosts) > 0 {
+		defaultConfig := history.CanonicalConfigFile("")
+		hosts := f.ConfigFiles[defaultConfig]
+		if hosts == nil {
+			hosts = make(map[string][]HealthSample)
+			f.ConfigFiles[defaultConfig] = hosts
+		}
+		for hostName, samples := range f.Hosts {
+			hosts[hostName] = mergeSamples(hosts[hostName], samples)
+		}
+	}
+	f.Hosts = nil
+}
+
+// GetHealthHistoryPath returns the path of the file the health history is saved to
+func GetHealthHistoryPath() (string, error) {
+	configDir, err := config.GetSSHMConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "health_history.json"), nil
+}
+
+// NewHealthHistory creates a health history of the hosts of configFile saved
+// to path, keeping up to size samples per host for at most ttl. A ttl of 0
+// keeps samples forever.
+func NewHealthHistory(path, configFile string, size int, ttl time.Duration) *HealthHistory {
+	if size <= 0 {
+		size = 1
+	}
+	return &HealthHistory{
+		path:       path,
+		configFile: history.CanonicalConfigFile(configFile),
+		size:       size,
+		ttl:        ttl,
+		samples:    make(map[string][]HealthSample),
+	}
+}
+
+// Load reads the saved samples, dropping the expired ones.
+// A missing file is not an error.
+func (h *HealthHistory) Load() error {
+	data, err := os.ReadFile(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var file healthHistoryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	file.migrate()
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	hosts := file.ConfigFiles[h.configFile]
+	h.samples = make(map[string][]HealthSample, len(hosts))
+	for hostName, samples := range hosts {
+		if samples = h.trim(samples); len(samples) > 0 {
+			h.samples[hostName] = samples
+		}
+	}
+	return nil
+}
+
+// Save writes the samples that have not expired. They are merged with the
+// samples other sshm processes saved since the history was loaded.
+func (h *HealthHistory) Save() error {
+	h.mutex.RLock()
+	hosts := make(map[string][]HealthSample, len(h.samples))
+	for hostName, samples := range h.samples {
+		hosts[hostName] = append([]HealthSample(nil), samples...)
+	}
+	h.mutex.RUnlock()
+
+	return history.UpdateFile(h.path, func(data []byte) ([]byte, error) {
+		// A history that cannot be parsed is replaced
+		var file healthHistoryFile
+		if data != nil && json.Unmarshal(data, &file) != nil {
+			file = healthHistoryFile{}
+		}
+		file.migrate()
+
+		for hostName, samples := range file.ConfigFiles[h.configFile] {
+			hosts[hostName] = mergeSamples(hosts[hostName], samples)
+		}
+		for hostName, samples := range hosts {
+			if samples = h.trim(samples); len(samples) > 0 {
+				hosts[hostName] = samples
+			} else {
+				delete(hosts, hostName)
+			}
+		}
+		file.ConfigFiles[h.configFile] = hosts
+
+		return json.MarshalIndent(file, "", "  ")
+	})
+}
+
+// Record adds the outcome of a completed check to the history of its host.
+// It reports whether the host went online or offline since the previous sample.
+func (h *HealthHistory) Record(result *HostPingResult) bool {
+	if result == nil || (result.Status != StatusOnline && result.Status != StatusOffline) {
+		return false
+	}
+
+	sample := HealthSample{
+		Time:    result.CheckedAt,
+		Online:  result.Status == StatusOnline,
+		Latency: result.Duration,
+	}
+	if sample.Time.IsZero() {
+		sample.Time = time.Now()
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	samples := h.unexpired(h.samples[result.HostName])
+	changed := len(samples) > 0 && samples[len(samples)-1].Online != sample.Online
+
+	// Drop the oldest sample once the buffer is full
+	samples = append(samples, sample)
+	if len(samples) > h.size {
+		samples = append(samples[:0:0], samples[len(samples)-h.size:]...)
+	}
+	h.samples[result.HostName] = samples
+
+	return changed
+}
+
+// Samples returns the samples of a host that have not expired, oldest first
+func (h *HealthHistory) Samples(hostName string) []HealthSample {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	return append([]HealthSample(nil), h.unexpired(h.samples[hostName])...)
+}
+
+// Uptime returns the share of the samples of a host in which it was online,
+// between 0 and 1. The second value is false when there is no sample.
+func (h *HealthHistory) Uptime(hostName string) (float64, bool) {
+	samples := h.Samples(hostName)
+	if len(samples) == 0 {
+		return 0, false
+	}
+
+	online := 0
+	for _, sample := range samples {
+		if sample.Online {
+			online++
+		}
+	}
+	return float64(online) / float64(len(samples)), true
+}
+
+// trim returns the last size samples recorded within the TTL
+func (h *HealthHistory) trim(samples []HealthSample) []HealthSample {
+	if len(samples) > h.size {
+		samples = samples[len(samples)-h.size:]
+	}
+	return h.unexpired(samples)
+}
+
+// mergeSamples returns the samples of a and b oldest first, those recorded
+// at the same time in both only once
+func mergeSamples(a, b []HealthSample) []HealthSample {
+	merged := append(append([]HealthSample(nil), a...), b...)
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Time.Before(merged[j].Time) })
+
+	unique := merged[:0]
+	for _, sample := range merged {
+		if len(unique) > 0 && unique[len(unique)-1].Time.Equal(sample.Time) {
+			continue
+		}
+		unique = append(unique, sample)
+	}
+	return unique
+}
+
+// unexpired returns the samples recorded within the TTL
+func (h *HealthHistory) unexpired(samples []HealthSample) []HealthSample {
+	if h.ttl <= 0 {
+		return samples
+	}
+
+	cutoff := time.Now().Add(-h.ttl)
+	for i, sample := range samples {
+		if sample.Time.After(cutoff) {
+			return samples[i:]
+		}
+	}
+	return nil
+}
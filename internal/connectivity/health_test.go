@@ -0,0 +1,154 @@
+package connectivity
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHealthHistory_Record(t *testing.T) {
+	history := NewHealthHistory(filepath.Join(t.TempDir(), "health.json"), "", 3, time.Hour)
+
+	tests := []struct {
+		status  PingStatus
+		changed bool
+	}{
+		{StatusOnline, false},     // First sample
+		{StatusConnecting, false}, // Not a completed check
+		{StatusOnline, false},
+		{StatusOffline, true},
+		{StatusOffline, false},
+		{StatusOnline, true},
+	}
+
+	for i, tt := range tests {
+		result := &HostPingResult{HostName: "web", Status: tt.status, Duration: time.Duration(i) * time.Millisecond, CheckedAt: time.Now()}
+		if changed := history.Record(result); changed != tt.changed {
+			t.Errorf("Record() #%d (%v) = %v, want %v", i, tt.status, changed, tt.changed)
+		}
+	}
+
+	// Only the last 3 samples are kept
+	samples := history.Samples("web")
+	if len(samples) != 3 {
+		t.Fatalf("Expected 3 samples, got %d", len(samples))
+	}
+	if samples[0].Online || samples[2].Latency != 5*time.Millisecond {
+		t.Errorf("Expected the oldest samples to be dropped, got %+v", samples)
+	}
+
+	uptime, ok := history.Uptime("web")
+	if !ok || uptime < 0.33 || uptime > 0.34 {
+		t.Errorf("Uptime() = %v, %v, want 1/3", uptime, ok)
+	}
+	if _, ok := history.Uptime("db"); ok {
+		t.Error("Expected no uptime for a host without samples")
+	}
+}
+
+func TestHealthHistory_SaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sshm", "health.json")
+	configFile := filepath.Join(dir, "config")
+
+	history := NewHealthHistory(path, configFile, 10, time.Hour)
+	history.Record(&HostPingResult{HostName: "old", Status: StatusOnline, CheckedAt: time.Now().Add(-2 * time.Hour)})
+	history.Record(&HostPingResult{HostName: "web", Status: StatusOnline, Duration: 12 * time.Millisecond, CheckedAt: time.Now()})
+	if err := history.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded := NewHealthHistory(path, configFile, 10, time.Hour)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if samples := loaded.Samples("web"); len(samples) != 1 || samples[0].Latency != 12*time.Millisecond {
+		t.Errorf("Expected the sample of web to be loaded, got %+v", samples)
+	}
+	if samples := loaded.Samples("old"); len(samples) != 0 {
+		t.Errorf("Expected expired samples to be dropped, got %+v", samples)
+	}
+
+	// A missing file is an empty history
+	if err := NewHealthHistory(filepath.Join(t.TempDir(), "missing.json"), "", 10, time.Hour).Load(); err != nil {
+		t.Errorf("Load() of a missing file error = %v", err)
+	}
+}
+
+func TestHealthHistory_SaveMergesProcesses(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "health.json")
+	defaultConfig := filepath.Join(dir, "config")
+	otherConfig := filepath.Join(dir, "other_config")
+
+	// Two TUIs on the same config, and one on another config with a host of
+	// the same name, all loaded before any of them saves
+	first := NewHealthHistory(path, defaultConfig, 10, time.Hour)
+	second := NewHealthHistory(path, defaultConfig, 10, time.Hour)
+	other := NewHealthHistory(path, otherConfig, 10, time.Hour)
+	for _, h := range []*HealthHistory{first, second, other} {
+		if err := h.Load(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	first.Record(&HostPingResult{HostName: "web", Status: StatusOnline, Duration: time.Millisecond, CheckedAt: now.Add(-2 * time.Minute)})
+	second.Record(&HostPingResult{HostName: "web", Status: StatusOnline, Duration: 2 * time.Millisecond, CheckedAt: now.Add(-time.Minute)})
+	second.Record(&HostPingResult{HostName: "db", Status: StatusOffline, CheckedAt: now})
+	other.Record(&HostPingResult{HostName: "web", Status: StatusOffline, CheckedAt: now})
+
+	for _, h := range []*HealthHistory{first, second, other, first} {
+		if err := h.Save(); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	loaded := NewHealthHistory(path, defaultConfig, 10, time.Hour)
+	if err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	samples := loaded.Samples("web")
+	if len(samples) != 2 || samples[0].Latency != time.Millisecond || samples[1].Latency != 2*time.Millisecond {
+		t.Errorf("Expected the samples of web of both processes, oldest first, got %+v", samples)
+	}
+	if samples := loaded.Samples("db"); len(samples) != 1 {
+		t.Errorf("Expected the sample of db to be kept, got %+v", samples)
+	}
+
+	loaded = NewHealthHistory(path, otherConfig, 10, time.Hour)
+	if err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if samples := loaded.Samples("web"); len(samples) != 1 || samples[0].Online {
+		t.Errorf("Expected only the sample of web in the other config, got %+v", samples)
+	}
+}
+
+func TestHealthHistory_LoadLegacyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "health.json")
+	legacy := fmt.Sprintf(`{"hosts": {"web": [{"time": %q, "online": true, "latency": 1000000}]}}`, time.Now().Format(time.RFC3339Nano))
+	if err := os.WriteFile(path, []byte(legacy), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Samples written before config files were told apart belong to the default config
+	h := NewHealthHistory(path, "", 10, time.Hour)
+	if err := h.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if samples := h.Samples("web"); len(samples) != 1 || samples[0].Latency != time.Millisecond {
+		t.Errorf("Expected the legacy sample of web, got %+v", samples)
+	}
+
+	other := NewHealthHistory(path, filepath.Join(t.TempDir(), "config"), 10, time.Hour)
+	if err := other.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if samples := other.Samples("web"); len(samples) != 0 {
+		t.Errorf("Expected no legacy sample in another config, got %+v", samples)
+	}
+}
@@ -0,0 +1,97 @@
+package connectivity
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+// jumpAddresses returns the addresses of the jump hosts crossed to reach a
+// host, sorted so that slots are always acquired in the same order
+func (pm *PingManager) jumpAddresses(host config.SSHHost) []string {
+	if config.ParseProxyJump(host.ProxyJump) == nil {
+		return nil
+	}
+
+	// An unresolvable chain fails in dialRoute without crossing any jump host
+	chain, err := config.ResolveProxyJumpChain(host, pm.knownHosts())
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(chain))
+	var addresses []string
+	for _, hop := range chain {
+		if address := hop.Address(); !seen[address] {
+			seen[address] = true
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// jumpSlotsFor returns the semaphores of the given jump hosts, or nil when
+// checks through jump hosts are not limited
+func (pm *PingManager) jumpSlotsFor(addresses []string) []chan struct{} {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if pm.jumpHostConcurrency <= 0 || len(addresses) == 0 {
+		return nil
+	}
+	if pm.jumpSlots == nil {
+		pm.jumpSlots = make(map[string]chan struct{})
+	}
+
+	slots := make([]chan struct{}, 0, len(addresses))
+	for _, address := range addresses {
+		slot, exists := pm.jumpSlots[address]
+		if !exists {
+			slot = make(chan struct{}, pm.jumpHostConcurrency)
+			pm.jumpSlots[address] = slot
+		}
+		slots = append(slots, slot)
+	}
+	return slots
+}
+
+// releaseSlots frees the given semaphores
+func releaseSlots(slots []chan struct{}) {
+	for _, slot := range slots {
+		<-slot
+	}
+}
+
+// acquireJumpHosts waits for a slot on every jump host crossed to reach a
+// host and returns a function releasing them. It fails if ctx is cancelled first.
+func (pm *PingManager) acquireJumpHosts(ctx context.Context, host config.SSHHost) (func(), error) {
+	slots := pm.jumpSlotsFor(pm.jumpAddresses(host))
+
+	for i, slot := range slots {
+		select {
+		case slot <- struct{}{}:
+		case <-ctx.Done():
+			releaseSlots(slots[:i])
+			return nil, ctx.Err()
+		}
+	}
+	return func() { releaseSlots(slots) }, nil
+}
+
+// tryAcquireJumpHosts takes a slot on every jump host crossed to reach a
+// host if all of them have one free, without waiting
+func (pm *PingManager) tryAcquireJumpHosts(host config.SSHHost) (func(), bool) {
+	slots := pm.jumpSlotsFor(pm.jumpAddresses(host))
+
+	for i, slot := range slots {
+		select {
+		case slot <- struct{}{}:
+		default:
+			releaseSlots(slots[:i])
+			return nil, false
+		}
+	}
+	return func() { releaseSlots(slots) }, true
+}
@@ -0,0 +1,128 @@
+package connectivity
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+func TestPingManager_PingAllHostsOrder(t *testing.T) {
+	hostname, port := splitTestAddress(t, closedTestPort(t))
+
+	var hosts []config.SSHHost
+	for i := 0; i < 5; i++ {
+		hosts = append(hosts, config.SSHHost{Name: fmt.Sprintf("host%d", i), Hostname: hostname, Port: port})
+	}
+
+	pm := NewPingManager(2 * time.Second)
+	pm.SetConcurrency(1)
+
+	var got []string
+	for result := range pm.PingAllHosts(context.Background(), hosts) {
+		got = append(got, result.HostName)
+	}
+
+	if len(got) != len(hosts) {
+		t.Fatalf("Expected %d results, got %d", len(hosts), len(got))
+	}
+	for i, host := range hosts {
+		if got[i] != host.Name {
+			t.Errorf("Result %d = %q, want %q (checks must start in order)", i, got[i], host.Name)
+		}
+	}
+}
+
+func TestPingManager_JumpHostConcurrency(t *testing.T) {
+	jumpHost, jumpPort := splitTestAddress(t, closedTestPort(t))
+	hostname, port := splitTestAddress(t, closedTestPort(t))
+
+	bastion := config.SSHHost{Name: "bastion", Hostname: jumpHost, Port: jumpPort}
+	web := config.SSHHost{Name: "web", Hostname: "10.0.0.1", ProxyJump: "bastion"}
+	db := config.SSHHost{Name: "db", Hostname: "10.0.0.2", ProxyJump: "bastion"}
+	direct := config.SSHHost{Name: "direct", Hostname: hostname, Port: port}
+
+	pm := NewPingManager(2 * time.Second)
+	pm.SetHosts([]config.SSHHost{bastion, web, db, direct})
+	pm.SetJumpHostConcurrency(1)
+
+	release, ok := pm.tryAcquireJumpHosts(web)
+	if !ok {
+		t.Fatal("Expected a free slot on the jump host")
+	}
+	if _, ok := pm.tryAcquireJumpHosts(db); ok {
+		t.Error("Expected the jump host to be busy")
+	}
+	if directRelease, ok := pm.tryAcquireJumpHosts(direct); !ok {
+		t.Error("Expected hosts without jump host not to be limited")
+	} else {
+		directRelease()
+	}
+
+	// A host waiting for the busy jump host does not hold back the next ones
+	pm.SetConcurrency(1)
+	results := pm.PingAllHosts(context.Background(), []config.SSHHost{db, direct})
+
+	first := <-results
+	if first == nil || first.HostName != "direct" {
+		t.Fatalf("Expected the direct host to be checked first, got %+v", first)
+	}
+
+	release()
+	second := <-results
+	if second == nil || second.HostName != "db" {
+		t.Fatalf("Expected db to be checked once the jump host is free, got %+v", second)
+	}
+	if second.FailedHop != "bastion" {
+		t.Errorf("Expected db to fail on bastion, got %q", second.FailedHop)
+	}
+	if _, open := <-results; open {
+		t.Error("Expected the results channel to be closed")
+	}
+}
+
+func TestPingManager_PingHostCancelled(t *testing.T) {
+	// A server that accepts connections but never answers
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	hostname, port := splitTestAddress(t, listener.Addr().String())
+	host := config.SSHHost{Name: "silent", Hostname: hostname, Port: port}
+
+	pm := NewPingManager(5 * time.Second)
+	pm.updateStatus(&HostPingResult{HostName: host.Name, Status: StatusOnline, Kind: KindSSHReady})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	if result := pm.PingHost(ctx, host); result != nil {
+		t.Errorf("Expected no result for a cancelled check, got %+v", result)
+	}
+	if status := pm.GetStatus(host.Name); status != StatusOnline {
+		t.Errorf("Expected the previous status to be kept, got %v", status)
+	}
+
+	// Cancelled runs stop and close their channel without results
+	count := 0
+	for range pm.PingAllHosts(ctx, []config.SSHHost{host, host}) {
+		count++
+	}
+	if count != 0 {
+		t.Errorf("Expected no results after cancellation, got %d", count)
+	}
+}
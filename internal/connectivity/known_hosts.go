@@ -0,0 +1,180 @@
+package connectivity
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// HostKeyStatus describes how the key presented by a host compares to the known hosts files
+type HostKeyStatus int
+
+const (
+	HostKeyUnchecked HostKeyStatus = iota // The handshake did not get as far as the host key
+	HostKeyKnown                          // The key matches a known hosts entry
+	HostKeyUnknown                        // The host is not in the known hosts files
+	HostKeyChanged                        // The host is known with a different key
+	HostKeyRevoked                        // The key is marked as revoked
+)
+
+func (s HostKeyStatus) String() string {
+	switch s {
+	case HostKeyKnown:
+		return "known"
+	case HostKeyUnknown:
+		return "unknown"
+	case HostKeyChanged:
+		return "changed"
+	case HostKeyRevoked:
+		return "revoked"
+	}
+	return "not checked"
+}
+
+// knownHostsCallback returns a callback checking host keys against the
+// given known hosts files. Missing files are skipped; nil is returned when
+// none can be read.
+func knownHostsCallback(files []string) ssh.HostKeyCallback {
+	var existing []string
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			existing = append(existing, file)
+		}
+	}
+	if len(existing) == 0 {
+		return nil
+	}
+
+	callback, err := knownhosts.New(existing...)
+	if err != nil {
+		return nil
+	}
+	return callback
+}
+
+// hostKeyCheck verifies the host key during the handshake and records
+// whether the key exchange got as far as checking it
+type hostKeyCheck struct {
+	callback ssh.HostKeyCallback // May be nil when no known hosts file exists
+	address  string              // Name and port the key is looked up under
+
+	mutex       sync.Mutex
+	seen        bool
+	status      HostKeyStatus
+	keyType     string
+	fingerprint string
+}
+
+// newHostKeyCheck prepares the verification of the key of a host listening on port
+func newHostKeyCheck(host config.SSHHost, port string) *hostKeyCheck {
+	// ssh looks up a HostKeyAlias without the port
+	if _, ok := host.GetOption("HostKeyAlias"); ok {
+		port = "22"
+	}
+
+	return &hostKeyCheck{
+		callback: knownHostsCallback(config.GetKnownHostsFiles(host)),
+		address:  net.JoinHostPort(config.GetHostKeyName(host), port),
+	}
+}
+
+// probeKey is a key that is in no known hosts file, used to list the keys known for a host
+var probeKey = sync.OnceValue(func() ssh.PublicKey {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil
+	}
+	key, err := ssh.NewPublicKey(public)
+	if err != nil {
+		return nil
+	}
+	return key
+})
+
+// algorithms returns the host key algorithms of the keys known for the host,
+// so that the server presents a key we can compare, as ssh does.
+// It returns nil when no key is known, leaving the default algorithms.
+func (h *hostKeyCheck) algorithms() []string {
+	if h.callback == nil || probeKey() == nil {
+		return nil
+	}
+
+	var keyErr *knownhosts.KeyError
+	if err := h.callback(h.address, &net.TCPAddr{}, probeKey()); !errors.As(err, &keyErr) {
+		return nil
+	}
+
+	var algorithms []string
+	seen := make(map[string]bool)
+	for _, known := range keyErr.Want {
+		for _, algorithm := range keyAlgorithms(known.Key.Type()) {
+			if !seen[algorithm] {
+				seen[algorithm] = true
+				algorithms = append(algorithms, algorithm)
+			}
+		}
+	}
+	return algorithms
+}
+
+// keyAlgorithms returns the signature algorithms usable with a key type
+func keyAlgorithms(keyType string) []string {
+	if keyType == ssh.KeyAlgoRSA {
+		return []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+	}
+	return []string{keyType}
+}
+
+func (h *hostKeyCheck) verify(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.seen = true
+	h.keyType = key.Type()
+	h.fingerprint = ssh.FingerprintSHA256(key)
+
+	if h.callback == nil {
+		h.status = HostKeyUnknown
+		return nil
+	}
+
+	// Tunnelled connections do not have a usable remote address
+	if _, _, err := net.SplitHostPort(remote.String()); err != nil {
+		remote = &net.TCPAddr{}
+	}
+
+	err := h.callback(h.address, remote, key)
+
+	var keyErr *knownhosts.KeyError
+	var revokedErr *knownhosts.RevokedError
+	switch {
+	case err == nil:
+		h.status = HostKeyKnown
+	case errors.As(err, &revokedErr):
+		h.status = HostKeyRevoked
+		return err
+	case errors.As(err, &keyErr) && len(keyErr.Want) > 0:
+		h.status = HostKeyChanged
+		return err
+	default:
+		// Unknown hosts are still checked: ssh would ask whether to trust them
+		h.status = HostKeyUnknown
+	}
+	return nil
+}
+
+// result returns the status, type and fingerprint of the key presented by the host
+func (h *hostKeyCheck) result() (HostKeyStatus, string, string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.status, h.keyType, h.fingerprint
+}
@@ -0,0 +1,144 @@
+package connectivity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// knownHostsLine returns a known_hosts entry for a key served at hostname:port
+func knownHostsLine(hostname, port string, key ssh.PublicKey) string {
+	return fmt.Sprintf("[%s]:%s %s", hostname, port, ssh.MarshalAuthorizedKey(key))
+}
+
+// passwordServerConfig returns a server configuration rejecting every password
+func passwordServerConfig() *ssh.ServerConfig {
+	return &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			return nil, errors.New("wrong password")
+		},
+	}
+}
+
+// writeKnownHosts writes the default known_hosts file of a test home directory
+func writeKnownHosts(t *testing.T, home string, lines ...string) {
+	t.Helper()
+
+	sshDir := filepath.Join(home, ".ssh")
+	if err := os.MkdirAll(sshDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	var content string
+	for _, line := range lines {
+		content += line
+	}
+	if err := os.WriteFile(filepath.Join(sshDir, "known_hosts"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPingHost_HostKeyStatus(t *testing.T) {
+	hostKey := testHostKey(t)
+	hostname, port := splitTestAddress(t, startTestSSHServer(t, passwordServerConfig(), hostKey))
+	otherKey := testHostKey(t)
+
+	tests := []struct {
+		name       string
+		knownHosts []string
+		options    string
+		wantStatus HostKeyStatus
+		wantKind   ResultKind
+	}{
+		{"no known_hosts file", nil, "", HostKeyUnknown, KindAuthRequired},
+		{"known key", []string{knownHostsLine(hostname, port, hostKey.PublicKey())}, "", HostKeyKnown, KindAuthRequired},
+		{"unknown host", []string{knownHostsLine("other.example.com", port, hostKey.PublicKey())}, "", HostKeyUnknown, KindAuthRequired},
+		{"changed key", []string{knownHostsLine(hostname, port, otherKey.PublicKey())}, "", HostKeyChanged, KindHostKeyChanged},
+		{"revoked key", []string{"@revoked * " + string(ssh.MarshalAuthorizedKey(hostKey.PublicKey()))}, "", HostKeyRevoked, KindHostKeyChanged},
+		{"host key alias", []string{"db " + string(ssh.MarshalAuthorizedKey(hostKey.PublicKey()))}, "HostKeyAlias db", HostKeyKnown, KindAuthRequired},
+		{"known hosts disabled", []string{knownHostsLine(hostname, port, otherKey.PublicKey())}, "UserKnownHostsFile none\nGlobalKnownHostsFile none", HostKeyUnknown, KindAuthRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			if tt.knownHosts != nil {
+				writeKnownHosts(t, home, tt.knownHosts...)
+			}
+
+			pm := NewPingManager(2 * time.Second)
+			host := config.SSHHost{Name: "test", Hostname: hostname, Port: port, Options: tt.options}
+			result := pm.PingHost(context.Background(), host)
+
+			if result.HostKeyStatus != tt.wantStatus {
+				t.Errorf("PingHost() host key status = %v, want %v (error: %v)", result.HostKeyStatus, tt.wantStatus, result.Error)
+			}
+			if result.Kind != tt.wantKind {
+				t.Errorf("PingHost() kind = %v, want %v", result.Kind, tt.wantKind)
+			}
+			if result.HostKeyType != ssh.KeyAlgoED25519 {
+				t.Errorf("PingHost() host key type = %q, want %q", result.HostKeyType, ssh.KeyAlgoED25519)
+			}
+			if want := ssh.FingerprintSHA256(hostKey.PublicKey()); result.HostKeyFingerprint != want {
+				t.Errorf("PingHost() fingerprint = %q, want %q", result.HostKeyFingerprint, want)
+			}
+		})
+	}
+}
+
+func TestPingHost_HostKeyPerHostKnownHostsFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	hostKey := testHostKey(t)
+	hostname, port := splitTestAddress(t, startTestSSHServer(t, passwordServerConfig(), hostKey))
+
+	file := filepath.Join(t.TempDir(), "work_hosts")
+	if err := os.WriteFile(file, []byte(knownHostsLine(hostname, port, hostKey.PublicKey())), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	pm := NewPingManager(2 * time.Second)
+	host := config.SSHHost{Name: "test", Hostname: hostname, Port: port, Options: "UserKnownHostsFile " + file}
+	result := pm.PingHost(context.Background(), host)
+
+	if result.HostKeyStatus != HostKeyKnown {
+		t.Errorf("PingHost() host key status = %v, want %v", result.HostKeyStatus, HostKeyKnown)
+	}
+}
+
+func TestPingHost_PrefersKnownHostKeyAlgorithm(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// The server offers an ed25519 and an RSA key, only the RSA key is known
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaSigner, err := ssh.NewSignerFromKey(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostname, port := splitTestAddress(t, startTestSSHServer(t, passwordServerConfig(), testHostKey(t), rsaSigner))
+	writeKnownHosts(t, home, knownHostsLine(hostname, port, rsaSigner.PublicKey()))
+
+	pm := NewPingManager(2 * time.Second)
+	result := pm.PingHost(context.Background(), config.SSHHost{Name: "test", Hostname: hostname, Port: port})
+
+	if result.HostKeyStatus != HostKeyKnown {
+		t.Errorf("PingHost() host key status = %v, want %v (error: %v)", result.HostKeyStatus, HostKeyKnown, result.Error)
+	}
+	if result.HostKeyType != ssh.KeyAlgoRSA {
+		t.Errorf("PingHost() host key type = %q, want %q", result.HostKeyType, ssh.KeyAlgoRSA)
+	}
+}
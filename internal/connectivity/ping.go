@@ -2,12 +2,14 @@ package connectivity
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net"
-	"github.com/Gu1llaum-3/sshm/internal/config"
-	"strings"
 	"sync"
 	"time"
 
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
 	"golang.org/x/crypto/ssh"
 )
 
@@ -37,10 +39,21 @@ func (s PingStatus) String() string {
 
 // HostPingResult represents the result of pinging a host
 type HostPingResult struct {
-	HostName string
-	Status   PingStatus
-	Error    error
-	Duration time.Duration
+	HostName      string
+	Status        PingStatus
+	Kind          ResultKind // Detailed outcome of the check
+	Error         error
+	Duration      time.Duration
+	CheckedAt     time.Time // When the check completed
+	FailedHop     string    // Jump host (or "ProxyCommand") on which the check failed, if any
+	ResolvedIP    string    // IP address the SSH port was reached on, unknown through proxies
+	ServerVersion string    // SSH version string sent by the server, e.g. "SSH-2.0-OpenSSH_9.6"
+	Cached        bool      // Loaded from the cache of a previous run, see LoadResults
+
+	// Host key presented by the server and how it compares to the known hosts files
+	HostKeyStatus      HostKeyStatus
+	HostKeyType        string // e.g. "ssh-ed25519"
+	HostKeyFingerprint string // SHA256 fingerprint, as printed by ssh
 }
 
 // PingManager manages SSH connectivity checks for multiple hosts
@@ -48,6 +61,17 @@ type PingManager struct {
 	results map[string]*HostPingResult
 	mutex   sync.RWMutex
 	timeout time.Duration
+
+	// hosts are the configured hosts, used to resolve ProxyJump aliases
+	hosts []config.SSHHost
+
+	// concurrency is the maximum number of checks run at once by PingAllHosts, 0 for no limit
+	concurrency int
+
+	// jumpHostConcurrency is the maximum number of checks crossing the same
+	// jump host at once, 0 for no limit. jumpSlots holds a semaphore per jump host address.
+	jumpHostConcurrency int
+	jumpSlots           map[string]chan struct{}
 }
 
 // NewPingManager creates a new ping manager with the specified timeout
@@ -58,6 +82,43 @@ func NewPingManager(timeout time.Duration) *PingManager {
 	}
 }
 
+// SetHosts sets the configured hosts, used to resolve the jump hosts
+// named in ProxyJump directives
+func (pm *PingManager) SetHosts(hosts []config.SSHHost) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	pm.hosts = append([]config.SSHHost(nil), hosts...)
+}
+
+// SetConcurrency limits the number of checks PingAllHosts runs at once.
+// A limit of 0 or less runs every check at once.
+func (pm *PingManager) SetConcurrency(limit int) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	pm.concurrency = limit
+}
+
+// SetJumpHostConcurrency limits the number of checks crossing the same
+// jump host at once, so that a bastion is not flooded with connections.
+// A limit of 0 or less does not limit them.
+func (pm *PingManager) SetJumpHostConcurrency(limit int) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	pm.jumpHostConcurrency = limit
+	pm.jumpSlots = nil
+}
+
+// knownHosts returns the configured hosts
+func (pm *PingManager) knownHosts() []config.SSHHost {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	return pm.hosts
+}
+
 // GetStatus returns the current status for a host
 func (pm *PingManager) GetStatus(hostName string) PingStatus {
 	pm.mutex.RLock()
@@ -79,134 +140,205 @@ func (pm *PingManager) GetResult(hostName string) (*HostPingResult, bool) {
 }
 
 // updateStatus updates the status for a host
-func (pm *PingManager) updateStatus(hostName string, status PingStatus, err error, duration time.Duration) {
+func (pm *PingManager) updateStatus(result *HostPingResult) {
 	pm.mutex.Lock()
 	defer pm.mutex.Unlock()
 
-	pm.results[hostName] = &HostPingResult{
-		HostName: hostName,
-		Status:   status,
-		Error:    err,
-		Duration: duration,
+	stored := *result
+	pm.results[result.HostName] = &stored
+}
+
+// restoreResult puts back the result a host had before an interrupted check
+func (pm *PingManager) restoreResult(hostName string, previous *HostPingResult) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if previous == nil {
+		delete(pm.results, hostName)
+		return
 	}
+	pm.results[hostName] = previous
 }
 
-// PingHost performs an SSH connectivity check for a single host
+// PingHost performs an SSH connectivity check for a single host.
+// Hosts with a ProxyJump are reached through their jump hosts, and hosts
+// with a ProxyCommand through the command, as ssh would.
+// If ctx is cancelled before the check completes, the previous result of
+// the host is kept and nil is returned.
 func (pm *PingManager) PingHost(ctx context.Context, host config.SSHHost) *HostPingResult {
-	start := time.Now()
+	release, err := pm.acquireJumpHosts(ctx, host)
+	if err != nil {
+		return nil
+	}
+	defer release()
 
-	// Mark as connecting
-	pm.updateStatus(host.Name, StatusConnecting, nil, 0)
+	return pm.pingHost(ctx, host)
+}
 
-	// Determine the actual hostname and port
-	hostname := host.Hostname
-	if hostname == "" {
-		hostname = host.Name
-	}
+// pingHost checks a host once its jump host slots are held, keeping the
+// previous result if ctx is cancelled
+func (pm *PingManager) pingHost(ctx context.Context, host config.SSHHost) *HostPingResult {
+	previous, _ := pm.GetResult(host.Name)
 
-	port := host.Port
-	if port == "" {
-		port = "22"
+	result := pm.checkHost(ctx, host)
+	if ctx.Err() != nil {
+		pm.restoreResult(host.Name, previous)
+		return nil
 	}
+	return result
+}
+
+// checkHost runs the connectivity check of a host and records its result
+func (pm *PingManager) checkHost(ctx context.Context, host config.SSHHost) *HostPingResult {
+	start := time.Now()
+
+	// Mark as connecting
+	pm.updateStatus(&HostPingResult{HostName: host.Name, Status: StatusConnecting})
+
+	address, port := hostAddress(host)
 
-	// Create context with timeout
-	pingCtx, cancel := context.WithTimeout(ctx, pm.timeout)
+	// Create context with timeout, leaving time for each jump host
+	timeout := pm.timeout * time.Duration(1+len(config.ParseProxyJump(host.ProxyJump)))
+	pingCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	// Try to establish a TCP connection first (faster than SSH handshake)
-	dialer := &net.Dialer{}
-	conn, err := dialer.DialContext(pingCtx, "tcp", net.JoinHostPort(hostname, port))
-	if err != nil {
-		duration := time.Since(start)
-		pm.updateStatus(host.Name, StatusOffline, err, duration)
-		return &HostPingResult{
-			HostName: host.Name,
-			Status:   StatusOffline,
-			Error:    err,
-			Duration: duration,
+	result := &HostPingResult{HostName: host.Name}
+	finish := func(kind ResultKind, err error) *HostPingResult {
+		result.Kind = kind
+		result.Status = kind.Status()
+		result.Error = err
+		result.Duration = time.Since(start)
+		result.CheckedAt = time.Now()
+
+		var hopErr *HopError
+		if errors.As(err, &hopErr) {
+			result.FailedHop = hopErr.Hop
 		}
+
+		pm.updateStatus(result)
+		return result
 	}
-	defer conn.Close()
 
-	// If TCP connection succeeds, try SSH handshake
+	// Establish a connection to the SSH port first, directly or through the proxies
+	r, err := pm.dialRoute(pingCtx, host, address)
+	if err != nil {
+		result.ResolvedIP = resolvedIP(nil, err)
+		return finish(classifyError(err), err)
+	}
+	defer r.Close()
+	if r.direct {
+		result.ResolvedIP = resolvedIP(r.conn, nil)
+	}
+
+	// If the connection succeeds, try SSH handshake. We don't authenticate,
+	// the handshake tells whether an SSH server answers and whether its key changed.
+	hostKey := newHostKeyCheck(host, port)
 	sshConfig := &ssh.ClientConfig{
-		User:            host.User,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // For ping purposes only
-		Timeout:         time.Second * 2,             // Short timeout for handshake
+		User:              host.User,
+		HostKeyCallback:   hostKey.verify,
+		HostKeyAlgorithms: hostKey.algorithms(),
+		Timeout:           time.Second * 2, // Short timeout for handshake
 	}
 
-	// We don't need to authenticate, just check if SSH is responding
-	sshConn, _, _, err := ssh.NewClientConn(conn, net.JoinHostPort(hostname, port), sshConfig)
+	conn := &bannerConn{Conn: r.conn}
+	handshakeCtx, cancelHandshake := context.WithTimeout(pingCtx, sshConfig.Timeout)
+	defer cancelHandshake()
+	sshConn, _, _, err := handshake(handshakeCtx, conn, address, sshConfig)
 	if sshConn != nil {
 		sshConn.Close()
 	}
+	result.ServerVersion = conn.serverVersion()
+	result.HostKeyStatus, result.HostKeyType, result.HostKeyFingerprint = hostKey.result()
 
-	duration := time.Since(start)
-
-	// Even if SSH handshake fails, if we got a TCP connection, consider it online
-	// This handles cases where authentication fails but the host is reachable
-	status := StatusOnline
-	if err != nil && isConnectionError(err) {
-		status = StatusOffline
+	// A ProxyCommand that exits before the server answers did not reach the host
+	if err != nil && r.command != nil && result.ServerVersion == "" && errors.Is(err, io.EOF) {
+		return finish(KindProxyFailed, &HopError{Hop: "ProxyCommand", Err: r.command.failure()})
 	}
 
-	pm.updateStatus(host.Name, status, err, duration)
-	return &HostPingResult{
-		HostName: host.Name,
-		Status:   status,
-		Error:    err,
-		Duration: duration,
-	}
+	return finish(classifyHandshake(err, result.ServerVersion, hostKey), err)
 }
 
-// PingAllHosts pings all hosts concurrently and returns a channel of results
+// jumpSlotRetryInterval is how often PingAllHosts retries hosts waiting for a jump host
+const jumpSlotRetryInterval = 100 * time.Millisecond
+
+// PingAllHosts checks hosts concurrently and returns a channel streaming
+// the results as they complete. Checks start in the order of hosts, so
+// callers can put the hosts they need first at the front, while respecting
+// the concurrency limits: a host waiting for a busy jump host does not hold
+// back the hosts after it. Cancelling ctx stops the checks not yet started,
+// interrupts the running ones and closes the channel once they return.
 func (pm *PingManager) PingAllHosts(ctx context.Context, hosts []config.SSHHost) <-chan *HostPingResult {
 	resultChan := make(chan *HostPingResult, len(hosts))
 
-	var wg sync.WaitGroup
+	pm.mutex.RLock()
+	limit := pm.concurrency
+	pm.mutex.RUnlock()
+
+	go func() {
+		var wg sync.WaitGroup
+		defer close(resultChan)
+		defer wg.Wait()
+
+		pending := append([]config.SSHHost(nil), hosts...)
+		finished := make(chan struct{}, len(hosts))
+		running := 0
+
+		for len(pending) > 0 {
+			// Start every check allowed by the limits, in order
+			waiting := pending[:0]
+			for _, host := range pending {
+				if limit > 0 && running >= limit {
+					waiting = append(waiting, host)
+					continue
+				}
+				release, ok := pm.tryAcquireJumpHosts(host)
+				if !ok {
+					waiting = append(waiting, host)
+					continue
+				}
+
+				running++
+				wg.Add(1)
+				go func(h config.SSHHost) {
+					defer wg.Done()
+					defer func() { finished <- struct{}{} }()
+					defer release()
+
+					if result := pm.pingHost(ctx, h); result != nil {
+						resultChan <- result
+					}
+				}(host)
+			}
+			pending = waiting
+			if len(pending) == 0 {
+				break
+			}
 
-	for _, host := range hosts {
-		wg.Add(1)
-		go func(h config.SSHHost) {
-			defer wg.Done()
-			result := pm.PingHost(ctx, h)
+			// Wait for a check to free its slots. Jump hosts may also be
+			// held by checks started elsewhere, which do not signal us.
 			select {
-			case resultChan <- result:
+			case <-finished:
+				running--
+			case <-time.After(jumpSlotRetryInterval):
 			case <-ctx.Done():
 				return
 			}
-		}(host)
-	}
-
-	// Close the channel when all goroutines are done
-	go func() {
-		wg.Wait()
-		close(resultChan)
+		}
 	}()
 
 	return resultChan
 }
 
-// isConnectionError determines if an error is a connection-related error
-func isConnectionError(err error) bool {
-	if err == nil {
-		return false
-	}
-
-	errStr := err.Error()
-	connectionErrors := []string{
-		"connection refused",
-		"no route to host",
-		"network is unreachable",
-		"timeout",
-		"connection timed out",
+// hostAddress returns the address of the SSH port of a host, and the port
+func hostAddress(host config.SSHHost) (string, string) {
+	hostname := host.Hostname
+	if hostname == "" {
+		hostname = host.Name
 	}
 
-	for _, connErr := range connectionErrors {
-		if strings.Contains(strings.ToLower(errStr), connErr) {
-			return true
-		}
+	port := host.Port
+	if port == "" {
+		port = "22"
 	}
-
-	return false
+	return net.JoinHostPort(hostname, port), port
 }
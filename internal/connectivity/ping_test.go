@@ -2,6 +2,9 @@ package connectivity
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"sync"
 	"testing"
 	"time"
 
@@ -141,4 +144,61 @@ func TestPingHost_Basic(t *testing.T) {
 	if status == StatusUnknown {
 		t.Error("Expected status to be set after ping attempt")
 	}
-}
\ No newline at end of file
+}
+
+func TestPingManager_PingAllHostsConcurrency(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// Hold each connection for a while and record how many are open at once
+	var mutex sync.Mutex
+	active, maxActive := 0, 0
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				mutex.Lock()
+				active++
+				maxActive = max(maxActive, active)
+				mutex.Unlock()
+
+				time.Sleep(50 * time.Millisecond)
+
+				// Count the connection as closed before the client can notice
+				mutex.Lock()
+				active--
+				mutex.Unlock()
+				conn.Close()
+			}()
+		}
+	}()
+
+	hostname, port, _ := net.SplitHostPort(listener.Addr().String())
+	var hosts []config.SSHHost
+	for i := 0; i < 6; i++ {
+		hosts = append(hosts, config.SSHHost{Name: fmt.Sprintf("host%d", i), Hostname: hostname, Port: port})
+	}
+
+	pm := NewPingManager(2 * time.Second)
+	pm.SetConcurrency(2)
+
+	count := 0
+	for range pm.PingAllHosts(context.Background(), hosts) {
+		count++
+	}
+
+	if count != len(hosts) {
+		t.Errorf("Expected %d results, got %d", len(hosts), count)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if maxActive > 2 {
+		t.Errorf("Expected at most 2 checks at once, got %d", maxActive)
+	}
+}
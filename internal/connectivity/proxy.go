@@ -0,0 +1,342 @@
+package connectivity
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// HopError reports a failure on one of the jump hosts leading to a host
+type HopError struct {
+	Hop string // Name of the jump host, or "ProxyCommand"
+	Err error
+}
+
+func (e *HopError) Error() string {
+	return fmt.Sprintf("via %s: %v", e.Hop, e.Err)
+}
+
+func (e *HopError) Unwrap() error {
+	return e.Err
+}
+
+// defaultIdentityFiles are the key files ssh tries when no IdentityFile is configured
+var defaultIdentityFiles = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// route describes how the target of a connectivity check is reached
+type route struct {
+	conn    net.Conn
+	closers []io.Closer  // Jump host clients to close once the check is over
+	command *commandConn // Set when the host is reached through its ProxyCommand
+	direct  bool         // The host is reached without a proxy
+}
+
+// Close closes the connection and every jump host client of the route
+func (r *route) Close() {
+	if r.conn != nil {
+		r.conn.Close()
+	}
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		r.closers[i].Close()
+	}
+}
+
+// dialRoute opens a connection to the SSH port of a host, following its
+// ProxyJump chain or running its ProxyCommand when one is configured
+func (pm *PingManager) dialRoute(ctx context.Context, host config.SSHHost, address string) (*route, error) {
+	switch {
+	case host.ProxyJump != "" && !strings.EqualFold(host.ProxyJump, "none"):
+		chain, err := config.ResolveProxyJumpChain(host, pm.knownHosts())
+		if err != nil {
+			return nil, &HopError{Hop: "ProxyJump", Err: err}
+		}
+		return pm.dialThroughJumps(ctx, chain, address)
+
+	case host.ProxyCommand != "" && !strings.EqualFold(host.ProxyCommand, "none"):
+		conn, err := dialProxyCommand(host, address)
+		if err != nil {
+			return nil, &HopError{Hop: "ProxyCommand", Err: err}
+		}
+		return &route{conn: conn, command: conn}, nil
+
+	default:
+		dialer := &net.Dialer{}
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return nil, err
+		}
+		return &route{conn: conn, direct: true}, nil
+	}
+}
+
+// dialThroughJumps connects to each jump host in turn, tunnelling every
+// connection through the previous hop, and finally opens a tunnel to address
+func (pm *PingManager) dialThroughJumps(ctx context.Context, chain []config.JumpHop, address string) (*route, error) {
+	r := &route{}
+	var client *ssh.Client
+
+	dial := func(addr string) (net.Conn, error) {
+		if client == nil {
+			dialer := &net.Dialer{}
+			return dialer.DialContext(ctx, "tcp", addr)
+		}
+		return client.Dial("tcp", addr)
+	}
+
+	for _, hop := range chain {
+		conn, err := dial(hop.Address())
+		if err != nil {
+			r.Close()
+			return nil, &HopError{Hop: hop.Name(), Err: err}
+		}
+
+		auth, agentConn := authMethods(hop)
+		if agentConn != nil {
+			r.closers = append(r.closers, agentConn)
+		}
+
+		hostKey := newHostKeyCheck(hopHost(hop), hopPort(hop))
+		clientConfig := &ssh.ClientConfig{
+			User:              hopUser(hop),
+			Auth:              auth,
+			HostKeyCallback:   hostKey.verify,
+			HostKeyAlgorithms: hostKey.algorithms(),
+			Timeout:           pm.timeout,
+		}
+
+		sshConn, chans, reqs, err := handshake(ctx, conn, hop.Address(), clientConfig)
+		if err != nil {
+			conn.Close()
+			r.Close()
+			return nil, &HopError{Hop: hop.Name(), Err: err}
+		}
+
+		client = ssh.NewClient(sshConn, chans, reqs)
+		r.closers = append(r.closers, client)
+	}
+
+	conn, err := dial(address)
+	if err != nil {
+		r.Close()
+		last := chain[len(chain)-1]
+		return nil, &HopError{Hop: last.Name(), Err: err}
+	}
+	r.conn = conn
+	return r, nil
+}
+
+// handshake performs an SSH handshake on conn, giving up when ctx is done.
+// Tunnelled connections do not support deadlines, so the connection is
+// closed to interrupt the handshake instead.
+func handshake(ctx context.Context, conn net.Conn, address string, clientConfig *ssh.ClientConfig) (ssh.Conn, <-chan ssh.NewChannel, <-chan *ssh.Request, error) {
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, address, clientConfig)
+	if err != nil && ctx.Err() != nil {
+		return nil, nil, nil, ctx.Err()
+	}
+	return sshConn, chans, reqs, err
+}
+
+// hopHost returns the host whose known hosts options verify the key of a
+// jump host: its configured alias, or the host named in ProxyJump
+func hopHost(hop config.JumpHop) config.SSHHost {
+	if hop.Alias != nil {
+		return *hop.Alias
+	}
+	return config.SSHHost{Name: hop.Hostname, Hostname: hop.Hostname}
+}
+
+// hopPort returns the SSH port of a jump host
+func hopPort(hop config.JumpHop) string {
+	if hop.Port == "" {
+		return "22"
+	}
+	return hop.Port
+}
+
+// hopUser returns the user to authenticate as on a jump host
+func hopUser(hop config.JumpHop) string {
+	if hop.User != "" {
+		return hop.User
+	}
+	if current, err := user.Current(); err == nil {
+		// On Windows the username is prefixed with the domain
+		name := current.Username
+		if i := strings.LastIndex(name, `\`); i >= 0 {
+			name = name[i+1:]
+		}
+		return name
+	}
+	return ""
+}
+
+// authMethods returns the authentication methods used on a jump host:
+// the SSH agent if available, then the configured or default key files.
+// Keys protected by a passphrase are skipped. The returned connection to
+// the agent, if any, must be closed once authentication is over.
+func authMethods(hop config.JumpHop) ([]ssh.AuthMethod, io.Closer) {
+	var methods []ssh.AuthMethod
+	var agentConn io.Closer
+
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			agentConn = conn
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+
+	var signers []ssh.Signer
+	for _, path := range identityFiles(hop) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+
+	return methods, agentConn
+}
+
+// identityFiles returns the key files to try for a jump host
+func identityFiles(hop config.JumpHop) []string {
+	if hop.Alias != nil && hop.Alias.Identity != "" {
+		return []string{config.ExpandHomePath(hop.Alias.Identity)}
+	}
+
+	sshDir, err := config.GetSSHDirectory()
+	if err != nil {
+		return nil
+	}
+
+	files := make([]string, 0, len(defaultIdentityFiles))
+	for _, name := range defaultIdentityFiles {
+		files = append(files, filepath.Join(sshDir, name))
+	}
+	return files
+}
+
+// expandProxyCommand replaces the ssh tokens supported in ProxyCommand
+func expandProxyCommand(command string, host config.SSHHost, address string) string {
+	hostname, port, _ := net.SplitHostPort(address)
+
+	replacer := strings.NewReplacer(
+		"%%", "%",
+		"%h", hostname,
+		"%p", port,
+		"%r", host.User,
+		"%n", host.Name,
+	)
+	return replacer.Replace(command)
+}
+
+// dialProxyCommand runs a ProxyCommand and returns a connection speaking to its stdin and stdout
+func dialProxyCommand(host config.SSHHost, address string) (*commandConn, error) {
+	command := expandProxyCommand(host.ProxyCommand, host, address)
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	conn := &commandConn{cmd: cmd, stdin: stdin, stdout: stdout}
+	cmd.Stderr = &conn.stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// commandConn is a net.Conn backed by the standard input and output of a command
+type commandConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	stderr bytes.Buffer
+
+	closeOnce sync.Once
+}
+
+// failure describes why the command stopped providing a connection,
+// using the last line it wrote on its standard error
+func (c *commandConn) failure() error {
+	c.Close()
+
+	lines := strings.Split(strings.TrimSpace(c.stderr.String()), "\n")
+	if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+		return errors.New(last)
+	}
+	if c.cmd.ProcessState != nil && !c.cmd.ProcessState.Success() {
+		return fmt.Errorf("command exited with status %d", c.cmd.ProcessState.ExitCode())
+	}
+	return errors.New("command closed the connection")
+}
+
+func (c *commandConn) Read(b []byte) (int, error)  { return c.stdout.Read(b) }
+func (c *commandConn) Write(b []byte) (int, error) { return c.stdin.Write(b) }
+
+func (c *commandConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		if c.cmd.Process != nil {
+			c.cmd.Process.Kill()
+		}
+		c.cmd.Wait()
+	})
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr                { return commandAddr{} }
+func (c *commandConn) RemoteAddr() net.Addr               { return commandAddr{} }
+func (c *commandConn) SetDeadline(t time.Time) error      { return nil }
+func (c *commandConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *commandConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// commandAddr is the address of a commandConn
+type commandAddr struct{}
+
+func (commandAddr) Network() string { return "proxycommand" }
+func (commandAddr) String() string  { return "proxycommand" }
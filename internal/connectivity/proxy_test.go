@@ -0,0 +1,317 @@
+package connectivity
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// testSSHServer starts an SSH server on a random local port. When
+// authorizedKey is set, the server accepts it and forwards direct-tcpip
+// channels like a jump host would. It returns the address of the server.
+func testSSHServer(t *testing.T, authorizedKey ssh.PublicKey, hostKeys ...ssh.Signer) string {
+	t.Helper()
+
+	serverConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if authorizedKey != nil && bytes.Equal(key.Marshal(), authorizedKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("unauthorized key")
+		},
+	}
+	return startTestSSHServer(t, serverConfig, hostKeys...)
+}
+
+// testHostKey generates an ed25519 host key
+func testHostKey(t *testing.T) ssh.Signer {
+	t.Helper()
+
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// startTestSSHServer serves SSH with the given configuration and host keys,
+// generating a host key if none is given, and returns the address of the server
+func startTestSSHServer(t *testing.T, serverConfig *ssh.ServerConfig, hostKeys ...ssh.Signer) string {
+	t.Helper()
+
+	if len(hostKeys) == 0 {
+		hostKeys = append(hostKeys, testHostKey(t))
+	}
+	for _, hostKey := range hostKeys {
+		serverConfig.AddHostKey(hostKey)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestConn(conn, serverConfig)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// serveTestConn handles one client of a test SSH server
+func serveTestConn(conn net.Conn, serverConfig *ssh.ServerConfig) {
+	defer conn.Close()
+
+	_, chans, reqs, err := ssh.NewServerConn(conn, serverConfig)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+
+		var payload struct {
+			Host     string
+			Port     uint32
+			OrigHost string
+			OrigPort uint32
+		}
+		if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			target.Close()
+			continue
+		}
+		go ssh.DiscardRequests(requests)
+		go func() {
+			defer channel.Close()
+			defer target.Close()
+			go io.Copy(target, channel)
+			io.Copy(channel, target)
+		}()
+	}
+}
+
+// writeTestKey generates a key pair, writes the private key to a file and
+// returns its path along with the public key
+func writeTestKey(t *testing.T) (string, ssh.PublicKey) {
+	t.Helper()
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(privateKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path, signer.PublicKey()
+}
+
+// splitTestAddress splits an address into the host and port of an SSHHost
+func splitTestAddress(t *testing.T, address string) (string, string) {
+	t.Helper()
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return host, port
+}
+
+func TestPingHost_ProxyJump(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	keyPath, publicKey := writeTestKey(t)
+	otherKeyPath, _ := writeTestKey(t)
+
+	targetHost, targetPort := splitTestAddress(t, testSSHServer(t, nil))
+	jumpHost, jumpPort := splitTestAddress(t, testSSHServer(t, publicKey))
+
+	target := config.SSHHost{Name: "target", Hostname: targetHost, Port: targetPort, ProxyJump: "bastion"}
+
+	tests := []struct {
+		name      string
+		identity  string
+		want      PingStatus
+		failedHop string
+	}{
+		{"authorized key", keyPath, StatusOnline, ""},
+		{"rejected key", otherKeyPath, StatusOffline, "bastion"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bastion := config.SSHHost{Name: "bastion", Hostname: jumpHost, Port: jumpPort, User: "test", Identity: tt.identity}
+
+			pm := NewPingManager(2 * time.Second)
+			pm.SetHosts([]config.SSHHost{bastion, target})
+
+			result := pm.PingHost(context.Background(), target)
+			if result.Status != tt.want {
+				t.Fatalf("PingHost() status = %v, want %v (error: %v)", result.Status, tt.want, result.Error)
+			}
+			if result.FailedHop != tt.failedHop {
+				t.Errorf("PingHost() failed hop = %q, want %q", result.FailedHop, tt.failedHop)
+			}
+		})
+	}
+}
+
+func TestPingHost_ProxyJumpUnreachableTarget(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	keyPath, publicKey := writeTestKey(t)
+	jumpHost, jumpPort := splitTestAddress(t, testSSHServer(t, publicKey))
+
+	// Reserve a port and release it so nothing listens on it
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedHost, closedPort := splitTestAddress(t, listener.Addr().String())
+	listener.Close()
+
+	bastion := config.SSHHost{Name: "bastion", Hostname: jumpHost, Port: jumpPort, User: "test", Identity: keyPath}
+	target := config.SSHHost{Name: "target", Hostname: closedHost, Port: closedPort, ProxyJump: "bastion"}
+
+	pm := NewPingManager(2 * time.Second)
+	pm.SetHosts([]config.SSHHost{bastion, target})
+
+	result := pm.PingHost(context.Background(), target)
+	if result.Status != StatusOffline {
+		t.Fatalf("PingHost() status = %v, want %v", result.Status, StatusOffline)
+	}
+	if result.FailedHop != "bastion" {
+		t.Errorf("PingHost() failed hop = %q, want %q", result.FailedHop, "bastion")
+	}
+}
+
+func TestPingHost_ProxyJumpHostKey(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	keyPath, publicKey := writeTestKey(t)
+	jumpKey := testHostKey(t)
+	jumpHost, jumpPort := splitTestAddress(t, testSSHServer(t, publicKey, jumpKey))
+	targetHost, targetPort := splitTestAddress(t, testSSHServer(t, nil))
+
+	tests := []struct {
+		name      string
+		knownKey  ssh.PublicKey
+		want      ResultKind
+		failedHop string
+	}{
+		{"known key", jumpKey.PublicKey(), KindAuthRequired, ""},
+		{"changed key", testHostKey(t).PublicKey(), KindProxyFailed, "bastion"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			writeKnownHosts(t, home, knownHostsLine(jumpHost, jumpPort, tt.knownKey))
+
+			bastion := config.SSHHost{Name: "bastion", Hostname: jumpHost, Port: jumpPort, User: "test", Identity: keyPath}
+			target := config.SSHHost{Name: "target", Hostname: targetHost, Port: targetPort, ProxyJump: "bastion"}
+
+			pm := NewPingManager(2 * time.Second)
+			pm.SetHosts([]config.SSHHost{bastion, target})
+
+			result := pm.PingHost(context.Background(), target)
+			if result.Kind != tt.want {
+				t.Fatalf("PingHost() kind = %v, want %v (error: %v)", result.Kind, tt.want, result.Error)
+			}
+			if result.FailedHop != tt.failedHop {
+				t.Errorf("PingHost() failed hop = %q, want %q", result.FailedHop, tt.failedHop)
+			}
+		})
+	}
+}
+
+func TestPingHost_ProxyCommandFailure(t *testing.T) {
+	host := config.SSHHost{
+		Name:         "target",
+		Hostname:     "example.invalid",
+		ProxyCommand: "echo 'cannot reach %h' >&2; exit 1",
+	}
+
+	pm := NewPingManager(2 * time.Second)
+	result := pm.PingHost(context.Background(), host)
+
+	if result.Status != StatusOffline {
+		t.Fatalf("PingHost() status = %v, want %v", result.Status, StatusOffline)
+	}
+	if result.FailedHop != "ProxyCommand" {
+		t.Errorf("PingHost() failed hop = %q, want %q", result.FailedHop, "ProxyCommand")
+	}
+	if want := "via ProxyCommand: cannot reach example.invalid"; result.Error == nil || result.Error.Error() != want {
+		t.Errorf("PingHost() error = %v, want %q", result.Error, want)
+	}
+}
+
+func TestExpandProxyCommand(t *testing.T) {
+	host := config.SSHHost{Name: "web", User: "deploy"}
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"nc %h %p", "nc 10.0.0.5 2222"},
+		{"ssh -W %h:%p %r@gateway", "ssh -W 10.0.0.5:2222 deploy@gateway"},
+		{"connect %n 100%%", "connect web 100%"},
+	}
+
+	for _, tt := range tests {
+		if got := expandProxyCommand(tt.command, host, "10.0.0.5:2222"); got != tt.want {
+			t.Errorf("expandProxyCommand(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
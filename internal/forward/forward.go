@@ -0,0 +1,430 @@
+// Package forward runs port forwards inside sshm over an SSH connection,
+// counting the traffic and the connections going through each of them.
+package forward
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/history"
+)
+
+// keepAliveInterval is how often the server is asked whether the connection is still up
+var keepAliveInterval = 30 * time.Second
+
+// Client is the SSH connection the forwards go through, as provided by
+// *ssh.Client
+type Client interface {
+	Dial(network, address string) (net.Conn, error)
+	Listen(network, address string) (net.Listener, error)
+	ListenUnix(socketPath string) (net.Listener, error)
+	SendRequest(name string, wantReply bool, payload []byte) (bool, []byte, error)
+	Wait() error
+	Close() error
+}
+
+// Stats are the traffic and connection counters of a forward. BytesOut is
+// sent by the connecting side to the target, BytesIn is sent back by the target.
+type Stats struct {
+	BytesIn     int64
+	BytesOut    int64
+	Active      int64  // Connections currently open
+	Connections int64  // Connections accepted since the forward started
+	Errors      int64  // Connections that could not reach the target
+	LastError   string // Why the last connection could not reach the target
+}
+
+// forwarder listens on one end of a forward and connects every accepted
+// connection to the other end
+type forwarder struct {
+	config   history.PortForwardConfig
+	listener net.Listener
+	dial     func(conn net.Conn) (net.Conn, error) // Connects to the target of an accepted connection
+
+	bytesIn     atomic.Int64
+	bytesOut    atomic.Int64
+	active      atomic.Int64
+	connections atomic.Int64
+	errors      atomic.Int64
+
+	mutex     sync.Mutex
+	lastError string
+	conns     map[net.Conn]struct{} // Open connections, closed when the tunnel stops
+}
+
+// stats returns the counters of the forward
+func (f *forwarder) stats() Stats {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return Stats{
+		BytesIn:     f.bytesIn.Load(),
+		BytesOut:    f.bytesOut.Load(),
+		Active:      f.active.Load(),
+		Connections: f.connections.Load(),
+		Errors:      f.errors.Load(),
+		LastError:   f.lastError,
+	}
+}
+
+// fail records a connection that could not reach the target
+func (f *forwarder) fail(err error) {
+	f.errors.Add(1)
+	f.mutex.Lock()
+	f.lastError = err.Error()
+	f.mutex.Unlock()
+}
+
+// track adds or removes an open connection, reporting false when the
+// forward is already closed
+func (f *forwarder) track(conn net.Conn, open bool) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if !open {
+		delete(f.conns, conn)
+		return true
+	}
+	if f.conns == nil {
+		return false
+	}
+	f.conns[conn] = struct{}{}
+	return true
+}
+
+// serve accepts connections until the listener is closed
+func (f *forwarder) serve(wg *sync.WaitGroup) {
+	defer wg.Done()
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		f.connections.Add(1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.handle(conn)
+		}()
+	}
+}
+
+// handle connects an accepted connection to the target and copies the
+// traffic both ways until one side closes
+func (f *forwarder) handle(conn net.Conn) {
+	defer conn.Close()
+	if !f.track(conn, true) {
+		return
+	}
+	defer f.track(conn, false)
+
+	target, err := f.dial(conn)
+	if err != nil {
+		f.fail(err)
+		return
+	}
+	defer target.Close()
+	if !f.track(target, true) {
+		return
+	}
+	defer f.track(target, false)
+
+	f.active.Add(1)
+	defer f.active.Add(-1)
+
+	done := make(chan struct{})
+	go func() {
+		copyCounted(target, conn, &f.bytesOut)
+		closeWrite(target)
+		close(done)
+	}()
+	copyCounted(conn, target, &f.bytesIn)
+	closeWrite(conn)
+	<-done
+}
+
+// close stops accepting connections and closes the open ones
+func (f *forwarder) close() {
+	f.listener.Close()
+
+	f.mutex.Lock()
+	conns := f.conns
+	f.conns = nil
+	f.mutex.Unlock()
+
+	for conn := range conns {
+		conn.Close()
+	}
+}
+
+// copyCounted copies src to dst, adding the bytes copied to counter as they go
+func copyCounted(dst io.Writer, src io.Reader, counter *atomic.Int64) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, werr := dst.Write(buf[:n]); werr != nil {
+				return
+			}
+			counter.Add(int64(n))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// closeWrite tells the other side of a connection nothing more will be
+// sent, or closes the connection when it cannot be half-closed
+func closeWrite(conn net.Conn) {
+	if c, ok := conn.(interface{ CloseWrite() error }); ok {
+		c.CloseWrite()
+		return
+	}
+	conn.Close()
+}
+
+// Tunnel is a set of forwards running over an SSH connection
+type Tunnel struct {
+	ID        string
+	HostName  string
+	Profile   string
+	Forwards  []history.PortForwardConfig
+	StartedAt time.Time
+
+	client     Client
+	forwarders []*forwarder
+	wg         sync.WaitGroup
+	done       chan struct{}
+	closeOnce  sync.Once
+
+	mutex sync.Mutex
+	err   error // Why the tunnel stopped, nil if it was stopped on purpose
+}
+
+// Start sets up the forwards of a profile over client. If one of them
+// cannot listen, the others are closed and the error is returned; client
+// is left open. Otherwise the tunnel owns client and closes it when it stops.
+func Start(client Client, hostName string, profile history.PortForwardProfile) (*Tunnel, error) {
+	if len(profile.Forwards) == 0 {
+		return nil, fmt.Errorf("no forward to set up")
+	}
+
+	t := &Tunnel{
+		HostName:  hostName,
+		Profile:   profile.Name,
+		Forwards:  profile.Forwards,
+		StartedAt: time.Now(),
+		client:    client,
+		done:      make(chan struct{}),
+	}
+	for _, config := range profile.Forwards {
+		f, err := newForwarder(client, config)
+		if err != nil {
+			for _, started := range t.forwarders {
+				started.close()
+			}
+			return nil, fmt.Errorf("%s %s: %w", config.Flag(), config.Spec(), err)
+		}
+		t.forwarders = append(t.forwarders, f)
+	}
+
+	for _, f := range t.forwarders {
+		t.wg.Add(1)
+		go f.serve(&t.wg)
+	}
+	go t.watch()
+	return t, nil
+}
+
+// newForwarder listens on the listened end of a forward
+func newForwarder(client Client, config history.PortForwardConfig) (*forwarder, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	f := &forwarder{config: config, conns: make(map[net.Conn]struct{})}
+
+	var err error
+	switch config.Type {
+	case "remote":
+		// The server listens and connections come back to this machine
+		if config.ListenSocket() {
+			f.listener, err = client.ListenUnix(config.LocalPort)
+		} else {
+			f.listener, err = client.Listen("tcp", listenAddress(config))
+		}
+		f.dial = func(net.Conn) (net.Conn, error) {
+			network, address := targetAddress(config)
+			return net.DialTimeout(network, address, 10*time.Second)
+		}
+
+	case "dynamic":
+		f.listener, err = net.Listen("tcp", listenAddress(config))
+		f.dial = func(conn net.Conn) (net.Conn, error) {
+			return socksConnect(conn, client)
+		}
+
+	default:
+		if config.ListenSocket() {
+			f.listener, err = net.Listen("unix", config.LocalPort)
+		} else {
+			f.listener, err = net.Listen("tcp", listenAddress(config))
+		}
+		f.dial = func(net.Conn) (net.Conn, error) {
+			return client.Dial(targetAddress(config))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// listenAddress returns the address a forward listens on. As with ssh,
+// forwards without a bind address only accept connections from the machine
+// they listen on.
+func listenAddress(config history.PortForwardConfig) string {
+	switch host := history.UnbracketAddress(config.BindAddress); host {
+	case "", "localhost":
+		return net.JoinHostPort("127.0.0.1", config.LocalPort)
+	case "*":
+		return net.JoinHostPort("0.0.0.0", config.LocalPort)
+	default:
+		return net.JoinHostPort(host, config.LocalPort)
+	}
+}
+
+// targetAddress returns the network and address a forward connects to
+func targetAddress(config history.PortForwardConfig) (string, string) {
+	if config.TargetSocket() {
+		return "unix", config.RemotePort
+	}
+	host := config.RemoteHost
+	if host == "" {
+		host = "localhost"
+	}
+	return "tcp", net.JoinHostPort(history.UnbracketAddress(host), config.RemotePort)
+}
+
+// watch stops the tunnel when the SSH connection drops, asking the server
+// regularly whether it is still there so that a dead connection is noticed
+func (t *Tunnel) watch() {
+	lost := make(chan error, 1)
+	go func() {
+		lost <- t.client.Wait()
+	}()
+
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.done:
+			return
+		case err := <-lost:
+			t.stop(connectionLost(err))
+			return
+		case <-ticker.C:
+			if _, _, err := t.client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+				t.stop(connectionLost(err))
+				return
+			}
+		}
+	}
+}
+
+// connectionLost returns the error reported when the SSH connection dropped
+func connectionLost(err error) error {
+	if err == nil || errors.Is(err, io.EOF) {
+		return errors.New("connection lost")
+	}
+	return fmt.Errorf("connection lost: %w", err)
+}
+
+// stop closes the forwards and the SSH connection, recording why
+func (t *Tunnel) stop(reason error) {
+	t.closeOnce.Do(func() {
+		t.mutex.Lock()
+		t.err = reason
+		t.mutex.Unlock()
+
+		close(t.done)
+		for _, f := range t.forwarders {
+			f.close()
+		}
+		t.client.Close()
+	})
+}
+
+// Close stops the tunnel and waits for its connections to be closed
+func (t *Tunnel) Close() {
+	t.stop(nil)
+	t.wg.Wait()
+}
+
+// Done returns a channel closed once the tunnel has stopped
+func (t *Tunnel) Done() <-chan struct{} {
+	return t.done
+}
+
+// Err returns why the tunnel stopped on its own, or nil
+func (t *Tunnel) Err() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.err
+}
+
+// Running reports whether the tunnel is still running
+func (t *Tunnel) Running() bool {
+	select {
+	case <-t.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Stats returns the counters of each forward, in the order of Forwards
+func (t *Tunnel) Stats() []Stats {
+	stats := make([]Stats, len(t.forwarders))
+	for i, f := range t.forwarders {
+		stats[i] = f.stats()
+	}
+	return stats
+}
+
+// Total returns the counters of all the forwards added up. LastError is the
+// last error of the first forward having one.
+func (t *Tunnel) Total() Stats {
+	var total Stats
+	for _, s := range t.Stats() {
+		total.BytesIn += s.BytesIn
+		total.BytesOut += s.BytesOut
+		total.Active += s.Active
+		total.Connections += s.Connections
+		total.Errors += s.Errors
+		if total.LastError == "" {
+			total.LastError = s.LastError
+		}
+	}
+	return total
+}
+
+// FormatBytes formats a byte count compactly, e.g. "512B", "1.5K" or "3.2M"
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	value, suffix := float64(n)/unit, 0
+	for value >= unit && suffix < 4 {
+		value /= unit
+		suffix++
+	}
+	return fmt.Sprintf("%.1f%c", value, "KMGTP"[suffix])
+}
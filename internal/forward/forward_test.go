@@ -0,0 +1,293 @@
+package forward
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/history"
+)
+
+// localClient stands for an SSH connection whose server is this machine
+type localClient struct {
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newLocalClient() *localClient {
+	return &localClient{closed: make(chan struct{})}
+}
+
+func (c *localClient) Dial(network, address string) (net.Conn, error) {
+	return net.Dial(network, address)
+}
+
+func (c *localClient) Listen(network, address string) (net.Listener, error) {
+	return net.Listen(network, address)
+}
+
+func (c *localClient) ListenUnix(socketPath string) (net.Listener, error) {
+	return net.Listen("unix", socketPath)
+}
+
+func (c *localClient) SendRequest(name string, wantReply bool, payload []byte) (bool, []byte, error) {
+	return true, nil, nil
+}
+
+func (c *localClient) Wait() error {
+	<-c.closed
+	return nil
+}
+
+func (c *localClient) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+// echoServer starts a server sending back what it receives and returns its port
+func echoServer(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+}
+
+// freePort returns a port nothing listens on
+func freePort(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+}
+
+// exchange sends message on conn and reads it back
+func exchange(t *testing.T, conn net.Conn, message string) {
+	t.Helper()
+	if _, err := conn.Write([]byte(message)); err != nil {
+		t.Fatal(err)
+	}
+	reply := make([]byte, len(message))
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		t.Fatal(err)
+	}
+	if string(reply) != message {
+		t.Errorf("reply = %q, want %q", reply, message)
+	}
+}
+
+// waitFor polls condition until it holds or a few seconds have passed
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestForwards(t *testing.T) {
+	for _, forwardType := range []string{"local", "remote"} {
+		t.Run(forwardType, func(t *testing.T) {
+			port := freePort(t)
+			config := history.PortForwardConfig{Type: forwardType, LocalPort: port, RemoteHost: "127.0.0.1", RemotePort: echoServer(t)}
+			tunnel, err := Start(newLocalClient(), "db1", history.PortForwardProfile{Forwards: []history.PortForwardConfig{config}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tunnel.Close()
+
+			conn, err := net.Dial("tcp", "127.0.0.1:"+port)
+			if err != nil {
+				t.Fatal(err)
+			}
+			exchange(t, conn, "hello")
+			if stats := tunnel.Stats()[0]; stats.Active != 1 || stats.Connections != 1 {
+				t.Errorf("Expected one active connection, got %+v", stats)
+			}
+			conn.Close()
+
+			waitFor(t, "the connection to be closed", func() bool { return tunnel.Stats()[0].Active == 0 })
+			if stats := tunnel.Stats()[0]; stats.BytesOut != 5 || stats.BytesIn != 5 || stats.Errors != 0 {
+				t.Errorf("Unexpected stats %+v", stats)
+			}
+		})
+	}
+}
+
+func TestForwardConnectError(t *testing.T) {
+	port := freePort(t)
+	config := history.PortForwardConfig{Type: "local", LocalPort: port, RemoteHost: "127.0.0.1", RemotePort: freePort(t)}
+	tunnel, err := Start(newLocalClient(), "db1", history.PortForwardProfile{Forwards: []history.PortForwardConfig{config}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tunnel.Close()
+
+	conn, err := net.Dial("tcp", "127.0.0.1:"+port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	waitFor(t, "the error to be recorded", func() bool { return tunnel.Stats()[0].Errors == 1 })
+	if stats := tunnel.Total(); !strings.Contains(stats.LastError, "refused") || stats.Active != 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestDynamicForward(t *testing.T) {
+	port := freePort(t)
+	target, _ := strconv.Atoi(echoServer(t))
+	config := history.PortForwardConfig{Type: "dynamic", LocalPort: port}
+	tunnel, err := Start(newLocalClient(), "db1", history.PortForwardProfile{Forwards: []history.PortForwardConfig{config}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tunnel.Close()
+
+	conn, err := net.Dial("tcp", "127.0.0.1:"+port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	// Greeting without authentication
+	conn.Write([]byte{5, 1, 0})
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil || !bytes.Equal(reply, []byte{5, 0}) {
+		t.Fatalf("Unexpected greeting reply %v (%v)", reply, err)
+	}
+
+	// CONNECT to the echo server by name
+	request := []byte{5, 1, 0, 3, 9}
+	request = append(request, "localhost"...)
+	request = append(request, byte(target>>8), byte(target))
+	conn.Write(request)
+	reply = make([]byte, 10)
+	if _, err := io.ReadFull(conn, reply); err != nil || reply[1] != 0 {
+		t.Fatalf("Unexpected connect reply %v (%v)", reply, err)
+	}
+
+	exchange(t, conn, "ping")
+	if stats := tunnel.Stats()[0]; stats.BytesOut != 4 || stats.BytesIn != 4 {
+		t.Errorf("Expected the SOCKS handshake not to be counted, got %+v", stats)
+	}
+}
+
+func TestStartListenError(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	busyPort := strconv.Itoa(busy.Addr().(*net.TCPAddr).Port)
+
+	free := freePort(t)
+	profile := history.PortForwardProfile{Forwards: []history.PortForwardConfig{
+		{Type: "dynamic", LocalPort: free},
+		{Type: "dynamic", LocalPort: busyPort},
+	}}
+	if _, err := Start(newLocalClient(), "db1", profile); err == nil || !strings.Contains(err.Error(), "-D "+busyPort) {
+		t.Fatalf("Expected the busy port to be reported, got %v", err)
+	}
+
+	// The forwards already set up were closed
+	listener, err := net.Listen("tcp", "127.0.0.1:"+free)
+	if err != nil {
+		t.Fatalf("Expected port %s to be released: %v", free, err)
+	}
+	listener.Close()
+}
+
+func TestTunnelConnectionLost(t *testing.T) {
+	client := newLocalClient()
+	tunnel, err := Start(client, "db1", history.PortForwardProfile{Forwards: []history.PortForwardConfig{{Type: "dynamic", LocalPort: freePort(t)}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.Close()
+	select {
+	case <-tunnel.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the tunnel to stop when the connection drops")
+	}
+	if tunnel.Running() || tunnel.Err() == nil || tunnel.Err().Error() != "connection lost" {
+		t.Errorf("Expected the tunnel to report the lost connection, got %v", tunnel.Err())
+	}
+}
+
+func TestManager(t *testing.T) {
+	m := NewManager()
+	profile := history.PortForwardProfile{Name: "web", Forwards: []history.PortForwardConfig{{Type: "dynamic", LocalPort: freePort(t)}}}
+	first, err := Start(newLocalClient(), "db1", profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile.Forwards[0].LocalPort = freePort(t)
+	second, err := Start(newLocalClient(), "db1", profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Add(first)
+	m.Add(second)
+
+	if first.ID != "db1-web" || second.ID != "db1-web-2" || len(m.List()) != 2 {
+		t.Fatalf("Unexpected tunnels %s, %s", first.ID, second.ID)
+	}
+	if err := m.Stop("db1-web"); err != nil || first.Running() {
+		t.Errorf("Expected the tunnel to be stopped (%v)", err)
+	}
+	if err := m.Stop("db1-web"); err == nil {
+		t.Error("Expected an error stopping an unknown tunnel")
+	}
+	m.StopAll()
+	if len(m.List()) != 0 || second.Running() {
+		t.Error("Expected every tunnel to be stopped")
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{0, "0B"},
+		{512, "512B"},
+		{1536, "1.5K"},
+		{5 * 1024 * 1024, "5.0M"},
+		{3 * 1024 * 1024 * 1024, "3.0G"},
+	}
+	for _, tt := range tests {
+		if got := FormatBytes(tt.bytes); got != tt.want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.bytes, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package forward
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Manager keeps the tunnels running inside sshm. Tunnels whose connection
+// dropped stay listed, with the reason, until they are stopped.
+type Manager struct {
+	mutex   sync.Mutex
+	tunnels map[string]*Tunnel
+}
+
+// NewManager creates a manager without tunnels
+func NewManager() *Manager {
+	return &Manager{tunnels: make(map[string]*Tunnel)}
+}
+
+// Add gives a started tunnel an ID no other tunnel uses and keeps it
+func (m *Manager) Add(t *Tunnel) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	base := t.HostName
+	if t.Profile != "" {
+		base += "-" + t.Profile
+	}
+	base = strings.ReplaceAll(base, " ", "_")
+
+	id := base
+	for n := 2; m.tunnels[id] != nil; n++ {
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+	t.ID = id
+	m.tunnels[id] = t
+}
+
+// List returns the tunnels sorted by ID
+func (m *Manager) List() []*Tunnel {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	tunnels := make([]*Tunnel, 0, len(m.tunnels))
+	for _, t := range m.tunnels {
+		tunnels = append(tunnels, t)
+	}
+	sort.Slice(tunnels, func(i, j int) bool {
+		return tunnels[i].ID < tunnels[j].ID
+	})
+	return tunnels
+}
+
+// Stop stops a tunnel and forgets it
+func (m *Manager) Stop(id string) error {
+	m.mutex.Lock()
+	t, ok := m.tunnels[id]
+	delete(m.tunnels, id)
+	m.mutex.Unlock()
+
+	if !ok {
+		return fmt.Errorf("no tunnel %s", id)
+	}
+	t.Close()
+	return nil
+}
+
+// StopAll stops every tunnel
+func (m *Manager) StopAll() {
+	for _, t := range m.List() {
+		m.Stop(t.ID)
+	}
+}
@@ -0,0 +1,132 @@
+package forward
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// SOCKS5 protocol values (RFC 1928)
+const (
+	socksVersion         = 0x05
+	socksNoAuth          = 0x00
+	socksNoAcceptable    = 0xff
+	socksCmdConnect      = 0x01
+	socksAddrIPv4        = 0x01
+	socksAddrDomain      = 0x03
+	socksAddrIPv6        = 0x04
+	socksSucceeded       = 0x00
+	socksGeneralFailure  = 0x01
+	socksNotSupported    = 0x07
+	socksAddrUnsupported = 0x08
+)
+
+// socksHandshakeTimeout is how long a client has to send its request
+const socksHandshakeTimeout = 10 * time.Second
+
+// socksConnect reads the SOCKS5 request of a client, connects to the
+// requested destination through the SSH connection and answers the client.
+// Only the CONNECT command without authentication is supported, as with ssh -D.
+func socksConnect(conn net.Conn, client Client) (net.Conn, error) {
+	conn.SetDeadline(time.Now().Add(socksHandshakeTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	address, err := readSocksRequest(conn)
+	if err != nil {
+		return nil, fmt.Errorf("socks: %w", err)
+	}
+
+	target, err := client.Dial("tcp", address)
+	if err != nil {
+		writeSocksReply(conn, socksGeneralFailure)
+		return nil, err
+	}
+	if err := writeSocksReply(conn, socksSucceeded); err != nil {
+		target.Close()
+		return nil, fmt.Errorf("socks: %w", err)
+	}
+	return target, nil
+}
+
+// readSocksRequest negotiates the authentication method and returns the
+// destination of the CONNECT request of a client
+func readSocksRequest(conn net.Conn) (string, error) {
+	// Greeting: version, number of methods, methods
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", err
+	}
+	if header[0] != socksVersion {
+		return "", fmt.Errorf("unsupported version %d", header[0])
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return "", err
+	}
+	method := byte(socksNoAcceptable)
+	for _, m := range methods {
+		if m == socksNoAuth {
+			method = socksNoAuth
+		}
+	}
+	if _, err := conn.Write([]byte{socksVersion, method}); err != nil {
+		return "", err
+	}
+	if method == socksNoAcceptable {
+		return "", errors.New("the client requires authentication")
+	}
+
+	// Request: version, command, reserved, address type, address, port
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return "", err
+	}
+	if request[1] != socksCmdConnect {
+		writeSocksReply(conn, socksNotSupported)
+		return "", fmt.Errorf("unsupported command %d", request[1])
+	}
+
+	var host string
+	switch request[3] {
+	case socksAddrIPv4, socksAddrIPv6:
+		size := net.IPv4len
+		if request[3] == socksAddrIPv6 {
+			size = net.IPv6len
+		}
+		ip := make([]byte, size)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return "", err
+		}
+		host = net.IP(ip).String()
+	case socksAddrDomain:
+		size := make([]byte, 1)
+		if _, err := io.ReadFull(conn, size); err != nil {
+			return "", err
+		}
+		domain := make([]byte, size[0])
+		if _, err := io.ReadFull(conn, domain); err != nil {
+			return "", err
+		}
+		host = string(domain)
+	default:
+		writeSocksReply(conn, socksAddrUnsupported)
+		return "", fmt.Errorf("unsupported address type %d", request[3])
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+// writeSocksReply answers a request. The bound address is not known on
+// this side of the SSH connection, so it is reported as 0.0.0.0:0.
+func writeSocksReply(conn net.Conn, status byte) error {
+	_, err := conn.Write([]byte{socksVersion, status, 0, socksAddrIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
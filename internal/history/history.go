@@ -1,10 +1,10 @@
 package history
 
 import (
-	"encoding/json"
 	"os"
 	"path/filepath"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/Gu1llaum-3/sshm/internal/config"
@@ -29,17 +29,30 @@ type ConnectionInfo struct {
 	HostName       string             `json:"host_name"`
 	LastConnect    time.Time          `json:"last_connect"`
 	ConnectCount   int                `json:"connect_count"`
-	PortForwarding *PortForwardConfig `json:"port_forwarding,omitempty"`
+	PortForwarding *PortForwardConfig `json:"port_forwarding,omitempty"` // Last forward set up from the TUI
+	Commands       []string           `json:"commands,omitempty"`        // Commands run from the TUI, most recent first
+
+	PortForwardProfiles []PortForwardProfile `json:"port_forward_profiles,omitempty"`
 }
 
-// HistoryManager manages the connection history
+// maxRecentCommands is the number of commands remembered per host
+const maxRecentCommands = 20
+
+// HistoryManager manages the connection history of the hosts of one SSH
+// config file. It is safe for concurrent use: the TUI records connections
+// from background commands while the host list reads them.
 type HistoryManager struct {
 	historyPath string
-	history     *ConnectionHistory
+	configFile  string // Canonical path of the SSH config file
+
+	mutex   sync.RWMutex       // Guards history and file
+	history *ConnectionHistory // Connections of the hosts of configFile
+	file    *historyFile       // Whole history file, with the other config files
 }
 
-// NewHistoryManager creates a new history manager
-func NewHistoryManager() (*HistoryManager, error) {
+// NewHistoryManager creates a new history manager for the hosts of
+// configFile, or of the default SSH config when it is empty
+func NewHistoryManager(configFile string) (*HistoryManager, error) {
 	configDir, err := config.GetSSHMConfigDir()
 	if err != nil {
 		return nil, err
@@ -60,16 +73,13 @@ func NewHistoryManager() (*HistoryManager, error) {
 
 	hm := &HistoryManager{
 		historyPath: historyPath,
+		configFile:  CanonicalConfigFile(configFile),
 		history:     &ConnectionHistory{Connections: make(map[string]ConnectionInfo)},
 	}
 
-	// Load existing history if it exists
-	err = hm.loadHistory()
-	if err != nil {
-		// If file doesn't exist, that's okay - we'll create it when needed
-		if !os.IsNotExist(err) {
-			return nil, err
-		}
+	// Load existing history if it exists, it is created when needed
+	if err := hm.Reload(); err != nil {
+		return nil, err
 	}
 
 	return hm, nil
@@ -115,56 +125,74 @@ func migrateOldHistoryFile(newHistoryPath string) error {
 	return nil
 }
 
-// loadHistory loads the connection history from the JSON file
-func (hm *HistoryManager) loadHistory() error {
-	data, err := os.ReadFile(hm.historyPath)
-	if err != nil {
-		return err
+// CanonicalConfigFile returns the path what sshm records about an SSH config
+// file is kept under: its absolute path with symbolic links resolved, the
+// default SSH config when it is empty
+func CanonicalConfigFile(configFile string) string {
+	if configFile == "" {
+		path, err := config.GetDefaultSSHConfigPath()
+		if err != nil {
+			return ""
+		}
+		configFile = path
 	}
-
-	return json.Unmarshal(data, hm.history)
-}
-
-// saveHistory saves the connection history to the JSON file
-func (hm *HistoryManager) saveHistory() error {
-	// Ensure the directory exists
-	dir := filepath.Dir(hm.historyPath)
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return err
+	if abs, err := filepath.Abs(configFile); err == nil {
+		configFile = abs
 	}
-
-	data, err := json.MarshalIndent(hm.history, "", "  ")
-	if err != nil {
-		return err
+	if resolved, err := filepath.EvalSymlinks(configFile); err == nil {
+		configFile = resolved
 	}
-
-	return os.WriteFile(hm.historyPath, data, 0600)
+	return configFile
 }
 
 // RecordConnection records a new connection for the specified host
 func (hm *HistoryManager) RecordConnection(hostName string) error {
-	now := time.Now()
-
-	if conn, exists := hm.history.Connections[hostName]; exists {
-		// Update existing connection
-		conn.LastConnect = now
-		conn.ConnectCount++
-		hm.history.Connections[hostName] = conn
-	} else {
-		// Create new connection record
-		hm.history.Connections[hostName] = ConnectionInfo{
-			HostName:     hostName,
-			LastConnect:  now,
-			ConnectCount: 1,
+	return hm.update(func() error {
+		now := time.Now()
+
+		if conn, exists := hm.history.Connections[hostName]; exists {
+			// Update existing connection
+			conn.LastConnect = now
+			conn.ConnectCount++
+			hm.history.Connections[hostName] = conn
+		} else {
+			// Create new connection record
+			hm.history.Connections[hostName] = ConnectionInfo{
+				HostName:     hostName,
+				LastConnect:  now,
+				ConnectCount: 1,
+			}
 		}
-	}
 
-	return hm.saveHistory()
+		return nil
+	})
+}
+
+// connection returns the connection information of a host
+func (hm *HistoryManager) connection(hostName string) (ConnectionInfo, bool) {
+	hm.mutex.RLock()
+	defer hm.mutex.RUnlock()
+
+	conn, exists := hm.history.Connections[hostName]
+	return conn, exists
+}
+
+// connections returns a copy of the connection information of every host,
+// which the history can be changed under while it is used
+func (hm *HistoryManager) connections() map[string]ConnectionInfo {
+	hm.mutex.RLock()
+	defer hm.mutex.RUnlock()
+
+	connections := make(map[string]ConnectionInfo, len(hm.history.Connections))
+	for hostName, conn := range hm.history.Connections {
+		connections[hostName] = conn
+	}
+	return connections
 }
 
 // GetLastConnectionTime returns the last connection time for a host
 func (hm *HistoryManager) GetLastConnectionTime(hostName string) (time.Time, bool) {
-	if conn, exists := hm.history.Connections[hostName]; exists {
+	if conn, exists := hm.connection(hostName); exists {
 		return conn.LastConnect, true
 	}
 	return time.Time{}, false
@@ -172,7 +200,7 @@ func (hm *HistoryManager) GetLastConnectionTime(hostName string) (time.Time, boo
 
 // GetConnectionCount returns the total number of connections for a host
 func (hm *HistoryManager) GetConnectionCount(hostName string) int {
-	if conn, exists := hm.history.Connections[hostName]; exists {
+	if conn, exists := hm.connection(hostName); exists {
 		return conn.ConnectCount
 	}
 	return 0
@@ -182,10 +210,12 @@ func (hm *HistoryManager) GetConnectionCount(hostName string) int {
 func (hm *HistoryManager) SortHostsByLastUsed(hosts []config.SSHHost) []config.SSHHost {
 	sorted := make([]config.SSHHost, len(hosts))
 	copy(sorted, hosts)
+	connections := hm.connections()
 
 	sort.Slice(sorted, func(i, j int) bool {
-		timeI, existsI := hm.GetLastConnectionTime(sorted[i].Name)
-		timeJ, existsJ := hm.GetLastConnectionTime(sorted[j].Name)
+		connI, existsI := connections[sorted[i].Name]
+		connJ, existsJ := connections[sorted[j].Name]
+		timeI, timeJ := connI.LastConnect, connJ.LastConnect
 
 		// If both have history, sort by most recent first
 		if existsI && existsJ {
@@ -211,22 +241,20 @@ func (hm *HistoryManager) SortHostsByLastUsed(hosts []config.SSHHost) []config.S
 func (hm *HistoryManager) SortHostsByMostUsed(hosts []config.SSHHost) []config.SSHHost {
 	sorted := make([]config.SSHHost, len(hosts))
 	copy(sorted, hosts)
+	connections := hm.connections()
 
 	sort.Slice(sorted, func(i, j int) bool {
-		countI := hm.GetConnectionCount(sorted[i].Name)
-		countJ := hm.GetConnectionCount(sorted[j].Name)
+		connI, existsI := connections[sorted[i].Name]
+		connJ, existsJ := connections[sorted[j].Name]
 
 		// If counts are different, sort by count (highest first)
-		if countI != countJ {
-			return countI > countJ
+		if connI.ConnectCount != connJ.ConnectCount {
+			return connI.ConnectCount > connJ.ConnectCount
 		}
 
 		// If counts are equal, sort by most recent
-		timeI, existsI := hm.GetLastConnectionTime(sorted[i].Name)
-		timeJ, existsJ := hm.GetLastConnectionTime(sorted[j].Name)
-
 		if existsI && existsJ {
-			return timeI.After(timeJ)
+			return connI.LastConnect.After(connJ.LastConnect)
 		}
 
 		// If neither has history, sort alphabetically
@@ -238,26 +266,28 @@ func (hm *HistoryManager) SortHostsByMostUsed(hosts []config.SSHHost) []config.S
 
 // CleanupOldEntries removes connection history for hosts that no longer exist
 func (hm *HistoryManager) CleanupOldEntries(currentHosts []config.SSHHost) error {
-	// Create a set of current host names
-	currentHostNames := make(map[string]bool)
-	for _, host := range currentHosts {
-		currentHostNames[host.Name] = true
-	}
+	return hm.update(func() error {
+		// Create a set of current host names
+		currentHostNames := make(map[string]bool)
+		for _, host := range currentHosts {
+			currentHostNames[host.Name] = true
+		}
 
-	// Remove entries for hosts that no longer exist
-	for hostName := range hm.history.Connections {
-		if !currentHostNames[hostName] {
-			delete(hm.history.Connections, hostName)
+		// Remove entries for hosts that no longer exist
+		for hostName := range hm.history.Connections {
+			if !currentHostNames[hostName] {
+				delete(hm.history.Connections, hostName)
+			}
 		}
-	}
 
-	return hm.saveHistory()
+		return nil
+	})
 }
 
 // GetAllConnectionsInfo returns all connection information sorted by last connection time
 func (hm *HistoryManager) GetAllConnectionsInfo() []ConnectionInfo {
 	var connections []ConnectionInfo
-	for _, conn := range hm.history.Connections {
+	for _, conn := range hm.connections() {
 		connections = append(connections, conn)
 	}
 
@@ -270,39 +300,73 @@ func (hm *HistoryManager) GetAllConnectionsInfo() []ConnectionInfo {
 
 // RecordPortForwarding saves port forwarding configuration for a host
 func (hm *HistoryManager) RecordPortForwarding(hostName, forwardType, localPort, remoteHost, remotePort, bindAddress string) error {
-	now := time.Now()
-
-	portForwardConfig := &PortForwardConfig{
-		Type:        forwardType,
-		LocalPort:   localPort,
-		RemoteHost:  remoteHost,
-		RemotePort:  remotePort,
-		BindAddress: bindAddress,
-	}
+	return hm.update(func() error {
+		now := time.Now()
+
+		portForwardConfig := &PortForwardConfig{
+			Type:        forwardType,
+			LocalPort:   localPort,
+			RemoteHost:  remoteHost,
+			RemotePort:  remotePort,
+			BindAddress: bindAddress,
+		}
 
-	if conn, exists := hm.history.Connections[hostName]; exists {
-		// Update existing connection
-		conn.LastConnect = now
-		conn.ConnectCount++
-		conn.PortForwarding = portForwardConfig
-		hm.history.Connections[hostName] = conn
-	} else {
-		// Create new connection record
-		hm.history.Connections[hostName] = ConnectionInfo{
-			HostName:       hostName,
-			LastConnect:    now,
-			ConnectCount:   1,
-			PortForwarding: portForwardConfig,
+		if conn, exists := hm.history.Connections[hostName]; exists {
+			// Update existing connection
+			conn.LastConnect = now
+			conn.ConnectCount++
+			conn.PortForwarding = portForwardConfig
+			hm.history.Connections[hostName] = conn
+		} else {
+			// Create new connection record
+			hm.history.Connections[hostName] = ConnectionInfo{
+				HostName:       hostName,
+				LastConnect:    now,
+				ConnectCount:   1,
+				PortForwarding: portForwardConfig,
+			}
 		}
-	}
 
-	return hm.saveHistory()
+		return nil
+	})
 }
 
 // GetPortForwardingConfig retrieves the last used port forwarding configuration for a host
 func (hm *HistoryManager) GetPortForwardingConfig(hostName string) *PortForwardConfig {
-	if conn, exists := hm.history.Connections[hostName]; exists {
+	if conn, exists := hm.connection(hostName); exists {
 		return conn.PortForwarding
 	}
 	return nil
 }
+
+// RecordCommand records a command run on a host, moving it to the front of
+// the recent commands of the host if it was already there
+func (hm *HistoryManager) RecordCommand(hostName, command string) error {
+	return hm.update(func() error {
+		conn, exists := hm.history.Connections[hostName]
+		if !exists {
+			conn = ConnectionInfo{HostName: hostName}
+		}
+		conn.LastConnect = time.Now()
+		conn.ConnectCount++
+
+		commands := []string{command}
+		for _, previous := range conn.Commands {
+			if previous != command && len(commands) < maxRecentCommands {
+				commands = append(commands, previous)
+			}
+		}
+		conn.Commands = commands
+		hm.history.Connections[hostName] = conn
+
+		return nil
+	})
+}
+
+// GetRecentCommands returns the commands recently run on a host, most recent first
+func (hm *HistoryManager) GetRecentCommands(hostName string) []string {
+	if conn, exists := hm.connection(hostName); exists {
+		return conn.Commands
+	}
+	return nil
+}
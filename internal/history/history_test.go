@@ -1,6 +1,8 @@
 package history
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -22,7 +24,7 @@ func createTestHistoryManager(t *testing.T) *HistoryManager {
 }
 
 func TestNewHistoryManager(t *testing.T) {
-	hm, err := NewHistoryManager()
+	hm, err := NewHistoryManager("")
 	if err != nil {
 		t.Fatalf("NewHistoryManager() error = %v", err)
 	}
@@ -96,6 +98,38 @@ func TestHistoryManager_GetConnectionCount(t *testing.T) {
 	}
 }
 
+func TestHistoryManager_Reload(t *testing.T) {
+	hm := createTestHistoryManager(t)
+
+	// Another manager sharing the same file records a connection
+	other := &HistoryManager{
+		historyPath: hm.historyPath,
+		history:     &ConnectionHistory{Connections: make(map[string]ConnectionInfo)},
+	}
+	if err := other.RecordConnection("testhost-reload"); err != nil {
+		t.Fatalf("RecordConnection() error = %v", err)
+	}
+
+	if hm.GetConnectionCount("testhost-reload") != 0 {
+		t.Fatal("Expected the connection to be unknown before reloading")
+	}
+	if err := hm.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if hm.GetConnectionCount("testhost-reload") != 1 {
+		t.Error("Expected the connection to be known after reloading")
+	}
+
+	// A missing file resets the history
+	os.Remove(hm.historyPath)
+	if err := hm.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if hm.GetConnectionCount("testhost-reload") != 0 {
+		t.Error("Expected an empty history after the file was removed")
+	}
+}
+
 func TestMigrateOldHistoryFile(t *testing.T) {
 	// This test verifies that migration doesn't fail when called
 	// The actual migration logic will be tested in integration tests
@@ -162,3 +196,131 @@ func TestMigrateOldHistoryFile_NewFileExists(t *testing.T) {
 		t.Error("New file was modified when it shouldn't have been")
 	}
 }
+
+func TestRecordCommand(t *testing.T) {
+	hm := createTestHistoryManager(t)
+
+	for _, command := range []string{"uptime", "df -h", "uptime"} {
+		if err := hm.RecordCommand("web", command); err != nil {
+			t.Fatalf("RecordCommand() error = %v", err)
+		}
+	}
+
+	// The last command comes first, without duplicates
+	commands := hm.GetRecentCommands("web")
+	if len(commands) != 2 || commands[0] != "uptime" || commands[1] != "df -h" {
+		t.Errorf("GetRecentCommands() = %v, want [uptime df -h]", commands)
+	}
+	if count := hm.GetConnectionCount("web"); count != 3 {
+		t.Errorf("GetConnectionCount() = %d, want 3", count)
+	}
+	if commands := hm.GetRecentCommands("db"); commands != nil {
+		t.Errorf("GetRecentCommands() of a host without commands = %v, want nil", commands)
+	}
+
+	// Only the most recent commands are kept
+	for i := 0; i < maxRecentCommands+5; i++ {
+		hm.RecordCommand("db", fmt.Sprintf("echo %d", i))
+	}
+	if commands := hm.GetRecentCommands("db"); len(commands) != maxRecentCommands {
+		t.Errorf("Expected %d commands to be kept, got %d", maxRecentCommands, len(commands))
+	}
+}
+
+func TestHistoryKeyedByConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	historyPath := filepath.Join(dir, "sshm_history.json")
+	work := &HistoryManager{historyPath: historyPath, configFile: "/home/user/.ssh/work_config"}
+	work.setFile(&historyFile{})
+	personal := &HistoryManager{historyPath: historyPath, configFile: "/home/user/.ssh/personal_config"}
+	personal.setFile(&historyFile{})
+
+	if err := work.RecordConnection("dev"); err != nil {
+		t.Fatal(err)
+	}
+	if err := personal.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if err := personal.RecordConnection("dev"); err != nil {
+		t.Fatal(err)
+	}
+	if err := personal.RecordConnection("dev"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := work.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := work.GetConnectionCount("dev"); got != 1 {
+		t.Errorf("Expected 1 connection to dev of the work config, got %d", got)
+	}
+	if got := personal.GetConnectionCount("dev"); got != 2 {
+		t.Errorf("Expected 2 connections to dev of the personal config, got %d", got)
+	}
+}
+
+func TestHistoryMigratedToDefaultConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	historyPath := filepath.Join(t.TempDir(), "sshm_history.json")
+	legacy := `{"connections":{"dev":{"host_name":"dev","last_connect":"2024-01-02T15:04:05Z","connect_count":7}}}`
+	if err := os.WriteFile(historyPath, []byte(legacy), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	hm := &HistoryManager{historyPath: historyPath, configFile: CanonicalConfigFile("")}
+	if err := hm.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := hm.GetConnectionCount("dev"); got != 7 {
+		t.Errorf("Expected the connections to be migrated to the default config, got %d", got)
+	}
+
+	other := &HistoryManager{historyPath: historyPath, configFile: filepath.Join(home, "other_config")}
+	if err := other.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := other.GetConnectionCount("dev"); got != 0 {
+		t.Errorf("Expected no connection for another config, got %d", got)
+	}
+
+	// The migrated history is written in the new format
+	if err := hm.RecordConnection("dev"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file historyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	if file.Connections != nil || file.ConfigFiles[hm.configFile]["dev"].ConnectCount != 8 {
+		t.Errorf("Unexpected history file %s", data)
+	}
+}
+
+func TestCanonicalConfigFile(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(dir, "config")
+	if err := os.WriteFile(target, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("Symbolic links are not supported: %v", err)
+	}
+
+	if got := CanonicalConfigFile(link); got != target {
+		t.Errorf("CanonicalConfigFile(%q) = %q, want %q", link, got, target)
+	}
+	if got := CanonicalConfigFile(""); !filepath.IsAbs(got) {
+		t.Errorf("CanonicalConfigFile(\"\") = %q, want the absolute path of the default config", got)
+	}
+}
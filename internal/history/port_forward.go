@@ -0,0 +1,392 @@
+package history
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/ports"
+	"github.com/Gu1llaum-3/sshm/internal/validation"
+)
+
+// PortForwardProfile is a named set of port forwards saved for a host
+type PortForwardProfile struct {
+	Name     string              `json:"name"`
+	Forwards []PortForwardConfig `json:"forwards"`
+	LastUsed time.Time           `json:"last_used,omitempty"`
+}
+
+// Flag returns the ssh flag setting up the forward
+func (c PortForwardConfig) Flag() string {
+	switch c.Type {
+	case "remote":
+		return "-R"
+	case "dynamic":
+		return "-D"
+	default:
+		return "-L"
+	}
+}
+
+// ListenSocket reports whether the forward listens on a Unix socket
+// rather than a port
+func (c PortForwardConfig) ListenSocket() bool {
+	return ports.IsSocketPath(c.LocalPort)
+}
+
+// TargetSocket reports whether the forward connects to a Unix socket
+// rather than to a host and port
+func (c PortForwardConfig) TargetSocket() bool {
+	return c.Type != "dynamic" && ports.IsSocketPath(c.RemotePort)
+}
+
+// Spec returns the argument of the ssh flag, e.g. 127.0.0.1:8080:localhost:80
+// or /tmp/docker.sock:/var/run/docker.sock
+func (c PortForwardConfig) Spec() string {
+	if target := c.target(); target != "" {
+		return c.listen() + ":" + target
+	}
+	return c.listen()
+}
+
+// Directive returns the forward as an ssh_config directive, e.g.
+// "LocalForward 8080 localhost:80"
+func (c PortForwardConfig) Directive() string {
+	keyword := "LocalForward"
+	switch c.Type {
+	case "remote":
+		keyword = "RemoteForward"
+	case "dynamic":
+		keyword = "DynamicForward"
+	}
+	if target := c.target(); target != "" {
+		return keyword + " " + c.listen() + " " + target
+	}
+	return keyword + " " + c.listen()
+}
+
+// listen returns the end of the forward that is listened on
+func (c PortForwardConfig) listen() string {
+	if c.BindAddress != "" && !c.ListenSocket() {
+		return bracketAddress(c.BindAddress) + ":" + c.LocalPort
+	}
+	return c.LocalPort
+}
+
+// target returns the end of the forward connected to, empty for dynamic forwards
+func (c PortForwardConfig) target() string {
+	switch {
+	case c.Type == "dynamic":
+		return ""
+	case c.TargetSocket():
+		return c.RemotePort
+	}
+	remoteHost := c.RemoteHost
+	if remoteHost == "" {
+		remoteHost = "localhost"
+	}
+	return bracketAddress(remoteHost) + ":" + c.RemotePort
+}
+
+// ParseForward parses a forward written as on the ssh command line
+// ("-L 8080:localhost:80") or as an ssh_config directive
+// ("LocalForward 8080 localhost:80"), and validates it
+func ParseForward(text string) (PortForwardConfig, error) {
+	fields := strings.Fields(text)
+	if len(fields) < 2 || len(fields) > 3 {
+		return PortForwardConfig{}, fmt.Errorf("invalid forward %q (e.g. -L 8080:localhost:80)", text)
+	}
+
+	var config PortForwardConfig
+	switch strings.ToLower(fields[0]) {
+	case "-l", "localforward":
+		config.Type = "local"
+	case "-r", "remoteforward":
+		config.Type = "remote"
+	case "-d", "dynamicforward":
+		config.Type = "dynamic"
+	default:
+		return PortForwardConfig{}, fmt.Errorf("unknown forward %q (use -L, -R, -D or LocalForward, RemoteForward, DynamicForward)", fields[0])
+	}
+
+	// Directives separate the two ends of the forward with a space
+	tokens := splitForwardSpec(strings.Join(fields[1:], ":"))
+	if config.Type == "dynamic" {
+		switch len(tokens) {
+		case 1:
+			config.LocalPort = tokens[0]
+		case 2:
+			config.BindAddress, config.LocalPort = UnbracketAddress(tokens[0]), tokens[1]
+		default:
+			return PortForwardConfig{}, fmt.Errorf("invalid forward %q", text)
+		}
+		return config, config.Validate()
+	}
+
+	// The listened end is a socket, a port, or a bind address and a port
+	// when the target still has a host and a port, or is a socket
+	switch {
+	case ports.IsSocketPath(tokens[0]):
+	case len(tokens) == 4, len(tokens) == 3 && ports.IsSocketPath(tokens[2]):
+		config.BindAddress = UnbracketAddress(tokens[0])
+		tokens = tokens[1:]
+	}
+	config.LocalPort = tokens[0]
+
+	switch target := tokens[1:]; {
+	case len(target) == 1 && ports.IsSocketPath(target[0]):
+		config.RemotePort = target[0]
+	case len(target) == 2:
+		config.RemoteHost, config.RemotePort = UnbracketAddress(target[0]), target[1]
+	default:
+		return PortForwardConfig{}, fmt.Errorf("invalid forward %q", text)
+	}
+	return config, config.Validate()
+}
+
+// splitForwardSpec splits a forward specification on colons, except for
+// the colons of IPv6 addresses between brackets
+func splitForwardSpec(spec string) []string {
+	var tokens []string
+	start, depth := 0, 0
+	for i, r := range spec {
+		switch r {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ':':
+			if depth == 0 {
+				tokens = append(tokens, spec[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(tokens, spec[start:])
+}
+
+// String returns the forward as written on the ssh command line
+func (c PortForwardConfig) String() string {
+	return c.Flag() + " " + c.Spec()
+}
+
+// Validate checks the ports of the forward are set and are valid port
+// numbers or Unix socket paths, and that its addresses are well formed.
+// For remote forwards, LocalPort is the port opened on the remote host.
+func (c PortForwardConfig) Validate() error {
+	if c.LocalPort == "" {
+		return fmt.Errorf("port is required")
+	}
+	if c.ListenSocket() {
+		if c.Type == "dynamic" {
+			return fmt.Errorf("dynamic forwarding cannot listen on a socket")
+		}
+		if err := ports.ValidateSocketPath(c.LocalPort); err != nil {
+			return err
+		}
+		if c.BindAddress != "" {
+			return fmt.Errorf("a bind address cannot be used with a socket")
+		}
+	} else if !validPort(c.LocalPort) {
+		return fmt.Errorf("invalid port number")
+	}
+	if !ValidBindAddress(c.BindAddress) {
+		return fmt.Errorf("invalid bind address %q", c.BindAddress)
+	}
+
+	switch c.Type {
+	case "local":
+		if c.RemotePort == "" {
+			return fmt.Errorf("remote port is required for local forwarding")
+		}
+		if !c.TargetSocket() && !validPort(c.RemotePort) {
+			return fmt.Errorf("invalid remote port number")
+		}
+	case "remote":
+		if c.RemotePort == "" {
+			return fmt.Errorf("local port is required for remote forwarding")
+		}
+		if !c.TargetSocket() && !validPort(c.RemotePort) {
+			return fmt.Errorf("invalid local port number")
+		}
+	case "dynamic":
+		return nil
+	default:
+		return fmt.Errorf("unknown forward type %q", c.Type)
+	}
+
+	if c.TargetSocket() {
+		return ports.ValidateSocketPath(c.RemotePort)
+	}
+	if c.RemoteHost != "" && !validHost(c.RemoteHost) {
+		return fmt.Errorf("invalid host %q", c.RemoteHost)
+	}
+	return nil
+}
+
+// ValidBindAddress reports whether address can be given to ssh as the bind
+// address of a forward: empty, "*", "localhost", an IPv4 or IPv6 address
+// (with or without brackets) or a host name
+func ValidBindAddress(address string) bool {
+	switch address {
+	case "", "*", "localhost":
+		return true
+	}
+	return validHost(address)
+}
+
+// validHost reports whether host is an IP address or a host name
+func validHost(host string) bool {
+	if _, err := netip.ParseAddr(UnbracketAddress(host)); err == nil {
+		return true
+	}
+	return validation.ValidateHostname(host) && !strings.Contains(host, "%")
+}
+
+// validPort reports whether port is a TCP port number
+func validPort(port string) bool {
+	number, err := strconv.Atoi(port)
+	return err == nil && number >= 1 && number <= 65535
+}
+
+// UnbracketAddress removes the brackets around an IPv6 address
+func UnbracketAddress(address string) string {
+	if strings.HasPrefix(address, "[") && strings.HasSuffix(address, "]") {
+		return address[1 : len(address)-1]
+	}
+	return address
+}
+
+// bracketAddress puts an IPv6 address between brackets, as ssh expects in
+// forward specifications
+func bracketAddress(address string) string {
+	if strings.Contains(address, ":") && !strings.HasPrefix(address, "[") {
+		return "[" + address + "]"
+	}
+	return address
+}
+
+// SSHArgs returns the ssh arguments setting up the forwards of the profile
+func (p PortForwardProfile) SSHArgs() ([]string, error) {
+	var args []string
+	for _, forward := range p.Forwards {
+		if err := forward.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", forward.String(), err)
+		}
+		args = append(args, forward.Flag(), forward.Spec())
+	}
+	return args, nil
+}
+
+// Summary returns the forwards of the profile on one line
+func (p PortForwardProfile) Summary() string {
+	forwards := make([]string, len(p.Forwards))
+	for i, forward := range p.Forwards {
+		forwards[i] = forward.String()
+	}
+	return strings.Join(forwards, ", ")
+}
+
+// GetPortForwardProfiles returns the port forwarding profiles of a host sorted by name
+func (hm *HistoryManager) GetPortForwardProfiles(hostName string) []PortForwardProfile {
+	conn, exists := hm.connection(hostName)
+	if !exists {
+		return nil
+	}
+
+	profiles := make([]PortForwardProfile, len(conn.PortForwardProfiles))
+	copy(profiles, conn.PortForwardProfiles)
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Name < profiles[j].Name
+	})
+	return profiles
+}
+
+// GetPortForwardProfile returns the port forwarding profile of a host with the given name
+func (hm *HistoryManager) GetPortForwardProfile(hostName, name string) (PortForwardProfile, bool) {
+	if conn, exists := hm.connection(hostName); exists {
+		for _, profile := range conn.PortForwardProfiles {
+			if profile.Name == name {
+				return profile, true
+			}
+		}
+	}
+	return PortForwardProfile{}, false
+}
+
+// SavePortForwardProfile saves a port forwarding profile for a host,
+// replacing the profile with the same name if there is one
+func (hm *HistoryManager) SavePortForwardProfile(hostName string, profile PortForwardProfile) error {
+	profile.Name = strings.TrimSpace(profile.Name)
+	if profile.Name == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	if len(profile.Forwards) == 0 {
+		return fmt.Errorf("profile %s has no forward", profile.Name)
+	}
+	if _, err := profile.SSHArgs(); err != nil {
+		return err
+	}
+
+	return hm.update(func() error {
+		conn, exists := hm.history.Connections[hostName]
+		if !exists {
+			conn = ConnectionInfo{HostName: hostName}
+		}
+
+		replaced := false
+		for i, existing := range conn.PortForwardProfiles {
+			if existing.Name == profile.Name {
+				if profile.LastUsed.IsZero() {
+					profile.LastUsed = existing.LastUsed
+				}
+				conn.PortForwardProfiles[i] = profile
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			conn.PortForwardProfiles = append(conn.PortForwardProfiles, profile)
+		}
+		hm.history.Connections[hostName] = conn
+
+		return nil
+	})
+}
+
+// DeletePortForwardProfile deletes a port forwarding profile of a host
+func (hm *HistoryManager) DeletePortForwardProfile(hostName, name string) error {
+	return hm.update(func() error {
+		conn, exists := hm.history.Connections[hostName]
+		if exists {
+			for i, profile := range conn.PortForwardProfiles {
+				if profile.Name == name {
+					conn.PortForwardProfiles = append(conn.PortForwardProfiles[:i:i], conn.PortForwardProfiles[i+1:]...)
+					hm.history.Connections[hostName] = conn
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("no port forwarding profile %q for %s", name, hostName)
+	})
+}
+
+// MarkPortForwardProfileUsed records that a port forwarding profile of a host was just used
+func (hm *HistoryManager) MarkPortForwardProfileUsed(hostName, name string) error {
+	return hm.update(func() error {
+		conn, exists := hm.history.Connections[hostName]
+		if exists {
+			for i, profile := range conn.PortForwardProfiles {
+				if profile.Name == name {
+					conn.PortForwardProfiles[i].LastUsed = time.Now()
+					hm.history.Connections[hostName] = conn
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("no port forwarding profile %q for %s", name, hostName)
+	})
+}
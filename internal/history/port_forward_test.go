@@ -3,6 +3,7 @@ package history
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -181,3 +182,214 @@ func TestGetPortForwardingConfigNonExistent(t *testing.T) {
 		t.Errorf("Expected nil config for non-existent host, got %+v", config)
 	}
 }
+
+func TestPortForwardProfiles(t *testing.T) {
+	historyPath := filepath.Join(t.TempDir(), "test_history.json")
+	hm := &HistoryManager{
+		historyPath: historyPath,
+		history:     &ConnectionHistory{Connections: make(map[string]ConnectionInfo)},
+	}
+
+	grafana := PortForwardProfile{
+		Name: "grafana",
+		Forwards: []PortForwardConfig{
+			{Type: "local", LocalPort: "3000", RemoteHost: "localhost", RemotePort: "3000"},
+			{Type: "local", LocalPort: "9090", RemoteHost: "prometheus", RemotePort: "9090"},
+		},
+	}
+	postgres := PortForwardProfile{
+		Name:     "postgres",
+		Forwards: []PortForwardConfig{{Type: "local", LocalPort: "5432", RemoteHost: "db", RemotePort: "5432"}},
+	}
+
+	for _, profile := range []PortForwardProfile{postgres, grafana} {
+		if err := hm.SavePortForwardProfile("monitoring", profile); err != nil {
+			t.Fatalf("SavePortForwardProfile(%s) error = %v", profile.Name, err)
+		}
+	}
+
+	profiles := hm.GetPortForwardProfiles("monitoring")
+	if len(profiles) != 2 || profiles[0].Name != "grafana" || profiles[1].Name != "postgres" {
+		t.Fatalf("Expected the profiles sorted by name, got %+v", profiles)
+	}
+	if len(profiles[0].Forwards) != 2 {
+		t.Errorf("Expected 2 forwards in grafana, got %d", len(profiles[0].Forwards))
+	}
+
+	// Saving a profile with the same name replaces it
+	postgres.Forwards[0].LocalPort = "15432"
+	if err := hm.SavePortForwardProfile("monitoring", postgres); err != nil {
+		t.Fatalf("SavePortForwardProfile() error = %v", err)
+	}
+	if profile, _ := hm.GetPortForwardProfile("monitoring", "postgres"); profile.Forwards[0].LocalPort != "15432" {
+		t.Errorf("Expected the profile to be replaced, got %+v", profile)
+	}
+	if got := len(hm.GetPortForwardProfiles("monitoring")); got != 2 {
+		t.Errorf("Expected 2 profiles after replacing one, got %d", got)
+	}
+
+	if err := hm.MarkPortForwardProfileUsed("monitoring", "grafana"); err != nil {
+		t.Fatalf("MarkPortForwardProfileUsed() error = %v", err)
+	}
+
+	// Profiles are persisted
+	other := &HistoryManager{
+		historyPath: historyPath,
+		history:     &ConnectionHistory{Connections: make(map[string]ConnectionInfo)},
+	}
+	if err := other.loadHistory(); err != nil {
+		t.Fatalf("Failed to load history: %v", err)
+	}
+	if profile, exists := other.GetPortForwardProfile("monitoring", "grafana"); !exists || profile.LastUsed.IsZero() {
+		t.Errorf("Expected grafana to be loaded with its last use, got %+v", profile)
+	}
+
+	if err := hm.DeletePortForwardProfile("monitoring", "grafana"); err != nil {
+		t.Fatalf("DeletePortForwardProfile() error = %v", err)
+	}
+	if _, exists := hm.GetPortForwardProfile("monitoring", "grafana"); exists {
+		t.Error("Expected grafana to be deleted")
+	}
+	if err := hm.DeletePortForwardProfile("monitoring", "grafana"); err == nil {
+		t.Error("Expected an error deleting a missing profile")
+	}
+}
+
+func TestSavePortForwardProfileValidation(t *testing.T) {
+	hm := &HistoryManager{
+		historyPath: filepath.Join(t.TempDir(), "test_history.json"),
+		history:     &ConnectionHistory{Connections: make(map[string]ConnectionInfo)},
+	}
+
+	tests := []struct {
+		name    string
+		profile PortForwardProfile
+	}{
+		{"no name", PortForwardProfile{Name: " ", Forwards: []PortForwardConfig{{Type: "dynamic", LocalPort: "1080"}}}},
+		{"no forward", PortForwardProfile{Name: "empty"}},
+		{"invalid port", PortForwardProfile{Name: "bad", Forwards: []PortForwardConfig{{Type: "local", LocalPort: "http", RemotePort: "80"}}}},
+		{"invalid socket", PortForwardProfile{Name: "docker", Forwards: []PortForwardConfig{{Type: "local", LocalPort: "docker.sock/", RemotePort: "/var/run/docker.sock"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := hm.SavePortForwardProfile("host", tt.profile); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestPortForwardConfigSpec(t *testing.T) {
+	tests := []struct {
+		config PortForwardConfig
+		want   string
+	}{
+		{PortForwardConfig{Type: "local", LocalPort: "8080", RemotePort: "80"}, "-L 8080:localhost:80"},
+		{PortForwardConfig{Type: "local", LocalPort: "8080", RemoteHost: "web", RemotePort: "80", BindAddress: "0.0.0.0"}, "-L 0.0.0.0:8080:web:80"},
+		{PortForwardConfig{Type: "remote", LocalPort: "9000", RemoteHost: "localhost", RemotePort: "3000"}, "-R 9000:localhost:3000"},
+		{PortForwardConfig{Type: "dynamic", LocalPort: "1080", BindAddress: "127.0.0.1"}, "-D 127.0.0.1:1080"},
+		{PortForwardConfig{Type: "local", LocalPort: "8080", RemoteHost: "::1", RemotePort: "80", BindAddress: "::1"}, "-L [::1]:8080:[::1]:80"},
+		{PortForwardConfig{Type: "dynamic", LocalPort: "1080", BindAddress: "[fe80::1%eth0]"}, "-D [fe80::1%eth0]:1080"},
+		{PortForwardConfig{Type: "local", LocalPort: "/tmp/docker.sock", RemotePort: "/var/run/docker.sock"}, "-L /tmp/docker.sock:/var/run/docker.sock"},
+		{PortForwardConfig{Type: "local", LocalPort: "15432", RemoteHost: "localhost", RemotePort: "/var/run/postgresql/.s.PGSQL.5432"}, "-L 15432:/var/run/postgresql/.s.PGSQL.5432"},
+		{PortForwardConfig{Type: "remote", LocalPort: "/tmp/agent.sock", RemoteHost: "localhost", RemotePort: "3000"}, "-R /tmp/agent.sock:localhost:3000"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.config.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestPortForwardConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  PortForwardConfig
+		wantErr string
+	}{
+		{"local", PortForwardConfig{Type: "local", LocalPort: "8080", RemoteHost: "web.internal", RemotePort: "80"}, ""},
+		{"any address", PortForwardConfig{Type: "dynamic", LocalPort: "1080", BindAddress: "*"}, ""},
+		{"IPv6 bind address", PortForwardConfig{Type: "local", LocalPort: "8080", RemotePort: "80", BindAddress: "::1"}, ""},
+		{"bracketed IPv6 host", PortForwardConfig{Type: "remote", LocalPort: "9000", RemoteHost: "[2001:db8::1]", RemotePort: "3000"}, ""},
+		{"sockets", PortForwardConfig{Type: "local", LocalPort: "/tmp/docker.sock", RemotePort: "/var/run/docker.sock"}, ""},
+		{"remote socket", PortForwardConfig{Type: "remote", LocalPort: "/run/user/1000/app.sock", RemoteHost: "localhost", RemotePort: "8080"}, ""},
+		{"relative socket", PortForwardConfig{Type: "local", LocalPort: "8080", RemotePort: "run/docker.sock"}, "must be absolute"},
+		{"socket with bind address", PortForwardConfig{Type: "local", LocalPort: "/tmp/docker.sock", RemotePort: "80", BindAddress: "127.0.0.1"}, "bind address cannot be used"},
+		{"dynamic socket", PortForwardConfig{Type: "dynamic", LocalPort: "/tmp/socks.sock"}, "cannot listen on a socket"},
+		{"no port", PortForwardConfig{Type: "local", RemotePort: "80"}, "port is required"},
+		{"port out of range", PortForwardConfig{Type: "dynamic", LocalPort: "70000"}, "invalid port number"},
+		{"port zero", PortForwardConfig{Type: "local", LocalPort: "0", RemotePort: "80"}, "invalid port number"},
+		{"bad remote port", PortForwardConfig{Type: "local", LocalPort: "8080", RemotePort: "http"}, "invalid remote port number"},
+		{"bad bind address", PortForwardConfig{Type: "local", LocalPort: "8080", RemotePort: "80", BindAddress: "127.0.0.1:80"}, "invalid bind address"},
+		{"bad IPv6 address", PortForwardConfig{Type: "dynamic", LocalPort: "1080", BindAddress: "[::1"}, "invalid bind address"},
+		{"bad host", PortForwardConfig{Type: "local", LocalPort: "8080", RemoteHost: "web server", RemotePort: "80"}, "invalid host"},
+		{"unknown type", PortForwardConfig{Type: "reverse", LocalPort: "8080"}, "unknown forward type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPortForwardConfigDirective(t *testing.T) {
+	tests := []struct {
+		config PortForwardConfig
+		want   string
+	}{
+		{PortForwardConfig{Type: "local", LocalPort: "8080", RemoteHost: "localhost", RemotePort: "80"}, "LocalForward 8080 localhost:80"},
+		{PortForwardConfig{Type: "remote", LocalPort: "9000", RemoteHost: "::1", RemotePort: "3000", BindAddress: "0.0.0.0"}, "RemoteForward 0.0.0.0:9000 [::1]:3000"},
+		{PortForwardConfig{Type: "dynamic", LocalPort: "1080"}, "DynamicForward 1080"},
+		{PortForwardConfig{Type: "local", LocalPort: "/tmp/docker.sock", RemotePort: "/var/run/docker.sock"}, "LocalForward /tmp/docker.sock /var/run/docker.sock"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.config.Directive(); got != tt.want {
+			t.Errorf("Directive() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestParseForward(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    PortForwardConfig
+		wantErr bool
+	}{
+		{"-L 8080:localhost:80", PortForwardConfig{Type: "local", LocalPort: "8080", RemoteHost: "localhost", RemotePort: "80"}, false},
+		{"LocalForward 127.0.0.1:8080 web:80", PortForwardConfig{Type: "local", LocalPort: "8080", RemoteHost: "web", RemotePort: "80", BindAddress: "127.0.0.1"}, false},
+		{"localforward [::1]:8080 [2001:db8::1]:80", PortForwardConfig{Type: "local", LocalPort: "8080", RemoteHost: "2001:db8::1", RemotePort: "80", BindAddress: "::1"}, false},
+		{"-L /tmp/docker.sock:/var/run/docker.sock", PortForwardConfig{Type: "local", LocalPort: "/tmp/docker.sock", RemotePort: "/var/run/docker.sock"}, false},
+		{"-L 127.0.0.1:2375:/var/run/docker.sock", PortForwardConfig{Type: "local", LocalPort: "2375", RemotePort: "/var/run/docker.sock", BindAddress: "127.0.0.1"}, false},
+		{"RemoteForward /tmp/app.sock localhost:3000", PortForwardConfig{Type: "remote", LocalPort: "/tmp/app.sock", RemoteHost: "localhost", RemotePort: "3000"}, false},
+		{"-D 1080", PortForwardConfig{Type: "dynamic", LocalPort: "1080"}, false},
+		{"DynamicForward *:1080", PortForwardConfig{Type: "dynamic", LocalPort: "1080", BindAddress: "*"}, false},
+		{"-L 8080", PortForwardConfig{}, true},
+		{"-L 8080:localhost:http", PortForwardConfig{}, true},
+		{"-X 8080:localhost:80", PortForwardConfig{}, true},
+		{"LocalForward", PortForwardConfig{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseForward(tt.text)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseForward(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseForward(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
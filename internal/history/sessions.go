@@ -0,0 +1,195 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// sessionsFileName is the name of the session log, next to the history file
+const sessionsFileName = "sshm_sessions.jsonl"
+
+// SessionKind is what an SSH session was started for
+type SessionKind string
+
+const (
+	SessionInteractive SessionKind = "interactive" // A shell on the host
+	SessionCommand     SessionKind = "command"     // A command run on the host
+	SessionForward     SessionKind = "forward"     // Port forwarding
+)
+
+// SessionKinds lists the kinds of session, in the order they are documented
+var SessionKinds = []SessionKind{SessionInteractive, SessionCommand, SessionForward}
+
+// Session is an SSH session as written in the session log
+type Session struct {
+	HostName   string      `json:"host_name"`
+	Kind       SessionKind `json:"kind"`
+	Command    string      `json:"command,omitempty"`  // Remote command, for command sessions
+	Forwards   string      `json:"forwards,omitempty"` // Forwards set up, for forward sessions
+	ConfigFile string      `json:"config_file"`
+	Start      time.Time   `json:"start"`
+	End        time.Time   `json:"end"`
+	Duration   float64     `json:"duration_seconds"`
+	ExitCode   int         `json:"exit_code"`       // -1 when ssh could not run or did not exit on its own
+	Error      string      `json:"error,omitempty"` // Why ssh could not run, if it could not
+}
+
+// NewSession starts a session on a host, read from configFile or from the
+// default SSH config when it is empty
+func NewSession(hostName string, kind SessionKind, configFile string) Session {
+	return Session{
+		HostName:   hostName,
+		Kind:       kind,
+		ConfigFile: CanonicalConfigFile(configFile),
+		Start:      time.Now(),
+		ExitCode:   -1,
+	}
+}
+
+// Finish ends the session now with the error returned by ssh
+func (s *Session) Finish(err error) {
+	s.End = time.Now()
+	s.Duration = s.End.Sub(s.Start).Seconds()
+
+	var runErr error
+	s.ExitCode, runErr = ExitStatus(err)
+	if runErr != nil {
+		s.Error = runErr.Error()
+	}
+}
+
+// ExitStatus returns the exit code of ssh from the error it returned, and
+// that error when ssh could not run, in which case the exit code is -1
+func ExitStatus(err error) (int, error) {
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return 0, nil
+	case errors.As(err, &exitErr):
+		return exitErr.ExitCode(), nil
+	default:
+		return -1, err
+	}
+}
+
+// Failed reports whether ssh could not run or exited with a non-zero status
+func (s Session) Failed() bool {
+	return s.ExitCode != 0 || s.Error != ""
+}
+
+// Elapsed returns the duration of the session
+func (s Session) Elapsed() time.Duration {
+	return time.Duration(s.Duration * float64(time.Second))
+}
+
+// SessionFilter selects sessions of the session log. Zero fields select everything.
+type SessionFilter struct {
+	HostName   string
+	Kind       SessionKind
+	Since      time.Time // Sessions started at or after
+	FailedOnly bool
+	Limit      int // Most recent sessions returned, 0 for all
+}
+
+// matches reports whether a session is selected by the filter
+func (f SessionFilter) matches(s Session) bool {
+	switch {
+	case f.HostName != "" && s.HostName != f.HostName:
+		return false
+	case f.Kind != "" && s.Kind != f.Kind:
+		return false
+	case !f.Since.IsZero() && s.Start.Before(f.Since):
+		return false
+	case f.FailedOnly && !s.Failed():
+		return false
+	}
+	return true
+}
+
+// sessionsPath returns the path of the session log
+func (hm *HistoryManager) sessionsPath() string {
+	return filepath.Join(filepath.Dir(hm.historyPath), sessionsFileName)
+}
+
+// RecordSession appends a finished session to the session log
+func (hm *HistoryManager) RecordSession(session Session) error {
+	path := hm.sessionsPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	// A single write of a line in append mode, so that sshm processes
+	// recording sessions at once do not interleave their lines
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// GetSessions returns the sessions of the session log with hosts of the
+// config file of the manager selected by the filter, most recent first.
+// Lines that cannot be read are skipped.
+func (hm *HistoryManager) GetSessions(filter SessionFilter) ([]Session, error) {
+	file, err := os.Open(hm.sessionsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	// Sessions logged by earlier versions recorded the config file without
+	// resolving symbolic links: their path is resolved as the manager's was
+	canonical := make(map[string]string)
+
+	var sessions []Session
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var session Session
+		if err := json.Unmarshal(scanner.Bytes(), &session); err != nil || session.HostName == "" {
+			continue
+		}
+		if hm.configFile != "" {
+			configFile, ok := canonical[session.ConfigFile]
+			if !ok {
+				configFile = CanonicalConfigFile(session.ConfigFile)
+				canonical[session.ConfigFile] = configFile
+			}
+			if configFile != hm.configFile {
+				continue
+			}
+		}
+		if filter.matches(session) {
+			sessions = append(sessions, session)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].Start.After(sessions[j].Start)
+	})
+	if filter.Limit > 0 && len(sessions) > filter.Limit {
+		sessions = sessions[:filter.Limit]
+	}
+	return sessions, nil
+}
@@ -0,0 +1,185 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSessionFinish(t *testing.T) {
+	exitErr := exec.Command("sh", "-c", "exit 3").Run()
+
+	tests := []struct {
+		name      string
+		err       error
+		wantCode  int
+		wantError string
+	}{
+		{"success", nil, 0, ""},
+		{"exit status", exitErr, 3, ""},
+		{"not run", errors.New("ssh not found"), -1, "ssh not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := NewSession("web", SessionInteractive, "")
+			session.Start = session.Start.Add(-2 * time.Second)
+			session.Finish(tt.err)
+
+			if session.ExitCode != tt.wantCode || session.Error != tt.wantError {
+				t.Errorf("Finish(%v) = exit %d, error %q, want exit %d, error %q", tt.err, session.ExitCode, session.Error, tt.wantCode, tt.wantError)
+			}
+			if session.Failed() != (tt.wantCode != 0) {
+				t.Errorf("Failed() = %v", session.Failed())
+			}
+			if session.Elapsed() < 2*time.Second {
+				t.Errorf("Elapsed() = %s, want at least 2s", session.Elapsed())
+			}
+		})
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	exitErr := exec.Command("sh", "-c", "exit 2").Run()
+	notRun := errors.New("ssh not found")
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantErr  error
+	}{
+		{"success", nil, 0, nil},
+		{"exit status", exitErr, 2, nil},
+		{"wrapped exit status", fmt.Errorf("session: %w", exitErr), 2, nil},
+		{"not run", notRun, -1, notRun},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := ExitStatus(tt.err)
+			if code != tt.wantCode || err != tt.wantErr {
+				t.Errorf("ExitStatus(%v) = %d, %v, want %d, %v", tt.err, code, err, tt.wantCode, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewSessionConfigFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	if got, want := NewSession("web", SessionCommand, "").ConfigFile, filepath.Join(home, ".ssh", "config"); got != want {
+		t.Errorf("ConfigFile = %q, want the default config %q", got, want)
+	}
+	if got := NewSession("web", SessionCommand, "custom_config").ConfigFile; !filepath.IsAbs(got) {
+		t.Errorf("ConfigFile = %q, want an absolute path", got)
+	}
+}
+
+func TestRecordAndGetSessions(t *testing.T) {
+	hm := createTestHistoryManager(t)
+	now := time.Now()
+
+	record := func(hostName string, kind SessionKind, ago time.Duration, exitCode int) {
+		t.Helper()
+		session := Session{HostName: hostName, Kind: kind, Start: now.Add(-ago), End: now.Add(-ago + time.Minute), Duration: 60, ExitCode: exitCode}
+		if err := hm.RecordSession(session); err != nil {
+			t.Fatalf("RecordSession() error = %v", err)
+		}
+	}
+	record("web", SessionInteractive, 3*time.Hour, 0)
+	record("db", SessionCommand, 2*time.Hour, 1)
+	record("web", SessionForward, time.Hour, 0)
+	record("web", SessionCommand, 30*time.Minute, 255)
+
+	// A line another version could not write completely is skipped
+	file, err := os.OpenFile(hm.sessionsPath(), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("{\"host_name\": \"web\"\n")
+	file.Close()
+
+	tests := []struct {
+		name   string
+		filter SessionFilter
+		want   []string // Kinds of the sessions, most recent first
+	}{
+		{"all", SessionFilter{}, []string{"command", "forward", "command", "interactive"}},
+		{"host", SessionFilter{HostName: "web"}, []string{"command", "forward", "interactive"}},
+		{"kind", SessionFilter{Kind: SessionCommand}, []string{"command", "command"}},
+		{"since", SessionFilter{Since: now.Add(-90 * time.Minute)}, []string{"command", "forward"}},
+		{"failed", SessionFilter{FailedOnly: true}, []string{"command", "command"}},
+		{"limit", SessionFilter{HostName: "web", Limit: 1}, []string{"command"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions, err := hm.GetSessions(tt.filter)
+			if err != nil {
+				t.Fatalf("GetSessions() error = %v", err)
+			}
+			var kinds []string
+			for _, session := range sessions {
+				kinds = append(kinds, string(session.Kind))
+			}
+			if len(kinds) != len(tt.want) {
+				t.Fatalf("GetSessions() = %v, want %v", kinds, tt.want)
+			}
+			for i := range kinds {
+				if kinds[i] != tt.want[i] {
+					t.Fatalf("GetSessions() = %v, want %v", kinds, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetSessionsNoLog(t *testing.T) {
+	hm := createTestHistoryManager(t)
+	sessions, err := hm.GetSessions(SessionFilter{})
+	if err != nil || len(sessions) != 0 {
+		t.Errorf("GetSessions() = %v, %v, want no session", sessions, err)
+	}
+}
+
+func TestGetSessionsConfigFileSymlink(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(dir, "config")
+	if err := os.WriteFile(target, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("Symbolic links are not supported: %v", err)
+	}
+
+	hm := createTestHistoryManager(t)
+	hm.configFile = CanonicalConfigFile(link)
+
+	// Earlier versions logged the path of the config as given, links included
+	now := time.Now()
+	for _, configFile := range []string{link, target, filepath.Join(dir, "other")} {
+		session := Session{HostName: "web", Kind: SessionInteractive, ConfigFile: configFile, Start: now}
+		if err := hm.RecordSession(session); err != nil {
+			t.Fatalf("RecordSession() error = %v", err)
+		}
+	}
+
+	sessions, err := hm.GetSessions(SessionFilter{})
+	if err != nil {
+		t.Fatalf("GetSessions() error = %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Errorf("Expected the sessions of the config and of its link, got %+v", sessions)
+	}
+}
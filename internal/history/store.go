@@ -0,0 +1,220 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// historyVersion is the version of the format of the history file. Bump it
+// when the format changes and upgrade older files in historyFile.migrate.
+//
+//	0: connections keyed by host name only
+//	1: connections keyed by SSH config file, then host name
+const historyVersion = 1
+
+// warningOutput is where problems sshm recovers from are reported
+var warningOutput io.Writer = os.Stderr
+
+// historyFile is the content of the history file: the history of each SSH
+// config file, keyed by its canonical path, so that hosts with the same name
+// in different config files are not mixed up
+type historyFile struct {
+	Version     int                                  `json:"version"`
+	ConfigFiles map[string]map[string]ConnectionInfo `json:"config_files"`
+
+	// Connections is the history written by version 0, keyed by host name
+	// only. It is moved to the default SSH config when loaded.
+	Connections map[string]ConnectionInfo `json:"connections,omitempty"`
+}
+
+// readHistoryFile reads the history file, upgraded to historyVersion. A
+// missing file is an empty history. A file that cannot be parsed, e.g. cut
+// short by a crash of an older version, is renamed aside with a warning and
+// replaced by an empty history.
+func (hm *HistoryManager) readHistoryFile() (*historyFile, error) {
+	data, err := os.ReadFile(hm.historyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &historyFile{Version: historyVersion}, nil
+		}
+		return nil, err
+	}
+
+	// Files without a version were written by version 0
+	file := &historyFile{}
+	if err := json.Unmarshal(data, file); err != nil {
+		quarantined := fmt.Sprintf("%s.corrupt-%s", hm.historyPath, time.Now().Format("20060102-150405"))
+		if renameErr := os.Rename(hm.historyPath, quarantined); renameErr != nil {
+			return nil, fmt.Errorf("history file %s is corrupted (%v) and could not be moved aside: %w", hm.historyPath, err, renameErr)
+		}
+		fmt.Fprintf(warningOutput, "Warning: history file %s was corrupted (%v), moved it to %s and started a new history\n", hm.historyPath, err, quarantined)
+		return &historyFile{Version: historyVersion}, nil
+	}
+
+	file.migrate()
+	return file, nil
+}
+
+// loadHistory loads the connection history from the JSON file. The mutex
+// of the manager must be locked.
+func (hm *HistoryManager) loadHistory() error {
+	file, err := hm.readHistoryFile()
+	if err != nil {
+		return err
+	}
+	hm.setFile(file)
+	return nil
+}
+
+// Reload reads the connection history from disk again, picking up
+// connections recorded by other sshm processes
+func (hm *HistoryManager) Reload() error {
+	hm.mutex.Lock()
+	defer hm.mutex.Unlock()
+
+	return hm.loadHistory()
+}
+
+// setFile makes file the history of the manager
+func (hm *HistoryManager) setFile(file *historyFile) {
+	if file.ConfigFiles == nil {
+		file.ConfigFiles = make(map[string]map[string]ConnectionInfo)
+	}
+	connections := file.ConfigFiles[hm.configFile]
+	if connections == nil {
+		connections = make(map[string]ConnectionInfo)
+	}
+	hm.file = file
+	hm.history = &ConnectionHistory{Connections: connections}
+}
+
+// migrate upgrades a history written by an older version to historyVersion.
+// Histories written by a newer version are left as they are.
+func (f *historyFile) migrate() {
+	if f.Version >= historyVersion {
+		return
+	}
+
+	// Version 0 did not tell config files apart: its connections are
+	// moved to the default SSH config
+	if len(f.Connections) > 0 {
+		if f.ConfigFiles == nil {
+			f.ConfigFiles = make(map[string]map[string]ConnectionInfo)
+		}
+		defaultConfig := CanonicalConfigFile("")
+		connections := f.ConfigFiles[defaultConfig]
+		if connections == nil {
+			connections = make(map[string]ConnectionInfo)
+			f.ConfigFiles[defaultConfig] = connections
+		}
+		for hostName, conn := range f.Connections {
+			if _, exists := connections[hostName]; !exists {
+				connections[hostName] = conn
+			}
+		}
+	}
+	f.Connections = nil
+	f.Version = historyVersion
+}
+
+// update changes the history on disk: with the history file locked against
+// other sshm processes, and the manager against other goroutines, the
+// history is read again, changed by change and written back at once.
+// Nothing is written if change fails.
+func (hm *HistoryManager) update(change func() error) error {
+	hm.mutex.Lock()
+	defer hm.mutex.Unlock()
+
+	dir := filepath.Dir(hm.historyPath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	lock, err := lockFile(hm.historyPath + ".lock")
+	if err != nil {
+		return fmt.Errorf("could not lock the history: %w", err)
+	}
+	defer lock.unlock()
+
+	if err := hm.loadHistory(); err != nil {
+		return err
+	}
+	if hm.file.Version > historyVersion {
+		return fmt.Errorf("history file %s was written by a newer version of sshm", hm.historyPath)
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	return hm.saveHistory()
+}
+
+// saveHistory writes the history back to the history file, which must be
+// locked
+func (hm *HistoryManager) saveHistory() error {
+	hm.file.Version = historyVersion
+	hm.file.ConfigFiles[hm.configFile] = hm.history.Connections
+
+	data, err := json.MarshalIndent(hm.file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(hm.historyPath, data)
+}
+
+// writeFileAtomic writes data to a temporary file renamed over path, so that
+// path is never left half written
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// UpdateFile changes a file shared by sshm processes the way the history is
+// changed: with the file locked against other processes, its current
+// content, nil if it does not exist, is passed to change and what change
+// returns is written back at once. Nothing is written if change fails.
+func UpdateFile(path string, change func(data []byte) ([]byte, error)) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	lock, err := lockFile(path + ".lock")
+	if err != nil {
+		return fmt.Errorf("could not lock %s: %w", path, err)
+	}
+	defer lock.unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	data, err = change(data)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
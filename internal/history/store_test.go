@@ -0,0 +1,177 @@
+package history
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+// newStoreTestManager creates a history manager of the hosts of a config file
+// with its history in historyPath
+func newStoreTestManager(t *testing.T, historyPath string) *HistoryManager {
+	t.Helper()
+	hm := &HistoryManager{historyPath: historyPath, configFile: "/home/user/.ssh/config"}
+	if err := hm.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	return hm
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	historyPath := filepath.Join(t.TempDir(), "sshm_history.json")
+
+	// Each manager stands for an sshm process recording connections at the same time
+	const processes, connections = 8, 10
+	var wg sync.WaitGroup
+	errs := make(chan error, processes*connections)
+	for i := 0; i < processes; i++ {
+		hm := newStoreTestManager(t, historyPath)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < connections; j++ {
+				if err := hm.RecordConnection("dev"); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("RecordConnection() error = %v", err)
+	}
+
+	hm := newStoreTestManager(t, historyPath)
+	if got := hm.GetConnectionCount("dev"); got != processes*connections {
+		t.Errorf("Expected %d connections, got %d: updates were lost", processes*connections, got)
+	}
+
+	// Only the history and its lock are left in the directory
+	entries, err := os.ReadDir(filepath.Dir(historyPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("Temporary file %s left behind", entry.Name())
+		}
+	}
+}
+
+func TestConcurrentUpdatesAndReads(t *testing.T) {
+	historyPath := filepath.Join(t.TempDir(), "sshm_history.json")
+	hm := newStoreTestManager(t, historyPath)
+	hosts := []config.SSHHost{{Name: "dev"}, {Name: "prod"}}
+
+	// As in the TUI, connections are recorded from background commands while
+	// the host list reads and sorts them
+	const records = 20
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < records; i++ {
+			if err := hm.RecordConnection("dev"); err != nil {
+				t.Errorf("RecordConnection() error = %v", err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < records; i++ {
+			hm.GetConnectionCount("dev")
+			hm.GetLastConnectionTime("prod")
+			hm.SortHostsByLastUsed(hosts)
+			hm.SortHostsByMostUsed(hosts)
+			hm.GetAllConnectionsInfo()
+			if err := hm.Reload(); err != nil {
+				t.Errorf("Reload() error = %v", err)
+			}
+		}
+	}()
+	wg.Wait()
+
+	if got := hm.GetConnectionCount("dev"); got != records {
+		t.Errorf("Expected %d connections, got %d", records, got)
+	}
+}
+
+func TestCorruptedHistoryQuarantined(t *testing.T) {
+	var warnings bytes.Buffer
+	warningOutput = &warnings
+	defer func() { warningOutput = os.Stderr }()
+
+	dir := t.TempDir()
+	historyPath := filepath.Join(dir, "sshm_history.json")
+	corrupted := `{"version": 1, "config_files": {"/home/user/.ssh/config": {"dev": {"host_name": "de`
+	if err := os.WriteFile(historyPath, []byte(corrupted), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	hm := newStoreTestManager(t, historyPath)
+	if got := hm.GetConnectionCount("dev"); got != 0 {
+		t.Errorf("Expected a new history, got %d connections", got)
+	}
+	if !strings.Contains(warnings.String(), "was corrupted") {
+		t.Errorf("Expected a warning, got %q", warnings.String())
+	}
+
+	quarantined, err := filepath.Glob(historyPath + ".corrupt-*")
+	if err != nil || len(quarantined) != 1 {
+		t.Fatalf("Expected the corrupted file to be moved aside, got %v", quarantined)
+	}
+	if data, err := os.ReadFile(quarantined[0]); err != nil || string(data) != corrupted {
+		t.Errorf("Expected the corrupted file to be kept as it was, got %q (%v)", data, err)
+	}
+
+	if err := hm.RecordConnection("dev"); err != nil {
+		t.Fatalf("RecordConnection() error = %v", err)
+	}
+	if got := newStoreTestManager(t, historyPath).GetConnectionCount("dev"); got != 1 {
+		t.Errorf("Expected the new history to be saved, got %d connections", got)
+	}
+}
+
+func TestHistoryFromNewerVersion(t *testing.T) {
+	historyPath := filepath.Join(t.TempDir(), "sshm_history.json")
+	newer := `{"version": 99, "config_files": {"/home/user/.ssh/config": {"dev": {"host_name": "dev", "connect_count": 4}}}, "favorites": ["dev"]}`
+	if err := os.WriteFile(historyPath, []byte(newer), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	hm := newStoreTestManager(t, historyPath)
+	if got := hm.GetConnectionCount("dev"); got != 4 {
+		t.Errorf("Expected the history to be read, got %d connections", got)
+	}
+	if err := hm.RecordConnection("dev"); err == nil || !strings.Contains(err.Error(), "newer version") {
+		t.Errorf("Expected the history not to be written, got %v", err)
+	}
+	if data, _ := os.ReadFile(historyPath); string(data) != newer {
+		t.Errorf("Expected the history file to be left as it was, got %s", data)
+	}
+}
+
+func TestHistoryVersionWritten(t *testing.T) {
+	historyPath := filepath.Join(t.TempDir(), "sshm_history.json")
+	hm := newStoreTestManager(t, historyPath)
+	if err := hm.RecordConnection("dev"); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := hm.readHistoryFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Version != historyVersion {
+		t.Errorf("Expected version %d, got %d", historyVersion, file.Version)
+	}
+	if info, err := os.Stat(historyPath); err == nil && info.Mode().Perm()&0077 != 0 && os.PathSeparator == '/' {
+		t.Errorf("Expected the history to be readable by its owner only, got %s", info.Mode())
+	}
+}
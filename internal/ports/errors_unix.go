@@ -0,0 +1,24 @@
+//go:build !windows
+
+package ports
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isAddrInUse reports whether a listen error is caused by a port in use
+func isAddrInUse(err error) bool {
+	return errors.Is(err, syscall.EADDRINUSE)
+}
+
+// isPermission reports whether a listen error is caused by missing privileges
+func isPermission(err error) bool {
+	return errors.Is(err, syscall.EACCES) || errors.Is(err, syscall.EPERM)
+}
+
+// isAddrNotAvailable reports whether a listen error is caused by an address
+// that does not belong to this machine
+func isAddrNotAvailable(err error) bool {
+	return errors.Is(err, syscall.EADDRNOTAVAIL)
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package ports
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Winsock error codes, not defined by the syscall package
+const (
+	wsaeacces        syscall.Errno = 10013
+	wsaeaddrinuse    syscall.Errno = 10048
+	wsaeaddrnotavail syscall.Errno = 10049
+)
+
+// isAddrInUse reports whether a listen error is caused by a port in use
+func isAddrInUse(err error) bool {
+	return errors.Is(err, wsaeaddrinuse)
+}
+
+// isPermission reports whether a listen error is caused by missing privileges
+// or a port reserved by the system
+func isPermission(err error) bool {
+	return errors.Is(err, wsaeacces)
+}
+
+// isAddrNotAvailable reports whether a listen error is caused by an address
+// that does not belong to this machine
+func isAddrNotAvailable(err error) bool {
+	return errors.Is(err, wsaeaddrnotavail)
+}
@@ -0,0 +1,104 @@
+//go:build linux
+
+package ports
+
+import (
+	"bufio"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// procRoot is the mount point of procfs
+var procRoot = "/proc"
+
+// tcpListen is the state of listening sockets in /proc/net/tcp
+const tcpListen = "0A"
+
+// findOwner returns the process listening on a TCP port, read from procfs.
+// Only the user is known when the process belongs to another user.
+func findOwner(port int) *Owner {
+	inode, uid, found := findListeningSocket(port)
+	if !found {
+		return nil
+	}
+
+	owner := &Owner{User: lookupUser(uid)}
+	if pid := findSocketProcess(inode); pid != 0 {
+		owner.PID = pid
+		if comm, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "comm")); err == nil {
+			owner.Command = strings.TrimSpace(string(comm))
+		}
+	}
+	return owner
+}
+
+// findListeningSocket returns the inode and the owner uid of the socket
+// listening on a TCP port
+func findListeningSocket(port int) (inode, uid string, found bool) {
+	for _, table := range []string{"tcp", "tcp6"} {
+		file, err := os.Open(filepath.Join(procRoot, "net", table))
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(file)
+		scanner.Scan() // Header
+		for scanner.Scan() {
+			// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 10 || fields[3] != tcpListen {
+				continue
+			}
+			_, portHex, ok := strings.Cut(fields[1], ":")
+			if !ok {
+				continue
+			}
+			if value, err := strconv.ParseInt(portHex, 16, 32); err == nil && int(value) == port {
+				file.Close()
+				return fields[9], fields[7], true
+			}
+		}
+		file.Close()
+	}
+	return "", "", false
+}
+
+// findSocketProcess returns the pid of a process with the socket open,
+// or 0 if none can be seen
+func findSocketProcess(inode string) int {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return 0
+	}
+
+	target := "socket:[" + inode + "]"
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join(procRoot, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			// Process of another user
+			continue
+		}
+		for _, fd := range fds {
+			if link, err := os.Readlink(filepath.Join(fdDir, fd.Name())); err == nil && link == target {
+				return pid
+			}
+		}
+	}
+	return 0
+}
+
+// lookupUser returns the name of a user, or its uid if it has no name
+func lookupUser(uid string) string {
+	if u, err := user.LookupId(uid); err == nil {
+		return u.Username
+	}
+	return uid
+}
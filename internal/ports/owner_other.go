@@ -0,0 +1,56 @@
+//go:build !linux
+
+package ports
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lsofTimeout is how long lsof may take to find the owner of a port
+const lsofTimeout = 2 * time.Second
+
+// findOwner returns the process listening on a TCP port, as reported by
+// lsof, or nil if lsof is not available or does not see it
+func findOwner(port int) *Owner {
+	ctx, cancel := context.WithTimeout(context.Background(), lsofTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "lsof", "-nP", "-iTCP:"+strconv.Itoa(port), "-sTCP:LISTEN", "-Fpcu").Output()
+	if err != nil && len(output) == 0 {
+		return nil
+	}
+	return parseLsof(string(output))
+}
+
+// parseLsof reads the first process of lsof -F output, made of lines
+// starting with a field identifier (p: pid, c: command, u: uid)
+func parseLsof(output string) *Owner {
+	var owner *Owner
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			continue
+		}
+		value := line[1:]
+		switch line[0] {
+		case 'p':
+			if owner != nil {
+				return owner
+			}
+			pid, _ := strconv.Atoi(value)
+			owner = &Owner{PID: pid}
+		case 'c':
+			if owner != nil {
+				owner.Command = value
+			}
+		case 'u':
+			if owner != nil {
+				owner.User = value
+			}
+		}
+	}
+	return owner
+}
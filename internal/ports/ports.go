@@ -0,0 +1,147 @@
+package ports
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// maxSuggestionAttempts is the number of ports tried when looking for a free one
+const maxSuggestionAttempts = 100
+
+// Owner is the process listening on a port
+type Owner struct {
+	PID     int
+	Command string
+	User    string
+}
+
+// String describes the owner, e.g. "nginx (pid 1234)"
+func (o *Owner) String() string {
+	switch {
+	case o.PID != 0 && o.Command != "":
+		return fmt.Sprintf("%s (pid %d)", o.Command, o.PID)
+	case o.PID != 0:
+		return fmt.Sprintf("pid %d", o.PID)
+	case o.User != "":
+		return "a process of user " + o.User
+	default:
+		return "another process"
+	}
+}
+
+// Conflict is returned when a port cannot be listened on because it is
+// already in use or needs privileges
+type Conflict struct {
+	BindAddress string
+	Port        int
+	Privileged  bool   // The port is below 1024 and only root may listen on it
+	Owner       *Owner // Process holding the port, nil if unknown
+	Suggestion  int    // Free port to use instead, 0 if none was found
+}
+
+func (c *Conflict) Error() string {
+	var message string
+	if c.Privileged {
+		message = fmt.Sprintf("port %d is privileged and can only be forwarded by root", c.Port)
+	} else {
+		message = fmt.Sprintf("port %d is already in use", c.Port)
+		if c.Owner != nil {
+			message += " by " + c.Owner.String()
+		}
+	}
+	if c.Suggestion != 0 {
+		message += fmt.Sprintf("; port %d is free", c.Suggestion)
+	}
+	return message
+}
+
+// CheckLocal checks a forward can listen on the port of this machine at
+// the given bind address, as ssh does for local and dynamic forwards. It
+// returns a *Conflict if the port is in use or privileged.
+func CheckLocal(bindAddress string, port int) error {
+	err := probe(bindAddress, port)
+	if err == nil {
+		return nil
+	}
+
+	var addrErr *net.AddrError
+	var dnsErr *net.DNSError
+	switch {
+	case isAddrInUse(err):
+		conflict := &Conflict{BindAddress: bindAddress, Port: port, Owner: findOwner(port)}
+		conflict.Suggestion = NextFree(bindAddress, port+1)
+		return conflict
+	case isPermission(err):
+		conflict := &Conflict{BindAddress: bindAddress, Port: port, Privileged: port < 1024}
+		if !conflict.Privileged {
+			return fmt.Errorf("cannot listen on port %d: permission denied", port)
+		}
+		// Suggest the usual unprivileged counterpart, e.g. 8080 for 80
+		conflict.Suggestion = NextFree(bindAddress, port+8000)
+		return conflict
+	case isAddrNotAvailable(err):
+		return fmt.Errorf("bind address %s is not an address of this machine", bindAddress)
+	case errors.As(err, &dnsErr):
+		return fmt.Errorf("cannot resolve bind address %s", bindAddress)
+	case errors.As(err, &addrErr):
+		return fmt.Errorf("invalid bind address %s", bindAddress)
+	default:
+		return fmt.Errorf("cannot listen on port %d: %w", port, err)
+	}
+}
+
+// NextFree returns the first port from the given one that can be listened
+// on at the bind address, or 0 if none was found
+func NextFree(bindAddress string, from int) int {
+	for port := from; port <= 65535 && port < from+maxSuggestionAttempts; port++ {
+		if probe(bindAddress, port) == nil {
+			return port
+		}
+	}
+	return 0
+}
+
+// probe listens on the port at every address ssh would listen on, then
+// closes the listeners
+func probe(bindAddress string, port int) error {
+	for _, address := range listenAddresses(bindAddress, port) {
+		listener, err := net.Listen("tcp", address.address)
+		if err != nil {
+			if address.optional && !isAddrInUse(err) && !isPermission(err) {
+				// No IPv6 loopback on this machine
+				continue
+			}
+			return err
+		}
+		listener.Close()
+	}
+	return nil
+}
+
+// listenAddress is an address ssh listens on for a forward
+type listenAddress struct {
+	address  string
+	optional bool // ssh goes on without it if the address family is not available
+}
+
+// listenAddresses returns the addresses ssh listens on for a bind address:
+// the loopback addresses by default, every address for "*"
+func listenAddresses(bindAddress string, port int) []listenAddress {
+	portText := strconv.Itoa(port)
+	host := strings.TrimSuffix(strings.TrimPrefix(bindAddress, "["), "]")
+
+	switch host {
+	case "", "localhost":
+		return []listenAddress{
+			{address: net.JoinHostPort("127.0.0.1", portText)},
+			{address: net.JoinHostPort("::1", portText), optional: true},
+		}
+	case "*":
+		return []listenAddress{{address: ":" + portText}}
+	default:
+		return []listenAddress{{address: net.JoinHostPort(host, portText)}}
+	}
+}
@@ -0,0 +1,242 @@
+package ports
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// listen listens on a free loopback port and returns its number
+func listen(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestCheckLocal(t *testing.T) {
+	port := listen(t)
+
+	err := CheckLocal("", port)
+	var conflict *Conflict
+	if !errors.As(err, &conflict) {
+		t.Fatalf("CheckLocal() error = %v, want a conflict", err)
+	}
+	if conflict.Privileged || conflict.Suggestion <= port {
+		t.Errorf("Expected a free port after %d to be suggested, got %+v", port, conflict)
+	}
+	if CheckLocal("", conflict.Suggestion) != nil {
+		t.Errorf("Expected the suggested port %d to be free", conflict.Suggestion)
+	}
+	if runtime.GOOS == "linux" && (conflict.Owner == nil || conflict.Owner.PID != os.Getpid()) {
+		t.Errorf("Expected this process to hold the port, got %+v", conflict.Owner)
+	}
+
+	// The port is in use on the loopback address only
+	if err := CheckLocal("127.0.0.1", port); !errors.As(err, &conflict) {
+		t.Errorf("CheckLocal(127.0.0.1) error = %v, want a conflict", err)
+	}
+
+	// 192.0.2.0/24 is reserved for documentation
+	if err := CheckLocal("192.0.2.1", conflict.Suggestion); err == nil || !strings.Contains(err.Error(), "not an address of this machine") {
+		t.Errorf("CheckLocal(192.0.2.1) error = %v, want a foreign address error", err)
+	}
+}
+
+func TestListenAddresses(t *testing.T) {
+	tests := []struct {
+		bindAddress string
+		want        []listenAddress
+	}{
+		{"", []listenAddress{{address: "127.0.0.1:8080"}, {address: "[::1]:8080", optional: true}}},
+		{"localhost", []listenAddress{{address: "127.0.0.1:8080"}, {address: "[::1]:8080", optional: true}}},
+		{"*", []listenAddress{{address: ":8080"}}},
+		{"0.0.0.0", []listenAddress{{address: "0.0.0.0:8080"}}},
+		{"::1", []listenAddress{{address: "[::1]:8080"}}},
+		{"[::1]", []listenAddress{{address: "[::1]:8080"}}},
+	}
+
+	for _, tt := range tests {
+		if got := listenAddresses(tt.bindAddress, 8080); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("listenAddresses(%q) = %v, want %v", tt.bindAddress, got, tt.want)
+		}
+	}
+}
+
+func TestConflictError(t *testing.T) {
+	tests := []struct {
+		conflict Conflict
+		want     string
+	}{
+		{Conflict{Port: 8080, Owner: &Owner{PID: 42, Command: "nginx"}, Suggestion: 8081}, "port 8080 is already in use by nginx (pid 42); port 8081 is free"},
+		{Conflict{Port: 5432, Owner: &Owner{User: "postgres"}}, "port 5432 is already in use by a process of user postgres"},
+		{Conflict{Port: 80, Privileged: true, Suggestion: 8080}, "port 80 is privileged and can only be forwarded by root; port 8080 is free"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.conflict.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestValidateSocketPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{"/var/run/docker.sock", ""},
+		{"docker.sock", "must be absolute"},
+		{"/tmp/a:b.sock", "cannot contain ':'"},
+		{"/tmp/", "is a directory"},
+		{"/tmp/" + strings.Repeat("s", 100), "too long"},
+	}
+
+	for _, tt := range tests {
+		err := ValidateSocketPath(tt.path)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("ValidateSocketPath(%q) error = %v, want %q", tt.path, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix sockets")
+	}
+	// Temporary directories of tests may be too long for socket paths
+	dir, err := os.MkdirTemp("", "sshm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	free := filepath.Join(dir, "free.sock")
+	if err := CheckSocket(free); err != nil {
+		t.Errorf("CheckSocket(free) error = %v", err)
+	}
+	if err := CheckSocket(filepath.Join(dir, "missing", "db.sock")); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Expected an error for a missing directory, got %v", err)
+	}
+
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckSocket(file); err == nil || !strings.Contains(err.Error(), "not a socket") {
+		t.Errorf("Expected an error for a regular file, got %v", err)
+	}
+
+	used := filepath.Join(dir, "used.sock")
+	listener, err := net.Listen("unix", used)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckSocket(used); err == nil || !strings.Contains(err.Error(), "already in use") {
+		t.Errorf("Expected an error for a socket in use, got %v", err)
+	}
+
+	// Closing a listener removes its socket: leave a stale one behind
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+	if err := CheckSocket(used); err == nil || !strings.Contains(err.Error(), "stale socket") {
+		t.Errorf("Expected an error for a stale socket, got %v", err)
+	}
+}
+
+func TestParseListeners(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []Listener
+	}{
+		{
+			name: "ss",
+			output: `State  Recv-Q Send-Q Local Address:Port  Peer Address:Port Process
+LISTEN 0      4096   127.0.0.53%lo:53      0.0.0.0:*     users:(("systemd-resolve",pid=612,fd=14))
+LISTEN 0      128          0.0.0.0:22      0.0.0.0:*     users:(("sshd",pid=1000,fd=3))
+LISTEN 0      244        127.0.0.1:5432    0.0.0.0:*
+LISTEN 0      128             [::]:22         [::]:*     users:(("sshd",pid=1000,fd=4))
+LISTEN 0      4096               *:9100          *:*     users:(("node_exporter",812,3))
+`,
+			want: []Listener{
+				{Address: "0.0.0.0", Port: 22, Process: "sshd", PID: 1000},
+				{Address: "127.0.0.53", Port: 53, Process: "systemd-resolve", PID: 612},
+				{Address: "127.0.0.1", Port: 5432},
+				{Address: "*", Port: 9100, Process: "node_exporter", PID: 812},
+			},
+		},
+		{
+			name: "netstat on Linux",
+			output: `Active Internet connections (only servers)
+Proto Recv-Q Send-Q Local Address           Foreign Address         State       PID/Program name
+tcp        0      0 0.0.0.0:80              0.0.0.0:*               LISTEN      731/nginx
+tcp        0      0 127.0.0.1:6379          0.0.0.0:*               LISTEN      -
+tcp6       0      0 :::80                   :::*                    LISTEN      731/nginx
+tcp6       0      0 ::1:3000                :::*                    LISTEN      -
+`,
+			want: []Listener{
+				{Address: "0.0.0.0", Port: 80, Process: "nginx", PID: 731},
+				{Address: "::1", Port: 3000},
+				{Address: "127.0.0.1", Port: 6379},
+			},
+		},
+		{
+			name: "netstat on macOS",
+			output: `Active Internet connections (including servers)
+Proto Recv-Q Send-Q  Local Address          Foreign Address        (state)
+tcp4       0      0  127.0.0.1.5432         *.*                    LISTEN
+tcp6       0      0  ::1.5432               *.*                    LISTEN
+tcp46      0      0  *.8080                 *.*                    LISTEN
+tcp4       0      0  192.168.1.10.52100     17.57.146.20.443       ESTABLISHED
+`,
+			want: []Listener{
+				{Address: "127.0.0.1", Port: 5432},
+				{Address: "::1", Port: 5432},
+				{Address: "*", Port: 8080},
+			},
+		},
+		{
+			name:   "no output",
+			output: "bash: ss: command not found\n",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseListeners(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseListeners() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListener(t *testing.T) {
+	wildcard := Listener{Address: "::", Port: 5432, Process: "postgres", PID: 812}
+	if wildcard.TargetHost() != "localhost" || wildcard.String() != "5432 postgres (pid 812)" {
+		t.Errorf("Unexpected wildcard listener %q to %s", wildcard, wildcard.TargetHost())
+	}
+	loopback := Listener{Address: "127.0.0.1", Port: 6379}
+	if loopback.TargetHost() != "127.0.0.1" || loopback.String() != "6379 on 127.0.0.1" {
+		t.Errorf("Unexpected loopback listener %q to %s", loopback, loopback.TargetHost())
+	}
+}
+
+func TestSuggestLocal(t *testing.T) {
+	port := listen(t)
+	if got := SuggestLocal("", port); got <= port {
+		t.Errorf("SuggestLocal() = %d, want a free port after %d", got, port)
+	}
+	if got := SuggestLocal("", 80); got < 8080 {
+		t.Errorf("SuggestLocal() = %d, want 8080 or after", got)
+	}
+}
@@ -0,0 +1,163 @@
+package ports
+
+import (
+	"net/netip"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RemoteProbeCommand lists the TCP ports listened on by a host: ss where
+// available, then the netstat of Linux, then the netstat of BSD and macOS
+const RemoteProbeCommand = "ss -tlnp 2>/dev/null || netstat -tlnp 2>/dev/null || netstat -an -p tcp 2>/dev/null"
+
+// Listener is a TCP port listened on by a host
+type Listener struct {
+	Address string // Address listened on, e.g. "0.0.0.0", "::" or "127.0.0.1"
+	Port    int
+	Process string // Name of the listening process, empty when it is not shown
+	PID     int
+}
+
+// Wildcard reports whether the port is listened on at every address
+func (l Listener) Wildcard() bool {
+	switch l.Address {
+	case "", "*", "0.0.0.0", "::":
+		return true
+	}
+	return false
+}
+
+// TargetHost returns the host a forward should connect to on the host to
+// reach the listener: localhost, unless only one address is listened on
+func (l Listener) TargetHost() string {
+	if l.Wildcard() {
+		return "localhost"
+	}
+	return l.Address
+}
+
+// String describes the listener, e.g. "5432 postgres (pid 812) on 127.0.0.1"
+func (l Listener) String() string {
+	description := strconv.Itoa(l.Port)
+	if l.Process != "" {
+		owner := &Owner{PID: l.PID, Command: l.Process}
+		description += " " + owner.String()
+	}
+	if !l.Wildcard() {
+		description += " on " + l.Address
+	}
+	return description
+}
+
+// ssProcess matches the first process of the users column of ss, written
+// users:(("sshd",pid=812,fd=3)) or users:(("sshd",812,3)) by older versions
+var ssProcess = regexp.MustCompile(`\("([^"]+)",(?:pid=)?(\d+)`)
+
+// ParseListeners parses the output of RemoteProbeCommand. Ports listened on
+// through both IPv4 and IPv6 are listed once, and the listeners are sorted by port.
+func ParseListeners(output string) []Listener {
+	var listeners []Listener
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		listener, ok := parseListenerLine(strings.Fields(line))
+		if !ok {
+			continue
+		}
+		key := listener.TargetHost() + " " + strconv.Itoa(listener.Port)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		listeners = append(listeners, listener)
+	}
+
+	sort.SliceStable(listeners, func(i, j int) bool {
+		return listeners[i].Port < listeners[j].Port
+	})
+	return listeners
+}
+
+// parseListenerLine parses a line of ss or netstat, skipping the headers
+// and the sockets that are not listening
+func parseListenerLine(fields []string) (Listener, bool) {
+	var local, process string
+	switch {
+	case len(fields) >= 5 && fields[0] == "LISTEN":
+		// ss: State Recv-Q Send-Q Local Peer [Process]
+		local = fields[3]
+		if len(fields) > 5 {
+			process = strings.Join(fields[5:], " ")
+		}
+	case len(fields) >= 6 && strings.HasPrefix(fields[0], "tcp") && fields[5] == "LISTEN":
+		// netstat: Proto Recv-Q Send-Q Local Foreign State [PID/Program]
+		local = fields[3]
+		if len(fields) > 6 {
+			process = fields[6]
+		}
+	default:
+		return Listener{}, false
+	}
+
+	address, port, ok := splitListenAddress(local)
+	if !ok {
+		return Listener{}, false
+	}
+	listener := Listener{Address: address, Port: port}
+
+	if match := ssProcess.FindStringSubmatch(process); match != nil {
+		listener.Process = match[1]
+		listener.PID, _ = strconv.Atoi(match[2])
+	} else if pid, name, ok := strings.Cut(process, "/"); ok {
+		// netstat: 812/postgres
+		if n, err := strconv.Atoi(pid); err == nil {
+			listener.Process, listener.PID = name, n
+		}
+	}
+	return listener, true
+}
+
+// splitListenAddress splits a local address as printed by ss and netstat:
+// 0.0.0.0:22, [::]:22, *:22, 127.0.0.53%lo:53 and :::22 on Linux, or
+// 127.0.0.1.5432, ::1.5432 and *.22 on BSD and macOS
+func splitListenAddress(local string) (string, int, bool) {
+	separator := strings.LastIndex(local, ":")
+	if dot := strings.LastIndex(local, "."); dot > separator {
+		if _, err := strconv.Atoi(local[dot+1:]); err == nil {
+			separator = dot
+		}
+	}
+	if separator < 0 {
+		return "", 0, false
+	}
+
+	port, err := strconv.Atoi(local[separator+1:])
+	if err != nil || port < 1 || port > 65535 {
+		return "", 0, false
+	}
+
+	address := strings.Trim(local[:separator], "[]")
+	if zone := strings.Index(address, "%"); zone >= 0 {
+		address = address[:zone]
+	}
+	if address != "*" {
+		ip, err := netip.ParseAddr(address)
+		if err != nil {
+			return "", 0, false
+		}
+		address = ip.Unmap().String()
+	}
+	return address, port, true
+}
+
+// SuggestLocal returns a free local port to forward a remote port to: the
+// same port when it is free, or the next free one. Privileged ports are
+// moved up by 8000 first, e.g. 80 to 8080. It returns 0 if none was found.
+func SuggestLocal(bindAddress string, remotePort int) int {
+	from := remotePort
+	if from < 1024 {
+		from += 8000
+	}
+	return NextFree(bindAddress, from)
+}
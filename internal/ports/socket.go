@@ -0,0 +1,71 @@
+package ports
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxSocketPath is the longest Unix socket path accepted everywhere: the
+// sun_path field holds 104 bytes on macOS and BSDs, 108 on Linux, both
+// including the terminating NUL
+const maxSocketPath = 103
+
+// IsSocketPath reports whether a forward endpoint is a Unix socket path
+// rather than a port, as ssh decides: socket paths contain a slash
+func IsSocketPath(endpoint string) bool {
+	return strings.Contains(endpoint, "/")
+}
+
+// ValidateSocketPath checks a Unix socket path can be used as a forward
+// endpoint, on either side of the connection
+func ValidateSocketPath(path string) error {
+	switch {
+	case !strings.HasPrefix(path, "/"):
+		return fmt.Errorf("socket path %s must be absolute", path)
+	case strings.Contains(path, ":"):
+		return fmt.Errorf("socket path %s cannot contain ':'", path)
+	case strings.ContainsAny(path, " \t"):
+		return fmt.Errorf("socket path %s cannot contain spaces", path)
+	case strings.HasSuffix(path, "/"):
+		return fmt.Errorf("socket path %s is a directory", path)
+	case len(path) > maxSocketPath:
+		return fmt.Errorf("socket path %s is too long (%d characters, at most %d)", path, len(path), maxSocketPath)
+	}
+	return nil
+}
+
+// CheckSocket checks ssh can create a Unix socket at path on this machine
+// to listen on it: its directory exists and nothing is there yet
+func CheckSocket(path string) error {
+	if err := ValidateSocketPath(path); err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("directory %s does not exist", dir)
+	}
+
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s already exists and is not a socket", path)
+	}
+
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("socket %s is already in use", path)
+	}
+	// ssh refuses to replace a socket file unless StreamLocalBindUnlink is set
+	return fmt.Errorf("stale socket %s already exists (remove it, or set StreamLocalBindUnlink yes)", path)
+}
@@ -0,0 +1,39 @@
+//go:build !windows
+
+package tunnel
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// detachedProcAttr starts the supervisor in its own session, so that it
+// keeps running when the terminal it was started from is closed
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
+
+// processAlive reports whether a process is running
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// terminateProcess asks a process to exit
+func terminateProcess(pid int) {
+	if process, err := os.FindProcess(pid); err == nil {
+		process.Signal(syscall.SIGTERM)
+	}
+}
+
+// killProcess kills a process
+func killProcess(pid int) {
+	if process, err := os.FindProcess(pid); err == nil {
+		process.Kill()
+	}
+}
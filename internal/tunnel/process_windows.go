@@ -0,0 +1,65 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// Process creation flag and exit code not defined by the syscall package
+const (
+	detachedProcess = 0x00000008
+	stillActive     = 259
+)
+
+// detachedProcAttr starts the supervisor without console, so that it keeps
+// running when the console it was started from is closed
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: detachedProcess | syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// processAlive reports whether a process is running
+func processAlive(pid int) bool {
+	handle, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(handle)
+
+	var code uint32
+	if err := syscall.GetExitCodeProcess(handle, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
+
+// processStartTime returns when a process started, in nanoseconds since the epoch
+func processStartTime(pid int) (uint64, bool) {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return 0, false
+	}
+	defer windows.CloseHandle(handle)
+
+	var creation, exit, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(handle, &creation, &exit, &kernel, &user); err != nil {
+		return 0, false
+	}
+	return uint64(creation.Nanoseconds()), true
+}
+
+// terminateProcess asks a process to exit. Processes cannot be signalled on
+// Windows: it is killed, and ssh is killed separately.
+func terminateProcess(pid int) {
+	killProcess(pid)
+}
+
+// killProcess kills a process
+func killProcess(pid int) {
+	if process, err := os.FindProcess(pid); err == nil {
+		process.Kill()
+	}
+}
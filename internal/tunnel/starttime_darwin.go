@@ -0,0 +1,13 @@
+package tunnel
+
+import "golang.org/x/sys/unix"
+
+// processStartTime returns when a process started, in microseconds since the epoch
+func processStartTime(pid int) (uint64, bool) {
+	info, err := unix.SysctlKinfoProc("kern.proc.pid", pid)
+	if err != nil {
+		return 0, false
+	}
+	start := info.Proc.P_starttime
+	return uint64(start.Sec)*1e6 + uint64(start.Usec), true
+}
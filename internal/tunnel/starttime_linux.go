@@ -0,0 +1,28 @@
+package tunnel
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// processStartTime returns when a process started, in clock ticks since boot
+func processStartTime(pid int) (uint64, bool) {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return 0, false
+	}
+
+	// The command name, in parentheses, may contain spaces: the fields
+	// are counted from the end of it, starttime being the 22nd
+	i := strings.LastIndexByte(string(data), ')')
+	if i < 0 {
+		return 0, false
+	}
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 20 {
+		return 0, false
+	}
+	start, err := strconv.ParseUint(fields[19], 10, 64)
+	return start, err == nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package tunnel
+
+// processStartTime cannot tell when a process started on this system
+func processStartTime(pid int) (uint64, bool) {
+	return 0, false
+}
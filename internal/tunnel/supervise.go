@@ -0,0 +1,216 @@
+package tunnel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backoff between two restarts of a tunnel that drops. The delay doubles
+// each time the tunnel drops soon after starting, up to maxBackoff.
+var (
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+	// stableAfter is how long ssh must have run for the delay to be reset
+	stableAfter = 30 * time.Second
+)
+
+// errRemoved is returned when the state of the tunnel was removed, meaning
+// the tunnel was stopped
+var errRemoved = errors.New("tunnel removed")
+
+// SSHArgs returns the arguments of the ssh process of a tunnel. ssh exits
+// if a forward cannot be set up or the server stops answering, and never
+// prompts since it runs without terminal.
+func SSHArgs(t *Tunnel) []string {
+	var args []string
+	if t.ConfigFile != "" {
+		args = append(args, "-F", t.ConfigFile)
+	}
+	args = append(args,
+		"-N",
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "BatchMode=yes",
+		"-o", "ServerAliveInterval=15",
+		"-o", "ServerAliveCountMax=3",
+	)
+	for _, forward := range t.Forwards {
+		args = append(args, forward.Flag(), forward.Spec())
+	}
+	return append(args, t.HostName)
+}
+
+// supervisor runs the ssh process of a tunnel and records its state
+type supervisor struct {
+	dir       string
+	tunnel    *Tunnel
+	sshBinary string
+}
+
+// save writes the state of the tunnel, keeping the auto-restart policy
+// that may have been changed since
+func (s *supervisor) save() error {
+	current, err := readState(s.dir, s.tunnel.ID)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return errRemoved
+		}
+		return err
+	}
+	s.tunnel.AutoRestart = current.AutoRestart
+	return writeState(s.dir, s.tunnel)
+}
+
+// Supervise runs the ssh process of a tunnel until ctx is cancelled or the
+// tunnel is removed. When ssh exits, it is started again after a delay if
+// the tunnel auto-restarts, otherwise the tunnel is marked failed.
+func Supervise(ctx context.Context, dir, id, sshBinary string) error {
+	t, err := readState(dir, id)
+	if err != nil {
+		return err
+	}
+	t.SupervisorPID = os.Getpid()
+	t.SupervisorStart, _ = processStartTime(t.SupervisorPID)
+	s := &supervisor{dir: dir, tunnel: t, sshBinary: sshBinary}
+
+	delay := minBackoff
+	for {
+		ran, err := s.run(ctx)
+		if err != nil || ctx.Err() != nil {
+			if errors.Is(err, errRemoved) {
+				return nil
+			}
+			return err
+		}
+
+		if !s.tunnel.AutoRestart {
+			s.tunnel.State = StateFailed
+			s.tunnel.NextRestart = time.Time{}
+			return ignoreRemoved(s.save())
+		}
+
+		if ran >= stableAfter {
+			delay = minBackoff
+		}
+		s.tunnel.State = StateRestarting
+		s.tunnel.NextRestart = time.Now().Add(delay)
+		s.tunnel.Restarts++
+		if err := s.save(); err != nil {
+			return ignoreRemoved(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxBackoff)
+	}
+}
+
+// ignoreRemoved returns nil for errRemoved
+func ignoreRemoved(err error) error {
+	if errors.Is(err, errRemoved) {
+		return nil
+	}
+	return err
+}
+
+// run runs ssh until it exits or ctx is cancelled, and returns how long it
+// ran. The state of the tunnel is saved while it runs and once it exited,
+// except when ctx is cancelled.
+func (s *supervisor) run(ctx context.Context) (time.Duration, error) {
+	t := s.tunnel
+	t.State = StateStarting
+	t.PID, t.PIDStart = 0, 0
+	t.NextRestart = time.Time{}
+	if err := s.save(); err != nil {
+		return 0, err
+	}
+
+	var stderr tailBuffer
+	cmd := exec.Command(s.sshBinary, SSHArgs(t)...)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		t.LastError = err.Error()
+		return 0, s.save()
+	}
+
+	t.State = StateRunning
+	t.PID = cmd.Process.Pid
+	t.PIDStart, _ = processStartTime(t.PID)
+	t.StartedAt = time.Now()
+	if err := s.save(); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return 0, err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var err error
+	select {
+	case <-ctx.Done():
+		cmd.Process.Kill()
+		<-done
+		return time.Since(t.StartedAt), nil
+	case err = <-done:
+	}
+
+	ran := time.Since(t.StartedAt)
+	t.PID, t.PIDStart = 0, 0
+	t.LastError = exitReason(err, stderr.lastLine())
+	return ran, nil
+}
+
+// exitReason describes why ssh exited, from its last line of error output
+func exitReason(err error, lastLine string) string {
+	if lastLine != "" {
+		return lastLine
+	}
+	if err == nil {
+		return "ssh exited"
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Sprintf("ssh exited with status %d", exitErr.ExitCode())
+	}
+	return err.Error()
+}
+
+// maxTail is the amount of error output of ssh kept to explain why it exited
+const maxTail = 4096
+
+// tailBuffer keeps the end of what is written to it
+type tailBuffer struct {
+	mutex sync.Mutex
+	data  []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.data = append(b.data, p...)
+	if len(b.data) > maxTail {
+		b.data = b.data[len(b.data)-maxTail:]
+	}
+	return len(p), nil
+}
+
+// lastLine returns the last non-empty line written
+func (b *tailBuffer) lastLine() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	lines := bytes.Split(bytes.TrimSpace(b.data), []byte("\n"))
+	return strings.TrimSpace(string(lines[len(lines)-1]))
+}
@@ -0,0 +1,495 @@
+package tunnel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/history"
+)
+
+// State is the state of a tunnel as recorded by its supervisor
+type State string
+
+const (
+	StateStarting   State = "starting"   // ssh is being started
+	StateRunning    State = "running"    // ssh is running
+	StateRestarting State = "restarting" // ssh exited and will be started again
+	StateFailed     State = "failed"     // ssh exited and will not be started again
+)
+
+// Tunnel is a port forwarding ssh process running in the background. Its
+// state is written by the supervisor process watching over it.
+type Tunnel struct {
+	ID              string                      `json:"id"`
+	HostName        string                      `json:"host_name"`
+	Profile         string                      `json:"profile,omitempty"`
+	Forwards        []history.PortForwardConfig `json:"forwards"`
+	ConfigFile      string                      `json:"config_file,omitempty"`
+	AutoRestart     bool                        `json:"auto_restart"`
+	State           State                       `json:"state"`
+	SupervisorPID   int                         `json:"supervisor_pid,omitempty"`
+	SupervisorStart uint64                      `json:"supervisor_start,omitempty"` // Start time of the supervisor, telling it apart from a process given its PID later
+	PID             int                         `json:"pid,omitempty"`              // Process of ssh, 0 when it is not running
+	PIDStart        uint64                      `json:"pid_start,omitempty"`        // Start time of ssh
+	CreatedAt       time.Time                   `json:"created_at"`
+	StartedAt       time.Time                   `json:"started_at,omitempty"` // When ssh was last started
+	Restarts        int                         `json:"restarts,omitempty"`
+	NextRestart     time.Time                   `json:"next_restart,omitempty"`
+	LastError       string                      `json:"last_error,omitempty"`
+}
+
+// Health describes whether a tunnel is working, as seen from this machine
+type Health struct {
+	OK      bool
+	Problem string // Why the tunnel is not working
+}
+
+// String returns a short description of the health
+func (h Health) String() string {
+	if h.OK {
+		return "healthy"
+	}
+	return h.Problem
+}
+
+// Summary returns the forwards of the tunnel on one line
+func (t *Tunnel) Summary() string {
+	return history.PortForwardProfile{Forwards: t.Forwards}.Summary()
+}
+
+// Uptime returns how long ssh has been running, or 0 if it is not running
+func (t *Tunnel) Uptime() time.Duration {
+	if t.State != StateRunning || t.StartedAt.IsZero() {
+		return 0
+	}
+	return time.Since(t.StartedAt)
+}
+
+// Supervised reports whether the supervisor of the tunnel is still running
+func (t *Tunnel) Supervised() bool {
+	return sameProcess(t.SupervisorPID, t.SupervisorStart)
+}
+
+// sshRunning reports whether the ssh process of the tunnel is still running
+func (t *Tunnel) sshRunning() bool {
+	return sameProcess(t.PID, t.PIDStart)
+}
+
+// sameProcess reports whether pid is still the process that started at
+// start, and not another process that was given its PID since it exited
+func sameProcess(pid int, start uint64) bool {
+	if pid == 0 || start == 0 || !processAlive(pid) {
+		return false
+	}
+	current, ok := processStartTime(pid)
+	return ok && current == start
+}
+
+// CheckHealth checks the supervisor and ssh are running and the local ends
+// of the forwards accept connections. Remote ends cannot be checked from here.
+func (t *Tunnel) CheckHealth(timeout time.Duration) Health {
+	switch {
+	case t.State == StateFailed:
+		return Health{Problem: "failed: " + orUnknown(t.LastError)}
+	case !t.Supervised():
+		return Health{Problem: "supervisor not running"}
+	case t.State == StateStarting:
+		return Health{Problem: "starting"}
+	case t.State == StateRestarting:
+		problem := "restarting"
+		if !t.NextRestart.IsZero() {
+			problem = fmt.Sprintf("restarting in %s", time.Until(t.NextRestart).Round(time.Second))
+		}
+		if t.LastError != "" {
+			problem += " (" + t.LastError + ")"
+		}
+		return Health{Problem: problem}
+	case !t.sshRunning():
+		return Health{Problem: "ssh not running"}
+	}
+
+	for _, forward := range t.Forwards {
+		if forward.Type == "remote" {
+			continue
+		}
+		network, address := "tcp", localAddress(forward)
+		if forward.ListenSocket() {
+			network, address = "unix", forward.LocalPort
+		}
+		conn, err := net.DialTimeout(network, address, timeout)
+		if err != nil {
+			return Health{Problem: fmt.Sprintf("%s not accepting connections", address)}
+		}
+		conn.Close()
+	}
+	return Health{OK: true}
+}
+
+// localAddress returns the address a local or dynamic forward listens on
+func localAddress(forward history.PortForwardConfig) string {
+	host := history.UnbracketAddress(forward.BindAddress)
+	if host == "" || host == "*" || host == "0.0.0.0" {
+		host = "127.0.0.1"
+	} else if host == "::" {
+		host = "::1"
+	}
+	return net.JoinHostPort(host, forward.LocalPort)
+}
+
+// orUnknown returns value, or "unknown error" if it is empty
+func orUnknown(value string) string {
+	if value == "" {
+		return "unknown error"
+	}
+	return value
+}
+
+// Manager starts, stops and lists the tunnels whose state is kept in a directory
+type Manager struct {
+	dir string
+
+	// Supervisor is the command started in the background to supervise a
+	// tunnel, followed by the state directory and the tunnel ID
+	Supervisor []string
+}
+
+// GetTunnelsDir returns the directory the state of the tunnels is kept in
+func GetTunnelsDir() (string, error) {
+	configDir, err := config.GetSSHMConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "tunnels"), nil
+}
+
+// NewManager creates a manager of the tunnels whose state is kept in dir.
+// Supervisors are started with "<this executable> tunnel supervise".
+func NewManager(dir string) (*Manager, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{dir: dir, Supervisor: []string{executable, "tunnel", "supervise"}}, nil
+}
+
+// NewDefaultManager creates a manager of the tunnels kept in the sshm config directory
+func NewDefaultManager() (*Manager, error) {
+	dir, err := GetTunnelsDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewManager(dir)
+}
+
+// statePath returns the path of the state file of a tunnel
+func statePath(dir, id string) string {
+	return filepath.Join(dir, id+".json")
+}
+
+// readState reads the state of a tunnel
+func readState(dir, id string) (*Tunnel, error) {
+	data, err := os.ReadFile(statePath(dir, id))
+	if err != nil {
+		return nil, err
+	}
+	var t Tunnel
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("invalid state of tunnel %s: %w", id, err)
+	}
+	return &t, nil
+}
+
+// writeState writes the state of a tunnel, replacing the previous one at once
+func writeState(dir string, t *Tunnel) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, t.ID+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), statePath(dir, t.ID)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// List returns the tunnels sorted by ID
+func (m *Manager) List() ([]*Tunnel, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var tunnels []*Tunnel
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		t, err := readState(m.dir, id)
+		if err != nil {
+			// Removed while listing, or not a tunnel
+			continue
+		}
+		tunnels = append(tunnels, t)
+	}
+
+	sort.Slice(tunnels, func(i, j int) bool {
+		return tunnels[i].ID < tunnels[j].ID
+	})
+	return tunnels, nil
+}
+
+// Get returns the tunnel with the given ID
+func (m *Manager) Get(id string) (*Tunnel, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("no tunnel %s", id)
+	}
+	t, err := readState(m.dir, id)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no tunnel %s", id)
+	}
+	return t, err
+}
+
+// newID returns an ID for a new tunnel of a host, from the profile name if
+// there is one, that no other tunnel uses
+func (m *Manager) newID(hostName, profile string) string {
+	base := hostName
+	if profile != "" {
+		base += "-" + profile
+	}
+	base = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ' ' || r == ':' {
+			return '_'
+		}
+		return r
+	}, base)
+
+	id := base
+	for n := 2; ; n++ {
+		if _, err := os.Stat(statePath(m.dir, id)); os.IsNotExist(err) {
+			return id
+		}
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// Start starts a tunnel setting up the forwards of a profile of a host,
+// read from configFile if it is set, in the background. It waits up to wait
+// for ssh to be running, so that forwards failing right away are reported:
+// the tunnel is then removed.
+func (m *Manager) Start(hostName string, profile history.PortForwardProfile, configFile string, autoRestart bool, wait time.Duration) (*Tunnel, error) {
+	if _, err := profile.SSHArgs(); err != nil {
+		return nil, err
+	}
+	if len(profile.Forwards) == 0 {
+		return nil, fmt.Errorf("no forward to set up")
+	}
+
+	// The supervisor does not run from the current directory
+	if configFile != "" {
+		absolute, err := filepath.Abs(configFile)
+		if err != nil {
+			return nil, err
+		}
+		configFile = absolute
+	}
+
+	t := &Tunnel{
+		ID:          m.newID(hostName, profile.Name),
+		HostName:    hostName,
+		Profile:     profile.Name,
+		Forwards:    profile.Forwards,
+		ConfigFile:  configFile,
+		AutoRestart: autoRestart,
+		State:       StateStarting,
+		CreatedAt:   time.Now(),
+	}
+	if err := writeState(m.dir, t); err != nil {
+		return nil, err
+	}
+
+	started, err := m.spawn(t, wait)
+	if err != nil {
+		m.Stop(t.ID)
+	}
+	return started, err
+}
+
+// spawn starts the supervisor of a tunnel whose state was written
+func (m *Manager) spawn(t *Tunnel, wait time.Duration) (*Tunnel, error) {
+	args := append(append([]string(nil), m.Supervisor[1:]...), m.dir, t.ID)
+	cmd := exec.Command(m.Supervisor[0], args...)
+	cmd.SysProcAttr = detachedProcAttr()
+	if err := cmd.Start(); err != nil {
+		os.Remove(statePath(m.dir, t.ID))
+		return nil, fmt.Errorf("could not start the tunnel supervisor: %w", err)
+	}
+
+	// The supervisor runs on its own: reap it if it exits while we are still around
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+
+	deadline := time.After(wait)
+	for {
+		select {
+		case <-exited:
+			current, err := readState(m.dir, t.ID)
+			if err != nil || current.State != StateFailed {
+				return current, fmt.Errorf("the tunnel supervisor exited")
+			}
+			return current, fmt.Errorf("tunnel failed: %s", orUnknown(current.LastError))
+		case <-deadline:
+			current, err := readState(m.dir, t.ID)
+			if err != nil {
+				return t, nil
+			}
+			return current, nil
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		current, err := readState(m.dir, t.ID)
+		if err != nil {
+			continue
+		}
+		switch {
+		case current.State == StateFailed:
+			return current, fmt.Errorf("tunnel failed: %s", orUnknown(current.LastError))
+		case current.State == StateRestarting:
+			return current, fmt.Errorf("tunnel dropped: %s", orUnknown(current.LastError))
+		case current.State == StateRunning && time.Since(current.StartedAt) >= startupGrace:
+			// ssh is still running after setting up the forwards
+			return current, nil
+		}
+	}
+}
+
+// startupGrace is how long ssh must have been running to consider the
+// tunnel started: with ExitOnForwardFailure, ssh exits if a forward fails
+const startupGrace = time.Second
+
+// Stop stops a tunnel and forgets it. Processes whose PID was recorded are
+// only signalled if they are still the ones that started the tunnel: the
+// state of a tunnel whose processes exited is removed without signalling.
+func (m *Manager) Stop(id string) error {
+	t, err := m.Get(id)
+	if err != nil {
+		return err
+	}
+
+	if t.Supervised() {
+		// The supervisor stops ssh when it is terminated
+		terminateProcess(t.SupervisorPID)
+		if !waitExit(t.Supervised, 5*time.Second) {
+			killProcess(t.SupervisorPID)
+		}
+	}
+	if t.sshRunning() {
+		killProcess(t.PID)
+	}
+
+	if err := os.Remove(statePath(m.dir, id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Restart stops a tunnel and starts it again with the same forwards
+func (m *Manager) Restart(id string, wait time.Duration) (*Tunnel, error) {
+	t, err := m.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Stop(id); err != nil {
+		return nil, err
+	}
+
+	restarted := &Tunnel{
+		ID:          t.ID,
+		HostName:    t.HostName,
+		Profile:     t.Profile,
+		Forwards:    t.Forwards,
+		ConfigFile:  t.ConfigFile,
+		AutoRestart: t.AutoRestart,
+		State:       StateStarting,
+		CreatedAt:   t.CreatedAt,
+		Restarts:    t.Restarts + 1,
+	}
+	if err := writeState(m.dir, restarted); err != nil {
+		return nil, err
+	}
+	return m.spawn(restarted, wait)
+}
+
+// SetAutoRestart changes whether a tunnel is restarted when it drops. The
+// supervisor reads it again when ssh exits.
+func (m *Manager) SetAutoRestart(id string, autoRestart bool) error {
+	t, err := m.Get(id)
+	if err != nil {
+		return err
+	}
+	t.AutoRestart = autoRestart
+	return writeState(m.dir, t)
+}
+
+// waitExit waits up to timeout for a process to exit, running reporting
+// whether it is still running
+func waitExit(running func() bool, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if !running() {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return !running()
+}
+
+// FormatUptime formats an uptime compactly, e.g. "45s", "12m05s", "3h20m" or "2d04h"
+func FormatUptime(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "-"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd%02dh", int(d.Hours()/24), int(d.Hours())%24)
+	}
+}
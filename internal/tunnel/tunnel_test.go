@@ -0,0 +1,351 @@
+package tunnel
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/history"
+)
+
+// TestMain runs the supervisor when the test binary is started by a
+// manager as the supervisor of a tunnel, as "sshm tunnel supervise" does
+func TestMain(m *testing.M) {
+	if sshBinary := os.Getenv("SSHM_TEST_SUPERVISOR_SSH"); sshBinary != "" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		args := os.Args[len(os.Args)-2:]
+		if err := Supervise(ctx, args[0], args[1], sshBinary); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeSSH writes a script standing in for ssh and returns its path
+func fakeSSH(t *testing.T, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ssh is a shell script")
+	}
+
+	path := filepath.Join(t.TempDir(), "ssh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testManager returns a manager starting this test binary as supervisor,
+// running the given fake ssh
+func testManager(t *testing.T, sshBinary string) *Manager {
+	t.Helper()
+	t.Setenv("SSHM_TEST_SUPERVISOR_SSH", sshBinary)
+	return &Manager{dir: t.TempDir(), Supervisor: []string{os.Args[0]}}
+}
+
+var testProfile = history.PortForwardProfile{
+	Name:     "postgres",
+	Forwards: []history.PortForwardConfig{{Type: "local", LocalPort: "15432", RemoteHost: "localhost", RemotePort: "5432"}},
+}
+
+func TestSSHArgs(t *testing.T) {
+	tunnel := &Tunnel{HostName: "db1", ConfigFile: "/tmp/config", Forwards: testProfile.Forwards}
+	want := []string{
+		"-F", "/tmp/config", "-N",
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "BatchMode=yes",
+		"-o", "ServerAliveInterval=15",
+		"-o", "ServerAliveCountMax=3",
+		"-L", "15432:localhost:5432", "db1",
+	}
+	if got := SSHArgs(tunnel); !reflect.DeepEqual(got, want) {
+		t.Errorf("SSHArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestSuperviseFailure(t *testing.T) {
+	ssh := fakeSSH(t, "echo 'bind [127.0.0.1]:15432: Address already in use' >&2\necho 'Could not request local forwarding.' >&2\nexit 255\n")
+	dir := t.TempDir()
+	if err := writeState(dir, &Tunnel{ID: "db1", HostName: "db1", Forwards: testProfile.Forwards}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Supervise(context.Background(), dir, "db1", ssh); err != nil {
+		t.Fatalf("Supervise() error = %v", err)
+	}
+
+	tunnel, err := readState(dir, "db1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tunnel.State != StateFailed || tunnel.LastError != "Could not request local forwarding." || tunnel.PID != 0 {
+		t.Errorf("Expected a failed tunnel with the last error of ssh, got %+v", tunnel)
+	}
+}
+
+func TestSuperviseAutoRestart(t *testing.T) {
+	defer func(previous time.Duration) { minBackoff = previous }(minBackoff)
+	minBackoff = 10 * time.Millisecond
+
+	// ssh drops right away: each restart waits twice as long as the previous one
+	ssh := fakeSSH(t, "exit 255\n")
+	dir := t.TempDir()
+	if err := writeState(dir, &Tunnel{ID: "db1", HostName: "db1", Forwards: testProfile.Forwards, AutoRestart: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- Supervise(ctx, dir, "db1", ssh) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	var tunnel *Tunnel
+	for time.Now().Before(deadline) {
+		if tunnel, _ = readState(dir, "db1"); tunnel != nil && tunnel.Restarts >= 3 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if tunnel == nil || tunnel.Restarts < 3 {
+		t.Fatalf("Expected the tunnel to be restarted, got %+v", tunnel)
+	}
+	if tunnel.LastError != "ssh exited with status 255" {
+		t.Errorf("Expected the exit status as last error, got %q", tunnel.LastError)
+	}
+
+	// Turning auto-restart off makes the next drop final
+	tunnel.AutoRestart = false
+	if err := writeState(dir, tunnel); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Supervise() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		cancel()
+		t.Fatal("Expected the supervisor to stop once auto-restart is off")
+	}
+	cancel()
+
+	if tunnel, _ = readState(dir, "db1"); tunnel.State != StateFailed {
+		t.Errorf("Expected a failed tunnel, got %s", tunnel.State)
+	}
+}
+
+func TestManagerStartStop(t *testing.T) {
+	manager := testManager(t, fakeSSH(t, "exec sleep 30\n"))
+
+	tunnel, err := manager.Start("db1", testProfile, "", false, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if tunnel.ID != "db1-postgres" || tunnel.State != StateRunning || tunnel.PID == 0 {
+		t.Fatalf("Expected a running tunnel, got %+v", tunnel)
+	}
+	if !tunnel.Supervised() || !tunnel.sshRunning() {
+		t.Fatal("Expected the supervisor and ssh to be running")
+	}
+
+	// A second tunnel of the same profile gets another ID
+	if id := manager.newID("db1", "postgres"); id != "db1-postgres-2" {
+		t.Errorf("newID() = %q, want db1-postgres-2", id)
+	}
+
+	tunnels, err := manager.List()
+	if err != nil || len(tunnels) != 1 {
+		t.Fatalf("List() = %v, %v, want one tunnel", tunnels, err)
+	}
+
+	restarted, err := manager.Restart(tunnel.ID, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Restart() error = %v", err)
+	}
+	if restarted.PID == tunnel.PID || restarted.Restarts != 1 || !waitExit(tunnel.sshRunning, time.Second) {
+		t.Errorf("Expected ssh to be started again, got %+v", restarted)
+	}
+
+	if err := manager.Stop(tunnel.ID); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if !waitExit(restarted.sshRunning, time.Second) {
+		t.Errorf("Expected ssh to be stopped")
+	}
+	if !waitExit(restarted.Supervised, time.Second) {
+		t.Errorf("Expected the supervisor to be stopped")
+	}
+	if _, err := manager.Get(tunnel.ID); err == nil {
+		t.Error("Expected the stopped tunnel to be forgotten")
+	}
+}
+
+func TestManagerStartConfigFile(t *testing.T) {
+	manager := testManager(t, fakeSSH(t, "exec sleep 30\n"))
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	tunnel, err := manager.Start("db1", testProfile, "config", false, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer manager.Stop(tunnel.ID)
+
+	if want, _ := filepath.Abs("config"); tunnel.ConfigFile != want {
+		t.Errorf("ConfigFile = %q, want the absolute path %q", tunnel.ConfigFile, want)
+	}
+}
+
+func TestManagerStopStale(t *testing.T) {
+	self := os.Getpid()
+	selfStart, ok := processStartTime(self)
+	if !ok {
+		t.Skip("process start times are not available on this system")
+	}
+
+	// The processes of the tunnel exited and their PIDs were given to this
+	// test process: stopping the tunnel must not signal it
+	manager := &Manager{dir: t.TempDir()}
+	stale := &Tunnel{ID: "db1", HostName: "db1", State: StateRunning, SupervisorPID: self, SupervisorStart: selfStart - 1, PID: self, PIDStart: selfStart - 1}
+	if err := writeState(manager.dir, stale); err != nil {
+		t.Fatal(err)
+	}
+	if stale.Supervised() {
+		t.Fatal("Expected a process started at another time not to be the supervisor")
+	}
+
+	if err := manager.Stop(stale.ID); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if _, err := manager.Get(stale.ID); err == nil {
+		t.Error("Expected the stale tunnel to be forgotten")
+	}
+}
+
+func TestProcessStartTime(t *testing.T) {
+	start, ok := processStartTime(os.Getpid())
+	if !ok {
+		t.Skip("process start times are not available on this system")
+	}
+	if start == 0 {
+		t.Error("Expected a start time")
+	}
+	if again, _ := processStartTime(os.Getpid()); again != start {
+		t.Errorf("processStartTime() = %d then %d, want the same time", start, again)
+	}
+	if _, ok := processStartTime(closedPID()); ok {
+		t.Error("Expected no start time for a process that exited")
+	}
+}
+
+// closedPID returns the PID of a process that exited
+func closedPID() int {
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		return 0
+	}
+	return cmd.Process.Pid
+}
+
+func TestManagerStartFailure(t *testing.T) {
+	manager := testManager(t, fakeSSH(t, "echo 'Could not request local forwarding.' >&2\nexit 255\n"))
+
+	_, err := manager.Start("db1", testProfile, "", false, 5*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "Could not request local forwarding.") {
+		t.Errorf("Start() error = %v, want the error of ssh", err)
+	}
+	if tunnels, _ := manager.List(); len(tunnels) != 0 {
+		t.Errorf("Expected the tunnel that failed to start to be removed, got %+v", tunnels)
+	}
+}
+
+func TestCheckHealth(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	self := os.Getpid()
+	selfStart, ok := processStartTime(self)
+	if !ok {
+		t.Skip("process start times are not available on this system")
+	}
+	running := func(forwards ...history.PortForwardConfig) *Tunnel {
+		return &Tunnel{State: StateRunning, SupervisorPID: self, SupervisorStart: selfStart, PID: self, PIDStart: selfStart, Forwards: forwards}
+	}
+
+	tests := []struct {
+		name    string
+		tunnel  *Tunnel
+		wantOK  bool
+		problem string
+	}{
+		{"listening", running(history.PortForwardConfig{Type: "local", LocalPort: port, RemotePort: "80"}), true, ""},
+		{"remote forwards are not checked", running(history.PortForwardConfig{Type: "remote", LocalPort: "1", RemotePort: "80"}), true, ""},
+		{"not listening", running(history.PortForwardConfig{Type: "dynamic", LocalPort: strconv.Itoa(closedPort(t))}), false, "not accepting connections"},
+		{"socket not listening", running(history.PortForwardConfig{Type: "local", LocalPort: "/nonexistent/docker.sock", RemotePort: "/var/run/docker.sock"}), false, "/nonexistent/docker.sock not accepting connections"},
+		{"failed", &Tunnel{State: StateFailed, LastError: "Permission denied"}, false, "failed: Permission denied"},
+		{"no supervisor", &Tunnel{State: StateRunning}, false, "supervisor not running"},
+		{"supervisor PID reused", &Tunnel{State: StateRunning, SupervisorPID: self, SupervisorStart: selfStart + 1}, false, "supervisor not running"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := tt.tunnel.CheckHealth(time.Second)
+			if health.OK != tt.wantOK || !strings.Contains(health.Problem, tt.problem) {
+				t.Errorf("CheckHealth() = %+v, want ok %v with %q", health, tt.wantOK, tt.problem)
+			}
+		})
+	}
+}
+
+// closedPort returns a local port nothing listens on
+func closedPort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+	return port
+}
+
+func TestFormatUptime(t *testing.T) {
+	tests := []struct {
+		uptime time.Duration
+		want   string
+	}{
+		{0, "-"},
+		{45 * time.Second, "45s"},
+		{12*time.Minute + 5*time.Second, "12m05s"},
+		{3*time.Hour + 20*time.Minute, "3h20m"},
+		{52 * time.Hour, "2d04h"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatUptime(tt.uptime); got != tt.want {
+			t.Errorf("FormatUptime(%s) = %q, want %q", tt.uptime, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,520 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+	"github.com/Gu1llaum-3/sshm/internal/forward"
+	"github.com/Gu1llaum-3/sshm/internal/history"
+	"github.com/Gu1llaum-3/sshm/internal/tunnel"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Actions of the host list. They are shared by the key bindings and the command palette.
+
+// editorFinishedMsg is sent when the external editor opened on a config file exits
+type editorFinishedMsg struct {
+	err error
+}
+
+// showError displays a transient error message above the search bar
+func (m *Model) showError(message string) tea.Cmd {
+	m.errorMessage = message
+	m.showingError = true
+	return func() tea.Msg {
+		time.Sleep(3 * time.Second) // Show error for 3 seconds
+		return errorMsg("clear")
+	}
+}
+
+// selectedHostName returns the name of the host under the table cursor
+func (m *Model) selectedHostName() (string, bool) {
+	selected := m.table.SelectedRow()
+	if len(selected) == 0 {
+		return "", false
+	}
+	return extractHostNameFromTableRow(selected[m.nameColumnIndex()]), true // Extract hostname from the Name column
+}
+
+// reloadHosts parses the SSH configuration again and refreshes the table,
+// keeping the active search filter
+func (m *Model) reloadHosts() error {
+	var hosts []config.SSHHost
+	var err error
+
+	if m.configFile != "" {
+		hosts, err = config.ParseSSHConfigFile(m.configFile)
+	} else {
+		hosts, err = config.ParseSSHConfig()
+	}
+	if err != nil {
+		return err
+	}
+	m.hosts = m.sortHosts(hosts)
+
+	// Reapply search filter if there is one active
+	if m.searchInput.Value() != "" {
+		m.filteredHosts = m.filterHosts(m.searchInput.Value())
+	} else {
+		m.filteredHosts = m.hosts
+	}
+
+	m.updateTableRows()
+	return nil
+}
+
+// connectToSelectedHost connects to the selected host with ssh
+func (m Model) connectToSelectedHost() (tea.Model, tea.Cmd) {
+	hostName, ok := m.selectedHostName()
+	if !ok {
+		return m, nil
+	}
+
+	// Record the connection in history
+	if m.historyManager != nil {
+		err := m.historyManager.RecordConnection(hostName)
+		if err != nil {
+			// Log the error but don't prevent the connection
+			fmt.Printf("Warning: Could not record connection history: %v\n", err)
+		}
+	}
+
+	// Build the SSH command with the appropriate config file
+	var sshCmd *exec.Cmd
+	if m.configFile != "" {
+		sshCmd = exec.Command("ssh", "-F", m.configFile, hostName)
+	} else {
+		sshCmd = exec.Command("ssh", hostName)
+	}
+
+	// The checks would compete with the session for the network
+	m.cancelPings()
+
+	return m, runSession(sshCmd, hostName, false)
+}
+
+// openEditForm opens the edit form for the selected host
+func (m Model) openEditForm() (tea.Model, tea.Cmd) {
+	hostName, ok := m.selectedHostName()
+	if !ok {
+		return m, nil
+	}
+
+	editForm, err := NewEditForm(hostName, m.styles, m.width, m.height, m.configFile)
+	if err != nil {
+		// Handle error - could show in UI
+		return m, nil
+	}
+	m.editForm = editForm
+	m.viewMode = ViewEdit
+	return m, textinput.Blink
+}
+
+// openMoveForm opens the form moving the selected host to another config file
+func (m Model) openMoveForm() (tea.Model, tea.Cmd) {
+	hostName, ok := m.selectedHostName()
+	if !ok {
+		return m, nil
+	}
+
+	moveForm, err := NewMoveForm(hostName, m.styles, m.width, m.height, m.configFile)
+	if err != nil {
+		// Show error message to user
+		return m, m.showError(err.Error())
+	}
+	m.moveForm = moveForm
+	m.viewMode = ViewMove
+	return m, textinput.Blink
+}
+
+// moveSelectedHostToFile moves the selected host to the given config file
+func (m Model) moveSelectedHostToFile(targetFile string) (tea.Model, tea.Cmd) {
+	hostName, ok := m.selectedHostName()
+	if !ok {
+		return m, nil
+	}
+
+	return m, func() tea.Msg {
+		err := config.MoveHostToFile(hostName, targetFile)
+		return moveFormSubmitMsg{hostName: hostName, targetFile: targetFile, err: err}
+	}
+}
+
+// openInfoForm shows the information view of the selected host
+func (m Model) openInfoForm() (tea.Model, tea.Cmd) {
+	hostName, ok := m.selectedHostName()
+	if !ok {
+		return m, nil
+	}
+
+	infoForm, err := NewInfoForm(hostName, m.styles, m.width, m.height, m.configFile)
+	if err != nil {
+		// Handle error - could show in UI
+		return m, nil
+	}
+	if m.pingManager != nil {
+		if result, ok := m.pingManager.GetResult(hostName); ok {
+			infoForm.pingResult = result
+			infoForm.pingStale = m.isStale(result)
+		}
+	}
+	if m.health != nil {
+		infoForm.healthSamples = m.health.Samples(hostName)
+		infoForm.uptime, _ = m.health.Uptime(hostName)
+	}
+	infoForm.pinned = m.monitorConfig().IsPinned(hostName)
+	if m.historyManager != nil {
+		infoForm.sessions, _ = m.historyManager.GetSessions(history.SessionFilter{HostName: hostName, Limit: infoSessionCount})
+	}
+	m.infoForm = infoForm
+	m.viewMode = ViewInfo
+
+	// Find out in the background whether the host accepts our keys
+	cmd := m.probeAuthCmd(*infoForm.host)
+	infoForm.authProbing = cmd != nil
+	return m, cmd
+}
+
+// probeAuthCmd probes the authentication methods of a host
+func (m *Model) probeAuthCmd(host config.SSHHost) tea.Cmd {
+	if m.pingManager == nil {
+		return nil
+	}
+
+	// Jump hosts named in ProxyJump directives are resolved from the configured hosts
+	m.pingManager.SetHosts(m.hosts)
+	pm := m.pingManager
+	return func() tea.Msg {
+		return authProbeMsg{result: pm.ProbeAuth(context.Background(), host)}
+	}
+}
+
+// openAddForm opens the add form, asking for the target file first when
+// the configuration is split across several files
+func (m Model) openAddForm() (tea.Model, tea.Cmd) {
+	// Check if there are multiple config files starting from the current base config
+	var configFiles []string
+	var err error
+
+	if m.configFile != "" {
+		// Use the specified config file as base
+		configFiles, err = config.GetAllConfigFilesFromBase(m.configFile)
+	} else {
+		// Use the default config file as base
+		configFiles, err = config.GetAllConfigFiles()
+	}
+
+	if err != nil || len(configFiles) <= 1 {
+		// Only one config file (or error), go directly to add form
+		var configFile string
+		if len(configFiles) == 1 {
+			configFile = configFiles[0]
+		} else {
+			configFile = m.configFile
+		}
+		m.addForm = NewAddForm("", m.styles, m.width, m.height, configFile)
+		m.viewMode = ViewAdd
+	} else {
+		// Multiple config files, show file selector
+		fileSelectorForm, err := NewFileSelectorFromBase("Select config file to add host to:", m.styles, m.width, m.height, m.configFile)
+		if err != nil {
+			// Fallback to default behavior if file selector fails
+			m.addForm = NewAddForm("", m.styles, m.width, m.height, m.configFile)
+			m.viewMode = ViewAdd
+		} else {
+			m.fileSelectorForm = fileSelectorForm
+			m.viewMode = ViewFileSelector
+		}
+	}
+	return m, textinput.Blink
+}
+
+// startDeleteSelectedHost asks for confirmation before deleting the selected host
+func (m Model) startDeleteSelectedHost() (tea.Model, tea.Cmd) {
+	cursor := m.table.Cursor()
+	if cursor >= 0 && cursor < len(m.filteredHosts) {
+		// Get the host at the cursor position (which corresponds to filteredHosts index)
+		targetHost := &m.filteredHosts[cursor]
+
+		m.deleteMode = true
+		m.deleteHost = targetHost
+		m.table.Blur()
+	}
+	return m, nil
+}
+
+// openPortForwardForm opens the port forwarding form for the selected host
+func (m Model) openPortForwardForm() (tea.Model, tea.Cmd) {
+	hostName, ok := m.selectedHostName()
+	if !ok {
+		return m, nil
+	}
+
+	m.portForwardForm = NewPortForwardForm(hostName, m.styles, m.width, m.height, m.configFile, m.historyManager)
+	m.viewMode = ViewPortForward
+	return m, textinput.Blink
+}
+
+// openCommandForm prompts for a command to run on the selected host
+func (m Model) openCommandForm() (tea.Model, tea.Cmd) {
+	hostName, ok := m.selectedHostName()
+	if !ok {
+		return m, nil
+	}
+
+	m.commandForm = NewCommandForm(hostName, m.styles, m.width, m.height, m.configFile, m.historyManager)
+	m.viewMode = ViewCommand
+	return m, textinput.Blink
+}
+
+// tunnelStartedMsg is sent when a tunnel was started in the background or in sshm
+type tunnelStartedMsg struct {
+	id        string
+	inProcess bool // The tunnel runs in sshm rather than in the background
+	err       error
+}
+
+// getTunnelManager returns the manager of the tunnels, creating it on first use
+func (m *Model) getTunnelManager() (*tunnel.Manager, error) {
+	if m.tunnelManager == nil {
+		manager, err := tunnel.NewDefaultManager()
+		if err != nil {
+			return nil, err
+		}
+		m.tunnelManager = manager
+	}
+	return m.tunnelManager, nil
+}
+
+// openTunnels shows the tunnels running in the background, with the outcome
+// of the last action if there is one
+func (m Model) openTunnels(status string) (tea.Model, tea.Cmd) {
+	manager, err := m.getTunnelManager()
+	if err != nil {
+		return m, m.showError(fmt.Sprintf("Cannot manage tunnels: %v", err))
+	}
+
+	m.tunnelsForm = NewTunnelsForm(manager, m.forwardManager, m.styles, m.width, m.height)
+	m.tunnelsForm.status = status
+	m.viewMode = ViewTunnels
+	return m, m.tunnelsForm.Init()
+}
+
+// startTunnelCmd starts forwards of a host as a tunnel in the background
+func (m *Model) startTunnelCmd(hostName string, profile history.PortForwardProfile) tea.Cmd {
+	manager, err := m.getTunnelManager()
+	if err != nil {
+		return func() tea.Msg { return tunnelStartedMsg{err: err} }
+	}
+	configFile := m.configFile
+	return func() tea.Msg {
+		t, err := manager.Start(hostName, profile, configFile, false, tunnelStartWait)
+		if err != nil {
+			if t != nil {
+				err = fmt.Errorf("tunnel %s: %w", t.ID, err)
+			}
+			return tunnelStartedMsg{err: err}
+		}
+		return tunnelStartedMsg{id: t.ID}
+	}
+}
+
+// startInProcessCmd connects to a host and runs forwards over the
+// connection inside sshm, where their traffic can be followed
+func (m *Model) startInProcessCmd(hostName string, profile history.PortForwardProfile) tea.Cmd {
+	var host *config.SSHHost
+	for i := range m.hosts {
+		if m.hosts[i].Name == hostName {
+			host = &m.hosts[i]
+			break
+		}
+	}
+	if host == nil {
+		return func() tea.Msg { return tunnelStartedMsg{err: fmt.Errorf("host %s not found", hostName)} }
+	}
+	if m.pingManager == nil {
+		m.pingManager = connectivity.NewPingManager(5 * time.Second)
+	}
+	if m.forwardManager == nil {
+		m.forwardManager = forward.NewManager()
+	}
+
+	pingManager, manager, target := m.pingManager, m.forwardManager, *host
+	pingManager.SetHosts(m.hosts)
+	return func() tea.Msg {
+		client, err := pingManager.Connect(context.Background(), target)
+		if err != nil {
+			return tunnelStartedMsg{err: fmt.Errorf("could not connect to %s: %w", hostName, err)}
+		}
+		t, err := forward.Start(client, hostName, profile)
+		if err != nil {
+			client.Close()
+			return tunnelStartedMsg{err: err}
+		}
+		manager.Add(t)
+		return tunnelStartedMsg{id: t.ID, inProcess: true}
+	}
+}
+
+// pingSelectedHost checks the connectivity of the selected host
+func (m Model) pingSelectedHost() (tea.Model, tea.Cmd) {
+	host := m.selectedHost()
+	if host == nil || m.pingManager == nil {
+		return m, nil
+	}
+	m.pingManager.SetHosts(m.hosts)
+	return m, pingSingleHostCmd(m.pingManager, *host)
+}
+
+// openHelp shows the help window
+func (m Model) openHelp() (tea.Model, tea.Cmd) {
+	m.helpForm = NewHelpForm(m.styles, m.width, m.height)
+	m.viewMode = ViewHelp
+	return m, nil
+}
+
+// openColumnsForm shows the column picker
+func (m Model) openColumnsForm() (tea.Model, tea.Cmd) {
+	m.columnsForm = NewColumnsForm(m.columns, m.styles, m.width, m.height)
+	m.viewMode = ViewColumns
+	return m, nil
+}
+
+// togglePreview shows or hides the preview pane and remembers the choice
+func (m Model) togglePreview() (tea.Model, tea.Cmd) {
+	m.showPreview = !m.showPreview
+	m.updateTableHeight()
+	m.updateTableColumns()
+
+	if m.appConfig != nil {
+		m.appConfig.Layout.ShowPreview = m.showPreview
+		return m, m.saveAppConfig()
+	}
+	return m, nil
+}
+
+// setSortMode changes the sort mode and re-applies the current filter
+func (m Model) setSortMode(mode SortMode) (tea.Model, tea.Cmd) {
+	m.sortMode = mode
+	// Re-apply the current filter with the new sort mode
+	if m.searchInput.Value() != "" {
+		m.filteredHosts = m.filterHosts(m.searchInput.Value())
+	} else {
+		m.filteredHosts = m.sortHosts(m.hosts)
+	}
+	m.updateTableRows()
+	return m, nil
+}
+
+// refreshSort re-applies the filter and sort mode after the sort keys of the
+// hosts changed, keeping the cursor on the selected host
+func (m *Model) refreshSort() {
+	selected, hasSelection := m.selectedHostName()
+
+	if m.searchInput.Value() != "" {
+		m.filteredHosts = m.filterHosts(m.searchInput.Value())
+	} else {
+		m.filteredHosts = m.sortHosts(m.hosts)
+	}
+	m.updateTableRows()
+
+	if hasSelection {
+		for i, host := range m.filteredHosts {
+			if host.Name == selected {
+				m.table.SetCursor(i)
+				break
+			}
+		}
+	}
+}
+
+// changedHostKeysFilter is the search selecting hosts whose host key changed
+const changedHostKeysFilter = "key:changed"
+
+// toggleChangedHostKeysFilter shows only the hosts whose host key changed
+// since it was recorded in known_hosts, or clears that filter
+func (m Model) toggleChangedHostKeysFilter() (tea.Model, tea.Cmd) {
+	if m.searchInput.Value() == changedHostKeysFilter {
+		m.searchInput.SetValue("")
+		m.filteredHosts = m.sortHosts(m.hosts)
+	} else {
+		m.searchInput.SetValue(changedHostKeysFilter)
+		m.filteredHosts = m.filterHosts(changedHostKeysFilter)
+	}
+	m.updateTableRows()
+	m.table.SetCursor(0)
+	return m, nil
+}
+
+// switchTheme activates another colour theme and remembers the choice
+func (m Model) switchTheme(name string) (tea.Model, tea.Cmd) {
+	themeConfig := config.GetDefaultThemeConfig()
+	if m.appConfig != nil {
+		themeConfig = m.appConfig.Theme
+	}
+	themeConfig.Name = name
+
+	ApplyTheme(themeConfig)
+	m.styles = NewStyles(m.width)
+	m.updateTableStyles()
+
+	if m.appConfig != nil {
+		m.appConfig.Theme.Name = name
+		return m, m.saveAppConfig()
+	}
+	return m, nil
+}
+
+// reload parses the SSH configuration again
+func (m Model) reload() (tea.Model, tea.Cmd) {
+	if err := m.reloadHosts(); err != nil {
+		return m, m.showError(fmt.Sprintf("Could not reload SSH config: %v", err))
+	}
+	return m, nil
+}
+
+// editedConfigFile returns the config file to open in the editor: the file
+// defining the selected host, or the main config file
+func (m *Model) editedConfigFile() (string, error) {
+	if host := m.selectedHost(); host != nil && host.SourceFile != "" {
+		return host.SourceFile, nil
+	}
+	if m.configFile != "" {
+		return m.configFile, nil
+	}
+	return config.GetDefaultSSHConfigPath()
+}
+
+// openConfigInEditor opens a config file in $VISUAL or $EDITOR and reloads
+// the hosts when the editor exits
+func (m Model) openConfigInEditor() (tea.Model, tea.Cmd) {
+	configPath, err := m.editedConfigFile()
+	if err != nil {
+		return m, m.showError(fmt.Sprintf("Could not find SSH config file: %v", err))
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// The editor variable may contain arguments (e.g. "code --wait")
+	args := strings.Fields(editor)
+	args = append(args, configPath)
+	editorCmd := exec.Command(args[0], args[1:]...)
+
+	return m, tea.ExecProcess(editorCmd, func(err error) tea.Msg {
+		return editorFinishedMsg{err: err}
+	})
+}
@@ -49,7 +49,7 @@ func NewAddForm(hostname string, styles Styles, width, height int, configFile st
 		}
 	}
 
-	inputs := make([]textinput.Model, 11)
+	inputs := make([]textinput.Model, 12)
 
 	// Name input
 	inputs[nameInput] = textinput.New()
@@ -109,6 +109,12 @@ func NewAddForm(hostname string, styles Styles, width, height int, configFile st
 	inputs[tagsInput].CharLimit = 200
 	inputs[tagsInput].Width = 50
 
+	// Description input
+	inputs[descriptionInput] = textinput.New()
+	inputs[descriptionInput].Placeholder = "Production web server"
+	inputs[descriptionInput].CharLimit = 200
+	inputs[descriptionInput].Width = 50
+
 	// Remote Command input
 	inputs[remoteCommandInput] = textinput.New()
 	inputs[remoteCommandInput].Placeholder = "ls -la, htop, bash"
@@ -147,6 +153,7 @@ const (
 	proxyCommandInput
 	optionsInput
 	tagsInput
+	descriptionInput
 	// Advanced tab inputs
 	remoteCommandInput
 	requestTTYInput
@@ -236,11 +243,11 @@ func (m *addFormModel) getFirstInputForTab(tab int) int {
 func (m *addFormModel) getInputsForCurrentTab() []int {
 	switch m.currentTab {
 	case tabGeneral:
-		return []int{nameInput, hostnameInput, userInput, portInput, identityInput, proxyJumpInput, proxyCommandInput, tagsInput}
+		return []int{nameInput, hostnameInput, userInput, portInput, identityInput, proxyJumpInput, proxyCommandInput, tagsInput, descriptionInput}
 	case tabAdvanced:
 		return []int{optionsInput, remoteCommandInput, requestTTYInput}
 	default:
-		return []int{nameInput, hostnameInput, userInput, portInput, identityInput, proxyJumpInput, proxyCommandInput, tagsInput}
+		return []int{nameInput, hostnameInput, userInput, portInput, identityInput, proxyJumpInput, proxyCommandInput, tagsInput, descriptionInput}
 	}
 }
 
@@ -428,6 +435,7 @@ func (m *addFormModel) renderGeneralTab() string {
 		{proxyJumpInput, "ProxyJump"},
 		{proxyCommandInput, "ProxyCommand"},
 		{tagsInput, "Tags (comma-separated)"},
+		{descriptionInput, "Description"},
 	}
 
 	for _, field := range fields {
@@ -497,6 +505,7 @@ func (m standaloneAddForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // RunAddForm provides backward compatibility for standalone add form
 func RunAddForm(hostname string, configFile string) error {
+	applyConfiguredTheme()
 	styles := NewStyles(80)
 	addForm := NewAddForm(hostname, styles, 80, 24, configFile)
 	m := standaloneAddForm{addForm}
@@ -519,6 +528,7 @@ func (m *addFormModel) submitForm() tea.Cmd {
 		options := strings.TrimSpace(m.inputs[optionsInput].Value())
 		remoteCommand := strings.TrimSpace(m.inputs[remoteCommandInput].Value())
 		requestTTY := strings.TrimSpace(m.inputs[requestTTYInput].Value())
+		description := strings.TrimSpace(m.inputs[descriptionInput].Value())
 
 		// Set defaults
 		if user == "" {
@@ -557,6 +567,7 @@ func (m *addFormModel) submitForm() tea.Cmd {
 			Options:       config.ParseSSHOptionsFromCommand(options),
 			RemoteCommand: remoteCommand,
 			RequestTTY:    requestTTY,
+			Description:   description,
 			Tags:          tags,
 		}
 
@@ -0,0 +1,273 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+)
+
+// columnDef describes a column the host table knows how to display
+type columnDef struct {
+	id    string
+	title string
+
+	// headerWidth is the minimum content width, enough to fit the header
+	headerWidth int
+	// minWidth is the width the column shrinks to when space is short
+	minWidth int
+	// maxWidth caps the column when the terminal width is unknown
+	maxWidth int
+
+	// sortMode is the sort mode this column reflects, if sortable
+	sortable bool
+	sortMode SortMode
+
+	// value renders the cell for a host
+	value func(m *Model, host config.SSHHost) string
+}
+
+// columnDefs contains every available column, keyed by identifier
+var columnDefs = map[string]columnDef{
+	config.ColumnName: {
+		id: config.ColumnName, title: "Name",
+		headerWidth: 8, minWidth: 15, maxWidth: 40,
+		sortable: true, sortMode: SortByName,
+		value: func(m *Model, host config.SSHHost) string {
+			return m.getPingStatusIndicator(host.Name) + " " + host.Name
+		},
+	},
+	config.ColumnHostname: {
+		id: config.ColumnHostname, title: "Hostname",
+		headerWidth: 8, minWidth: 15, maxWidth: 25,
+		value: func(m *Model, host config.SSHHost) string { return host.Hostname },
+	},
+	config.ColumnUser: {
+		id: config.ColumnUser, title: "User",
+		headerWidth: 6, minWidth: 8, maxWidth: 20,
+		value: func(m *Model, host config.SSHHost) string { return host.User },
+	},
+	config.ColumnPort: {
+		id: config.ColumnPort, title: "Port",
+		headerWidth: 5, minWidth: 6, maxWidth: 8,
+		value: func(m *Model, host config.SSHHost) string { return host.Port },
+	},
+	config.ColumnProxyJump: {
+		id: config.ColumnProxyJump, title: "ProxyJump",
+		headerWidth: 9, minWidth: 12, maxWidth: 30,
+		value: func(m *Model, host config.SSHHost) string { return host.ProxyJump },
+	},
+	config.ColumnSourceFile: {
+		id: config.ColumnSourceFile, title: "File",
+		headerWidth: 6, minWidth: 14, maxWidth: 40,
+		value: func(m *Model, host config.SSHHost) string {
+			if host.SourceFile == "" {
+				return ""
+			}
+			return formatConfigFile(host.SourceFile)
+		},
+	},
+	config.ColumnConnections: {
+		id: config.ColumnConnections, title: "Count",
+		headerWidth: 5, minWidth: 7, maxWidth: 10,
+		value: func(m *Model, host config.SSHHost) string {
+			if m.historyManager == nil {
+				return ""
+			}
+			if count := m.historyManager.GetConnectionCount(host.Name); count > 0 {
+				return strconv.Itoa(count)
+			}
+			return ""
+		},
+	},
+	config.ColumnLatency: {
+		id: config.ColumnLatency, title: "Latency",
+		headerWidth: 7, minWidth: 9, maxWidth: 12,
+		sortable: true, sortMode: SortByLatency,
+		value: func(m *Model, host config.SSHHost) string {
+			if m.pingManager == nil {
+				return ""
+			}
+			if result, ok := m.pingManager.GetResult(host.Name); ok && result.Status == connectivity.StatusOnline {
+				// Stale results are shown with their age
+				if m.isStale(result) {
+					return formatLatency(result.Duration.Milliseconds()) + " · " + formatAge(result.CheckedAt)
+				}
+				return formatLatency(result.Duration.Milliseconds())
+			}
+			return ""
+		},
+	},
+	config.ColumnHealth: {
+		id: config.ColumnHealth, title: "Health",
+		headerWidth: 6, minWidth: 12, maxWidth: 22,
+		value: func(m *Model, host config.SSHHost) string {
+			if m.health == nil {
+				return ""
+			}
+			return renderSparkline(m.health.Samples(host.Name), healthColumnSamples)
+		},
+	},
+	config.ColumnTags: {
+		id: config.ColumnTags, title: "Tags",
+		headerWidth: 8, minWidth: 10, maxWidth: 40,
+		value: func(m *Model, host config.SSHHost) string { return formatTableTags(host.Tags) },
+	},
+	config.ColumnDescription: {
+		id: config.ColumnDescription, title: "Description",
+		headerWidth: 11, minWidth: 12, maxWidth: 40,
+		value: func(m *Model, host config.SSHHost) string { return host.Description },
+	},
+	config.ColumnLastLogin: {
+		id: config.ColumnLastLogin, title: "Last Login",
+		headerWidth: 12, minWidth: 12, maxWidth: 20,
+		sortable: true, sortMode: SortByLastUsed,
+		value: func(m *Model, host config.SSHHost) string {
+			if m.historyManager == nil {
+				return ""
+			}
+			if lastConnect, exists := m.historyManager.GetLastConnectionTime(host.Name); exists {
+				return formatTimeAgo(lastConnect)
+			}
+			return ""
+		},
+	},
+}
+
+// allColumnIDs lists every column in the order offered by the column picker
+var allColumnIDs = []string{
+	config.ColumnName,
+	config.ColumnHostname,
+	config.ColumnUser,
+	config.ColumnPort,
+	config.ColumnProxyJump,
+	config.ColumnTags,
+	config.ColumnDescription,
+	config.ColumnSourceFile,
+	config.ColumnConnections,
+	config.ColumnLatency,
+	config.ColumnHealth,
+	config.ColumnLastLogin,
+}
+
+// healthColumnSamples is the number of samples drawn in the Health column
+const healthColumnSamples = 20
+
+// tableColumn is a configured column: its definition and the user's width policy
+type tableColumn struct {
+	def      columnDef
+	hidden   bool
+	width    int // fixed width, 0 = sized from content
+	minWidth int
+	maxWidth int // 0 = unbounded
+}
+
+// resolveColumns turns the column configuration into table columns.
+// Unknown identifiers and duplicates are ignored, and the Name column is always
+// present and visible since it carries the connectivity indicator.
+func resolveColumns(cfg []config.ColumnConfig) []tableColumn {
+	var columns []tableColumn
+	seen := make(map[string]bool)
+
+	for _, c := range cfg {
+		id := config.NormalizeColumnID(strings.ToLower(strings.TrimSpace(c.ID)))
+		def, ok := columnDefs[id]
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		col := tableColumn{
+			def:      def,
+			hidden:   c.Hidden && id != config.ColumnName,
+			width:    c.Width,
+			minWidth: def.minWidth,
+			maxWidth: c.MaxWidth,
+		}
+		if c.MinWidth > 0 {
+			col.minWidth = c.MinWidth
+		}
+		columns = append(columns, col)
+	}
+
+	if !seen[config.ColumnName] {
+		name := tableColumn{def: columnDefs[config.ColumnName], minWidth: columnDefs[config.ColumnName].minWidth}
+		columns = append([]tableColumn{name}, columns...)
+	}
+
+	return columns
+}
+
+// columnsToConfig converts table columns back to their configuration form
+func columnsToConfig(columns []tableColumn) []config.ColumnConfig {
+	cfg := make([]config.ColumnConfig, 0, len(columns))
+	for _, col := range columns {
+		c := config.ColumnConfig{
+			ID:       col.def.id,
+			Hidden:   col.hidden,
+			Width:    col.width,
+			MaxWidth: col.maxWidth,
+		}
+		if col.minWidth != col.def.minWidth {
+			c.MinWidth = col.minWidth
+		}
+		cfg = append(cfg, c)
+	}
+	return cfg
+}
+
+// withAllColumns returns the configured columns followed by every column that
+// is not configured yet, hidden. It is used by the column picker.
+func withAllColumns(columns []tableColumn) []tableColumn {
+	result := append([]tableColumn(nil), columns...)
+	present := make(map[string]bool)
+	for _, col := range columns {
+		present[col.def.id] = true
+	}
+	for _, id := range allColumnIDs {
+		if !present[id] {
+			def := columnDefs[id]
+			result = append(result, tableColumn{def: def, hidden: true, minWidth: def.minWidth})
+		}
+	}
+	return result
+}
+
+// visibleColumns returns the columns currently displayed in the table
+func (m *Model) visibleColumns() []tableColumn {
+	columns := m.columns
+	if columns == nil {
+		columns = resolveColumns(config.GetDefaultTableConfig().Columns)
+	}
+
+	var visible []tableColumn
+	for _, col := range columns {
+		if !col.hidden {
+			visible = append(visible, col)
+		}
+	}
+	return visible
+}
+
+// formatTableTags formats tags for display in the table
+func formatTableTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	// Add the # prefix to each tag and join them with spaces
+	formattedTags := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		formattedTags = append(formattedTags, "#"+tag)
+	}
+	return strings.Join(formattedTags, " ")
+}
+
+// formatLatency formats a latency in milliseconds for display
+func formatLatency(ms int64) string {
+	if ms >= 1000 {
+		return fmt.Sprintf("%.1fs", float64(ms)/1000)
+	}
+	return fmt.Sprintf("%dms", ms)
+}
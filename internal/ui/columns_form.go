@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type columnsFormModel struct {
+	columns  []tableColumn
+	selected int
+	styles   Styles
+	width    int
+	height   int
+}
+
+// columnsFormCloseMsg is sent when the column picker is closed
+type columnsFormCloseMsg struct {
+	columns []tableColumn
+	changed bool
+}
+
+// NewColumnsForm creates a column picker listing the configured columns followed
+// by the columns that are not part of the configuration yet
+func NewColumnsForm(columns []tableColumn, styles Styles, width, height int) *columnsFormModel {
+	if columns == nil {
+		columns = resolveColumns(config.GetDefaultTableConfig().Columns)
+	}
+
+	return &columnsFormModel{
+		columns: withAllColumns(columns),
+		styles:  styles,
+		width:   width,
+		height:  height,
+	}
+}
+
+func (m *columnsFormModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *columnsFormModel) Update(msg tea.Msg) (*columnsFormModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.styles = NewStyles(m.width)
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q", "ctrl+c":
+			return m, func() tea.Msg { return columnsFormCloseMsg{} }
+
+		case "enter", "c":
+			columns := m.columns
+			return m, func() tea.Msg { return columnsFormCloseMsg{columns: columns, changed: true} }
+
+		case "up", "k":
+			if m.selected > 0 {
+				m.selected--
+			}
+
+		case "down", "j":
+			if m.selected < len(m.columns)-1 {
+				m.selected++
+			}
+
+		case " ", "x":
+			m.toggle(m.selected)
+
+		case "shift+up", "K":
+			if m.selected > 0 {
+				m.swap(m.selected, m.selected-1)
+				m.selected--
+			}
+
+		case "shift+down", "J":
+			if m.selected < len(m.columns)-1 {
+				m.swap(m.selected, m.selected+1)
+				m.selected++
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// toggle shows or hides a column; the Name column cannot be hidden
+func (m *columnsFormModel) toggle(i int) {
+	if i < 0 || i >= len(m.columns) || m.columns[i].def.id == config.ColumnName {
+		return
+	}
+	m.columns[i].hidden = !m.columns[i].hidden
+}
+
+// swap exchanges the position of two columns
+func (m *columnsFormModel) swap(i, j int) {
+	m.columns[i], m.columns[j] = m.columns[j], m.columns[i]
+}
+
+func (m *columnsFormModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(m.styles.FormTitle.Render("Table columns"))
+	b.WriteString("\n\n")
+
+	for i, col := range m.columns {
+		checkbox := "[x]"
+		if col.hidden {
+			checkbox = "[ ]"
+		}
+		line := fmt.Sprintf("%s %s", checkbox, col.def.title)
+		if col.def.id == config.ColumnName {
+			line += " (always shown)"
+		}
+
+		if i == m.selected {
+			b.WriteString(m.styles.Selected.Render("▶ " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(m.styles.FormHelp.Render("↑/↓: navigate • Space: show/hide • Shift+↑/↓ or K/J: reorder • Enter: apply • Esc: cancel"))
+
+	return b.String()
+}
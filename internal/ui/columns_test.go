@@ -0,0 +1,145 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestResolveColumns(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      []config.ColumnConfig
+		expected []string
+		hidden   []string
+	}{
+		{
+			name:     "default layout",
+			cfg:      config.GetDefaultTableConfig().Columns,
+			expected: []string{"name", "hostname", "tags", "last_login"},
+		},
+		{
+			name:     "name column is added when missing",
+			cfg:      []config.ColumnConfig{{ID: "hostname"}, {ID: "user"}},
+			expected: []string{"name", "hostname", "user"},
+		},
+		{
+			name:     "aliases, unknown and duplicate columns",
+			cfg:      []config.ColumnConfig{{ID: "Labels"}, {ID: "unknown"}, {ID: "name"}, {ID: "tags"}, {ID: "ping"}},
+			expected: []string{"tags", "name", "latency"},
+		},
+		{
+			name:     "name column cannot be hidden",
+			cfg:      []config.ColumnConfig{{ID: "name", Hidden: true}, {ID: "port", Hidden: true}},
+			expected: []string{"name", "port"},
+			hidden:   []string{"port"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns := resolveColumns(tt.cfg)
+			if len(columns) != len(tt.expected) {
+				t.Fatalf("resolveColumns() returned %d columns, want %d", len(columns), len(tt.expected))
+			}
+
+			hidden := make(map[string]bool)
+			for _, id := range tt.hidden {
+				hidden[id] = true
+			}
+			for i, col := range columns {
+				if col.def.id != tt.expected[i] {
+					t.Errorf("column %d = %q, want %q", i, col.def.id, tt.expected[i])
+				}
+				if col.hidden != hidden[col.def.id] {
+					t.Errorf("column %q hidden = %v, want %v", col.def.id, col.hidden, hidden[col.def.id])
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateDynamicColumnWidths(t *testing.T) {
+	hosts := []config.SSHHost{
+		{Name: "server1", Hostname: "server1.example.com", User: "admin", Port: "2222"},
+		{Name: "a-much-longer-host-name", Hostname: "db.example.com", Tags: []string{"prod", "database"}},
+	}
+
+	m := createTestModel()
+	m.columns = resolveColumns([]config.ColumnConfig{
+		{ID: "name"}, {ID: "hostname"}, {ID: "user"}, {ID: "port", Width: 7}, {ID: "tags", Hidden: true},
+	})
+
+	// Wide terminal: every column gets its content width
+	m.width = 200
+	widths := m.calculateDynamicColumnWidths(hosts)
+	expected := []int{len("⚫ a-much-longer-host-name") + 2, len("server1.example.com") + 2, 8, 7}
+	if len(widths) != len(expected) {
+		t.Fatalf("Expected %d widths, got %d", len(expected), len(widths))
+	}
+	for i := range expected {
+		if widths[i] != expected[i] {
+			t.Errorf("Wide terminal: width[%d] = %d, want %d", i, widths[i], expected[i])
+		}
+	}
+
+	// Narrow terminal: the columns shrink to fit the available width
+	m.width = 60
+	widths = m.calculateDynamicColumnWidths(hosts)
+	total := len(widths) + 1
+	for _, w := range widths {
+		total += w
+	}
+	if total != m.width {
+		t.Errorf("Narrow terminal: total width = %d, want %d", total, m.width)
+	}
+	if widths[3] != 7 {
+		t.Errorf("Fixed width column should keep its width, got %d", widths[3])
+	}
+}
+
+func TestColumnsFormToggleAndReorder(t *testing.T) {
+	m := createTestModel()
+
+	// Open the column picker
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	m = newModel.(Model)
+	if m.viewMode != ViewColumns || m.columnsForm == nil {
+		t.Fatal("Expected 'c' to open the column picker")
+	}
+
+	form := m.columnsForm
+	if len(form.columns) != len(allColumnIDs) {
+		t.Errorf("Expected the picker to list %d columns, got %d", len(allColumnIDs), len(form.columns))
+	}
+
+	// The Name column cannot be hidden
+	form.toggle(0)
+	if form.columns[0].hidden {
+		t.Error("Name column should not be hideable")
+	}
+
+	// Hide the hostname column and move it down
+	form.selected = 1
+	form.toggle(1)
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("J")})
+	if form.columns[2].def.id != config.ColumnHostname || !form.columns[2].hidden {
+		t.Errorf("Expected hidden hostname column at position 2, got %q", form.columns[2].def.id)
+	}
+
+	// Apply the layout to the table (no app config, so nothing is saved)
+	newModel, _ = m.Update(columnsFormCloseMsg{columns: form.columns, changed: true})
+	m = newModel.(Model)
+	if m.viewMode != ViewList {
+		t.Error("Expected to return to the list view")
+	}
+	for _, col := range m.visibleColumns() {
+		if col.def.id == config.ColumnHostname {
+			t.Error("Hostname column should be hidden")
+		}
+	}
+	if got := len(m.table.Columns()); got != len(m.visibleColumns()) {
+		t.Errorf("Table has %d columns, want %d", got, len(m.visibleColumns()))
+	}
+}
@@ -0,0 +1,465 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/history"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxCommandOutputLines is the number of output lines kept; older lines are dropped
+const maxCommandOutputLines = 10000
+
+// commandOutputMsg carries output of the command running on the host,
+// along with the channel streaming the rest of it
+type commandOutputMsg struct {
+	run    int
+	data   string
+	stderr bool
+	stream <-chan tea.Msg
+}
+
+// commandDoneMsg is sent when the command exits
+type commandDoneMsg struct {
+	run      int
+	exitCode int   // -1 if the command did not exit on its own
+	err      error // Error other than a non-zero exit status
+	duration time.Duration
+}
+
+// commandCloseMsg is sent when the command view is closed
+type commandCloseMsg struct{}
+
+// commandLine is a line of output, from stdout or stderr
+type commandLine struct {
+	text   string
+	stderr bool
+}
+
+// commandModel prompts for a command, runs it on a host without a terminal
+// and shows its output as it arrives
+type commandModel struct {
+	hostName       string
+	configFile     string
+	styles         Styles
+	width          int
+	height         int
+	historyManager *history.HistoryManager
+
+	input    textinput.Model
+	viewport viewport.Model
+	editing  bool // Keys go to the input rather than the output
+
+	// recent are the commands run on the host, most recent first, and
+	// recentIndex the one shown in the input, or -1 for the typed draft
+	recent      []string
+	recentIndex int
+	draft       string
+
+	run      int // Identifies the current run, so that output of older runs is ignored
+	command  string
+	running  bool
+	cancel   context.CancelFunc
+	lines    []commandLine
+	open     bool // The last line did not end with a newline yet
+	done     *commandDoneMsg
+	canceled bool
+}
+
+// NewCommandForm creates the command view of a host, prompting for a command
+func NewCommandForm(hostName string, styles Styles, width, height int, configFile string, historyManager *history.HistoryManager) *commandModel {
+	input := textinput.New()
+	input.Placeholder = "df -h"
+	input.Prompt = "$ "
+	input.CharLimit = 1000
+	input.Focus()
+
+	m := &commandModel{
+		hostName:       hostName,
+		configFile:     configFile,
+		styles:         styles,
+		width:          width,
+		height:         height,
+		historyManager: historyManager,
+		input:          input,
+		viewport:       viewport.New(0, 0),
+		editing:        true,
+		recentIndex:    -1,
+	}
+	if historyManager != nil {
+		m.recent = historyManager.GetRecentCommands(hostName)
+	}
+	m.setSize(width, height)
+	return m
+}
+
+// setSize fits the input and the output pane in the window
+func (m *commandModel) setSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.input.Width = max(width-16, 20)
+	m.viewport.Width = max(width-8, 20)
+	// Leave room for the title, host, input, status, help and borders
+	m.viewport.Height = max(height-14, 3)
+}
+
+func (m *commandModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m *commandModel) Update(msg tea.Msg) (*commandModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case commandOutputMsg:
+		if msg.run != m.run {
+			// Drain the output of a stopped run, so that it can exit
+			return m, waitForCommandOutput(msg.stream)
+		}
+		follow := m.viewport.AtBottom()
+		m.appendOutput(msg.data, msg.stderr)
+		m.viewport.SetContent(m.renderOutput())
+		if follow {
+			m.viewport.GotoBottom()
+		}
+		return m, waitForCommandOutput(msg.stream)
+
+	case commandDoneMsg:
+		if msg.run != m.run {
+			return m, nil
+		}
+		m.running = false
+		m.cancel = nil
+		m.done = &msg
+		m.recordSession(msg)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.editing {
+			return m.updateInput(msg)
+		}
+		return m.updateOutput(msg)
+	}
+	return m, nil
+}
+
+// updateInput handles keys while typing a command
+func (m *commandModel) updateInput(msg tea.KeyMsg) (*commandModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.stop()
+		return m, func() tea.Msg { return commandCloseMsg{} }
+
+	case "enter":
+		command := strings.TrimSpace(m.input.Value())
+		if command == "" {
+			return m, nil
+		}
+		return m, m.start(command)
+
+	case "up":
+		// Recall the previous command run on the host
+		if m.recentIndex+1 < len(m.recent) {
+			if m.recentIndex < 0 {
+				m.draft = m.input.Value()
+			}
+			m.recentIndex++
+			m.input.SetValue(m.recent[m.recentIndex])
+			m.input.CursorEnd()
+		}
+		return m, nil
+
+	case "down":
+		if m.recentIndex >= 0 {
+			m.recentIndex--
+			if m.recentIndex < 0 {
+				m.input.SetValue(m.draft)
+			} else {
+				m.input.SetValue(m.recent[m.recentIndex])
+			}
+			m.input.CursorEnd()
+		}
+		return m, nil
+
+	case "pgup", "pgdown":
+		var cmd tea.Cmd
+		m.viewport, cmd = m.viewport.Update(msg)
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// updateOutput handles keys while reading the output
+func (m *commandModel) updateOutput(msg tea.KeyMsg) (*commandModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.stop()
+		return m, func() tea.Msg { return commandCloseMsg{} }
+
+	case "ctrl+c":
+		if m.running {
+			m.stop()
+			return m, nil
+		}
+		return m, func() tea.Msg { return commandCloseMsg{} }
+
+	case "enter", "e":
+		// Edit the command, or type another one
+		if m.running {
+			return m, nil
+		}
+		m.editing = true
+		m.input.Focus()
+		return m, textinput.Blink
+
+	case "r":
+		if !m.running {
+			return m, m.start(m.command)
+		}
+		return m, nil
+
+	case "g", "home":
+		m.viewport.GotoTop()
+		return m, nil
+
+	case "G", "end":
+		m.viewport.GotoBottom()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+// start runs a command on the host, remembering it for the host
+func (m *commandModel) start(command string) tea.Cmd {
+	m.stop()
+
+	m.run++
+	m.command = command
+	m.running = true
+	m.canceled = false
+	m.done = nil
+	m.lines = nil
+	m.open = false
+	m.viewport.SetContent("")
+	m.viewport.GotoTop()
+
+	m.editing = false
+	m.input.Blur()
+	m.input.SetValue(command)
+	m.recentIndex = -1
+
+	if m.historyManager != nil {
+		_ = m.historyManager.RecordCommand(m.hostName, command)
+		m.recent = m.historyManager.GetRecentCommands(m.hostName)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	stream := runRemoteCommand(ctx, m.run, commandSSHArgs(m.configFile, m.hostName, command))
+	return waitForCommandOutput(stream)
+}
+
+// recordSession logs the command once it exited
+func (m *commandModel) recordSession(msg commandDoneMsg) {
+	if m.historyManager == nil {
+		return
+	}
+
+	session := history.NewSession(m.hostName, history.SessionCommand, m.configFile)
+	session.Command = m.command
+	session.End = time.Now()
+	session.Start = session.End.Add(-msg.duration)
+	session.Duration = msg.duration.Seconds()
+	session.ExitCode = msg.exitCode
+	switch {
+	case msg.err != nil:
+		session.Error = msg.err.Error()
+	case m.canceled:
+		session.Error = "cancelled"
+	}
+	_ = m.historyManager.RecordSession(session)
+}
+
+// stop cancels the command if it is still running
+func (m *commandModel) stop() {
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+		m.canceled = m.running
+	}
+}
+
+// appendOutput adds output to the lines, continuing the last line when it
+// did not end yet and comes from the same stream
+func (m *commandModel) appendOutput(data string, stderr bool) {
+	data = strings.ReplaceAll(data, "\r", "")
+	parts := strings.Split(data, "\n")
+	for i, part := range parts {
+		last := i == len(parts)-1
+		if last && part == "" {
+			break
+		}
+		if i == 0 && m.open && m.lines[len(m.lines)-1].stderr == stderr {
+			m.lines[len(m.lines)-1].text += part
+		} else {
+			m.lines = append(m.lines, commandLine{text: part, stderr: stderr})
+		}
+	}
+	m.open = !strings.HasSuffix(data, "\n") && data != ""
+
+	if len(m.lines) > maxCommandOutputLines {
+		m.lines = append(m.lines[:0:0], m.lines[len(m.lines)-maxCommandOutputLines:]...)
+	}
+}
+
+// renderOutput renders the output lines, stderr in the error colour
+func (m *commandModel) renderOutput() string {
+	var b strings.Builder
+	for i, line := range m.lines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if line.stderr {
+			b.WriteString(m.styles.StatusFailure.Render(line.text))
+		} else {
+			b.WriteString(line.text)
+		}
+	}
+	return b.String()
+}
+
+// renderStatus describes the state of the command
+func (m *commandModel) renderStatus() string {
+	switch {
+	case m.running:
+		return m.styles.HelpText.Render("⏳ Running...")
+	case m.done == nil:
+		return ""
+	case m.canceled:
+		return m.styles.StatusFailure.Render("✗ Cancelled")
+	case m.done.err != nil:
+		return m.styles.StatusFailure.Render(fmt.Sprintf("✗ Could not run ssh: %v", m.done.err))
+	}
+
+	duration := m.done.duration.Round(10 * time.Millisecond)
+	switch m.done.exitCode {
+	case 0:
+		return m.styles.StatusSuccess.Render(fmt.Sprintf("✓ Exit 0 in %s", duration))
+	case 255:
+		// ssh exits with 255 when it cannot connect or log in
+		return m.styles.StatusFailure.Render(fmt.Sprintf("✗ ssh failed (exit 255) in %s", duration))
+	}
+	return m.styles.StatusFailure.Render(fmt.Sprintf("✗ Exit %d in %s", m.done.exitCode, duration))
+}
+
+func (m *commandModel) View() string {
+	var sections []string
+	sections = append(sections, m.styles.Header.Render("⚡ Run Command"))
+	sections = append(sections, m.styles.HelpText.Render(fmt.Sprintf("Host: %s", m.hostName)))
+	sections = append(sections, m.input.View())
+
+	output := m.viewport.View()
+	if len(m.lines) == 0 && m.done == nil && !m.running {
+		output = m.styles.HelpText.Render("The output of the command is shown here")
+	}
+	sections = append(sections, m.styles.InfoBorder.Width(m.viewport.Width).Render(output))
+
+	if status := m.renderStatus(); status != "" {
+		sections = append(sections, status)
+	}
+
+	help := " Enter: run • ↑/↓: previous commands • PgUp/PgDn: scroll • Esc: back to list"
+	if !m.editing {
+		help = " ↑/↓/PgUp/PgDn: scroll • Enter: edit • r: run again • Ctrl+C: stop • Esc: back to list"
+	}
+	sections = append(sections, m.styles.HelpText.Render(help))
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		lipgloss.JoinVertical(lipgloss.Left, sections...),
+	)
+}
+
+// commandSSHArgs returns the arguments of the ssh client running a command
+// on a host. The command runs without a terminal, and ssh must not prompt
+// since the TUI owns the terminal.
+func commandSSHArgs(configFile, hostName, command string) []string {
+	var args []string
+	if configFile != "" {
+		args = append(args, "-F", configFile)
+	}
+	return append(args, "-T", "-o", "BatchMode=yes", hostName, "--", command)
+}
+
+// commandStreamWriter sends what the command writes to the TUI
+type commandStreamWriter struct {
+	run    int
+	stderr bool
+	stream chan tea.Msg
+}
+
+func (w *commandStreamWriter) Write(b []byte) (int, error) {
+	w.stream <- commandOutputMsg{run: w.run, data: string(b), stderr: w.stderr, stream: w.stream}
+	return len(b), nil
+}
+
+// runRemoteCommand runs ssh with the given arguments until it exits or ctx
+// is cancelled. Its output, then a commandDoneMsg, are sent on the returned channel.
+func runRemoteCommand(ctx context.Context, run int, args []string) <-chan tea.Msg {
+	stream := make(chan tea.Msg, 64)
+
+	go func() {
+		defer close(stream)
+
+		sshCmd := exec.CommandContext(ctx, "ssh", args...)
+		sshCmd.Stdout = &commandStreamWriter{run: run, stream: stream}
+		sshCmd.Stderr = &commandStreamWriter{run: run, stderr: true, stream: stream}
+		// Do not wait for processes the remote command left holding the output
+		sshCmd.WaitDelay = time.Second
+
+		start := time.Now()
+		err := sshCmd.Run()
+		done := commandDoneMsg{run: run, duration: time.Since(start)}
+
+		var exitErr *exec.ExitError
+		switch {
+		case err == nil:
+			done.exitCode = 0
+		case errors.As(err, &exitErr):
+			done.exitCode = exitErr.ExitCode()
+		default:
+			done.exitCode = -1
+			done.err = err
+		}
+		stream <- done
+	}()
+
+	return stream
+}
+
+// waitForCommandOutput waits for the next message of a running command
+func waitForCommandOutput(stream <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-stream
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
@@ -0,0 +1,147 @@
+package ui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/history"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCommandSSHArgs(t *testing.T) {
+	want := []string{"-T", "-o", "BatchMode=yes", "web", "--", "df -h"}
+	if got := commandSSHArgs("", "web", "df -h"); !reflect.DeepEqual(got, want) {
+		t.Errorf("commandSSHArgs() = %v, want %v", got, want)
+	}
+	if got := commandSSHArgs("/tmp/config", "web", "uptime"); got[0] != "-F" || got[1] != "/tmp/config" {
+		t.Errorf("commandSSHArgs() = %v, want the config file first", got)
+	}
+}
+
+func TestCommandAppendOutput(t *testing.T) {
+	m := NewCommandForm("web", NewStyles(80), 80, 24, "", nil)
+
+	m.appendOutput("first\nsec", false)
+	m.appendOutput("ond\r\n", false)
+	m.appendOutput("oops", true)
+	m.appendOutput("\n", true)
+
+	want := []commandLine{{"first", false}, {"second", false}, {"oops", true}}
+	if !reflect.DeepEqual(m.lines, want) {
+		t.Errorf("lines = %+v, want %+v", m.lines, want)
+	}
+}
+
+func TestCommandRecall(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	hm, err := history.NewHistoryManager("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hm.RecordCommand("web", "uptime")
+	hm.RecordCommand("web", "df -h")
+
+	m := NewCommandForm("web", NewStyles(80), 80, 24, "", hm)
+	m.input.SetValue("draft")
+
+	press := func(key tea.KeyType) {
+		m, _ = m.Update(tea.KeyMsg{Type: key})
+	}
+
+	press(tea.KeyUp)
+	if m.input.Value() != "df -h" {
+		t.Errorf("Expected the last command, got %q", m.input.Value())
+	}
+	press(tea.KeyUp)
+	press(tea.KeyUp)
+	if m.input.Value() != "uptime" {
+		t.Errorf("Expected the oldest command, got %q", m.input.Value())
+	}
+	press(tea.KeyDown)
+	press(tea.KeyDown)
+	if m.input.Value() != "draft" {
+		t.Errorf("Expected the draft back, got %q", m.input.Value())
+	}
+}
+
+func TestCommandIgnoresOutputOfOlderRuns(t *testing.T) {
+	m := NewCommandForm("web", NewStyles(80), 80, 24, "", nil)
+	m.run = 2
+	m.running = true
+
+	stream := make(chan tea.Msg)
+	close(stream)
+	m, _ = m.Update(commandOutputMsg{run: 1, data: "old\n", stream: stream})
+	m, _ = m.Update(commandDoneMsg{run: 1})
+	if len(m.lines) != 0 || !m.running {
+		t.Fatalf("Expected the output of an older run to be ignored, got %+v", m.lines)
+	}
+
+	m, _ = m.Update(commandOutputMsg{run: 2, data: "new\n", stream: stream})
+	m, _ = m.Update(commandDoneMsg{run: 2, exitCode: 3})
+	if len(m.lines) != 1 || m.running || !strings.Contains(m.renderStatus(), "Exit 3") {
+		t.Errorf("Expected the output and exit status of the run, got %+v, %q", m.lines, m.renderStatus())
+	}
+}
+
+func TestCommandIsLogged(t *testing.T) {
+	hm := newTestHistoryManager(t)
+	m := NewCommandForm("web", NewStyles(80), 80, 24, "", hm)
+	m.run = 1
+	m.command = "df -h"
+	m.running = true
+
+	m, _ = m.Update(commandDoneMsg{run: 1, exitCode: 2, duration: 3 * time.Second})
+
+	sessions, err := hm.GetSessions(history.SessionFilter{HostName: "web"})
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("Expected the command to be logged, got %v (%v)", sessions, err)
+	}
+	if s := sessions[0]; s.Kind != history.SessionCommand || s.Command != "df -h" || s.ExitCode != 2 || s.Duration != 3 {
+		t.Errorf("Unexpected session %+v", s)
+	}
+}
+
+func TestRunRemoteCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ssh is a shell script")
+	}
+
+	// A fake ssh writing to stdout and stderr and failing
+	bin := t.TempDir()
+	script := "#!/bin/sh\necho out\necho err >&2\nexit 3\n"
+	if err := os.WriteFile(filepath.Join(bin, "ssh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	var stdout, stderr string
+	var done *commandDoneMsg
+	for msg := range runRemoteCommand(context.Background(), 1, commandSSHArgs("", "web", "true")) {
+		switch msg := msg.(type) {
+		case commandOutputMsg:
+			if msg.stderr {
+				stderr += msg.data
+			} else {
+				stdout += msg.data
+			}
+		case commandDoneMsg:
+			done = &msg
+		}
+	}
+
+	if stdout != "out\n" || stderr != "err\n" {
+		t.Errorf("Expected separate stdout and stderr, got %q and %q", stdout, stderr)
+	}
+	if done == nil || done.exitCode != 3 || done.err != nil {
+		t.Errorf("Expected exit status 3, got %+v", done)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/history"
 	"github.com/Gu1llaum-3/sshm/internal/validation"
 
 	"github.com/charmbracelet/bubbles/textinput"
@@ -16,6 +17,12 @@ const (
 	focusAreaProperties
 )
 
+// editForwardInput is the index of the input adding a port forward
+const editForwardInput = 10
+
+// editDescriptionInput is the index of the description input
+const editDescriptionInput = 11
+
 type editFormSubmitMsg struct {
 	hostname string
 	err      error
@@ -38,6 +45,8 @@ type editFormModel struct {
 	actualConfigFile string          // Actual config file to use (either configFile or host.SourceFile)
 	width            int
 	height           int
+	forwards         []string // LocalForward, RemoteForward and DynamicForward directives of the host
+	forwardCursor    int      // Selected forward, -1 if none
 }
 
 // NewEditForm creates a new edit form model that supports both single and multi-host editing
@@ -91,7 +100,7 @@ func NewEditForm(hostName string, styles Styles, width, height int, configFile s
 		}
 	}
 
-	inputs := make([]textinput.Model, 10)
+	inputs := make([]textinput.Model, 12)
 
 	// Hostname input
 	inputs[0] = textinput.New()
@@ -140,8 +149,9 @@ func NewEditForm(hostName string, styles Styles, width, height int, configFile s
 	inputs[6].Placeholder = "-o StrictHostKeyChecking=no"
 	inputs[6].CharLimit = 200
 	inputs[6].Width = 50
-	if host.Options != "" {
-		inputs[6].SetValue(config.FormatSSHOptionsForCommand(host.Options))
+	forwards, options := config.SplitForwardOptions(host.Options)
+	if options != "" {
+		inputs[6].SetValue(config.FormatSSHOptionsForCommand(options))
 	}
 
 	// Tags input
@@ -153,6 +163,13 @@ func NewEditForm(hostName string, styles Styles, width, height int, configFile s
 		inputs[7].SetValue(strings.Join(host.Tags, ", "))
 	}
 
+	// Description input
+	inputs[editDescriptionInput] = textinput.New()
+	inputs[editDescriptionInput].Placeholder = "Production web server"
+	inputs[editDescriptionInput].CharLimit = 200
+	inputs[editDescriptionInput].Width = 50
+	inputs[editDescriptionInput].SetValue(host.Description)
+
 	// Remote Command input
 	inputs[8] = textinput.New()
 	inputs[8].Placeholder = "ls -la, htop, bash"
@@ -167,6 +184,17 @@ func NewEditForm(hostName string, styles Styles, width, height int, configFile s
 	inputs[9].Width = 30
 	inputs[9].SetValue(host.RequestTTY)
 
+	// Port forward input, added to the forwards with Enter
+	inputs[editForwardInput] = textinput.New()
+	inputs[editForwardInput].Placeholder = "-L 8080:localhost:80 or LocalForward 8080 localhost:80"
+	inputs[editForwardInput].CharLimit = 250
+	inputs[editForwardInput].Width = 70
+
+	forwardCursor := -1
+	if len(forwards) > 0 {
+		forwardCursor = 0
+	}
+
 	return &editFormModel{
 		hostInputs:       hostInputs,
 		inputs:           inputs,
@@ -181,6 +209,8 @@ func NewEditForm(hostName string, styles Styles, width, height int, configFile s
 		styles:           styles,
 		width:            width,
 		height:           height,
+		forwards:         forwards,
+		forwardCursor:    forwardCursor,
 	}, nil
 }
 
@@ -260,19 +290,19 @@ func (m *editFormModel) updateFocus() tea.Cmd {
 func (m *editFormModel) getPropertiesForCurrentTab() []int {
 	switch m.currentTab {
 	case 0: // General
-		return []int{0, 1, 2, 3, 4, 5, 7} // hostname, user, port, identity, proxyjump, proxycommand, tags
+		return []int{0, 1, 2, 3, 4, 5, 7, editDescriptionInput} // hostname, user, port, identity, proxyjump, proxycommand, tags, description
 	case 1: // Advanced
-		return []int{6, 8, 9} // options, remotecommand, requesttty
+		return []int{6, 8, 9, editForwardInput} // options, remotecommand, requesttty, port forwards
 	default:
-		return []int{0, 1, 2, 3, 4, 5, 7}
+		return []int{0, 1, 2, 3, 4, 5, 7, editDescriptionInput}
 	}
 }
 
 // getFirstPropertyForTab returns the first property index for a given tab
 func (m *editFormModel) getFirstPropertyForTab(tab int) int {
-	properties := []int{0, 1, 2, 3, 4, 5, 7} // General tab
+	properties := []int{0, 1, 2, 3, 4, 5, 7, editDescriptionInput} // General tab
 	if tab == 1 {
-		properties = []int{6, 8, 9} // Advanced tab
+		properties = []int{6, 8, 9, editForwardInput} // Advanced tab
 	}
 	if len(properties) > 0 {
 		return properties[0]
@@ -374,10 +404,14 @@ func (m *editFormModel) getMinimumHeight() int {
 	if m.currentTab == 0 {
 		fieldsCount = 6 // 6 fields in general tab
 	} else {
-		fieldsCount = 3 // 3 fields in advanced tab
+		fieldsCount = 4 // 4 fields in advanced tab
 	}
 	// Each field: reduced from 4 to 3 lines per field
 	fieldsLines := fieldsCount * 3
+	if m.currentTab == 1 {
+		// Port forwards list with its help line
+		fieldsLines += max(len(m.forwards), 1) + 2
+	}
 	// Help text: 3 lines
 	helpLines := 3
 	// Error message space when needed: 2 lines
@@ -443,9 +477,28 @@ func (m *editFormModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, m.updateFocus()
 
-		case "tab", "shift+tab", "enter", "up", "down":
+		case "enter":
+			if m.focusArea == focusAreaProperties && m.focused == editForwardInput && strings.TrimSpace(m.inputs[editForwardInput].Value()) != "" {
+				m.addForward()
+				return m, nil
+			}
 			return m, m.handleEditNavigation(msg.String())
 
+		case "tab", "shift+tab", "up", "down":
+			return m, m.handleEditNavigation(msg.String())
+
+		case "pgup":
+			if m.currentTab == 1 && m.forwardCursor > 0 {
+				m.forwardCursor--
+			}
+			return m, nil
+
+		case "pgdown":
+			if m.currentTab == 1 && m.forwardCursor < len(m.forwards)-1 {
+				m.forwardCursor++
+			}
+			return m, nil
+
 		case "ctrl+a":
 			// Add a new host input
 			return m, m.addHostInput()
@@ -455,6 +508,11 @@ func (m *editFormModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.focusArea == focusAreaHosts && len(m.hostInputs) > 1 {
 				return m, m.deleteHostInput()
 			}
+			// Or the selected port forward
+			if m.focusArea == focusAreaProperties && m.focused == editForwardInput {
+				m.removeForward()
+				return m, nil
+			}
 		}
 
 	case editFormSubmitMsg:
@@ -589,6 +647,7 @@ func (m *editFormModel) renderEditGeneralTab() string {
 		{4, "Proxy Jump"},
 		{5, "Proxy Command"},
 		{7, "Tags (comma-separated)"},
+		{editDescriptionInput, "Description"},
 	}
 
 	for _, field := range fields {
@@ -629,9 +688,66 @@ func (m *editFormModel) renderEditAdvancedTab() string {
 		b.WriteString("\n\n")
 	}
 
+	// Port forwards set up on each connection
+	fieldStyle := m.styles.FormField
+	if m.focusArea == focusAreaProperties && m.focused == editForwardInput {
+		fieldStyle = m.styles.FocusedLabel
+	}
+	b.WriteString(fieldStyle.Render("Port Forwards"))
+	b.WriteString("\n")
+	if len(m.forwards) == 0 {
+		b.WriteString(m.styles.FormHelp.Render("  No port forward"))
+		b.WriteString("\n")
+	}
+	for i, forward := range m.forwards {
+		if i == m.forwardCursor {
+			b.WriteString(m.styles.Selected.Render("▶ " + forward))
+		} else {
+			b.WriteString("  " + forward)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(m.inputs[editForwardInput].View())
+	b.WriteString("\n")
+	b.WriteString(m.styles.FormHelp.Render("Enter: add forward • PgUp/PgDn: select • Ctrl+D: remove selected forward"))
+	b.WriteString("\n\n")
+
 	return b.String()
 }
 
+// addForward adds the forward typed in the port forward input
+func (m *editFormModel) addForward() {
+	forward, err := history.ParseForward(strings.TrimSpace(m.inputs[editForwardInput].Value()))
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+
+	m.err = ""
+	directive := forward.Directive()
+	for i, existing := range m.forwards {
+		if existing == directive {
+			m.forwardCursor = i
+			m.inputs[editForwardInput].SetValue("")
+			return
+		}
+	}
+	m.forwards = append(m.forwards, directive)
+	m.forwardCursor = len(m.forwards) - 1
+	m.inputs[editForwardInput].SetValue("")
+}
+
+// removeForward removes the selected port forward
+func (m *editFormModel) removeForward() {
+	if m.forwardCursor < 0 || m.forwardCursor >= len(m.forwards) {
+		return
+	}
+	m.forwards = append(m.forwards[:m.forwardCursor:m.forwardCursor], m.forwards[m.forwardCursor+1:]...)
+	if m.forwardCursor >= len(m.forwards) {
+		m.forwardCursor = len(m.forwards) - 1
+	}
+}
+
 // Standalone wrapper for edit form
 type standaloneEditForm struct {
 	*editFormModel
@@ -658,6 +774,7 @@ func (m standaloneEditForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // RunEditForm runs the edit form as a standalone program
 func RunEditForm(hostName string, configFile string) error {
+	applyConfiguredTheme()
 	styles := NewStyles(80) // Default width
 	editForm, err := NewEditForm(hostName, styles, 80, 24, configFile)
 	if err != nil {
@@ -701,6 +818,17 @@ func (m *editFormModel) submitEditForm() tea.Cmd {
 			port = "22"
 		}
 
+		// Port forwards, including one typed but not added yet
+		forwards := m.forwards
+		if pending := strings.TrimSpace(m.inputs[editForwardInput].Value()); pending != "" {
+			forward, err := history.ParseForward(pending)
+			if err != nil {
+				return editFormSubmitMsg{err: err}
+			}
+			forwards = append(append([]string(nil), forwards...), forward.Directive())
+		}
+		options = config.JoinForwardOptions(options, forwards)
+
 		// Validate hostname
 		if hostname == "" {
 			return editFormSubmitMsg{err: fmt.Errorf("hostname is required")}
@@ -736,6 +864,7 @@ func (m *editFormModel) submitEditForm() tea.Cmd {
 			Options:       options,
 			RemoteCommand: remoteCommand,
 			RequestTTY:    requestTTY,
+			Description:   strings.TrimSpace(m.inputs[editDescriptionInput].Value()),
 			Tags:          tags,
 		}
 
@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestEditFormForwards(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	configFile := filepath.Join(t.TempDir(), "config")
+	content := `Host db1
+    HostName db1.example.com
+    Compression yes
+    LocalForward 5432 localhost:5432
+    DynamicForward 1080
+`
+	if err := os.WriteFile(configFile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewEditForm("db1", NewStyles(120), 120, 80, configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m.forwards, []string{"LocalForward 5432 localhost:5432", "DynamicForward 1080"}) {
+		t.Fatalf("Expected the forwards of the host to be listed, got %v", m.forwards)
+	}
+	if m.inputs[6].Value() != "-o Compression=yes" {
+		t.Errorf("Expected the forwards to be left out of the options, got %q", m.inputs[6].Value())
+	}
+
+	// Go to the port forwards of the Advanced tab
+	m.currentTab = 1
+	m.focusArea = focusAreaProperties
+	m.focused = editForwardInput
+	if !strings.Contains(m.View(), "LocalForward 5432 localhost:5432") {
+		t.Error("Expected the view to list the forwards")
+	}
+
+	// Invalid forwards are rejected
+	m.inputs[editForwardInput].SetValue("-L 8080")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.err == "" || len(m.forwards) != 2 {
+		t.Errorf("Expected an invalid forward to be rejected, got %v", m.forwards)
+	}
+
+	m.inputs[editForwardInput].SetValue("-R 9000:localhost:3000")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.err != "" || len(m.forwards) != 3 || m.forwards[2] != "RemoteForward 9000 localhost:3000" {
+		t.Fatalf("Expected the forward to be added, got %v (%s)", m.forwards, m.err)
+	}
+
+	// Remove the dynamic forward
+	m.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	if !reflect.DeepEqual(m.forwards, []string{"LocalForward 5432 localhost:5432", "RemoteForward 9000 localhost:3000"}) {
+		t.Fatalf("Expected the dynamic forward to be removed, got %v", m.forwards)
+	}
+
+	if msg := m.submitEditForm()().(editFormSubmitMsg); msg.err != nil {
+		t.Fatalf("submitEditForm() error = %v", msg.err)
+	}
+	host, err := config.GetSSHHostFromFile("db1", configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host.Options != "Compression yes\nLocalForward 5432 localhost:5432\nRemoteForward 9000 localhost:3000" {
+		t.Errorf("Unexpected options saved: %q", host.Options)
+	}
+}
+
+func TestEditFormDescription(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	configFile := filepath.Join(t.TempDir(), "config")
+	content := `Host web
+    # Description: Public web server
+    HostName web.example.com
+`
+	if err := os.WriteFile(configFile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewEditForm("web", NewStyles(120), 120, 80, configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.inputs[editDescriptionInput].Value(); got != "Public web server" {
+		t.Fatalf("Expected the description to be filled in, got %q", got)
+	}
+
+	m.inputs[editDescriptionInput].SetValue("  Internal web server ")
+	if msg := m.submitEditForm()().(editFormSubmitMsg); msg.err != nil {
+		t.Fatalf("submitEditForm() error = %v", msg.err)
+	}
+	host, err := config.GetSSHHostFromFile("web", configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host.Description != "Internal web server" {
+		t.Errorf("Expected the description to be saved, got %q", host.Description)
+	}
+}
+
+func TestAddFormDescription(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	configFile := filepath.Join(t.TempDir(), "config")
+	m := NewAddForm("api", NewStyles(120), 120, 80, configFile)
+	m.inputs[hostnameInput].SetValue("api.example.com")
+	m.inputs[descriptionInput].SetValue("Public API gateway")
+	if msg := m.submitForm()().(addFormSubmitMsg); msg.err != nil {
+		t.Fatalf("submitForm() error = %v", msg.err)
+	}
+
+	host, err := config.GetSSHHostFromFile("api", configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host.Description != "Public API gateway" {
+		t.Errorf("Expected the description to be saved, got %q", host.Description)
+	}
+}
@@ -52,6 +52,9 @@ func (m *helpModel) View() string {
 		lipgloss.JoinHorizontal(lipgloss.Left,
 			m.styles.FocusedLabel.Render("i  "),
 			m.styles.HelpText.Render("show host information")),
+		lipgloss.JoinHorizontal(lipgloss.Left,
+			m.styles.FocusedLabel.Render("v  "),
+			m.styles.HelpText.Render("toggle preview pane")),
 		lipgloss.JoinHorizontal(lipgloss.Left,
 			m.styles.FocusedLabel.Render("/  "),
 			m.styles.HelpText.Render("search hosts")),
@@ -81,9 +84,21 @@ func (m *helpModel) View() string {
 		lipgloss.JoinHorizontal(lipgloss.Left,
 			m.styles.FocusedLabel.Render("p  "),
 			m.styles.HelpText.Render("ping all hosts")),
+		lipgloss.JoinHorizontal(lipgloss.Left,
+			m.styles.FocusedLabel.Render("M  "),
+			m.styles.HelpText.Render("toggle background monitor")),
+		lipgloss.JoinHorizontal(lipgloss.Left,
+			m.styles.FocusedLabel.Render("P  "),
+			m.styles.HelpText.Render("pin host (announce changes)")),
 		lipgloss.JoinHorizontal(lipgloss.Left,
 			m.styles.FocusedLabel.Render("f  "),
 			m.styles.HelpText.Render("setup port forwarding")),
+		lipgloss.JoinHorizontal(lipgloss.Left,
+			m.styles.FocusedLabel.Render("x  "),
+			m.styles.HelpText.Render("run command on host")),
+		lipgloss.JoinHorizontal(lipgloss.Left,
+			m.styles.FocusedLabel.Render("t  "),
+			m.styles.HelpText.Render("tunnels")),
 		lipgloss.JoinHorizontal(lipgloss.Left,
 			m.styles.FocusedLabel.Render("s  "),
 			m.styles.HelpText.Render("cycle sort modes")),
@@ -93,9 +108,21 @@ func (m *helpModel) View() string {
 		lipgloss.JoinHorizontal(lipgloss.Left,
 			m.styles.FocusedLabel.Render("r  "),
 			m.styles.HelpText.Render("sort by recent connection")),
+		lipgloss.JoinHorizontal(lipgloss.Left,
+			m.styles.FocusedLabel.Render("l  "),
+			m.styles.HelpText.Render("sort by latency")),
+		lipgloss.JoinHorizontal(lipgloss.Left,
+			m.styles.FocusedLabel.Render("c  "),
+			m.styles.HelpText.Render("choose table columns")),
+		lipgloss.JoinHorizontal(lipgloss.Left,
+			m.styles.FocusedLabel.Render("K  "),
+			m.styles.HelpText.Render("hosts whose host key changed")),
 		"",
 		m.styles.FocusedLabel.Render("System"),
 		"",
+		lipgloss.JoinHorizontal(lipgloss.Left,
+			m.styles.FocusedLabel.Render("^p "),
+			m.styles.HelpText.Render("open command palette")),
 		lipgloss.JoinHorizontal(lipgloss.Left,
 			m.styles.FocusedLabel.Render("h  "),
 			m.styles.HelpText.Render("show this help")),
@@ -3,7 +3,10 @@ package ui
 import (
 	"fmt"
 	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+	"github.com/Gu1llaum-3/sshm/internal/history"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -16,8 +19,29 @@ type infoFormModel struct {
 	height     int
 	configFile string
 	hostName   string
+
+	// pingResult is the last connectivity check of the host, if any, and
+	// pingStale whether it is old enough to be shown dimmed
+	pingResult *connectivity.HostPingResult
+	pingStale  bool
+
+	// healthSamples are the recent checks of the host, oldest first
+	healthSamples []connectivity.HealthSample
+	uptime        float64
+	pinned        bool
+
+	// auth is the outcome of the authentication probe started when the
+	// view opened, nil while authProbing
+	auth        *connectivity.AuthProbeResult
+	authProbing bool
+
+	// sessions are the last sessions with the host, most recent first
+	sessions []history.Session
 }
 
+// infoSessionCount is the number of sessions listed in the info view
+const infoSessionCount = 5
+
 // Messages for communication with parent model
 type infoFormEditMsg struct {
 	hostName string
@@ -86,10 +110,7 @@ func (m *infoFormModel) View() string {
 	b.WriteString("\n\n")
 
 	// Create info sections with consistent formatting
-	sections := []struct {
-		label string
-		value string
-	}{
+	sections := []infoLine{
 		{"Host Name", m.host.Name},
 		{"Config File", formatConfigFile(m.host.SourceFile)},
 		{"Hostname/IP", m.host.Hostname},
@@ -100,49 +121,47 @@ func (m *infoFormModel) View() string {
 		{"ProxyCommand", formatOptionalValue(m.host.ProxyCommand)},
 		{"SSH Options", formatSSHOptions(m.host.Options)},
 		{"Tags", formatTags(m.host.Tags)},
+		{"Description", formatOptionalValue(m.host.Description)},
 	}
 
 	// Render each section
 	for _, section := range sections {
-		// Label style
-		labelStyle := lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("39")). // Bright blue
-			Width(15).
-			AlignHorizontal(lipgloss.Right)
-
-		// Value style
-		valueStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("255")) // White
-
-		// If value is empty or default, use a muted style
-		if section.value == "Not set" || section.value == "22" && section.label == "Port" {
-			valueStyle = valueStyle.Foreground(lipgloss.Color("243")) // Gray
-		}
-
-		line := lipgloss.JoinHorizontal(
-			lipgloss.Top,
-			labelStyle.Render(section.label+":"),
-			" ",
-			valueStyle.Render(section.value),
-		)
-		b.WriteString(line)
+		b.WriteString(m.renderInfoLine(section.label, section.value))
 		b.WriteString("\n")
 	}
 
 	b.WriteString("\n")
 
+	// Last connectivity check
+	if connectivityInfo := m.renderConnectivity(); connectivityInfo != "" {
+		b.WriteString(connectivityInfo)
+		b.WriteString("\n")
+	}
+
+	// Latency and uptime over the recent checks
+	if authInfo := m.renderAuth(); authInfo != "" {
+		b.WriteString("\n")
+		b.WriteString(authInfo)
+	}
+
+	if healthInfo := m.renderHealth(); healthInfo != "" {
+		b.WriteString(healthInfo)
+		b.WriteString("\n")
+	}
+
+	if sessionsInfo := m.renderSessions(); sessionsInfo != "" {
+		b.WriteString(sessionsInfo)
+		b.WriteString("\n")
+	}
+
 	// Action instructions
-	helpStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("243")).
+	helpStyle := m.styles.InfoMuted.
 		Italic(true)
 
 	b.WriteString(helpStyle.Render("Actions:"))
 	b.WriteString("\n")
 
-	actionStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("120")). // Green
-		Bold(true)
+	actionStyle := m.styles.InfoAction
 
 	b.WriteString("  ")
 	b.WriteString(actionStyle.Render("e/Enter"))
@@ -156,9 +175,7 @@ func (m *infoFormModel) View() string {
 	// Wrap in a border for better visual separation
 	content := b.String()
 
-	borderStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("39")).
+	borderStyle := m.styles.InfoBorder.
 		Padding(1).
 		Margin(1)
 
@@ -172,8 +189,205 @@ func (m *infoFormModel) View() string {
 	)
 }
 
+// infoLine is a label and its value in the info view
+type infoLine struct {
+	label string
+	value string
+}
+
+// renderInfoLine renders a label and its value
+func (m *infoFormModel) renderInfoLine(label, value string) string {
+	// Label style
+	labelStyle := m.styles.InfoLabel.
+		Width(15).
+		AlignHorizontal(lipgloss.Right)
+
+	// Value style
+	valueStyle := m.styles.InfoValue
+
+	// If value is empty or default, use a muted style
+	if value == "Not set" || value == "22" && label == "Port" {
+		valueStyle = m.styles.InfoMuted
+	}
+
+	return lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		labelStyle.Render(label+":"),
+		" ",
+		valueStyle.Render(value),
+	)
+}
+
+// renderMutedInfoLine renders a label and its value dimmed, for outdated information
+func (m *infoFormModel) renderMutedInfoLine(label, value string) string {
+	labelStyle := m.styles.InfoLabel.
+		Width(15).
+		AlignHorizontal(lipgloss.Right)
+
+	return lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		labelStyle.Render(label+":"),
+		" ",
+		m.styles.InfoMuted.Render(value),
+	)
+}
+
+// renderConnectivity renders the outcome of the last connectivity check,
+// or an empty string if the host was not checked
+func (m *infoFormModel) renderConnectivity() string {
+	result := m.pingResult
+	if result == nil || result.Kind == connectivity.KindNone {
+		return ""
+	}
+
+	checked := fmt.Sprintf("%s (%s)", formatTimeAgo(result.CheckedAt), formatLatency(result.Duration.Milliseconds()))
+	if result.Cached {
+		checked += ", by a previous run"
+	}
+
+	title := "Connectivity"
+	renderLine := m.renderInfoLine
+	if m.pingStale {
+		title += " (stale, press p to refresh)"
+		renderLine = m.renderMutedInfoLine
+	}
+
+	var b strings.Builder
+	b.WriteString(m.styles.InfoLabel.Render(title))
+	b.WriteString("\n")
+
+	lines := []infoLine{
+		{"Status", pingKindIndicator(result.Kind) + " " + result.Kind.String()},
+		{"Resolved IP", formatOptionalValue(result.ResolvedIP)},
+		{"Server", formatOptionalValue(result.ServerVersion)},
+		{"Host Key", formatOptionalValue(result.HostKeyType)},
+		{"Fingerprint", formatOptionalValue(result.HostKeyFingerprint)},
+		{"Known Hosts", result.HostKeyStatus.String()},
+		{"Checked", checked},
+	}
+	if result.FailedHop != "" {
+		lines = append(lines, infoLine{"Failed hop", result.FailedHop})
+	}
+	for _, line := range lines {
+		b.WriteString(renderLine(line.label, line.value))
+		b.WriteString("\n")
+	}
+
+	if result.Error != nil && result.Kind != connectivity.KindAuthRequired {
+		b.WriteString(m.styles.ErrorText.Width(60).Render(result.Error.Error()))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// renderAuth renders the authentication methods offered by the host and
+// whether it accepts one of our keys, or an empty string if no probe was started
+func (m *infoFormModel) renderAuth() string {
+	if !m.authProbing && m.auth == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(m.styles.InfoLabel.Render("Authentication"))
+	b.WriteString("\n")
+
+	if m.authProbing {
+		b.WriteString(m.renderMutedInfoLine("Status", "Checking..."))
+		b.WriteString("\n")
+		return b.String()
+	}
+	if m.auth.Error != nil {
+		b.WriteString(m.renderMutedInfoLine("Status", "Not checked"))
+		b.WriteString("\n")
+		b.WriteString(m.styles.ErrorText.Width(60).Render(m.auth.Error.Error()))
+		b.WriteString("\n")
+		return b.String()
+	}
+
+	key := fmt.Sprintf("None accepted (%d offered)", m.auth.Keys)
+	if m.auth.AcceptedKey != "" {
+		key = m.auth.AcceptedKey
+	}
+	methods := "None"
+	if len(m.auth.Methods) > 0 {
+		methods = strings.Join(m.auth.Methods, ", ")
+	}
+	for _, line := range []infoLine{
+		{"Status", m.auth.Status.String()},
+		{"Methods", methods},
+		{"Key", key},
+	} {
+		b.WriteString(m.renderInfoLine(line.label, line.value))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// renderHealth renders the latency history and uptime of the host,
+// or an empty string if it has no recent check
+func (m *infoFormModel) renderHealth() string {
+	if len(m.healthSamples) == 0 {
+		return ""
+	}
+
+	pinned := "No (P to pin)"
+	if m.pinned {
+		pinned = "Yes"
+	}
+
+	var b strings.Builder
+	b.WriteString(m.styles.InfoLabel.Render("Health"))
+	b.WriteString("\n")
+	for _, line := range []infoLine{
+		{"Uptime", formatUptime(m.uptime, m.healthSamples)},
+		{"Latency", renderSparkline(m.healthSamples, 40)},
+		{"Pinned", pinned},
+	} {
+		b.WriteString(m.renderInfoLine(line.label, line.value))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// renderSessions renders the last sessions with the host, or an empty
+// string if none was logged
+func (m *infoFormModel) renderSessions() string {
+	if len(m.sessions) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(m.styles.InfoLabel.Render("Sessions"))
+	b.WriteString("\n")
+	for _, session := range m.sessions {
+		value := fmt.Sprintf("%s, %s, %s", session.Kind, session.Elapsed().Round(time.Second), formatSessionExit(session))
+		if session.Command != "" {
+			value += ": " + truncate(session.Command, 30)
+		}
+		line := m.renderInfoLine(formatTimeAgo(session.Start), value)
+		if session.Failed() {
+			line = m.renderMutedInfoLine(formatTimeAgo(session.Start), value)
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
 // Helper functions for formatting values
 
+// formatSessionExit describes how a session ended
+func formatSessionExit(session history.Session) string {
+	switch {
+	case session.Error != "":
+		return session.Error
+	case session.ExitCode < 0:
+		return "killed"
+	default:
+		return fmt.Sprintf("exit %d", session.ExitCode)
+	}
+}
+
 func formatOptionalValue(value string) string {
 	if value == "" {
 		return "Not set"
@@ -216,11 +430,15 @@ func (m standaloneInfoForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // RunInfoForm provides a standalone info form for testing
 func RunInfoForm(hostName string, configFile string) error {
+	applyConfiguredTheme()
 	styles := NewStyles(80)
 	infoForm, err := NewInfoForm(hostName, styles, 80, 24, configFile)
 	if err != nil {
 		return err
 	}
+	if historyManager, err := history.NewHistoryManager(configFile); err == nil {
+		infoForm.sessions, _ = historyManager.GetSessions(history.SessionFilter{HostName: hostName, Limit: infoSessionCount})
+	}
 	m := standaloneInfoForm{infoForm}
 
 	p := tea.NewProgram(m, tea.WithAltScreen())
@@ -1,14 +1,17 @@
 package ui
 
 import (
+	"context"
+
 	"github.com/Gu1llaum-3/sshm/internal/config"
 	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+	"github.com/Gu1llaum-3/sshm/internal/forward"
 	"github.com/Gu1llaum-3/sshm/internal/history"
+	"github.com/Gu1llaum-3/sshm/internal/tunnel"
 	"github.com/Gu1llaum-3/sshm/internal/version"
 
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textinput"
-	"github.com/charmbracelet/lipgloss"
 )
 
 // SortMode defines the available sorting modes
@@ -17,14 +20,20 @@ type SortMode int
 const (
 	SortByName SortMode = iota
 	SortByLastUsed
+	SortByLatency
 )
 
+// sortModeCount is the number of sort modes, cycled through with s
+const sortModeCount = 3
+
 func (s SortMode) String() string {
 	switch s {
 	case SortByName:
 		return "Name (A-Z)"
 	case SortByLastUsed:
 		return "Last Login"
+	case SortByLatency:
+		return "Latency"
 	default:
 		return "Name (A-Z)"
 	}
@@ -42,6 +51,10 @@ const (
 	ViewPortForward
 	ViewHelp
 	ViewFileSelector
+	ViewColumns
+	ViewPalette
+	ViewCommand
+	ViewTunnels
 )
 
 // PortForwardType defines the type of port forwarding
@@ -78,7 +91,9 @@ type Model struct {
 	historyManager *history.HistoryManager
 	pingManager    *connectivity.PingManager
 	sortMode       SortMode
-	configFile     string // Path to the SSH config file
+	configFile     string        // Path to the SSH config file
+	columns        []tableColumn // Configured table columns, in display order
+	showPreview    bool          // Show the preview pane next to the table
 
 	// Application configuration
 	appConfig *config.AppConfig
@@ -96,6 +111,10 @@ type Model struct {
 	portForwardForm  *portForwardModel
 	helpForm         *helpModel
 	fileSelectorForm *fileSelectorModel
+	columnsForm      *columnsFormModel
+	palette          *commandPaletteModel
+	commandForm      *commandModel
+	tunnelsForm      *tunnelsModel
 
 	// Terminal size and styles
 	width  int
@@ -106,6 +125,28 @@ type Model struct {
 	// Error handling
 	errorMessage string
 	showingError bool
+
+	// Outcome of the last SSH session, shown in the status bar
+	lastSession *sessionStatus
+
+	// Check of all hosts in progress, cancelled when leaving the TUI
+	pingCancel  context.CancelFunc
+	pingResults <-chan *connectivity.HostPingResult
+
+	// Background health monitor and the latency history of each host
+	health     *connectivity.HealthHistory
+	monitoring bool
+	monitorGen int // Incremented whenever the monitor starts or stops
+
+	// Port forwarding tunnels running in the background, created when first needed
+	tunnelManager *tunnel.Manager
+
+	// Port forwarding tunnels running in sshm, created when first needed
+	forwardManager *forward.Manager
+
+	// Alert flashed above the search bar, e.g. when a pinned host changes state
+	alertMessage string
+	alertSeq     int
 }
 
 // updateTableStyles updates the table header border color based on focus state
@@ -115,18 +156,10 @@ func (m *Model) updateTableStyles() {
 
 	if m.searchMode {
 		// When in search mode, use secondary color for table header
-		s.Header = s.Header.
-			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color(SecondaryColor)).
-			BorderBottom(true).
-			Bold(false)
+		s.Header = m.styles.TableHeaderUnfocused
 	} else {
 		// When table is focused, use primary color for table header
-		s.Header = s.Header.
-			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color(PrimaryColor)).
-			BorderBottom(true).
-			Bold(false)
+		s.Header = m.styles.TableHeaderFocused
 	}
 
 	m.table.SetStyles(s)
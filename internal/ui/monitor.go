@@ -0,0 +1,215 @@
+package ui
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// monitorTickMsg triggers a round of background checks. Ticks of a monitor
+// that was stopped since carry an older generation and are ignored.
+type monitorTickMsg struct {
+	generation int
+}
+
+// alertClearMsg clears the alert flashed above the search bar, unless a newer one replaced it
+type alertClearMsg struct {
+	seq int
+}
+
+// sparklineLevels are the characters used to draw latencies, from fastest to slowest
+var sparklineLevels = []rune("▁▂▃▄▅▆▇█")
+
+// sparklineOffline marks a check in which the host was offline
+const sparklineOffline = '×'
+
+// monitorConfig returns the health monitor configuration
+func (m *Model) monitorConfig() config.MonitorConfig {
+	if m.appConfig == nil {
+		return config.GetDefaultMonitorConfig()
+	}
+	return m.appConfig.Connectivity.Monitor
+}
+
+// monitorTickCmd schedules the next round of background checks after the
+// configured interval and a random jitter
+func (m *Model) monitorTickCmd() tea.Cmd {
+	monitor := m.monitorConfig()
+	delay := monitor.IntervalDuration()
+	if jitter := monitor.JitterDuration(); jitter > 0 {
+		delay += rand.N(jitter)
+	}
+
+	generation := m.monitorGen
+	return tea.Tick(delay, func(time.Time) tea.Msg {
+		return monitorTickMsg{generation: generation}
+	})
+}
+
+// handleMonitorTick checks every host unless a check is still running,
+// and schedules the next round
+func (m Model) handleMonitorTick(msg monitorTickMsg) (tea.Model, tea.Cmd) {
+	if !m.monitoring || msg.generation != m.monitorGen {
+		return m, nil
+	}
+
+	cmds := []tea.Cmd{m.monitorTickCmd()}
+	if m.pingResults == nil {
+		cmds = append(cmds, m.startPingAllCmd())
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// toggleMonitor starts or stops the background health monitor
+func (m Model) toggleMonitor() (tea.Model, tea.Cmd) {
+	m.monitoring = !m.monitoring
+	m.monitorGen++
+	if !m.monitoring {
+		cmd := m.showAlert("Background monitor stopped")
+		return m, cmd
+	}
+
+	// Check right away, then on every interval
+	cmds := []tea.Cmd{m.monitorTickCmd(), m.showAlert("Background monitor started")}
+	if m.pingResults == nil {
+		cmds = append(cmds, m.startPingAllCmd())
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// togglePinned pins the selected host, announcing its state changes, or unpins it
+func (m Model) togglePinned() (tea.Model, tea.Cmd) {
+	hostName, ok := m.selectedHostName()
+	if !ok || m.appConfig == nil {
+		return m, nil
+	}
+
+	monitor := &m.appConfig.Connectivity.Monitor
+	message := fmt.Sprintf("📌 %s pinned: state changes will be announced", hostName)
+	if index := slices.Index(monitor.PinnedHosts, hostName); index >= 0 {
+		monitor.PinnedHosts = slices.Delete(monitor.PinnedHosts, index, index+1)
+		message = fmt.Sprintf("%s unpinned", hostName)
+	} else {
+		monitor.PinnedHosts = append(monitor.PinnedHosts, hostName)
+	}
+
+	cmd := tea.Batch(m.saveAppConfig(), m.showAlert(message))
+	return m, cmd
+}
+
+// recordHealth adds a check result to the health history and announces
+// the state changes of pinned hosts
+func (m *Model) recordHealth(result *connectivity.HostPingResult) tea.Cmd {
+	if m.health == nil || result == nil {
+		return nil
+	}
+	if !m.health.Record(result) || !m.monitorConfig().IsPinned(result.HostName) {
+		return nil
+	}
+
+	state := "is back online"
+	if result.Status != connectivity.StatusOnline {
+		state = "went offline: " + result.Kind.String()
+	}
+	cmds := []tea.Cmd{m.showAlert(fmt.Sprintf("📌 %s %s", result.HostName, state))}
+	if m.monitorConfig().Bell {
+		cmds = append(cmds, ringBell)
+	}
+	return tea.Batch(cmds...)
+}
+
+// ringBell rings the terminal bell
+func ringBell() tea.Msg {
+	fmt.Fprint(os.Stderr, "\a")
+	return nil
+}
+
+// showAlert flashes a message above the search bar for a few seconds
+func (m *Model) showAlert(message string) tea.Cmd {
+	m.alertSeq++
+	m.alertMessage = message
+
+	seq := m.alertSeq
+	return tea.Tick(5*time.Second, func(time.Time) tea.Msg {
+		return alertClearMsg{seq: seq}
+	})
+}
+
+// saveChecks writes the health history and the last check results to disk,
+// so that the next run shows them right away. It shows an error if they cannot be saved.
+func (m *Model) saveChecks() tea.Cmd {
+	if err := m.writeChecks(); err != nil {
+		return m.showError(fmt.Sprintf("Could not save connectivity results: %v", err))
+	}
+	return nil
+}
+
+// writeChecks writes the health history and the ping cache
+func (m *Model) writeChecks() error {
+	if m.health != nil {
+		if err := m.health.Save(); err != nil {
+			return err
+		}
+	}
+	if m.pingManager != nil {
+		path, err := connectivity.GetPingCachePath()
+		if err != nil {
+			return err
+		}
+		return m.pingManager.SaveResults(path, m.configFile)
+	}
+	return nil
+}
+
+// renderSparkline draws the latency of the last width samples, scaled
+// between the fastest and the slowest of them
+func renderSparkline(samples []connectivity.HealthSample, width int) string {
+	if len(samples) > width {
+		samples = samples[len(samples)-width:]
+	}
+
+	var fastest, slowest time.Duration
+	first := true
+	for _, sample := range samples {
+		if !sample.Online {
+			continue
+		}
+		if first || sample.Latency < fastest {
+			fastest = sample.Latency
+		}
+		if first || sample.Latency > slowest {
+			slowest = sample.Latency
+		}
+		first = false
+	}
+
+	var b strings.Builder
+	for _, sample := range samples {
+		if !sample.Online {
+			b.WriteRune(sparklineOffline)
+			continue
+		}
+		level := 0
+		if slowest > fastest {
+			level = int((sample.Latency - fastest) * time.Duration(len(sparklineLevels)-1) / (slowest - fastest))
+		}
+		b.WriteRune(sparklineLevels[level])
+	}
+	return b.String()
+}
+
+// formatUptime formats the uptime of a host over its samples
+func formatUptime(uptime float64, samples []connectivity.HealthSample) string {
+	if len(samples) == 0 {
+		return "No data"
+	}
+	return fmt.Sprintf("%.1f%% over %d checks since %s", uptime*100, len(samples), formatTimeAgo(samples[0].Time))
+}
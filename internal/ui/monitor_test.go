@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+)
+
+func TestRenderSparkline(t *testing.T) {
+	samples := []connectivity.HealthSample{
+		{Online: true, Latency: 10 * time.Millisecond},
+		{Online: true, Latency: 80 * time.Millisecond},
+		{Online: false},
+		{Online: true, Latency: 45 * time.Millisecond},
+	}
+
+	tests := []struct {
+		width int
+		want  string
+	}{
+		{10, "▁█×▄"},
+		{2, "×▁"}, // Only the last samples are drawn and scaled
+	}
+	for _, tt := range tests {
+		if got := renderSparkline(samples, tt.width); got != tt.want {
+			t.Errorf("renderSparkline(width %d) = %q, want %q", tt.width, got, tt.want)
+		}
+	}
+
+	// Identical latencies are drawn at the lowest level
+	if got := renderSparkline(samples[:1], 10); got != "▁" {
+		t.Errorf("renderSparkline() of a single sample = %q, want %q", got, "▁")
+	}
+}
+
+func TestRecordHealthAnnouncesPinnedHosts(t *testing.T) {
+	m := createTestModel()
+	m.health = connectivity.NewHealthHistory(filepath.Join(t.TempDir(), "health.json"), "", 10, time.Hour)
+	m.appConfig = &config.AppConfig{Connectivity: config.ConnectivityConfig{
+		Monitor: config.MonitorConfig{PinnedHosts: []string{"server1"}},
+	}}
+
+	online := func(name string) *connectivity.HostPingResult {
+		return &connectivity.HostPingResult{HostName: name, Status: connectivity.StatusOnline, Kind: connectivity.KindSSHReady}
+	}
+	offline := func(name string) *connectivity.HostPingResult {
+		return &connectivity.HostPingResult{HostName: name, Status: connectivity.StatusOffline, Kind: connectivity.KindTCPTimeout}
+	}
+
+	m.recordHealth(online("server1"))
+	m.recordHealth(online("server2"))
+	if m.alertMessage != "" {
+		t.Fatalf("Expected no alert for first samples, got %q", m.alertMessage)
+	}
+
+	m.recordHealth(offline("server2"))
+	if m.alertMessage != "" {
+		t.Errorf("Expected no alert for a host that is not pinned, got %q", m.alertMessage)
+	}
+
+	if cmd := m.recordHealth(offline("server1")); cmd == nil {
+		t.Error("Expected a command clearing the alert")
+	}
+	if !strings.Contains(m.alertMessage, "server1 went offline: timeout") {
+		t.Errorf("Expected an alert for the pinned host, got %q", m.alertMessage)
+	}
+}
+
+func TestMonitorTickIgnoresStoppedMonitor(t *testing.T) {
+	m := createTestModel()
+	m.monitoring = true
+	m.monitorGen = 2
+
+	if _, cmd := m.handleMonitorTick(monitorTickMsg{generation: 1}); cmd != nil {
+		t.Error("Expected ticks of a previous monitor to be ignored")
+	}
+	if _, cmd := m.handleMonitorTick(monitorTickMsg{generation: 2}); cmd == nil {
+		t.Error("Expected the next round to be scheduled")
+	}
+}
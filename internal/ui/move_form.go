@@ -175,6 +175,7 @@ func (m standaloneMoveForm) View() string {
 
 // RunMoveForm provides backward compatibility for standalone move form
 func RunMoveForm(hostName string, configFile string) error {
+	applyConfiguredTheme()
 	styles := NewStyles(80)
 	moveForm, err := NewMoveForm(hostName, styles, 80, 24, configFile)
 	if err != nil {
@@ -0,0 +1,524 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Command palette identifiers
+const (
+	paletteConnect      = "connect"
+	paletteInfo         = "info"
+	paletteEdit         = "edit"
+	paletteAdd          = "add"
+	paletteDelete       = "delete"
+	paletteMove         = "move"
+	paletteMoveToFile   = "move-to-file"
+	paletteForward      = "forward"
+	paletteRunCommand   = "run-command"
+	paletteTunnels      = "tunnels"
+	palettePingHost     = "ping-host"
+	palettePingAll      = "ping-all"
+	paletteSortName     = "sort-name"
+	paletteSortLastUsed = "sort-last-used"
+	paletteSortLatency  = "sort-latency"
+	paletteSortCycle    = "sort-cycle"
+	paletteMonitor      = "monitor"
+	palettePin          = "pin"
+	paletteTheme        = "theme"
+	paletteReload       = "reload"
+	paletteOpenEditor   = "open-editor"
+	paletteColumns      = "columns"
+	palettePreview      = "preview"
+	paletteSearch       = "search"
+	paletteKeysChanged  = "host-keys-changed"
+	paletteHelp         = "help"
+	paletteQuit         = "quit"
+)
+
+// paletteCommand is an action offered by the command palette
+type paletteCommand struct {
+	id        string
+	title     string
+	key       string // Key binding of the action, if any
+	needsHost bool   // The action applies to the selected host
+
+	// args lists the values the action can be applied to; nil for actions without argument
+	args func(m *Model) ([]paletteArg, error)
+}
+
+// paletteArg is a value an action taking an argument can be applied to
+type paletteArg struct {
+	label string
+	value string
+}
+
+// paletteRunMsg is sent when a command (and its argument, if any) is chosen
+type paletteRunMsg struct {
+	id  string
+	arg string
+}
+
+// paletteCloseMsg is sent when the command palette is closed without running a command
+type paletteCloseMsg struct{}
+
+// paletteCommands returns every action offered by the command palette
+func (m *Model) paletteCommands() []paletteCommand {
+	quitKey := "q"
+	if m.appConfig != nil && len(m.appConfig.KeyBindings.QuitKeys) > 0 {
+		quitKey = strings.Join(m.appConfig.KeyBindings.QuitKeys, ", ")
+	}
+
+	return []paletteCommand{
+		{id: paletteConnect, title: "Connect to host", key: "enter", needsHost: true},
+		{id: paletteInfo, title: "Show host information", key: "i", needsHost: true},
+		{id: paletteEdit, title: "Edit host", key: "e", needsHost: true},
+		{id: paletteAdd, title: "Add new host", key: "a"},
+		{id: paletteDelete, title: "Delete host", key: "d", needsHost: true},
+		{id: paletteMove, title: "Move host to another config file", key: "m", needsHost: true},
+		{id: paletteMoveToFile, title: "Move host to file…", needsHost: true, args: moveTargetArgs},
+		{id: paletteForward, title: "Set up port forwarding", key: "f", needsHost: true},
+		{id: paletteRunCommand, title: "Run command on host", key: "x", needsHost: true},
+		{id: paletteTunnels, title: "Show tunnels", key: "t"},
+		{id: palettePingHost, title: "Ping host", needsHost: true},
+		{id: palettePingAll, title: "Ping all hosts", key: "p"},
+		{id: paletteMonitor, title: "Toggle background monitor", key: "M"},
+		{id: palettePin, title: "Pin or unpin host", key: "P", needsHost: true},
+		{id: paletteSortName, title: "Sort by name", key: "n"},
+		{id: paletteSortLastUsed, title: "Sort by last login", key: "r"},
+		{id: paletteSortLatency, title: "Sort by latency", key: "l"},
+		{id: paletteSortCycle, title: "Cycle sort modes", key: "s"},
+		{id: paletteTheme, title: "Switch theme…", args: themeArgs},
+		{id: paletteReload, title: "Reload SSH config"},
+		{id: paletteOpenEditor, title: "Open config file in editor"},
+		{id: paletteColumns, title: "Choose table columns", key: "c"},
+		{id: palettePreview, title: "Toggle preview pane", key: "v"},
+		{id: paletteSearch, title: "Search hosts", key: "/"},
+		{id: paletteKeysChanged, title: "Filter hosts whose host key changed", key: "K"},
+		{id: paletteHelp, title: "Show help", key: "h"},
+		{id: paletteQuit, title: "Quit", key: quitKey},
+	}
+}
+
+// moveTargetArgs lists the config files the selected host can be moved to
+func moveTargetArgs(m *Model) ([]paletteArg, error) {
+	hostName, ok := m.selectedHostName()
+	if !ok {
+		return nil, fmt.Errorf("no host selected")
+	}
+
+	files, err := config.GetConfigFilesExcludingCurrent(hostName, m.configFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no includes found in SSH config file - move operation requires multiple config files")
+	}
+
+	args := make([]paletteArg, 0, len(files))
+	for _, file := range files {
+		args = append(args, paletteArg{label: formatConfigFile(file), value: file})
+	}
+	return args, nil
+}
+
+// themeArgs lists the available colour themes
+func themeArgs(m *Model) ([]paletteArg, error) {
+	themeConfig := config.GetDefaultThemeConfig()
+	if m.appConfig != nil {
+		themeConfig = m.appConfig.Theme
+	}
+
+	var args []paletteArg
+	for _, name := range AvailableThemes(themeConfig) {
+		label := name
+		if name == themeConfig.Name {
+			label += " (current)"
+		}
+		args = append(args, paletteArg{label: label, value: name})
+	}
+	return args, nil
+}
+
+// fuzzyScore reports whether all characters of the pattern appear in order in
+// the text, and scores the match: consecutive characters and matches at the
+// start of words score higher. Matching is case-insensitive.
+func fuzzyScore(pattern, text string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+
+	score := 0
+	pi := 0
+	previous := -2
+	for ti := 0; ti < len(t) && pi < len(p); ti++ {
+		if unicode.IsSpace(p[pi]) {
+			// Spaces in the pattern match anywhere
+			pi++
+			if pi == len(p) {
+				break
+			}
+		}
+		if t[ti] != p[pi] {
+			continue
+		}
+
+		score++
+		if ti == previous+1 {
+			score += 2
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) {
+			score += 3
+		}
+		previous = ti
+		pi++
+	}
+
+	if pi < len(p) {
+		return 0, false
+	}
+	return score, true
+}
+
+// paletteEntry is a line of the command palette: a command or an argument
+type paletteEntry struct {
+	label string
+	key   string
+	index int // Index in the unfiltered list
+}
+
+type commandPaletteModel struct {
+	input    textinput.Model
+	commands []paletteCommand
+	hasHost  bool
+
+	// When choosing an argument, command is the command the argument is for
+	command *paletteCommand
+	args    []paletteArg
+
+	filtered []paletteEntry
+	selected int
+	err      string
+
+	styles Styles
+	width  int
+	height int
+}
+
+// NewCommandPalette creates a command palette listing the given commands
+func NewCommandPalette(commands []paletteCommand, hasHost bool, styles Styles, width, height int) *commandPaletteModel {
+	input := textinput.New()
+	input.Placeholder = "Type a command..."
+	input.Prompt = "> "
+	input.CharLimit = 50
+	input.Focus()
+
+	p := &commandPaletteModel{
+		input:    input,
+		commands: commands,
+		hasHost:  hasHost,
+		styles:   styles,
+		width:    width,
+		height:   height,
+	}
+	p.filter()
+	return p
+}
+
+// openCommandPalette shows the command palette
+func (m Model) openCommandPalette() (tea.Model, tea.Cmd) {
+	m.palette = NewCommandPalette(m.paletteCommands(), m.selectedHost() != nil, m.styles, m.width, m.height)
+	m.viewMode = ViewPalette
+	return m, textinput.Blink
+}
+
+// entries returns the unfiltered entries of the current step
+func (p *commandPaletteModel) entries() []paletteEntry {
+	var entries []paletteEntry
+	if p.command != nil {
+		for i, arg := range p.args {
+			entries = append(entries, paletteEntry{label: arg.label, index: i})
+		}
+		return entries
+	}
+
+	for i, cmd := range p.commands {
+		if cmd.needsHost && !p.hasHost {
+			continue
+		}
+		entries = append(entries, paletteEntry{label: cmd.title, key: cmd.key, index: i})
+	}
+	return entries
+}
+
+// filter applies the fuzzy filter to the entries, best matches first
+func (p *commandPaletteModel) filter() {
+	query := strings.TrimSpace(p.input.Value())
+
+	type scored struct {
+		entry paletteEntry
+		score int
+	}
+	var matches []scored
+	for _, entry := range p.entries() {
+		if score, ok := fuzzyScore(query, entry.label); ok {
+			matches = append(matches, scored{entry, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	p.filtered = p.filtered[:0]
+	for _, match := range matches {
+		p.filtered = append(p.filtered, match.entry)
+	}
+	if p.selected >= len(p.filtered) {
+		p.selected = 0
+	}
+}
+
+func (p *commandPaletteModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (p *commandPaletteModel) Update(msg tea.Msg) (*commandPaletteModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.width = msg.Width
+		p.height = msg.Height
+		p.styles = NewStyles(p.width)
+		return p, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "ctrl+c", "ctrl+p":
+			if p.command != nil {
+				// Go back to the command list
+				p.command = nil
+				p.args = nil
+				p.err = ""
+				p.input.SetValue("")
+				p.filter()
+				return p, nil
+			}
+			return p, func() tea.Msg { return paletteCloseMsg{} }
+
+		case "up", "ctrl+k":
+			if p.selected > 0 {
+				p.selected--
+			}
+			return p, nil
+
+		case "down", "ctrl+j":
+			if p.selected < len(p.filtered)-1 {
+				p.selected++
+			}
+			return p, nil
+
+		case "enter":
+			return p.choose()
+		}
+	}
+
+	oldValue := p.input.Value()
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	if p.input.Value() != oldValue {
+		p.selected = 0
+		p.filter()
+	}
+	return p, cmd
+}
+
+// choose runs the selected command, or asks for its argument first
+func (p *commandPaletteModel) choose() (*commandPaletteModel, tea.Cmd) {
+	if len(p.filtered) == 0 {
+		return p, nil
+	}
+	entry := p.filtered[p.selected]
+
+	if p.command != nil {
+		run := paletteRunMsg{id: p.command.id, arg: p.args[entry.index].value}
+		return p, func() tea.Msg { return run }
+	}
+
+	command := p.commands[entry.index]
+	if command.args == nil {
+		run := paletteRunMsg{id: command.id}
+		return p, func() tea.Msg { return run }
+	}
+
+	return p, func() tea.Msg { return paletteArgsMsg{command: command} }
+}
+
+// paletteArgsMsg asks the model for the arguments of a command, which
+// are computed from the model state
+type paletteArgsMsg struct {
+	command paletteCommand
+}
+
+// setArgs switches the palette to choosing an argument for a command
+func (p *commandPaletteModel) setArgs(command paletteCommand, args []paletteArg, err error) {
+	p.command = &command
+	p.args = args
+	p.err = ""
+	if err != nil {
+		p.err = err.Error()
+	}
+	p.selected = 0
+	p.input.SetValue("")
+	p.filter()
+}
+
+func (p *commandPaletteModel) View() string {
+	width := p.width * 2 / 3
+	if width < 40 {
+		width = 40
+	}
+	if width > 80 {
+		width = 80
+	}
+	contentWidth := width - 4
+
+	var b strings.Builder
+
+	title := "Command Palette"
+	if p.command != nil {
+		title = strings.TrimSuffix(p.command.title, "…")
+	}
+	b.WriteString(p.styles.FormTitle.Render(title))
+	b.WriteString("\n\n")
+	b.WriteString(p.input.View())
+	b.WriteString("\n\n")
+
+	// Leave room for the title, input, help and borders
+	maxEntries := p.height - 12
+	if maxEntries < 3 {
+		maxEntries = 3
+	}
+	start := 0
+	if p.selected >= maxEntries {
+		start = p.selected - maxEntries + 1
+	}
+
+	switch {
+	case p.err != "":
+		b.WriteString(p.styles.ErrorText.Width(contentWidth).Render(p.err))
+		b.WriteString("\n")
+	case len(p.filtered) == 0:
+		b.WriteString(p.styles.InfoMuted.Render("No matching command"))
+		b.WriteString("\n")
+	}
+
+	for i := start; i < len(p.filtered) && i < start+maxEntries; i++ {
+		entry := p.filtered[i]
+
+		label := entry.label
+		key := entry.key
+		gap := contentWidth - 2 - lipgloss.Width(label) - lipgloss.Width(key)
+		if gap < 1 {
+			gap = 1
+		}
+		line := label + strings.Repeat(" ", gap) + key
+
+		if i == p.selected {
+			b.WriteString(p.styles.Selected.Render("▶ " + line))
+		} else {
+			b.WriteString("  " + label + strings.Repeat(" ", gap) + p.styles.HelpText.Render(key))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	help := "↑/↓: navigate • Enter: run • Esc: close"
+	if p.command != nil {
+		help = "↑/↓: navigate • Enter: select • Esc: back"
+	}
+	b.WriteString(p.styles.FormHelp.Render(help))
+
+	return lipgloss.Place(
+		p.width,
+		p.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		p.styles.FormContainer.Width(width).Render(b.String()),
+	)
+}
+
+// runPaletteCommand runs a command chosen in the command palette
+func (m Model) runPaletteCommand(id, arg string) (tea.Model, tea.Cmd) {
+	switch id {
+	case paletteConnect:
+		return m.connectToSelectedHost()
+	case paletteInfo:
+		return m.openInfoForm()
+	case paletteEdit:
+		return m.openEditForm()
+	case paletteAdd:
+		return m.openAddForm()
+	case paletteDelete:
+		return m.startDeleteSelectedHost()
+	case paletteMove:
+		return m.openMoveForm()
+	case paletteMoveToFile:
+		return m.moveSelectedHostToFile(arg)
+	case paletteForward:
+		return m.openPortForwardForm()
+	case paletteRunCommand:
+		return m.openCommandForm()
+	case paletteTunnels:
+		return m.openTunnels("")
+	case palettePingHost:
+		return m.pingSelectedHost()
+	case palettePingAll:
+		cmd := m.startPingAllCmd()
+		return m, cmd
+	case paletteSortName:
+		return m.setSortMode(SortByName)
+	case paletteSortLastUsed:
+		return m.setSortMode(SortByLastUsed)
+	case paletteSortLatency:
+		return m.setSortMode(SortByLatency)
+	case paletteSortCycle:
+		return m.setSortMode((m.sortMode + 1) % sortModeCount)
+	case paletteMonitor:
+		return m.toggleMonitor()
+	case palettePin:
+		return m.togglePinned()
+	case paletteTheme:
+		return m.switchTheme(arg)
+	case paletteReload:
+		return m.reload()
+	case paletteOpenEditor:
+		return m.openConfigInEditor()
+	case paletteColumns:
+		return m.openColumnsForm()
+	case palettePreview:
+		return m.togglePreview()
+	case paletteSearch:
+		m.searchMode = true
+		m.updateTableStyles()
+		m.table.Blur()
+		m.searchInput.Focus()
+		return m, textinput.Blink
+	case paletteKeysChanged:
+		return m.toggleChangedHostKeysFilter()
+	case paletteHelp:
+		return m.openHelp()
+	case paletteQuit:
+		return m, tea.Quit
+	}
+	return m, nil
+}
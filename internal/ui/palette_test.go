@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		pattern string
+		text    string
+		match   bool
+	}{
+		{"", "Connect to host", true},
+		{"conn", "Connect to host", true},
+		{"CTH", "Connect to host", true},
+		{"ping all", "Ping all hosts", true},
+		{"pah", "Ping all hosts", true},
+		{"xyz", "Ping all hosts", false},
+		{"hostp", "Ping all hosts", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.text, func(t *testing.T) {
+			if _, ok := fuzzyScore(tt.pattern, tt.text); ok != tt.match {
+				t.Errorf("fuzzyScore(%q, %q) match = %v, want %v", tt.pattern, tt.text, ok, tt.match)
+			}
+		})
+	}
+
+	// Prefix and consecutive matches rank higher than scattered ones
+	prefix, _ := fuzzyScore("sort", "Sort by name")
+	scattered, _ := fuzzyScore("sort", "Show host information over routes")
+	if prefix <= scattered {
+		t.Errorf("Expected prefix match (%d) to score higher than scattered match (%d)", prefix, scattered)
+	}
+}
+
+func typePalette(t *testing.T, m Model, text string) Model {
+	t.Helper()
+	for _, r := range text {
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = newModel.(Model)
+	}
+	return m
+}
+
+// runCmd runs a command and feeds the resulting message back to the model
+func runCmd(m Model, cmd tea.Cmd) Model {
+	if cmd == nil {
+		return m
+	}
+	newModel, _ := m.Update(cmd())
+	return newModel.(Model)
+}
+
+func TestCommandPaletteRunsCommand(t *testing.T) {
+	m := createTestModel()
+
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	m = newModel.(Model)
+	if m.viewMode != ViewPalette || m.palette == nil {
+		t.Fatal("Expected ctrl+p to open the command palette")
+	}
+
+	m = typePalette(t, m, "sort last")
+	if len(m.palette.filtered) == 0 || m.palette.filtered[0].label != "Sort by last login" {
+		t.Fatalf("Expected 'Sort by last login' as best match, got %+v", m.palette.filtered)
+	}
+	if m.palette.filtered[0].key != "r" {
+		t.Errorf("Expected the key binding to be shown, got %q", m.palette.filtered[0].key)
+	}
+
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = runCmd(newModel.(Model), cmd)
+
+	if m.viewMode != ViewList || m.palette != nil {
+		t.Error("Expected the palette to close after running a command")
+	}
+	if m.sortMode != SortByLastUsed {
+		t.Errorf("Expected sort mode to be last used, got %v", m.sortMode)
+	}
+}
+
+func TestCommandPaletteArgument(t *testing.T) {
+	previous := CurrentTheme()
+	defer SetTheme(previous)
+
+	m := createTestModel()
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	m = newModel.(Model)
+
+	m = typePalette(t, m, "theme")
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = runCmd(newModel.(Model), cmd)
+
+	if m.palette == nil || m.palette.command == nil || m.palette.command.id != paletteTheme {
+		t.Fatal("Expected the palette to ask for a theme")
+	}
+	if len(m.palette.filtered) != len(AvailableThemes(config.ThemeConfig{})) {
+		t.Errorf("Expected every theme to be listed, got %d entries", len(m.palette.filtered))
+	}
+
+	m = typePalette(t, m, "solar")
+	newModel, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = runCmd(newModel.(Model), cmd)
+
+	if CurrentTheme().Name != ThemeSolarized {
+		t.Errorf("Expected the solarized theme to be active, got %q", CurrentTheme().Name)
+	}
+	if m.viewMode != ViewList {
+		t.Error("Expected to return to the list view")
+	}
+}
+
+func TestCommandPaletteHidesHostCommandsWithoutHost(t *testing.T) {
+	m := createTestModel()
+	m.filteredHosts = []config.SSHHost{}
+	m.updateTableRows()
+
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	m = newModel.(Model)
+
+	for _, entry := range m.palette.filtered {
+		if entry.label == "Connect to host" {
+			t.Error("Host commands should not be offered when no host is selected")
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/history"
+	"github.com/Gu1llaum-3/sshm/internal/ports"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// discoveryTimeout is how long listing the ports of a host may take
+const discoveryTimeout = 15 * time.Second
+
+// portForwardDiscoveredMsg carries the ports listened on by the host
+type portForwardDiscoveredMsg struct {
+	listeners []ports.Listener
+	err       error
+}
+
+// discoverListeners lists the ports listened on by a host over ssh.
+// Tests replace it so as not to connect anywhere.
+var discoverListeners = func(configFile, hostName string) ([]ports.Listener, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), discoveryTimeout)
+	defer cancel()
+
+	var stderr strings.Builder
+	cmd := exec.CommandContext(ctx, "ssh", commandSSHArgs(configFile, hostName, ports.RemoteProbeCommand)...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+
+	listeners := ports.ParseListeners(string(output))
+	if len(listeners) > 0 {
+		return listeners, nil
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		return nil, fmt.Errorf("listing the ports of %s timed out", hostName)
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 255:
+		// ssh itself failed
+		message := strings.TrimSpace(stderr.String())
+		if lines := strings.Split(message, "\n"); message != "" {
+			return nil, errors.New(lines[len(lines)-1])
+		}
+		return nil, fmt.Errorf("could not connect to %s", hostName)
+	case err != nil && len(output) == 0:
+		return nil, fmt.Errorf("neither ss nor netstat could list the ports of %s", hostName)
+	default:
+		return nil, nil
+	}
+}
+
+// discover lists the ports listened on by the host
+func (m *portForwardModel) discover() tea.Cmd {
+	m.mode = pfModeDiscover
+	m.discovering = true
+	m.listeners = nil
+	m.listenerCursor = 0
+	m.err = ""
+	m.inputs[m.focused].Blur()
+
+	configFile, hostName := m.configFile, m.hostName
+	return func() tea.Msg {
+		listeners, err := discoverListeners(configFile, hostName)
+		return portForwardDiscoveredMsg{listeners: listeners, err: err}
+	}
+}
+
+// updateDiscover handles the keys of the list of discovered ports
+func (m *portForwardModel) updateDiscover(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc", "q", "ctrl+c":
+		return m.leaveDiscover()
+
+	case "up", "k":
+		if m.listenerCursor > 0 {
+			m.listenerCursor--
+		}
+
+	case "down", "j":
+		if m.listenerCursor < len(m.listeners)-1 {
+			m.listenerCursor++
+		}
+
+	case "r":
+		if !m.discovering {
+			return m.discover()
+		}
+
+	case "enter":
+		if m.listenerCursor < len(m.listeners) {
+			m.useListener(m.listeners[m.listenerCursor])
+			return m.leaveDiscover()
+		}
+	}
+	return nil
+}
+
+// leaveDiscover goes back to the forward inputs
+func (m *portForwardModel) leaveDiscover() tea.Cmd {
+	m.mode = pfModeEdit
+	m.discovering = false
+	m.err = ""
+	m.inputs[m.focused].Focus()
+	return textinput.Blink
+}
+
+// useListener fills the inputs with a local forward to a discovered port,
+// listening on a free local port
+func (m *portForwardModel) useListener(listener ports.Listener) {
+	bindAddress := strings.TrimSpace(m.inputs[pfBindAddressInput].Value())
+	local := ports.SuggestLocal(bindAddress, listener.Port)
+	if local == 0 {
+		local = listener.Port
+	}
+
+	m.setForward(history.PortForwardConfig{
+		Type:        "local",
+		LocalPort:   strconv.Itoa(local),
+		RemoteHost:  listener.TargetHost(),
+		RemotePort:  strconv.Itoa(listener.Port),
+		BindAddress: bindAddress,
+	})
+	m.conflict = nil
+	m.status = fmt.Sprintf("Forwarding local port %d to %s", local, listener)
+	m.inputs[m.focused].Blur()
+	m.focused = pfLocalPortInput
+}
+
+// viewDiscover renders the ports listened on by the host
+func (m *portForwardModel) viewDiscover() string {
+	var sections []string
+
+	sections = append(sections, m.styles.Header.Render("🔍 Listening Ports"))
+	sections = append(sections, m.styles.HelpText.Render(fmt.Sprintf("Host: %s", m.hostName)))
+
+	switch {
+	case m.discovering:
+		sections = append(sections, m.styles.HelpText.Render("Listing the ports of the host..."))
+	case m.err != "":
+		sections = append(sections, m.styles.Error.Render("Error: "+m.err))
+	case len(m.listeners) == 0:
+		sections = append(sections, m.styles.HelpText.Render("No listening port found"))
+	default:
+		header := fmt.Sprintf("  %6s  %-24s %s", "PORT", "PROCESS", "ADDRESS")
+		lines := []string{m.styles.Label.Render(header)}
+		for i, listener := range m.listeners {
+			process := "-"
+			if listener.Process != "" {
+				process = listener.Process
+				if listener.PID != 0 {
+					process += fmt.Sprintf(" (%d)", listener.PID)
+				}
+			}
+			address := listener.Address
+			if listener.Wildcard() {
+				address = "all addresses"
+			}
+			line := fmt.Sprintf("%6d  %-24s %s", listener.Port, truncate(process, 24), address)
+			if i == m.listenerCursor {
+				lines = append(lines, m.styles.Selected.Render("▶ "+line))
+			} else {
+				lines = append(lines, "  "+line)
+			}
+		}
+		sections = append(sections, lipgloss.JoinVertical(lipgloss.Left, lines...))
+	}
+
+	sections = append(sections, m.styles.HelpText.Render(" ↑/↓: select • Enter: forward this port • r: refresh • Esc: back to the form"))
+
+	content := lipgloss.JoinVertical(lipgloss.Left, sections...)
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		m.styles.FormContainer.Render(content),
+	)
+}
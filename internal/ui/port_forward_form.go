@@ -1,11 +1,15 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Gu1llaum-3/sshm/internal/config"
 	"github.com/Gu1llaum-3/sshm/internal/history"
+	"github.com/Gu1llaum-3/sshm/internal/ports"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -18,6 +22,16 @@ const (
 	pfRemoteHostInput
 	pfRemotePortInput
 	pfBindAddressInput
+	pfNameInput
+)
+
+// portForwardMode is the screen shown by the port forward form
+type portForwardMode int
+
+const (
+	pfModeEdit     portForwardMode = iota // Forwards to set up
+	pfModeProfiles                        // Saved profiles of the host
+	pfModeDiscover                        // Ports listened on by the host
 )
 
 type portForwardModel struct {
@@ -31,6 +45,36 @@ type portForwardModel struct {
 	height         int
 	configFile     string
 	historyManager *history.HistoryManager
+
+	mode          portForwardMode
+	profiles      []history.PortForwardProfile
+	selected      int                         // Selected profile
+	confirmDelete bool                        // Waiting for the deletion of the selected profile to be confirmed
+	editing       string                      // Name of the profile being edited, empty for a new one
+	forwards      []history.PortForwardConfig // Forwards added besides the one in the inputs
+	forwardCursor int                         // Selected forward in forwards, -1 if none
+	conflict      *ports.Conflict             // Port in use reported by the last error, if any
+	status        string                      // Outcome of saving the forwards in the SSH config
+
+	discovering    bool             // The ports of the host are being listed
+	listeners      []ports.Listener // Ports listened on by the host
+	listenerCursor int              // Selected port in listeners
+}
+
+// checkLocalForward checks the local end of a forward can be listened on.
+// Tests replace it so as not to depend on the ports in use on the machine.
+var checkLocalForward = func(forward history.PortForwardConfig) error {
+	if forward.Type == "remote" {
+		return nil
+	}
+	if forward.ListenSocket() {
+		return ports.CheckSocket(forward.LocalPort)
+	}
+	port, err := strconv.Atoi(forward.LocalPort)
+	if err != nil {
+		return err
+	}
+	return ports.CheckLocal(forward.BindAddress, port)
 }
 
 // portForwardSubmitMsg is sent when the port forward form is submitted
@@ -42,9 +86,15 @@ type portForwardSubmitMsg struct {
 // portForwardCancelMsg is sent when the port forward form is cancelled
 type portForwardCancelMsg struct{}
 
+// portForwardConfigSavedMsg is sent when forwards were written into the SSH config
+type portForwardConfigSavedMsg struct {
+	message string
+	err     error
+}
+
 // NewPortForwardForm creates a new port forward form model
 func NewPortForwardForm(hostName string, styles Styles, width, height int, configFile string, historyManager *history.HistoryManager) *portForwardModel {
-	inputs := make([]textinput.Model, 5)
+	inputs := make([]textinput.Model, 6)
 
 	// Forward type input (display only, controlled by arrow keys)
 	inputs[pfTypeInput] = textinput.New()
@@ -55,8 +105,8 @@ func NewPortForwardForm(hostName string, styles Styles, width, height int, confi
 	// Local port input
 	inputs[pfLocalPortInput] = textinput.New()
 	inputs[pfLocalPortInput].Placeholder = "8080"
-	inputs[pfLocalPortInput].CharLimit = 5
-	inputs[pfLocalPortInput].Width = 20
+	inputs[pfLocalPortInput].CharLimit = 104 // Ports or Unix socket paths
+	inputs[pfLocalPortInput].Width = 40
 
 	// Remote host input
 	inputs[pfRemoteHostInput] = textinput.New()
@@ -68,8 +118,8 @@ func NewPortForwardForm(hostName string, styles Styles, width, height int, confi
 	// Remote port input
 	inputs[pfRemotePortInput] = textinput.New()
 	inputs[pfRemotePortInput].Placeholder = "80"
-	inputs[pfRemotePortInput].CharLimit = 5
-	inputs[pfRemotePortInput].Width = 20
+	inputs[pfRemotePortInput].CharLimit = 104 // Ports or Unix socket paths
+	inputs[pfRemotePortInput].Width = 40
 
 	// Bind address input (optional)
 	inputs[pfBindAddressInput] = textinput.New()
@@ -77,6 +127,12 @@ func NewPortForwardForm(hostName string, styles Styles, width, height int, confi
 	inputs[pfBindAddressInput].CharLimit = 50
 	inputs[pfBindAddressInput].Width = 30
 
+	// Profile name input (optional)
+	inputs[pfNameInput] = textinput.New()
+	inputs[pfNameInput].Placeholder = "Name to save these forwards as (optional)"
+	inputs[pfNameInput].CharLimit = 50
+	inputs[pfNameInput].Width = 30
+
 	pf := &portForwardModel{
 		inputs:         inputs,
 		focused:        0,
@@ -87,6 +143,7 @@ func NewPortForwardForm(hostName string, styles Styles, width, height int, confi
 		height:         height,
 		configFile:     configFile,
 		historyManager: historyManager,
+		forwardCursor:  -1,
 	}
 
 	// Load previous port forwarding configuration if available
@@ -95,6 +152,13 @@ func NewPortForwardForm(hostName string, styles Styles, width, height int, confi
 	// Initialize input visibility
 	pf.updateInputVisibility()
 
+	// Start from the saved profiles of the host if there are any
+	pf.loadProfiles()
+	if len(pf.profiles) > 0 {
+		pf.mode = pfModeProfiles
+		pf.inputs[pf.focused].Blur()
+	}
+
 	return pf
 }
 
@@ -105,12 +169,91 @@ func (m *portForwardModel) Init() tea.Cmd {
 func (m *portForwardModel) Update(msg tea.Msg) (*portForwardModel, tea.Cmd) {
 	var cmd tea.Cmd
 
+	if msg, ok := msg.(portForwardDiscoveredMsg); ok {
+		m.discovering = false
+		m.listeners, m.listenerCursor = msg.listeners, 0
+		if msg.err != nil {
+			m.err = msg.err.Error()
+		}
+		return m, nil
+	}
+
+	if m.mode == pfModeProfiles {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			return m, m.updateProfiles(msg)
+		}
+		return m, nil
+	}
+
+	if m.mode == pfModeDiscover {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			return m, m.updateDiscover(msg)
+		}
+		return m, nil
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "esc", "ctrl+c":
+			if msg.String() == "esc" && len(m.profiles) > 0 {
+				// Back to the saved profiles
+				m.mode = pfModeProfiles
+				m.err = ""
+				m.inputs[m.focused].Blur()
+				return m, nil
+			}
 			return m, func() tea.Msg { return portForwardCancelMsg{} }
 
+		case "ctrl+a":
+			m.addForward()
+			return m, nil
+
+		case "ctrl+b":
+			return m, m.submitBackground()
+
+		case "ctrl+p":
+			return m, m.submitInProcess()
+
+		case "ctrl+d":
+			m.removeForward()
+			return m, nil
+
+		case "ctrl+e":
+			m.editForward()
+			return m, nil
+
+		case "ctrl+f":
+			m.useSuggestedPort()
+			return m, nil
+
+		case "ctrl+r":
+			return m, m.discover()
+
+		case "ctrl+w", "ctrl+t":
+			forwards, err := m.collectForwards()
+			if err != nil {
+				m.setError(err)
+				return m, nil
+			}
+			return m, m.saveToConfig(forwards, msg.String() == "ctrl+t")
+
+		case "pgup":
+			if m.forwardCursor > 0 {
+				m.forwardCursor--
+			} else if len(m.forwards) > 0 {
+				m.forwardCursor = len(m.forwards) - 1
+			}
+			return m, nil
+
+		case "pgdown":
+			if m.forwardCursor < len(m.forwards)-1 {
+				m.forwardCursor++
+			} else if len(m.forwards) > 0 {
+				m.forwardCursor = 0
+			}
+			return m, nil
+
 		case "enter":
 			nextField := m.getNextValidField(m.focused)
 			if nextField != -1 {
@@ -189,21 +332,21 @@ func (m *portForwardModel) Update(msg tea.Msg) (*portForwardModel, tea.Cmd) {
 func (m *portForwardModel) updateInputVisibility() {
 	// Reset all inputs visibility
 	for i := range m.inputs {
-		if i != pfTypeInput {
+		if i != pfTypeInput && i != pfNameInput {
 			m.inputs[i].Placeholder = ""
 		}
 	}
 
 	switch m.forwardType {
 	case LocalForward:
-		m.inputs[pfLocalPortInput].Placeholder = "Local port (e.g., 8080)"
+		m.inputs[pfLocalPortInput].Placeholder = "Local port or socket (e.g., 8080, /tmp/docker.sock)"
 		m.inputs[pfRemoteHostInput].Placeholder = "Remote host (e.g., localhost)"
-		m.inputs[pfRemotePortInput].Placeholder = "Remote port (e.g., 80)"
+		m.inputs[pfRemotePortInput].Placeholder = "Remote port or socket (e.g., 80, /var/run/docker.sock)"
 		m.inputs[pfBindAddressInput].Placeholder = "Bind address (optional, default: 127.0.0.1)"
 	case RemoteForward:
-		m.inputs[pfLocalPortInput].Placeholder = "Remote port (e.g., 8080)"
+		m.inputs[pfLocalPortInput].Placeholder = "Remote port or socket (e.g., 8080, /tmp/app.sock)"
 		m.inputs[pfRemoteHostInput].Placeholder = "Local host (e.g., localhost)"
-		m.inputs[pfRemotePortInput].Placeholder = "Local port (e.g., 80)"
+		m.inputs[pfRemotePortInput].Placeholder = "Local port or socket (e.g., 80, /run/app.sock)"
 		m.inputs[pfBindAddressInput].Placeholder = "Bind address (optional)"
 	case DynamicForward:
 		m.inputs[pfLocalPortInput].Placeholder = "SOCKS port (e.g., 1080)"
@@ -214,10 +357,20 @@ func (m *portForwardModel) updateInputVisibility() {
 }
 
 func (m *portForwardModel) View() string {
+	if m.mode == pfModeProfiles {
+		return m.viewProfiles()
+	}
+	if m.mode == pfModeDiscover {
+		return m.viewDiscover()
+	}
+
 	var sections []string
 
 	// Title
 	title := m.styles.Header.Render("🔗 Port Forwarding Setup")
+	if m.editing != "" {
+		title = m.styles.Header.Render("🔗 Edit Port Forwarding Profile: " + m.editing)
+	}
 	sections = append(sections, title)
 
 	// Host info
@@ -227,11 +380,30 @@ func (m *portForwardModel) View() string {
 	// Error message
 	if m.err != "" {
 		sections = append(sections, m.styles.Error.Render("Error: "+m.err))
+		if m.conflict != nil && m.conflict.Suggestion != 0 {
+			sections = append(sections, m.styles.HelpText.Render(fmt.Sprintf("Ctrl+F: use port %d instead", m.conflict.Suggestion)))
+		}
+	} else if m.status != "" {
+		sections = append(sections, m.styles.StatusSuccess.Render(m.status))
 	}
 
 	// Form fields
 	var fields []string
 
+	// Forwards added so far
+	if len(m.forwards) > 0 {
+		fields = append(fields, m.styles.Label.Render("Forwards:"))
+		for i, forward := range m.forwards {
+			if i == m.forwardCursor {
+				fields = append(fields, m.styles.Selected.Render("▶ "+forward.String()))
+			} else {
+				fields = append(fields, "  "+forward.String())
+			}
+		}
+		fields = append(fields, m.styles.HelpText.Render("PgUp/PgDn: select • Ctrl+E: edit • Ctrl+D: remove"))
+		fields = append(fields, "")
+	}
+
 	// Forward type
 	typeLabel := "Forward Type:"
 	if m.focused == pfTypeInput {
@@ -247,10 +419,11 @@ func (m *portForwardModel) View() string {
 	case LocalForward:
 		fields = append(fields, "")
 		fields = append(fields, m.styles.HelpText.Render("Local forwarding: ssh -L [bind_address:]local_port:remote_host:remote_port"))
+		fields = append(fields, m.styles.HelpText.Render("Either port may be a Unix socket path; the remote host is not used with a remote socket"))
 		fields = append(fields, "")
 
 		// Local port
-		localPortLabel := "Local Port:"
+		localPortLabel := "Local Port or Socket:"
 		if m.focused == pfLocalPortInput {
 			localPortLabel = m.styles.FocusedLabel.Render(localPortLabel)
 		} else {
@@ -270,7 +443,7 @@ func (m *portForwardModel) View() string {
 		fields = append(fields, m.inputs[pfRemoteHostInput].View())
 
 		// Remote port
-		remotePortLabel := "Remote Port:"
+		remotePortLabel := "Remote Port or Socket:"
 		if m.focused == pfRemotePortInput {
 			remotePortLabel = m.styles.FocusedLabel.Render(remotePortLabel)
 		} else {
@@ -282,10 +455,11 @@ func (m *portForwardModel) View() string {
 	case RemoteForward:
 		fields = append(fields, "")
 		fields = append(fields, m.styles.HelpText.Render("Remote forwarding: ssh -R [bind_address:]remote_port:local_host:local_port"))
+		fields = append(fields, m.styles.HelpText.Render("Either port may be a Unix socket path; the local host is not used with a local socket"))
 		fields = append(fields, "")
 
 		// Remote port
-		remotePortLabel := "Remote Port:"
+		remotePortLabel := "Remote Port or Socket:"
 		if m.focused == pfLocalPortInput {
 			remotePortLabel = m.styles.FocusedLabel.Render(remotePortLabel)
 		} else {
@@ -305,7 +479,7 @@ func (m *portForwardModel) View() string {
 		fields = append(fields, m.inputs[pfRemoteHostInput].View())
 
 		// Local port
-		localPortLabel := "Local Port:"
+		localPortLabel := "Local Port or Socket:"
 		if m.focused == pfRemotePortInput {
 			localPortLabel = m.styles.FocusedLabel.Render(localPortLabel)
 		} else {
@@ -341,12 +515,29 @@ func (m *portForwardModel) View() string {
 	fields = append(fields, bindLabel)
 	fields = append(fields, m.inputs[pfBindAddressInput].View())
 
+	// Profile name
+	fields = append(fields, "")
+	nameLabel := "Save as Profile (optional):"
+	if m.focused == pfNameInput {
+		nameLabel = m.styles.FocusedLabel.Render(nameLabel)
+	} else {
+		nameLabel = m.styles.Label.Render(nameLabel)
+	}
+	fields = append(fields, nameLabel)
+	fields = append(fields, m.inputs[pfNameInput].View())
+
 	// Join form fields
 	formContent := lipgloss.JoinVertical(lipgloss.Left, fields...)
 	sections = append(sections, formContent)
 
 	// Help text
-	helpText := " Tab/↓: next field • Shift+Tab/↑: previous field • Enter: connect • Esc: cancel"
+	helpText := " Tab/↓: next field • Shift+Tab/↑: previous field • Ctrl+A: add another forward • Ctrl+R: pick a port of the host\n"
+	helpText += fmt.Sprintf(" Ctrl+W: save in the SSH config of %s • Ctrl+T: save as %s\n", m.hostName, m.hostName+config.TunnelHostSuffix)
+	if len(m.profiles) > 0 {
+		helpText += " Enter: connect • Ctrl+B: start in background • Ctrl+P: run in sshm • Esc: back to profiles"
+	} else {
+		helpText += " Enter: connect • Ctrl+B: start in background • Ctrl+P: run in sshm • Esc: cancel"
+	}
 	sections = append(sections, m.styles.HelpText.Render(helpText))
 
 	// Join all sections
@@ -362,127 +553,498 @@ func (m *portForwardModel) View() string {
 	)
 }
 
+// viewProfiles renders the saved profiles of the host
+func (m *portForwardModel) viewProfiles() string {
+	var sections []string
+
+	sections = append(sections, m.styles.Header.Render("🔗 Port Forwarding Profiles"))
+	sections = append(sections, m.styles.HelpText.Render(fmt.Sprintf("Host: %s", m.hostName)))
+
+	if m.err != "" {
+		sections = append(sections, m.styles.Error.Render("Error: "+m.err))
+	} else if m.status != "" {
+		sections = append(sections, m.styles.StatusSuccess.Render(m.status))
+	}
+
+	var lines []string
+	for i, profile := range m.profiles {
+		line := profile.Name
+		if i == m.selected {
+			lines = append(lines, m.styles.Selected.Render("▶ "+line))
+		} else {
+			lines = append(lines, "  "+line)
+		}
+		lines = append(lines, m.styles.HelpText.Render("    "+profile.Summary()))
+	}
+	sections = append(sections, lipgloss.JoinVertical(lipgloss.Left, lines...))
+
+	if m.confirmDelete && m.selected < len(m.profiles) {
+		sections = append(sections, m.styles.Error.Render(fmt.Sprintf("Delete profile %s? (y/n)", m.profiles[m.selected].Name)))
+	} else {
+		sections = append(sections, m.styles.HelpText.Render(" ↑/↓: select • Enter: connect • b: start in background • p: run in sshm • e: edit • d: delete • n: new forwards • Esc: cancel"))
+		sections = append(sections, m.styles.HelpText.Render(fmt.Sprintf(" w: save in the SSH config of %s • t: save as %s", m.hostName, m.hostName+config.TunnelHostSuffix)))
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left, sections...)
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		m.styles.FormContainer.Render(content),
+	)
+}
+
 func (m *portForwardModel) submitForm() tea.Cmd {
 	return func() tea.Msg {
-		// Validate inputs
-		localPort := strings.TrimSpace(m.inputs[pfLocalPortInput].Value())
-		if localPort == "" {
-			return portForwardSubmitMsg{err: fmt.Errorf("port is required"), sshArgs: nil}
+		profile, err := m.prepareForwards()
+		if err != nil {
+			return portForwardSubmitMsg{err: err, sshArgs: nil}
+		}
+		return portForwardSubmitMsg{err: nil, sshArgs: m.sshArgs(profile.Forwards)}
+	}
+}
+
+// submitBackground starts the forwards as a tunnel in the background
+func (m *portForwardModel) submitBackground() tea.Cmd {
+	return func() tea.Msg {
+		profile, err := m.prepareForwards()
+		if err != nil {
+			return portForwardSubmitMsg{err: err, sshArgs: nil}
+		}
+		return portForwardBackgroundMsg{hostName: m.hostName, profile: profile}
+	}
+}
+
+// submitInProcess runs the forwards in sshm
+func (m *portForwardModel) submitInProcess() tea.Cmd {
+	return func() tea.Msg {
+		profile, err := m.prepareForwards()
+		if err != nil {
+			return portForwardSubmitMsg{err: err, sshArgs: nil}
+		}
+		return portForwardInProcessMsg{hostName: m.hostName, profile: profile}
+	}
+}
+
+// prepareForwards validates the forwards of the form, saves them as a
+// profile if they were given a name and remembers the last one
+func (m *portForwardModel) prepareForwards() (history.PortForwardProfile, error) {
+	forwards, err := m.collectForwards()
+	if err != nil {
+		return history.PortForwardProfile{}, err
+	}
+	if err := checkForwards(forwards); err != nil {
+		return history.PortForwardProfile{}, err
+	}
+	name := strings.TrimSpace(m.inputs[pfNameInput].Value())
+
+	if m.historyManager != nil {
+		// Save the forwards as a profile if they were given a name
+		if name != "" {
+			if err := m.saveProfile(name, forwards); err != nil {
+				return history.PortForwardProfile{}, err
+			}
 		}
 
-		// Validate port number
-		if _, err := strconv.Atoi(localPort); err != nil {
-			return portForwardSubmitMsg{err: fmt.Errorf("invalid port number"), sshArgs: nil}
+		// Remember the last forward to prefill the form next time
+		last := forwards[len(forwards)-1]
+		if err := m.historyManager.RecordPortForwarding(
+			m.hostName,
+			last.Type,
+			last.LocalPort,
+			last.RemoteHost,
+			last.RemotePort,
+			last.BindAddress,
+		); err != nil {
+			// Log the error but don't fail the connection
+			// In a production environment, you might want to handle this differently
 		}
+	}
 
-		// Get form values for saving to history
-		remoteHost := strings.TrimSpace(m.inputs[pfRemoteHostInput].Value())
-		remotePort := strings.TrimSpace(m.inputs[pfRemotePortInput].Value())
-		bindAddress := strings.TrimSpace(m.inputs[pfBindAddressInput].Value())
+	return history.PortForwardProfile{Name: name, Forwards: forwards}, nil
+}
+
+// sshArgs builds the ssh command line setting up the forwards
+func (m *portForwardModel) sshArgs(forwards []history.PortForwardConfig) []string {
+	var sshArgs []string
+
+	// Add config file if specified
+	if m.configFile != "" {
+		sshArgs = append(sshArgs, "-F", m.configFile)
+	}
 
-		// Build SSH command with port forwarding
-		var sshArgs []string
+	// Add forwarding arguments
+	for _, forward := range forwards {
+		sshArgs = append(sshArgs, forward.Flag(), forward.Spec())
+	}
+
+	// Add hostname
+	return append(sshArgs, m.hostName)
+}
 
-		// Add config file if specified
-		if m.configFile != "" {
-			sshArgs = append(sshArgs, "-F", m.configFile)
+// currentForward returns the forward described by the inputs
+func (m *portForwardModel) currentForward() history.PortForwardConfig {
+	forward := history.PortForwardConfig{
+		Type:        forwardTypeName(m.forwardType),
+		LocalPort:   strings.TrimSpace(m.inputs[pfLocalPortInput].Value()),
+		BindAddress: strings.TrimSpace(m.inputs[pfBindAddressInput].Value()),
+	}
+	if m.forwardType != DynamicForward {
+		forward.RemoteHost = strings.TrimSpace(m.inputs[pfRemoteHostInput].Value())
+		if forward.RemoteHost == "" {
+			forward.RemoteHost = "localhost"
 		}
+		forward.RemotePort = strings.TrimSpace(m.inputs[pfRemotePortInput].Value())
+		if forward.TargetSocket() {
+			// ssh connects to the socket directly
+			forward.RemoteHost = ""
+		}
+	}
+	return forward
+}
 
-		// Add forwarding arguments
-		var forwardTypeStr string
-		switch m.forwardType {
-		case LocalForward:
-			forwardTypeStr = "local"
-			if remoteHost == "" {
-				remoteHost = "localhost"
-			}
-			if remotePort == "" {
-				return portForwardSubmitMsg{err: fmt.Errorf("remote port is required for local forwarding"), sshArgs: nil}
-			}
+// setForward fills the inputs with a forward
+func (m *portForwardModel) setForward(forward history.PortForwardConfig) {
+	m.forwardType = parseForwardType(forward.Type)
+	m.inputs[pfTypeInput].SetValue(m.forwardType.String())
+	m.inputs[pfLocalPortInput].SetValue(forward.LocalPort)
+	if forward.RemoteHost != "" {
+		m.inputs[pfRemoteHostInput].SetValue(forward.RemoteHost)
+	} else if m.forwardType != DynamicForward {
+		// Default to localhost for local and remote forwarding if not set
+		m.inputs[pfRemoteHostInput].SetValue("localhost")
+	}
+	m.inputs[pfRemotePortInput].SetValue(forward.RemotePort)
+	m.inputs[pfBindAddressInput].SetValue(forward.BindAddress)
+	m.updateInputVisibility()
+}
 
-			// Validate remote port
-			if _, err := strconv.Atoi(remotePort); err != nil {
-				return portForwardSubmitMsg{err: fmt.Errorf("invalid remote port number"), sshArgs: nil}
-			}
+// clearPorts empties the port inputs, keeping the forward type, hosts and
+// bind address to ease adding similar forwards
+func (m *portForwardModel) clearPorts() {
+	m.inputs[pfLocalPortInput].SetValue("")
+	m.inputs[pfRemotePortInput].SetValue("")
+}
 
-			// Build -L argument
-			var forwardArg string
-			if bindAddress != "" {
-				forwardArg = fmt.Sprintf("%s:%s:%s:%s", bindAddress, localPort, remoteHost, remotePort)
-			} else {
-				forwardArg = fmt.Sprintf("%s:%s:%s", localPort, remoteHost, remotePort)
+// focusField moves the focus to an input
+func (m *portForwardModel) focusField(field int) {
+	m.inputs[m.focused].Blur()
+	m.focused = field
+	m.inputs[m.focused].Focus()
+}
+
+// collectForwards returns the forwards added to the list followed by the
+// one in the inputs, which may be left empty when the list is not
+func (m *portForwardModel) collectForwards() ([]history.PortForwardConfig, error) {
+	forwards := append([]history.PortForwardConfig(nil), m.forwards...)
+
+	current := m.currentForward()
+	if current.LocalPort == "" && len(forwards) > 0 {
+		return forwards, nil
+	}
+	if err := current.Validate(); err != nil {
+		return nil, err
+	}
+	return append(forwards, current), nil
+}
+
+// checkForwards checks no two forwards listen on the same port and that
+// the ports listened on locally are free
+func checkForwards(forwards []history.PortForwardConfig) error {
+	localPorts := make(map[string]bool)
+	remotePorts := make(map[string]bool)
+	for _, forward := range forwards {
+		used := localPorts
+		if forward.Type == "remote" {
+			used = remotePorts
+		}
+		if used[forward.LocalPort] {
+			return fmt.Errorf("port %s is used by two forwards", forward.LocalPort)
+		}
+		used[forward.LocalPort] = true
+	}
+
+	for _, forward := range forwards {
+		if err := checkLocalForward(forward); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setError shows an error, keeping the port conflict it reports if any so
+// that the suggested port can be used instead
+func (m *portForwardModel) setError(err error) {
+	m.err = err.Error()
+	m.conflict = nil
+	var conflict *ports.Conflict
+	if errors.As(err, &conflict) {
+		m.conflict = conflict
+	}
+}
+
+// useSuggestedPort replaces the port in conflict by the free port suggested
+func (m *portForwardModel) useSuggestedPort() {
+	if m.err == "" || m.conflict == nil || m.conflict.Suggestion == 0 {
+		return
+	}
+
+	port, suggestion := strconv.Itoa(m.conflict.Port), strconv.Itoa(m.conflict.Suggestion)
+	if current := m.currentForward(); current.Type != "remote" && current.LocalPort == port {
+		m.inputs[pfLocalPortInput].SetValue(suggestion)
+	} else {
+		for i := range m.forwards {
+			if m.forwards[i].Type != "remote" && m.forwards[i].LocalPort == port {
+				m.forwards[i].LocalPort = suggestion
+				break
 			}
-			sshArgs = append(sshArgs, "-L", forwardArg)
+		}
+	}
+	m.err = ""
+	m.conflict = nil
+}
 
-		case RemoteForward:
-			forwardTypeStr = "remote"
-			if remoteHost == "" {
-				remoteHost = "localhost"
+// addForward adds the forward in the inputs to the list and clears the ports
+// for the next one
+func (m *portForwardModel) addForward() {
+	current := m.currentForward()
+	if err := current.Validate(); err != nil {
+		m.setError(err)
+		return
+	}
+	if err := checkForwards(append(append([]history.PortForwardConfig(nil), m.forwards...), current)); err != nil {
+		m.setError(err)
+		return
+	}
+
+	m.err = ""
+	m.forwards = append(m.forwards, current)
+	m.forwardCursor = len(m.forwards) - 1
+	m.clearPorts()
+	m.focusField(pfLocalPortInput)
+}
+
+// removeForward removes the selected forward from the list
+func (m *portForwardModel) removeForward() {
+	if m.forwardCursor < 0 || m.forwardCursor >= len(m.forwards) {
+		return
+	}
+
+	m.forwards = append(m.forwards[:m.forwardCursor:m.forwardCursor], m.forwards[m.forwardCursor+1:]...)
+	if m.forwardCursor >= len(m.forwards) {
+		m.forwardCursor = len(m.forwards) - 1
+	}
+}
+
+// editForward moves the selected forward to the inputs. The forward being
+// entered, if any, takes its place in the list.
+func (m *portForwardModel) editForward() {
+	if m.forwardCursor < 0 || m.forwardCursor >= len(m.forwards) {
+		return
+	}
+
+	selected := m.forwards[m.forwardCursor]
+	current := m.currentForward()
+	if current.LocalPort != "" {
+		if err := current.Validate(); err != nil {
+			m.setError(err)
+			return
+		}
+		m.forwards[m.forwardCursor] = current
+	} else {
+		m.removeForward()
+	}
+
+	m.err = ""
+	m.setForward(selected)
+	m.focusField(pfLocalPortInput)
+}
+
+// saveProfile saves the forwards as a profile of the host, renaming the
+// profile being edited if its name changed
+func (m *portForwardModel) saveProfile(name string, forwards []history.PortForwardConfig) error {
+	if name != m.editing {
+		if _, exists := m.historyManager.GetPortForwardProfile(m.hostName, name); exists {
+			return fmt.Errorf("a profile named %s already exists", name)
+		}
+	}
+
+	profile := history.PortForwardProfile{Name: name, Forwards: forwards, LastUsed: time.Now()}
+	if err := m.historyManager.SavePortForwardProfile(m.hostName, profile); err != nil {
+		return err
+	}
+	if m.editing != "" && name != m.editing {
+		_ = m.historyManager.DeletePortForwardProfile(m.hostName, m.editing)
+	}
+	return nil
+}
+
+// loadProfiles reads the saved profiles of the host
+func (m *portForwardModel) loadProfiles() {
+	m.profiles = nil
+	if m.historyManager != nil {
+		m.profiles = m.historyManager.GetPortForwardProfiles(m.hostName)
+	}
+	if m.selected >= len(m.profiles) {
+		m.selected = max(len(m.profiles)-1, 0)
+	}
+}
+
+// updateProfiles handles the keys of the profile list
+func (m *portForwardModel) updateProfiles(msg tea.KeyMsg) tea.Cmd {
+	if m.confirmDelete {
+		m.confirmDelete = false
+		if msg.String() == "y" && m.selected < len(m.profiles) {
+			if err := m.historyManager.DeletePortForwardProfile(m.hostName, m.profiles[m.selected].Name); err != nil {
+				m.setError(err)
 			}
-			if remotePort == "" {
-				return portForwardSubmitMsg{err: fmt.Errorf("local port is required for remote forwarding"), sshArgs: nil}
+			m.loadProfiles()
+			if len(m.profiles) == 0 {
+				m.newProfile()
+				return textinput.Blink
 			}
+		}
+		return nil
+	}
 
-			// Validate local port
-			if _, err := strconv.Atoi(remotePort); err != nil {
-				return portForwardSubmitMsg{err: fmt.Errorf("invalid local port number"), sshArgs: nil}
-			}
+	switch msg.String() {
+	case "esc", "q", "ctrl+c":
+		return func() tea.Msg { return portForwardCancelMsg{} }
 
-			// Build -R argument (note: localPort is actually the remote port in this context)
-			var forwardArg string
-			if bindAddress != "" {
-				forwardArg = fmt.Sprintf("%s:%s:%s:%s", bindAddress, localPort, remoteHost, remotePort)
-			} else {
-				forwardArg = fmt.Sprintf("%s:%s:%s", localPort, remoteHost, remotePort)
-			}
-			sshArgs = append(sshArgs, "-R", forwardArg)
-
-		case DynamicForward:
-			forwardTypeStr = "dynamic"
-			// Build -D argument
-			var forwardArg string
-			if bindAddress != "" {
-				forwardArg = fmt.Sprintf("%s:%s", bindAddress, localPort)
-			} else {
-				forwardArg = localPort
+	case "up", "k":
+		if m.selected > 0 {
+			m.selected--
+		}
+
+	case "down", "j":
+		if m.selected < len(m.profiles)-1 {
+			m.selected++
+		}
+
+	case "enter":
+		if m.selected < len(m.profiles) {
+			return m.launchProfile(m.profiles[m.selected])
+		}
+
+	case "b", "p":
+		if m.selected < len(m.profiles) {
+			profile, inProcess := m.profiles[m.selected], msg.String() == "p"
+			return func() tea.Msg {
+				if err := checkForwards(profile.Forwards); err != nil {
+					return portForwardSubmitMsg{err: fmt.Errorf("%s: %w", profile.Name, err), sshArgs: nil}
+				}
+				if m.historyManager != nil {
+					_ = m.historyManager.MarkPortForwardProfileUsed(m.hostName, profile.Name)
+				}
+				if inProcess {
+					return portForwardInProcessMsg{hostName: m.hostName, profile: profile}
+				}
+				return portForwardBackgroundMsg{hostName: m.hostName, profile: profile}
 			}
-			sshArgs = append(sshArgs, "-D", forwardArg)
 		}
 
-		// Save port forwarding configuration to history
+	case "e":
+		if m.selected < len(m.profiles) {
+			m.editProfile(m.profiles[m.selected])
+			return textinput.Blink
+		}
+
+	case "d":
+		if m.selected < len(m.profiles) {
+			m.confirmDelete = true
+		}
+
+	case "w", "t":
+		if m.selected < len(m.profiles) {
+			return m.saveToConfig(m.profiles[m.selected].Forwards, msg.String() == "t")
+		}
+
+	case "n":
+		m.newProfile()
+		return textinput.Blink
+	}
+	return nil
+}
+
+// launchProfile connects with the forwards of a saved profile
+func (m *portForwardModel) launchProfile(profile history.PortForwardProfile) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := profile.SSHArgs(); err != nil {
+			return portForwardSubmitMsg{err: err, sshArgs: nil}
+		}
+		if err := checkForwards(profile.Forwards); err != nil {
+			return portForwardSubmitMsg{err: fmt.Errorf("%s: %w", profile.Name, err), sshArgs: nil}
+		}
 		if m.historyManager != nil {
-			if err := m.historyManager.RecordPortForwarding(
-				m.hostName,
-				forwardTypeStr,
-				localPort,
-				remoteHost,
-				remotePort,
-				bindAddress,
-			); err != nil {
-				// Log the error but don't fail the connection
-				// In a production environment, you might want to handle this differently
-			}
+			_ = m.historyManager.MarkPortForwardProfileUsed(m.hostName, profile.Name)
 		}
+		return portForwardSubmitMsg{err: nil, sshArgs: m.sshArgs(profile.Forwards)}
+	}
+}
 
-		// Add hostname
-		sshArgs = append(sshArgs, m.hostName)
+// newProfile opens the form for new forwards, prefilled with the last one used
+func (m *portForwardModel) newProfile() {
+	m.mode = pfModeEdit
+	m.err = ""
+	m.editing = ""
+	m.forwards = nil
+	m.forwardCursor = -1
+	m.inputs[pfNameInput].SetValue("")
+	m.loadPreviousConfig()
+	m.updateInputVisibility()
+	m.focusField(pfTypeInput)
+}
+
+// editProfile opens the form on the forwards of a saved profile. The last
+// forward is put in the inputs and the others in the list.
+func (m *portForwardModel) editProfile(profile history.PortForwardProfile) {
+	m.mode = pfModeEdit
+	m.err = ""
+	m.editing = profile.Name
+	m.inputs[pfNameInput].SetValue(profile.Name)
+
+	m.forwards = nil
+	m.forwardCursor = -1
+	if n := len(profile.Forwards); n > 0 {
+		m.forwards = append(m.forwards, profile.Forwards[:n-1]...)
+		m.forwardCursor = len(m.forwards) - 1
+		m.setForward(profile.Forwards[n-1])
+	}
+	m.focusField(pfTypeInput)
+}
+
+// forwardTypeName returns the name of a forward type saved in the history
+func forwardTypeName(forwardType PortForwardType) string {
+	switch forwardType {
+	case RemoteForward:
+		return "remote"
+	case DynamicForward:
+		return "dynamic"
+	default:
+		return "local"
+	}
+}
 
-		// Return success with the SSH command to execute
-		return portForwardSubmitMsg{err: nil, sshArgs: sshArgs}
+// parseForwardType returns the forward type of a name saved in the history
+func parseForwardType(name string) PortForwardType {
+	switch name {
+	case "remote":
+		return RemoteForward
+	case "dynamic":
+		return DynamicForward
+	default:
+		return LocalForward
 	}
 }
 
 // getValidFields returns the list of valid field indices for the current forward type
 func (m *portForwardModel) getValidFields() []int {
 	switch m.forwardType {
-	case LocalForward:
-		return []int{pfTypeInput, pfLocalPortInput, pfRemoteHostInput, pfRemotePortInput, pfBindAddressInput}
-	case RemoteForward:
-		return []int{pfTypeInput, pfLocalPortInput, pfRemoteHostInput, pfRemotePortInput, pfBindAddressInput}
 	case DynamicForward:
-		return []int{pfTypeInput, pfLocalPortInput, pfBindAddressInput}
+		return []int{pfTypeInput, pfLocalPortInput, pfBindAddressInput, pfNameInput}
 	default:
-		return []int{pfTypeInput, pfLocalPortInput, pfRemoteHostInput, pfRemotePortInput, pfBindAddressInput}
+		return []int{pfTypeInput, pfLocalPortInput, pfRemoteHostInput, pfRemotePortInput, pfBindAddressInput, pfNameInput}
 	}
 }
 
@@ -523,33 +1085,44 @@ func (m *portForwardModel) loadPreviousConfig() {
 		return
 	}
 
-	// Set forward type based on saved configuration
-	switch config.Type {
-	case "local":
-		m.forwardType = LocalForward
-	case "remote":
-		m.forwardType = RemoteForward
-	case "dynamic":
-		m.forwardType = DynamicForward
-	default:
-		m.forwardType = LocalForward
-	}
-	m.inputs[pfTypeInput].SetValue(m.forwardType.String())
+	m.setForward(*config)
+}
 
-	// Set values from saved configuration
-	if config.LocalPort != "" {
-		m.inputs[pfLocalPortInput].SetValue(config.LocalPort)
-	}
-	if config.RemoteHost != "" {
-		m.inputs[pfRemoteHostInput].SetValue(config.RemoteHost)
-	} else if m.forwardType != DynamicForward {
-		// Default to localhost for local and remote forwarding if not set
-		m.inputs[pfRemoteHostInput].SetValue("localhost")
-	}
-	if config.RemotePort != "" {
-		m.inputs[pfRemotePortInput].SetValue(config.RemotePort)
-	}
-	if config.BindAddress != "" {
-		m.inputs[pfBindAddressInput].SetValue(config.BindAddress)
+// saveToConfig writes forwards into the SSH config as LocalForward,
+// RemoteForward and DynamicForward directives: in the block of the host,
+// or in a <host>-tunnel alias that only sets them up so that plain ssh
+// picks them up too
+func (m *portForwardModel) saveToConfig(forwards []history.PortForwardConfig, tunnelAlias bool) tea.Cmd {
+	hostName, configFile := m.hostName, m.configFile
+	m.status = ""
+	return func() tea.Msg {
+		var host *config.SSHHost
+		var err error
+		if configFile != "" {
+			host, err = config.GetSSHHostFromFile(hostName, configFile)
+		} else {
+			host, err = config.GetSSHHost(hostName)
+		}
+		if err != nil {
+			return portForwardConfigSavedMsg{err: err}
+		}
+
+		directives := make([]string, len(forwards))
+		for i, forward := range forwards {
+			directives[i] = forward.Directive()
+		}
+
+		if tunnelAlias {
+			tunnel := config.NewTunnelHost(*host, directives)
+			if err := config.SaveTunnelHost(tunnel, host.SourceFile); err != nil {
+				return portForwardConfigSavedMsg{err: err}
+			}
+			return portForwardConfigSavedMsg{message: fmt.Sprintf("Forwards saved in %s (connect with: ssh %s)", tunnel.Name, tunnel.Name)}
+		}
+
+		if err := config.AddHostForwards(*host, directives); err != nil {
+			return portForwardConfigSavedMsg{err: err}
+		}
+		return portForwardConfigSavedMsg{message: fmt.Sprintf("Forwards saved in the SSH config of %s", hostName)}
 	}
 }
@@ -0,0 +1,333 @@
+package ui
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/history"
+	"github.com/Gu1llaum-3/sshm/internal/ports"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newTestHistoryManager returns a history manager writing to a temporary directory
+func newTestHistoryManager(t *testing.T) *history.HistoryManager {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	hm, err := history.NewHistoryManager("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hm
+}
+
+// typeInto replaces the value of an input of the port forward form
+func typeInto(m *portForwardModel, field int, value string) {
+	m.inputs[field].SetValue(value)
+}
+
+// noPortChecks lets the forms use ports that may be in use on the machine
+func noPortChecks(t *testing.T) {
+	t.Helper()
+	previous := checkLocalForward
+	checkLocalForward = func(history.PortForwardConfig) error { return nil }
+	t.Cleanup(func() { checkLocalForward = previous })
+}
+
+func TestPortForwardFormMultipleForwards(t *testing.T) {
+	noPortChecks(t)
+	hm := newTestHistoryManager(t)
+	m := NewPortForwardForm("db1", NewStyles(80), 80, 24, "", hm)
+	if m.mode != pfModeEdit {
+		t.Fatalf("Expected the form without profiles to open on the forwards")
+	}
+
+	typeInto(m, pfLocalPortInput, "5432")
+	typeInto(m, pfRemotePortInput, "5432")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlA})
+	if len(m.forwards) != 1 || m.inputs[pfLocalPortInput].Value() != "" {
+		t.Fatalf("Expected the forward to be added and the ports cleared, got %+v", m.forwards)
+	}
+
+	// Dynamic forward in the inputs, saved as a profile
+	m.forwardType = DynamicForward
+	typeInto(m, pfLocalPortInput, "1080")
+	typeInto(m, pfNameInput, "postgres")
+
+	msg := m.submitForm()().(portForwardSubmitMsg)
+	if msg.err != nil {
+		t.Fatalf("submitForm() error = %v", msg.err)
+	}
+	want := []string{"-L", "5432:localhost:5432", "-D", "1080", "db1"}
+	if !reflect.DeepEqual(msg.sshArgs, want) {
+		t.Errorf("sshArgs = %v, want %v", msg.sshArgs, want)
+	}
+
+	profile, exists := hm.GetPortForwardProfile("db1", "postgres")
+	if !exists || len(profile.Forwards) != 2 {
+		t.Fatalf("Expected a profile with both forwards, got %+v", profile)
+	}
+
+	// A new profile may not take the name of another one
+	m = NewPortForwardForm("db1", NewStyles(80), 80, 24, "", hm)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	typeInto(m, pfLocalPortInput, "8080")
+	typeInto(m, pfRemotePortInput, "80")
+	typeInto(m, pfNameInput, "postgres")
+	if msg := m.submitForm()().(portForwardSubmitMsg); msg.err == nil {
+		t.Error("Expected an error saving a profile under an existing name")
+	}
+}
+
+func TestPortForwardFormProfiles(t *testing.T) {
+	noPortChecks(t)
+	hm := newTestHistoryManager(t)
+	for _, name := range []string{"grafana", "postgres"} {
+		profile := history.PortForwardProfile{
+			Name:     name,
+			Forwards: []history.PortForwardConfig{{Type: "local", LocalPort: "3000", RemoteHost: "localhost", RemotePort: "3000"}},
+		}
+		if err := hm.SavePortForwardProfile("db1", profile); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := NewPortForwardForm("db1", NewStyles(80), 80, 24, "/tmp/config", hm)
+	if m.mode != pfModeProfiles || len(m.profiles) != 2 {
+		t.Fatalf("Expected the form to open on the profiles, got mode %d with %d profiles", m.mode, len(m.profiles))
+	}
+
+	// Launch the selected profile
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	msg := cmd().(portForwardSubmitMsg)
+	want := []string{"-F", "/tmp/config", "-L", "3000:localhost:3000", "db1"}
+	if msg.err != nil || !reflect.DeepEqual(msg.sshArgs, want) {
+		t.Errorf("Launching a profile = %v, %v, want %v", msg.sshArgs, msg.err, want)
+	}
+	if profile, _ := hm.GetPortForwardProfile("db1", "grafana"); profile.LastUsed.IsZero() {
+		t.Error("Expected the launched profile to be marked used")
+	}
+
+	// Or start it in the background
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	if background, ok := cmd().(portForwardBackgroundMsg); !ok || background.profile.Name != "grafana" || background.hostName != "db1" {
+		t.Errorf("Expected b to start grafana in the background, got %+v", background)
+	}
+
+	// Or run it in sshm
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	if inProcess, ok := cmd().(portForwardInProcessMsg); !ok || inProcess.profile.Name != "grafana" || inProcess.hostName != "db1" {
+		t.Errorf("Expected p to run grafana in sshm, got %+v", inProcess)
+	}
+
+	// Edit a profile and rename it
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	if m.mode != pfModeEdit || m.editing != "postgres" || m.inputs[pfLocalPortInput].Value() != "3000" {
+		t.Fatalf("Expected the postgres profile in the form, got %q", m.editing)
+	}
+	typeInto(m, pfNameInput, "pg")
+	if msg := m.submitForm()().(portForwardSubmitMsg); msg.err != nil {
+		t.Fatalf("submitForm() error = %v", msg.err)
+	}
+	if _, exists := hm.GetPortForwardProfile("db1", "postgres"); exists {
+		t.Error("Expected the renamed profile to replace the old one")
+	}
+
+	// Esc goes back to the profiles, then delete one after confirmation
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.mode != pfModeProfiles {
+		t.Fatal("Expected Esc to go back to the profiles")
+	}
+	m.selected = 0
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if _, exists := hm.GetPortForwardProfile("db1", "grafana"); exists {
+		t.Error("Expected grafana to be deleted")
+	}
+}
+
+func TestPortForwardFormPortConflict(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+
+	m := NewPortForwardForm("db1", NewStyles(120), 120, 40, "", nil)
+	typeInto(m, pfLocalPortInput, port)
+	typeInto(m, pfRemotePortInput, "5432")
+
+	msg := m.submitForm()().(portForwardSubmitMsg)
+	if msg.err == nil || !strings.Contains(msg.err.Error(), "port "+port+" is already in use") {
+		t.Fatalf("Expected the port in use to be reported, got %v", msg.err)
+	}
+	m.setError(msg.err)
+	if m.conflict == nil || m.conflict.Suggestion == 0 {
+		t.Fatalf("Expected a free port to be suggested, got %+v", m.conflict)
+	}
+	if !strings.Contains(m.View(), "Ctrl+F: use port") {
+		t.Error("Expected the view to offer the suggested port")
+	}
+
+	suggestion := strconv.Itoa(m.conflict.Suggestion)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlF})
+	if m.inputs[pfLocalPortInput].Value() != suggestion || m.err != "" {
+		t.Fatalf("Expected Ctrl+F to use port %s, got %q", suggestion, m.inputs[pfLocalPortInput].Value())
+	}
+	if msg := m.submitForm()().(portForwardSubmitMsg); msg.err != nil {
+		t.Errorf("submitForm() error = %v", msg.err)
+	}
+
+	// Two forwards may not listen on the same port
+	m.forwards = []history.PortForwardConfig{{Type: "dynamic", LocalPort: suggestion}}
+	if msg := m.submitForm()().(portForwardSubmitMsg); msg.err == nil || !strings.Contains(msg.err.Error(), "used by two forwards") {
+		t.Errorf("Expected the same port used twice to be reported, got %v", msg.err)
+	}
+}
+
+func TestPortForwardFormSockets(t *testing.T) {
+	dir := t.TempDir()
+	if len(dir) > 80 {
+		t.Skip("temporary directory too long for socket paths")
+	}
+	socket := dir + "/docker.sock"
+
+	hm := newTestHistoryManager(t)
+	m := NewPortForwardForm("docker1", NewStyles(120), 120, 40, "", hm)
+	typeInto(m, pfLocalPortInput, socket)
+	typeInto(m, pfRemotePortInput, "/var/run/docker.sock")
+	typeInto(m, pfNameInput, "docker")
+
+	msg := m.submitForm()().(portForwardSubmitMsg)
+	if msg.err != nil {
+		t.Fatalf("submitForm() error = %v", msg.err)
+	}
+	want := []string{"-L", socket + ":/var/run/docker.sock", "docker1"}
+	if !reflect.DeepEqual(msg.sshArgs, want) {
+		t.Errorf("sshArgs = %v, want %v", msg.sshArgs, want)
+	}
+
+	// The sockets are kept in the profile
+	profile, _ := hm.GetPortForwardProfile("docker1", "docker")
+	if len(profile.Forwards) != 1 || profile.Forwards[0].LocalPort != socket || profile.Forwards[0].RemoteHost != "" {
+		t.Errorf("Expected the sockets to be saved, got %+v", profile.Forwards)
+	}
+
+	// A socket already there is reported
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("Unix sockets not available: %v", err)
+	}
+	defer listener.Close()
+	if msg := m.submitForm()().(portForwardSubmitMsg); msg.err == nil || !strings.Contains(msg.err.Error(), "already in use") {
+		t.Errorf("Expected the socket in use to be reported, got %v", msg.err)
+	}
+}
+
+func TestPortForwardFormSaveToConfig(t *testing.T) {
+	hm := newTestHistoryManager(t)
+	configFile := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(configFile, []byte("Host db1\n    HostName db1.example.com\n    User admin\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewPortForwardForm("db1", NewStyles(120), 120, 40, configFile, hm)
+	typeInto(m, pfLocalPortInput, "15432")
+	typeInto(m, pfRemotePortInput, "5432")
+
+	// In the block of the host
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlW})
+	if msg := cmd().(portForwardConfigSavedMsg); msg.err != nil {
+		t.Fatalf("Saving in the host error = %v", msg.err)
+	}
+	host, err := config.GetSSHHostFromFile("db1", configFile)
+	if err != nil || host.Options != "LocalForward 15432 localhost:5432" {
+		t.Errorf("Expected the forward in the host block, got %+v (%v)", host, err)
+	}
+
+	// In a tunnel alias
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	if msg := cmd().(portForwardConfigSavedMsg); msg.err != nil || !strings.Contains(msg.message, "ssh db1-tunnel") {
+		t.Fatalf("Saving as a tunnel = %q, %v", msg.message, msg.err)
+	}
+	tunnel, err := config.GetSSHHostFromFile("db1-tunnel", configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tunnel.Hostname != "db1.example.com" || tunnel.User != "admin" || tunnel.Options != "SessionType none\nExitOnForwardFailure yes\nLocalForward 15432 localhost:5432" {
+		t.Errorf("Unexpected tunnel alias %+v", tunnel)
+	}
+}
+
+func TestPortForwardFormDiscover(t *testing.T) {
+	// The discovered port is in use here, so another local port is suggested
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	busyPort := busy.Addr().(*net.TCPAddr).Port
+
+	previous := discoverListeners
+	discoverListeners = func(configFile, hostName string) ([]ports.Listener, error) {
+		if hostName != "db1" {
+			return nil, errors.New("unexpected host " + hostName)
+		}
+		return []ports.Listener{
+			{Address: "0.0.0.0", Port: 22, Process: "sshd", PID: 1000},
+			{Address: "127.0.0.1", Port: busyPort, Process: "postgres", PID: 812},
+		}, nil
+	}
+	t.Cleanup(func() { discoverListeners = previous })
+
+	m := NewPortForwardForm("db1", NewStyles(120), 120, 40, "", newTestHistoryManager(t))
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	if m.mode != pfModeDiscover || !m.discovering || !strings.Contains(m.View(), "Listing the ports") {
+		t.Fatalf("Expected the ports to be listed, got mode %d", m.mode)
+	}
+	m, _ = m.Update(cmd())
+	view := m.View()
+	for _, expected := range []string{"sshd (1000)", "all addresses", "postgres (812)", "127.0.0.1"} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("Expected the view to contain %q, got:\n%s", expected, view)
+		}
+	}
+
+	// Pick postgres
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	forward := m.currentForward()
+	if m.mode != pfModeEdit || m.focused != pfLocalPortInput || forward.Type != "local" {
+		t.Fatalf("Expected the form to be back on the local port, got mode %d, field %d", m.mode, m.focused)
+	}
+	if forward.RemoteHost != "127.0.0.1" || forward.RemotePort != strconv.Itoa(busyPort) {
+		t.Errorf("Expected the forward to target postgres, got %+v", forward)
+	}
+	if local, _ := strconv.Atoi(forward.LocalPort); local <= busyPort {
+		t.Errorf("Expected a free local port after %d, got %s", busyPort, forward.LocalPort)
+	}
+
+	// Errors are shown in the list
+	discoverListeners = func(string, string) ([]ports.Listener, error) {
+		return nil, errors.New("Permission denied (publickey).")
+	}
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	m, _ = m.Update(cmd())
+	if !strings.Contains(m.View(), "Permission denied") {
+		t.Error("Expected the error to be shown")
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.mode != pfModeEdit || m.err != "" {
+		t.Errorf("Expected Esc to go back to the form, got mode %d (%s)", m.mode, m.err)
+	}
+}
@@ -0,0 +1,257 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// previewMinTerminalWidth is the terminal width below which the preview pane is hidden
+	previewMinTerminalWidth = 100
+	// previewMinWidth and previewMaxWidth bound the automatic width of the preview pane
+	previewMinWidth = 32
+	previewMaxWidth = 60
+	// previewMinTableWidth is the width always left to the host table
+	previewMinTableWidth = 60
+	// previewLabelWidth is the width of the labels in the preview pane
+	previewLabelWidth = 11
+)
+
+// previewVisible reports whether the preview pane is shown next to the table
+func (m *Model) previewVisible() bool {
+	return m.showPreview && m.width >= previewMinTerminalWidth
+}
+
+// previewWidth returns the width of the preview pane, or 0 when it is hidden
+func (m *Model) previewWidth() int {
+	if !m.previewVisible() {
+		return 0
+	}
+
+	width := m.width * 2 / 5
+	if m.appConfig != nil && m.appConfig.Layout.PreviewWidth > 0 {
+		width = m.appConfig.Layout.PreviewWidth
+	} else {
+		if width < previewMinWidth {
+			width = previewMinWidth
+		}
+		if width > previewMaxWidth {
+			width = previewMaxWidth
+		}
+	}
+
+	// Always leave enough room for the table
+	if m.width-width < previewMinTableWidth {
+		width = m.width - previewMinTableWidth
+	}
+	return width
+}
+
+// tableWidth returns the width available to the host table
+func (m *Model) tableWidth() int {
+	return m.width - m.previewWidth()
+}
+
+// selectedHost returns the host under the table cursor
+func (m *Model) selectedHost() *config.SSHHost {
+	hosts := m.filteredHosts
+	if hosts == nil {
+		hosts = m.hosts
+	}
+
+	cursor := m.table.Cursor()
+	if cursor < 0 || cursor >= len(hosts) {
+		return nil
+	}
+	return &hosts[cursor]
+}
+
+// renderPreview renders the preview pane for the selected host
+func (m Model) renderPreview(width, height int) string {
+	// Border (2) and horizontal padding (2) are not available to the content
+	contentWidth := width - 4
+	if contentWidth < 1 {
+		return ""
+	}
+
+	var content string
+	if host := m.selectedHost(); host != nil {
+		content = m.renderPreviewContent(*host, contentWidth)
+	} else {
+		content = m.styles.InfoMuted.Render("No host selected")
+	}
+
+	style := m.styles.InfoBorder.
+		Padding(0, 1).
+		Width(width - 2)
+	if height > 2 {
+		style = style.Height(height - 2).MaxHeight(height)
+	}
+
+	return style.Render(content)
+}
+
+// renderPreviewContent renders the details of a host for the preview pane
+func (m Model) renderPreviewContent(host config.SSHHost, width int) string {
+	var lines []string
+
+	lines = append(lines, m.styles.FormTitle.Render(host.Name))
+	if host.Description != "" {
+		lines = append(lines, m.styles.InfoMuted.Width(width).Render(host.Description))
+	}
+	lines = append(lines, "")
+
+	// Host configuration
+	port := host.Port
+	if port == "" {
+		port = "22"
+	}
+	lines = append(lines,
+		m.previewLine("Hostname", host.Hostname, width),
+		m.previewLine("User", host.User, width),
+		m.previewLine("Port", port, width),
+		m.previewLine("Identity", host.Identity, width),
+		m.previewLine("ProxyJump", host.ProxyJump, width),
+	)
+	if host.ProxyCommand != "" {
+		lines = append(lines, m.previewLine("ProxyCmd", host.ProxyCommand, width))
+	}
+	if host.Options != "" {
+		lines = append(lines, m.previewLine("Options", host.Options, width))
+	}
+	if host.RemoteCommand != "" {
+		lines = append(lines, m.previewLine("Command", host.RemoteCommand, width))
+	}
+	lines = append(lines,
+		m.previewLine("Tags", formatTableTags(host.Tags), width),
+		m.previewLine("File", formatConfigFile(host.SourceFile), width),
+	)
+
+	// Resolved jump chain
+	if host.ProxyJump != "" {
+		lines = append(lines, "", m.styles.InfoLabel.Render("Jump chain"))
+		lines = append(lines, m.renderJumpChain(host, width)...)
+	}
+
+	// History statistics
+	lines = append(lines, "", m.styles.InfoLabel.Render("History"))
+	count := 0
+	lastLogin := "Never"
+	if m.historyManager != nil {
+		count = m.historyManager.GetConnectionCount(host.Name)
+		if lastConnect, exists := m.historyManager.GetLastConnectionTime(host.Name); exists {
+			lastLogin = formatTimeAgo(lastConnect)
+		}
+	}
+	lines = append(lines,
+		m.previewLine("Connections", strconv.Itoa(count), width),
+		m.previewLine("Last login", lastLogin, width),
+	)
+
+	// Last connectivity check
+	lines = append(lines, "", m.styles.InfoLabel.Render("Last ping"))
+	lines = append(lines, m.renderPingSummary(host.Name, width)...)
+
+	return strings.Join(lines, "\n")
+}
+
+// previewLine renders a label/value pair, muting empty values
+func (m Model) previewLine(label, value string, width int) string {
+	labelStyle := m.styles.InfoLabel.Width(previewLabelWidth)
+
+	valueWidth := width - previewLabelWidth - 1
+	if valueWidth < 1 {
+		valueWidth = 1
+	}
+	valueStyle := m.styles.InfoValue.Width(valueWidth)
+	if value == "" {
+		value = "Not set"
+		valueStyle = m.styles.InfoMuted.Width(valueWidth)
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		labelStyle.Render(label),
+		" ",
+		valueStyle.Render(value),
+	)
+}
+
+// renderJumpChain renders the resolved ProxyJump chain of a host, one hop per line
+func (m Model) renderJumpChain(host config.SSHHost, width int) []string {
+	chain, err := config.ResolveProxyJumpChain(host, m.hosts)
+	if err != nil {
+		return []string{m.styles.ErrorText.Width(width).Render(err.Error())}
+	}
+
+	var lines []string
+	for i, hop := range chain {
+		line := fmt.Sprintf("%d. %s", i+1, hop.Name())
+		if hop.Alias != nil || hop.User != "" || hop.Port != "" {
+			target := hop.Address()
+			if hop.User != "" {
+				target = hop.User + "@" + target
+			}
+			line += " (" + target + ")"
+		}
+		lines = append(lines, m.styles.InfoValue.Width(width).Render(line))
+	}
+
+	target := host.Hostname
+	if target == "" {
+		target = host.Name
+	}
+	lines = append(lines, m.styles.InfoMuted.Width(width).Render(fmt.Sprintf("→ %s (%s)", host.Name, target)))
+	return lines
+}
+
+// renderPingSummary renders the last connectivity check result of a host
+func (m Model) renderPingSummary(hostName string, width int) []string {
+	if m.pingManager == nil {
+		return []string{m.styles.InfoMuted.Render("Not checked (press p to ping)")}
+	}
+
+	result, ok := m.pingManager.GetResult(hostName)
+	if !ok || result.Status == connectivity.StatusUnknown {
+		return []string{m.styles.InfoMuted.Render("Not checked (press p to ping)")}
+	}
+
+	if result.Status == connectivity.StatusConnecting {
+		return []string{m.styles.InfoValue.Width(width).Render(m.getPingStatusIndicator(hostName) + " " + result.Status.String())}
+	}
+
+	summary := m.getPingStatusIndicator(hostName) + " " + result.Kind.String()
+	if result.Duration > 0 {
+		summary += " · " + formatLatency(result.Duration.Milliseconds())
+	}
+	if !result.CheckedAt.IsZero() {
+		summary += " · " + formatTimeAgo(result.CheckedAt)
+	}
+
+	// Stale results, e.g. cached by a previous run, are dimmed
+	summaryStyle := m.styles.InfoValue
+	if m.isStale(result) {
+		summary += " (stale)"
+		summaryStyle = m.styles.InfoMuted
+	}
+
+	lines := []string{summaryStyle.Width(width).Render(summary)}
+	if result.ResolvedIP != "" {
+		lines = append(lines, m.previewLine("IP", result.ResolvedIP, width))
+	}
+	if result.ServerVersion != "" {
+		lines = append(lines, m.previewLine("Server", result.ServerVersion, width))
+	}
+	if result.HostKeyStatus != connectivity.HostKeyUnchecked {
+		lines = append(lines, m.previewLine("Host key", formatHostKey(result), width))
+	}
+	if result.Error != nil && (result.Status == connectivity.StatusOffline || result.Kind == connectivity.KindHostKeyChanged) {
+		lines = append(lines, m.styles.ErrorText.Width(width).Render(result.Error.Error()))
+	}
+	return lines
+}
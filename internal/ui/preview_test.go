@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPreviewWidth(t *testing.T) {
+	tests := []struct {
+		name         string
+		width        int
+		showPreview  bool
+		configured   int
+		wantVisible  bool
+		wantPreview  int
+		wantMaxTable int
+	}{
+		{"hidden when disabled", 160, false, 0, false, 0, 160},
+		{"hidden on narrow terminals", 90, true, 0, false, 0, 90},
+		{"proportional width", 120, true, 0, true, 48, 72},
+		{"capped on wide terminals", 240, true, 0, true, previewMaxWidth, 240 - previewMaxWidth},
+		{"configured width", 160, true, 50, true, 50, 110},
+		{"table keeps its minimum width", 100, true, 80, true, 100 - previewMinTableWidth, previewMinTableWidth},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := createTestModel()
+			m.width = tt.width
+			m.showPreview = tt.showPreview
+			m.appConfig = &config.AppConfig{Layout: config.LayoutConfig{PreviewWidth: tt.configured}}
+
+			if got := m.previewVisible(); got != tt.wantVisible {
+				t.Errorf("previewVisible() = %v, want %v", got, tt.wantVisible)
+			}
+			if got := m.previewWidth(); got != tt.wantPreview {
+				t.Errorf("previewWidth() = %d, want %d", got, tt.wantPreview)
+			}
+			if got := m.tableWidth(); got != tt.wantMaxTable {
+				t.Errorf("tableWidth() = %d, want %d", got, tt.wantMaxTable)
+			}
+		})
+	}
+}
+
+func TestPreviewFollowsSelection(t *testing.T) {
+	m := createTestModel()
+	m.hosts[1].ProxyJump = "server3"
+	m.hosts[1].Tags = []string{"prod"}
+	m.filteredHosts = m.hosts
+	m.width = 140
+	m.height = 40
+	m.table.Focus()
+
+	// Toggle the preview pane on
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+	m = newModel.(Model)
+	if !m.showPreview {
+		t.Fatal("Expected 'v' to show the preview pane")
+	}
+
+	// Move to the second host
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = newModel.(Model)
+
+	preview := m.renderPreview(m.previewWidth(), 40)
+	for _, expected := range []string{"server2", "user2", "#prod", "Jump chain", "server3.example.com"} {
+		if !strings.Contains(preview, expected) {
+			t.Errorf("Expected preview to contain %q", expected)
+		}
+	}
+
+	if !strings.Contains(m.View(), "Jump chain") {
+		t.Error("Expected the list view to include the preview pane")
+	}
+}
+
+func TestPingKindIndicatorsAreDistinct(t *testing.T) {
+	kinds := []connectivity.ResultKind{
+		connectivity.KindAuthRequired,
+		connectivity.KindHostKeyChanged,
+		connectivity.KindHandshakeFailed,
+		connectivity.KindDNSFailure,
+		connectivity.KindTCPRefused,
+		connectivity.KindTCPTimeout,
+		connectivity.KindUnreachable,
+		connectivity.KindNotSSH,
+		connectivity.KindProxyFailed,
+		connectivity.KindNone,
+	}
+
+	seen := make(map[string]connectivity.ResultKind)
+	for _, kind := range kinds {
+		icon := pingKindIndicator(kind)
+		if other, exists := seen[icon]; exists {
+			t.Errorf("%v and %v share the icon %q", kind, other, icon)
+		}
+		seen[icon] = kind
+
+		// The host name is found after the icon in the table
+		if got := extractHostNameFromTableRow(icon + " web"); got != "web" {
+			t.Errorf("extractHostNameFromTableRow() with %v icon = %q, want %q", kind, got, "web")
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/history"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sessionFinishedMsg is sent when an SSH session started from the TUI exits
+type sessionFinishedMsg struct {
+	hostName   string
+	forwarding bool   // The session was a port forwarding
+	forwards   string // Forwards set up, for a port forwarding
+	startedAt  time.Time
+	err        error
+}
+
+// sessionStatus describes how the last SSH session ended
+type sessionStatus struct {
+	hostName   string
+	forwarding bool
+	exitCode   int   // Exit status of ssh, -1 if it could not be run
+	err        error // Error other than a non-zero exit status
+	duration   time.Duration
+}
+
+// runSession runs an ssh command in the terminal, suspending the TUI.
+// A sessionFinishedMsg is sent when the command exits.
+func runSession(sshCmd *exec.Cmd, hostName string, forwarding bool) tea.Cmd {
+	startedAt := time.Now()
+	var forwards string
+	if forwarding {
+		forwards = forwardSpecs(sshCmd.Args)
+	}
+	return tea.ExecProcess(sshCmd, func(err error) tea.Msg {
+		return sessionFinishedMsg{
+			hostName:   hostName,
+			forwarding: forwarding,
+			forwards:   forwards,
+			startedAt:  startedAt,
+			err:        err,
+		}
+	})
+}
+
+// forwardSpecs returns the forwards set up by ssh arguments, e.g. "-L 8080:localhost:80"
+func forwardSpecs(args []string) string {
+	var specs []string
+	for i := 0; i+1 < len(args); i++ {
+		switch args[i] {
+		case "-L", "-R", "-D":
+			specs = append(specs, args[i]+" "+args[i+1])
+			i++
+		}
+	}
+	return strings.Join(specs, ", ")
+}
+
+// recordSession logs a session finished in the terminal
+func (m *Model) recordSession(msg sessionFinishedMsg) {
+	if m.historyManager == nil {
+		return
+	}
+
+	session := history.NewSession(msg.hostName, history.SessionInteractive, m.configFile)
+	if msg.forwarding {
+		session.Kind = history.SessionForward
+		session.Forwards = msg.forwards
+	}
+	session.Start = msg.startedAt
+	session.Finish(msg.err)
+	_ = m.historyManager.RecordSession(session)
+}
+
+// newSessionStatus builds the status of a finished session
+func newSessionStatus(msg sessionFinishedMsg) *sessionStatus {
+	status := &sessionStatus{
+		hostName:   msg.hostName,
+		forwarding: msg.forwarding,
+		duration:   time.Since(msg.startedAt),
+	}
+	status.exitCode, status.err = history.ExitStatus(msg.err)
+	return status
+}
+
+// returnToList reports whether the TUI is resumed after a session instead of exiting
+func (m *Model) returnToList() bool {
+	return m.appConfig != nil && m.appConfig.Session.ReturnToList
+}
+
+// handleSessionFinished resumes the host list after a session, keeping the
+// cursor on the host, and refreshes its history and connectivity status
+func (m Model) handleSessionFinished(msg sessionFinishedMsg) (tea.Model, tea.Cmd) {
+	m.recordSession(msg)
+	if !m.returnToList() {
+		return m, tea.Quit
+	}
+
+	m.lastSession = newSessionStatus(msg)
+	m.viewMode = ViewList
+	m.portForwardForm = nil
+	if !m.searchMode {
+		m.table.Focus()
+	}
+
+	// The session may have changed the last login and connection count
+	if m.historyManager != nil {
+		_ = m.historyManager.Reload()
+	}
+
+	// Re-apply the filter and sort mode, which may move the host
+	if m.searchInput.Value() != "" {
+		m.filteredHosts = m.filterHosts(m.searchInput.Value())
+	} else {
+		m.filteredHosts = m.sortHosts(m.hosts)
+	}
+	m.updateTableHeight()
+	m.updateTableRows()
+
+	// Keep the cursor on the host of the session
+	for i, host := range m.filteredHosts {
+		if host.Name == msg.hostName {
+			m.table.SetCursor(i)
+			if m.pingManager != nil {
+				m.pingManager.SetHosts(m.hosts)
+				return m, pingSingleHostCmd(m.pingManager, host)
+			}
+			break
+		}
+	}
+
+	return m, nil
+}
+
+// renderSessionStatus renders the outcome of the last session for the status bar
+func (m Model) renderSessionStatus() string {
+	s := m.lastSession
+	if s == nil {
+		return ""
+	}
+
+	kind := "Session with"
+	if s.forwarding {
+		kind = "Port forwarding to"
+	}
+	duration := s.duration.Round(time.Second)
+
+	switch {
+	case s.err != nil:
+		return m.styles.StatusFailure.Render(fmt.Sprintf(" ✗ %s %s failed: %v", kind, s.hostName, s.err))
+	case s.exitCode != 0:
+		return m.styles.StatusFailure.Render(fmt.Sprintf(" ✗ %s %s ended with exit status %d after %s", kind, s.hostName, s.exitCode, duration))
+	default:
+		return m.styles.StatusSuccess.Render(fmt.Sprintf(" ✓ %s %s ended after %s", kind, s.hostName, duration))
+	}
+}
@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/history"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestNewSessionStatus(t *testing.T) {
+	exitErr := exec.Command("sh", "-c", "exit 3").Run()
+	if exitErr == nil {
+		t.Fatal("Expected the command to fail")
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		exitCode int
+		hasError bool
+	}{
+		{"success", nil, 0, false},
+		{"non-zero exit status", exitErr, 3, false},
+		{"command could not run", errors.New("executable file not found"), -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := newSessionStatus(sessionFinishedMsg{hostName: "server1", startedAt: time.Now(), err: tt.err})
+			if status.exitCode != tt.exitCode {
+				t.Errorf("exitCode = %d, want %d", status.exitCode, tt.exitCode)
+			}
+			if (status.err != nil) != tt.hasError {
+				t.Errorf("err = %v, want error %v", status.err, tt.hasError)
+			}
+		})
+	}
+}
+
+func TestSessionFinishedQuitsByDefault(t *testing.T) {
+	m := createTestModel()
+
+	_, cmd := m.Update(sessionFinishedMsg{hostName: "server1", startedAt: time.Now()})
+	if cmd == nil {
+		t.Fatal("Expected a command")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("Expected sshm to quit when returning to the list is not enabled")
+	}
+}
+
+func TestSessionFinishedReturnsToList(t *testing.T) {
+	m := createTestModel()
+	m.appConfig = &config.AppConfig{Session: config.SessionConfig{ReturnToList: true}}
+	m.viewMode = ViewPortForward
+	m.searchInput.SetValue("server")
+	m.filteredHosts = m.filterHosts("server")
+	m.updateTableRows()
+	m.table.SetCursor(0)
+
+	exitErr := exec.Command("sh", "-c", "exit 255").Run()
+	newModel, cmd := m.Update(sessionFinishedMsg{hostName: "web-server", forwarding: true, startedAt: time.Now(), err: exitErr})
+	m = newModel.(Model)
+
+	if cmd != nil {
+		if _, ok := cmd().(tea.QuitMsg); ok {
+			t.Fatal("Expected sshm not to quit")
+		}
+	}
+	if m.viewMode != ViewList {
+		t.Error("Expected to return to the list view")
+	}
+	if m.searchInput.Value() != "server" || len(m.filteredHosts) != 5 {
+		t.Errorf("Expected the filter to be preserved, got %q with %d hosts", m.searchInput.Value(), len(m.filteredHosts))
+	}
+	if host := m.selectedHost(); host == nil || host.Name != "web-server" {
+		t.Errorf("Expected the cursor to stay on the session's host, got %v", host)
+	}
+
+	status := m.renderSessionStatus()
+	if !strings.Contains(status, "web-server") || !strings.Contains(status, "exit status 255") {
+		t.Errorf("Unexpected status bar: %q", status)
+	}
+}
+
+func TestSessionFinishedIsLogged(t *testing.T) {
+	m := createTestModel()
+	m.historyManager = newTestHistoryManager(t)
+
+	sshCmd := exec.Command("ssh", "-N", "-L", "8080:localhost:80", "-D", "1080", "web-server")
+	msg := sessionFinishedMsg{hostName: "web-server", forwarding: true, forwards: forwardSpecs(sshCmd.Args), startedAt: time.Now().Add(-time.Minute)}
+	m.Update(msg)
+
+	sessions, err := m.historyManager.GetSessions(history.SessionFilter{})
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("Expected the session to be logged before quitting, got %v (%v)", sessions, err)
+	}
+	session := sessions[0]
+	if session.Kind != history.SessionForward || session.Forwards != "-L 8080:localhost:80, -D 1080" || session.ExitCode != 0 {
+		t.Errorf("Unexpected session %+v", session)
+	}
+	if session.ConfigFile == "" || session.Duration < 60 {
+		t.Errorf("Expected the config file and duration to be logged, got %+v", session)
+	}
+}
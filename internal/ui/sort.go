@@ -5,10 +5,21 @@ import (
 	"strings"
 
 	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
 )
 
+// hostKeyFilters are search keywords selecting hosts by the status of their
+// host key in the last connectivity check
+var hostKeyFilters = map[string][]connectivity.HostKeyStatus{
+	"key:changed": {connectivity.HostKeyChanged, connectivity.HostKeyRevoked},
+	"key:unknown": {connectivity.HostKeyUnknown},
+}
+
 // sortHosts sorts hosts according to the current sort mode
 func (m Model) sortHosts(hosts []config.SSHHost) []config.SSHHost {
+	if m.sortMode == SortByLatency {
+		return m.sortHostsByLatency(hosts)
+	}
 	if m.historyManager == nil {
 		return sortHostsByName(hosts)
 	}
@@ -35,6 +46,33 @@ func sortHostsByName(hosts []config.SSHHost) []config.SSHHost {
 	return sorted
 }
 
+// sortHostsByLatency sorts the online hosts from the fastest to the slowest
+// according to their last check, followed by the other hosts by name
+func (m Model) sortHostsByLatency(hosts []config.SSHHost) []config.SSHHost {
+	sorted := sortHostsByName(hosts)
+	if m.pingManager == nil {
+		return sorted
+	}
+
+	latency := func(host config.SSHHost) (int64, bool) {
+		result, ok := m.pingManager.GetResult(host.Name)
+		if !ok || result.Status != connectivity.StatusOnline {
+			return 0, false
+		}
+		return int64(result.Duration), true
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		li, oki := latency(sorted[i])
+		lj, okj := latency(sorted[j])
+		if oki != okj {
+			return oki
+		}
+		return oki && li < lj
+	})
+	return sorted
+}
+
 // filterHosts filters hosts according to the search query (name or tags)
 func (m Model) filterHosts(query string) []config.SSHHost {
 	subqueries := strings.Split(query, " ")
@@ -70,6 +108,8 @@ func (m Model) filterHostsByWord(word string) []config.SSHHost {
 
 	if word == "" {
 		filtered = m.hosts
+	} else if statuses, ok := hostKeyFilters[strings.ToLower(word)]; ok {
+		filtered = m.filterHostsByKeyStatus(statuses)
 	} else {
 		word = strings.ToLower(word)
 
@@ -104,3 +144,26 @@ func (m Model) filterHostsByWord(word string) []config.SSHHost {
 
 	return m.sortHosts(filtered)
 }
+
+// filterHostsByKeyStatus returns the hosts whose host key had one of the
+// given statuses in their last connectivity check
+func (m Model) filterHostsByKeyStatus(statuses []connectivity.HostKeyStatus) []config.SSHHost {
+	var filtered []config.SSHHost
+	if m.pingManager == nil {
+		return filtered
+	}
+
+	for _, host := range m.hosts {
+		result, ok := m.pingManager.GetResult(host.Name)
+		if !ok {
+			continue
+		}
+		for _, status := range statuses {
+			if result.HostKeyStatus == status {
+				filtered = append(filtered, host)
+				break
+			}
+		}
+	}
+	return filtered
+}
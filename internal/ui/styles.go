@@ -2,17 +2,6 @@ package ui
 
 import "github.com/charmbracelet/lipgloss"
 
-// Theme colors
-var (
-	// Primary interface color - easily modifiable
-	PrimaryColor = "#00ADD8" // Official Go logo blue color
-
-	// Secondary colors
-	SecondaryColor = "240" // Gray
-	ErrorColor     = "1"   // Red
-	SuccessColor   = "36"  // Green (for reference if needed)
-)
-
 // Styles struct centralizes all lipgloss styles
 type Styles struct {
 	// Layout
@@ -24,17 +13,26 @@ type Styles struct {
 	SearchUnfocused lipgloss.Style
 
 	// Table styles
-	TableFocused   lipgloss.Style
-	TableUnfocused lipgloss.Style
-	Selected       lipgloss.Style
+	TableFocused         lipgloss.Style
+	TableUnfocused       lipgloss.Style
+	TableHeaderFocused   lipgloss.Style
+	TableHeaderUnfocused lipgloss.Style
+	Selected             lipgloss.Style
 
 	// Info and help styles
-	SortInfo lipgloss.Style
-	HelpText lipgloss.Style
+	SortInfo      lipgloss.Style
+	HelpText      lipgloss.Style
+	StatusSuccess lipgloss.Style
+	StatusFailure lipgloss.Style
 
 	// Error and confirmation styles
-	Error     lipgloss.Style
-	ErrorText lipgloss.Style
+	Error        lipgloss.Style
+	ErrorText    lipgloss.Style
+	ErrorBanner  lipgloss.Style
+	UpdateBanner lipgloss.Style
+	DangerTitle  lipgloss.Style
+	DangerText   lipgloss.Style
+	DangerBorder lipgloss.Style
 
 	// Form styles (for add/edit forms)
 	FormTitle     lipgloss.Style
@@ -44,90 +42,214 @@ type Styles struct {
 	Label         lipgloss.Style
 	FocusedLabel  lipgloss.Style
 	HelpSection   lipgloss.Style
+
+	// Info view styles
+	InfoLabel  lipgloss.Style
+	InfoValue  lipgloss.Style
+	InfoMuted  lipgloss.Style
+	InfoAction lipgloss.Style
+	InfoBorder lipgloss.Style
 }
 
-// NewStyles creates a new Styles struct with the given terminal width
+// NewStyles creates a new Styles struct with the given terminal width,
+// using the colours of the active theme
 func NewStyles(width int) Styles {
-	return Styles{
+	t := activeTheme
+
+	selected := lipgloss.NewStyle().
+		Foreground(t.SelectedForeground).
+		Background(t.Primary).
+		Bold(false)
+	if t.ReverseSelection {
+		selected = lipgloss.NewStyle().Reverse(true)
+	}
+
+	s := Styles{
 		// Main app container
 		App: lipgloss.NewStyle().
 			Padding(1),
 
 		// Header style
 		Header: lipgloss.NewStyle().
-			Foreground(lipgloss.Color(PrimaryColor)).
+			Foreground(t.Primary).
 			Bold(true).
 			Align(lipgloss.Center),
 
 		// Search styles
 		SearchFocused: lipgloss.NewStyle().
 			BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color(PrimaryColor)).
+			BorderForeground(t.Primary).
 			Padding(0, 1),
 
 		SearchUnfocused: lipgloss.NewStyle().
 			BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color(SecondaryColor)).
+			BorderForeground(t.Secondary).
 			Padding(0, 1),
 
 		// Table styles
 		TableFocused: lipgloss.NewStyle().
 			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color(PrimaryColor)),
+			BorderForeground(t.Primary),
 
 		TableUnfocused: lipgloss.NewStyle().
 			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color(SecondaryColor)),
+			BorderForeground(t.Secondary),
+
+		TableHeaderFocused: lipgloss.NewStyle().
+			BorderStyle(lipgloss.NormalBorder()).
+			BorderForeground(t.Primary).
+			BorderBottom(true).
+			Bold(false).
+			Padding(0, 1),
+
+		TableHeaderUnfocused: lipgloss.NewStyle().
+			BorderStyle(lipgloss.NormalBorder()).
+			BorderForeground(t.Secondary).
+			BorderBottom(true).
+			Bold(false).
+			Padding(0, 1),
 
 		// Style for selected items
-		Selected: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("229")).
-			Background(lipgloss.Color(PrimaryColor)).
-			Bold(false),
+		Selected: selected,
 
 		// Info styles
 		SortInfo: lipgloss.NewStyle().
-			Foreground(lipgloss.Color(SecondaryColor)),
+			Foreground(t.Secondary),
 
 		HelpText: lipgloss.NewStyle().
-			Foreground(lipgloss.Color(SecondaryColor)),
+			Foreground(t.Secondary),
+
+		// Status bar styles for the outcome of the last session
+		StatusSuccess: lipgloss.NewStyle().
+			Foreground(t.Success),
+
+		StatusFailure: lipgloss.NewStyle().
+			Foreground(t.Error),
 
 		// Error style
 		Error: lipgloss.NewStyle().
 			BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color(ErrorColor)).
+			BorderForeground(t.Error).
 			Padding(1, 2),
 
 		// Error text style (no border, just red text)
 		ErrorText: lipgloss.NewStyle().
-			Foreground(lipgloss.Color(ErrorColor)).
+			Foreground(t.Error).
 			Bold(true),
 
+		// Banner shown above the search bar for transient errors
+		ErrorBanner: lipgloss.NewStyle().
+			Foreground(t.Error).
+			Bold(true).
+			Padding(0, 1).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(t.Error).
+			Align(lipgloss.Center),
+
+		// Banner shown when a new version is available
+		UpdateBanner: lipgloss.NewStyle().
+			Foreground(t.Success).
+			Bold(true).
+			Align(lipgloss.Center),
+
+		// Destructive confirmation dialog styles
+		DangerTitle: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(t.Error),
+
+		DangerText: lipgloss.NewStyle().
+			Foreground(t.Warning),
+
+		DangerBorder: lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(t.Error),
+
 		// Form styles
 		FormTitle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FFFDF5")).
-			Background(lipgloss.Color(PrimaryColor)).
+			Foreground(t.TitleForeground).
+			Background(t.Primary).
 			Padding(0, 1),
 
 		FormField: lipgloss.NewStyle().
-			Foreground(lipgloss.Color(PrimaryColor)),
+			Foreground(t.Primary),
 
 		FormHelp: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#626262")),
+			Foreground(t.Muted),
 
 		FormContainer: lipgloss.NewStyle().
 			BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color(PrimaryColor)).
+			BorderForeground(t.Primary).
 			Padding(1, 2),
 
 		Label: lipgloss.NewStyle().
-			Foreground(lipgloss.Color(SecondaryColor)),
+			Foreground(t.Secondary),
 
 		FocusedLabel: lipgloss.NewStyle().
-			Foreground(lipgloss.Color(PrimaryColor)),
+			Foreground(t.Primary),
 
 		HelpSection: lipgloss.NewStyle().
 			Padding(0, 2),
+
+		// Info view styles
+		InfoLabel: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(t.Accent),
+
+		InfoValue: lipgloss.NewStyle().
+			Foreground(t.Text),
+
+		InfoMuted: lipgloss.NewStyle().
+			Foreground(t.Muted),
+
+		InfoAction: lipgloss.NewStyle().
+			Foreground(t.Success).
+			Bold(true),
+
+		InfoBorder: lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(t.Accent),
+	}
+
+	applyStyleOverrides(&s, t.overrides)
+
+	return s
+}
+
+// named returns every style keyed by the name used in custom themes
+func (s *Styles) named() map[string]*lipgloss.Style {
+	return map[string]*lipgloss.Style{
+		"app":                    &s.App,
+		"header":                 &s.Header,
+		"search_focused":         &s.SearchFocused,
+		"search_unfocused":       &s.SearchUnfocused,
+		"table_focused":          &s.TableFocused,
+		"table_unfocused":        &s.TableUnfocused,
+		"table_header_focused":   &s.TableHeaderFocused,
+		"table_header_unfocused": &s.TableHeaderUnfocused,
+		"selected":               &s.Selected,
+		"sort_info":              &s.SortInfo,
+		"help_text":              &s.HelpText,
+		"status_success":         &s.StatusSuccess,
+		"status_failure":         &s.StatusFailure,
+		"error":                  &s.Error,
+		"error_text":             &s.ErrorText,
+		"error_banner":           &s.ErrorBanner,
+		"update_banner":          &s.UpdateBanner,
+		"danger_title":           &s.DangerTitle,
+		"danger_text":            &s.DangerText,
+		"danger_border":          &s.DangerBorder,
+		"form_title":             &s.FormTitle,
+		"form_field":             &s.FormField,
+		"form_help":              &s.FormHelp,
+		"form_container":         &s.FormContainer,
+		"label":                  &s.Label,
+		"focused_label":          &s.FocusedLabel,
+		"help_section":           &s.HelpSection,
+		"info_label":             &s.InfoLabel,
+		"info_value":             &s.InfoValue,
+		"info_muted":             &s.InfoMuted,
+		"info_action":            &s.InfoAction,
+		"info_border":            &s.InfoBorder,
 	}
 }
 
@@ -1,124 +1,147 @@
 package ui
 
 import (
-	"strings"
-
 	"github.com/Gu1llaum-3/sshm/internal/config"
-	"github.com/Gu1llaum-3/sshm/internal/history"
 
 	"github.com/charmbracelet/bubbles/table"
 )
 
-// calculateDynamicColumnWidths calculates optimal column widths based on terminal width
-// and content length, ensuring all content fits when possible
-func (m *Model) calculateDynamicColumnWidths(hosts []config.SSHHost) (int, int, int, int) {
-	if m.width <= 0 {
-		// Fallback to static widths if terminal width is not available
-		return calculateNameColumnWidth(hosts), 25, calculateTagsColumnWidth(hosts), calculateLastLoginColumnWidth(hosts, m.historyManager)
-	}
-
-	// Calculate content lengths
-	maxNameLength := 8       // Minimum for "Name" header + status indicator
-	maxHostnameLength := 8   // Minimum for "Hostname" header
-	maxTagsLength := 8       // Minimum for "Tags" header
-	maxLastLoginLength := 12 // Minimum for "Last Login" header
-
-	for _, host := range hosts {
-		// Name column includes status indicator (2 chars) + space (1 char) + name
-		nameLength := 3 + len(host.Name)
-		if nameLength > maxNameLength {
-			maxNameLength = nameLength
-		}
-
-		if len(host.Hostname) > maxHostnameLength {
-			maxHostnameLength = len(host.Hostname)
-		}
+// calculateDynamicColumnWidths calculates optimal widths for the visible columns based on
+// terminal width and content length, ensuring all content fits when possible
+func (m *Model) calculateDynamicColumnWidths(hosts []config.SSHHost) []int {
+	columns := m.visibleColumns()
+	widths := make([]int, len(columns))
 
-		// Calculate tags string length
-		var tagsStr string
-		if len(host.Tags) > 0 {
-			var formattedTags []string
-			for _, tag := range host.Tags {
-				formattedTags = append(formattedTags, "#"+tag)
+	// Calculate content lengths (with 2 characters of padding) for each column
+	wanted := make([]int, len(columns))
+	for i, col := range columns {
+		maxLength := col.def.headerWidth
+		for _, host := range hosts {
+			if length := len(col.def.value(m, host)); length > maxLength {
+				maxLength = length
 			}
-			tagsStr = strings.Join(formattedTags, " ")
 		}
-		if len(tagsStr) > maxTagsLength {
-			maxTagsLength = len(tagsStr)
+		wanted[i] = maxLength + 2
+		if col.maxWidth > 0 && wanted[i] > col.maxWidth {
+			wanted[i] = col.maxWidth
 		}
+	}
 
-		// Calculate last login length
-		if m.historyManager != nil {
-			if lastConnect, exists := m.historyManager.GetLastConnectionTime(host.Name); exists {
-				timeStr := formatTimeAgo(lastConnect)
-				if len(timeStr) > maxLastLoginLength {
-					maxLastLoginLength = len(timeStr)
-				}
+	if m.width <= 0 {
+		// Fallback to static widths if terminal width is not available
+		for i, col := range columns {
+			switch {
+			case col.width > 0:
+				widths[i] = col.width
+			case wanted[i] > col.def.maxWidth:
+				widths[i] = col.def.maxWidth
+			default:
+				widths[i] = wanted[i]
 			}
 		}
+		return widths
 	}
 
-	// Add padding to each column
-	maxNameLength += 2
-	maxHostnameLength += 2
-	maxTagsLength += 2
-	maxLastLoginLength += 2
-
 	// Calculate available width (minus borders and separators)
-	// Table has borders (2 chars) + column separators (3 chars between 4 columns)
-	availableWidth := m.width - 5
-
-	totalNeededWidth := maxNameLength + maxHostnameLength + maxTagsLength + maxLastLoginLength
+	// Table has borders (2 chars) + column separators (1 char between each column)
+	availableWidth := m.tableWidth() - (len(columns) + 1)
+
+	// Columns with a fixed width are taken out of the distribution
+	totalNeededWidth := 0
+	for i, col := range columns {
+		if col.width > 0 {
+			widths[i] = col.width
+			availableWidth -= col.width
+			continue
+		}
+		totalNeededWidth += wanted[i]
+	}
 
 	if totalNeededWidth <= availableWidth {
 		// Everything fits perfectly
-		return maxNameLength, maxHostnameLength, maxTagsLength, maxLastLoginLength
+		for i, col := range columns {
+			if col.width == 0 {
+				widths[i] = wanted[i]
+			}
+		}
+		return widths
 	}
 
-	// Need to adjust widths - prioritize columns by importance
-	// Priority: Name > Hostname > Last Login > Tags
-
-	// Calculate minimum widths
-	minNameWidth := 15 // Enough for status + short name
-	minHostnameWidth := 15
-	minLastLoginWidth := 12
-	minTagsWidth := 10
-
-	remainingWidth := availableWidth
-
 	// Allocate minimum widths first
-	nameWidth := minNameWidth
-	hostnameWidth := minHostnameWidth
-	lastLoginWidth := minLastLoginWidth
-	tagsWidth := minTagsWidth
-
-	remainingWidth -= (nameWidth + hostnameWidth + lastLoginWidth + tagsWidth)
+	remainingWidth := availableWidth
+	totalWant := 0
+	lastAuto := -1
+	for i, col := range columns {
+		if col.width > 0 {
+			continue
+		}
+		widths[i] = col.minWidth
+		if col.maxWidth > 0 && widths[i] > col.maxWidth {
+			widths[i] = col.maxWidth
+		}
+		remainingWidth -= widths[i]
+		if want := wanted[i] - widths[i]; want > 0 {
+			totalWant += want
+		}
+		lastAuto = i
+	}
 
-	// Distribute remaining space proportionally
-	if remainingWidth > 0 {
-		// Calculate how much each column wants beyond minimum
-		nameWant := maxNameLength - minNameWidth
-		hostnameWant := maxHostnameLength - minHostnameWidth
-		lastLoginWant := maxLastLoginLength - minLastLoginWidth
-		tagsWant := maxTagsLength - minTagsWidth
+	// Distribute remaining space proportionally to how much each column wants beyond minimum
+	if remainingWidth > 0 && totalWant > 0 {
+		distributed := 0
+		for i, col := range columns {
+			if col.width > 0 || i == lastAuto {
+				continue
+			}
+			if want := wanted[i] - widths[i]; want > 0 {
+				extra := (want * remainingWidth) / totalWant
+				widths[i] += extra
+				distributed += extra
+			}
+		}
+		widths[lastAuto] += remainingWidth - distributed
+		if maxWidth := columns[lastAuto].maxWidth; maxWidth > 0 && widths[lastAuto] > maxWidth {
+			widths[lastAuto] = maxWidth
+		}
+	}
 
-		totalWant := nameWant + hostnameWant + lastLoginWant + tagsWant
+	return widths
+}
 
-		if totalWant > 0 {
-			// Distribute proportionally
-			nameExtra := (nameWant * remainingWidth) / totalWant
-			hostnameExtra := (hostnameWant * remainingWidth) / totalWant
-			lastLoginExtra := (lastLoginWant * remainingWidth) / totalWant
-			tagsExtra := remainingWidth - nameExtra - hostnameExtra - lastLoginExtra
+// buildTableRow renders the visible columns for a host
+func (m *Model) buildTableRow(host config.SSHHost, columns []tableColumn) table.Row {
+	row := make(table.Row, len(columns))
+	for i, col := range columns {
+		row[i] = col.def.value(m, host)
+	}
+	return row
+}
 
-			nameWidth += nameExtra
-			hostnameWidth += hostnameExtra
-			lastLoginWidth += lastLoginExtra
-			tagsWidth += tagsExtra
+// buildTableColumns returns the table columns with their widths and sort indicators
+func (m *Model) buildTableColumns(hosts []config.SSHHost) []table.Column {
+	visible := m.visibleColumns()
+	widths := m.calculateDynamicColumnWidths(hosts)
+
+	columns := make([]table.Column, len(visible))
+	for i, col := range visible {
+		title := col.def.title
+		// Add sort indicators based on current sort mode
+		if col.def.sortable && col.def.sortMode == m.sortMode {
+			title += " ↓"
 		}
+		columns[i] = table.Column{Title: title, Width: widths[i]}
 	}
+	return columns
+}
 
-	return nameWidth, hostnameWidth, tagsWidth, lastLoginWidth
+// nameColumnIndex returns the position of the Name column among the visible columns
+func (m *Model) nameColumnIndex() int {
+	for i, col := range m.visibleColumns() {
+		if col.def.id == config.ColumnName {
+			return i
+		}
+	}
+	return 0
 }
 
 // updateTableRows updates the table with filtered hosts
@@ -129,39 +152,15 @@ func (m *Model) updateTableRows() {
 		hostsToShow = m.hosts
 	}
 
+	columns := m.visibleColumns()
 	for _, host := range hostsToShow {
-		// Get ping status indicator
-		statusIndicator := m.getPingStatusIndicator(host.Name)
-
-		// Format tags for display
-		var tagsStr string
-		if len(host.Tags) > 0 {
-			// Add the # prefix to each tag and join them with spaces
-			var formattedTags []string
-			for _, tag := range host.Tags {
-				formattedTags = append(formattedTags, "#"+tag)
-			}
-			tagsStr = strings.Join(formattedTags, " ")
-		}
-
-		// Format last login information
-		var lastLoginStr string
-		if m.historyManager != nil {
-			if lastConnect, exists := m.historyManager.GetLastConnectionTime(host.Name); exists {
-				lastLoginStr = formatTimeAgo(lastConnect)
-			}
-		}
-
-		rows = append(rows, table.Row{
-			statusIndicator + " " + host.Name,
-			host.Hostname,
-			// host.User,      // Commented to save space
-			// host.Port,      // Commented to save space
-			tagsStr,
-			lastLoginStr,
-		})
+		rows = append(rows, m.buildTableRow(host, columns))
 	}
 
+	// Clear the rows before changing the column layout, since the table
+	// cannot render rows that have more cells than columns
+	m.table.SetRows(nil)
+	m.table.SetColumns(m.buildTableColumns(hostsToShow))
 	m.table.SetRows(rows)
 
 	// Update table height and columns based on current terminal size
@@ -185,6 +184,10 @@ func (m *Model) updateTableHeight() {
 	// - Safety margin: 3 lines (to ensure UI elements are always visible)
 	// Total reserved: 14 lines minimum to preserve essential UI elements
 	reservedHeight := 14
+	if m.lastSession != nil {
+		// Status bar with the outcome of the last session
+		reservedHeight++
+	}
 	availableHeight := m.height - reservedHeight
 	hostCount := len(m.table.Rows())
 
@@ -200,6 +203,11 @@ func (m *Model) updateTableHeight() {
 	dataRowsNeeded := hostCount
 	maxDataRows := maxTableHeight - 1 // subtract 1 for header
 
+	// The preview pane uses the full height, so the table is extended to match it
+	if m.previewVisible() {
+		dataRowsNeeded = maxDataRows
+	}
+
 	if dataRowsNeeded <= maxDataRows {
 		// We have enough space for all hosts
 		tableHeight += dataRowsNeeded
@@ -227,121 +235,5 @@ func (m *Model) updateTableColumns() {
 		hostsToShow = m.hosts
 	}
 
-	// Use dynamic column width calculation
-	nameWidth, hostnameWidth, tagsWidth, lastLoginWidth := m.calculateDynamicColumnWidths(hostsToShow)
-
-	// Create new columns with updated widths and sort indicators
-	nameTitle := "Name"
-	lastLoginTitle := "Last Login"
-
-	// Add sort indicators based on current sort mode
-	switch m.sortMode {
-	case SortByName:
-		nameTitle += " ↓"
-	case SortByLastUsed:
-		lastLoginTitle += " ↓"
-	}
-
-	columns := []table.Column{
-		{Title: nameTitle, Width: nameWidth},
-		{Title: "Hostname", Width: hostnameWidth},
-		// {Title: "User", Width: userWidth},      // Commented to save space
-		// {Title: "Port", Width: portWidth},      // Commented to save space
-		{Title: "Tags", Width: tagsWidth},
-		{Title: lastLoginTitle, Width: lastLoginWidth},
-	}
-
-	m.table.SetColumns(columns)
-}
-
-// max returns the maximum of two integers
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
-
-// Legacy functions for compatibility
-
-// calculateNameColumnWidth calculates the optimal width for the Name column
-// based on the longest hostname, with a minimum of 8 and maximum of 40 characters
-func calculateNameColumnWidth(hosts []config.SSHHost) int {
-	maxLength := 8 // Minimum width to accommodate the "Name" header
-
-	for _, host := range hosts {
-		if len(host.Name) > maxLength {
-			maxLength = len(host.Name)
-		}
-	}
-
-	// Add some padding (2 characters) for better visual spacing
-	maxLength += 2
-
-	// Limit the maximum width to avoid extremely large columns
-	if maxLength > 40 {
-		maxLength = 40
-	}
-
-	return maxLength
-}
-
-// calculateTagsColumnWidth calculates the optimal width for the Tags column
-// based on the longest tag string, with a minimum of 8 and maximum of 40 characters
-func calculateTagsColumnWidth(hosts []config.SSHHost) int {
-	maxLength := 8 // Minimum width to accommodate the "Tags" header
-
-	for _, host := range hosts {
-		// Format tags exactly as they appear in the table
-		var tagsStr string
-		if len(host.Tags) > 0 {
-			// Add the # prefix to each tag and join them with spaces
-			var formattedTags []string
-			for _, tag := range host.Tags {
-				formattedTags = append(formattedTags, "#"+tag)
-			}
-			tagsStr = strings.Join(formattedTags, " ")
-		}
-
-		if len(tagsStr) > maxLength {
-			maxLength = len(tagsStr)
-		}
-	}
-
-	// Add some padding (2 characters) for better visual spacing
-	maxLength += 2
-
-	// Limit the maximum width to avoid extremely large columns
-	if maxLength > 40 {
-		maxLength = 40
-	}
-
-	return maxLength
-}
-
-// calculateLastLoginColumnWidth calculates the optimal width for the Last Login column
-// based on the longest time format, with a minimum of 12 and maximum of 20 characters
-func calculateLastLoginColumnWidth(hosts []config.SSHHost, historyManager *history.HistoryManager) int {
-	maxLength := 12 // Minimum width to accommodate the "Last Login" header
-
-	if historyManager != nil {
-		for _, host := range hosts {
-			if lastConnect, exists := historyManager.GetLastConnectionTime(host.Name); exists {
-				timeStr := formatTimeAgo(lastConnect)
-				if len(timeStr) > maxLength {
-					maxLength = len(timeStr)
-				}
-			}
-		}
-	}
-
-	// Add some padding (2 characters) for better visual spacing
-	maxLength += 2
-
-	// Limit the maximum width to avoid extremely large columns
-	if maxLength > 20 {
-		maxLength = 20
-	}
-
-	return maxLength
+	m.table.SetColumns(m.buildTableColumns(hostsToShow))
 }
@@ -0,0 +1,270 @@
+package ui
+
+import (
+	"os"
+	"sort"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Built-in theme names
+const (
+	ThemeAuto         = config.DefaultThemeName
+	ThemeDark         = "dark"
+	ThemeLight        = "light"
+	ThemeHighContrast = "high-contrast"
+	ThemeSolarized    = "solarized"
+	ThemeNoColor      = "no-color"
+)
+
+// Theme holds the colour palette used to build the UI styles
+type Theme struct {
+	Name               string
+	Primary            lipgloss.TerminalColor
+	Secondary          lipgloss.TerminalColor
+	Accent             lipgloss.TerminalColor
+	Error              lipgloss.TerminalColor
+	Warning            lipgloss.TerminalColor
+	Success            lipgloss.TerminalColor
+	Text               lipgloss.TerminalColor
+	Muted              lipgloss.TerminalColor
+	SelectedForeground lipgloss.TerminalColor
+	TitleForeground    lipgloss.TerminalColor
+
+	// ReverseSelection highlights the selected row with reverse video instead of colours
+	ReverseSelection bool
+
+	// overrides are per-style attributes applied on top of the palette (custom themes only)
+	overrides map[string]config.StyleOverride
+}
+
+// builtinThemes contains the themes shipped with sshm
+var builtinThemes = map[string]Theme{
+	ThemeDark: {
+		Name:               ThemeDark,
+		Primary:            lipgloss.Color("#00ADD8"), // Official Go logo blue color
+		Secondary:          lipgloss.Color("240"),
+		Accent:             lipgloss.Color("39"),
+		Error:              lipgloss.Color("1"),
+		Warning:            lipgloss.Color("203"),
+		Success:            lipgloss.Color("10"),
+		Text:               lipgloss.Color("255"),
+		Muted:              lipgloss.Color("243"),
+		SelectedForeground: lipgloss.Color("229"),
+		TitleForeground:    lipgloss.Color("#FFFDF5"),
+	},
+	ThemeLight: {
+		Name:               ThemeLight,
+		Primary:            lipgloss.Color("#00718F"),
+		Secondary:          lipgloss.Color("246"),
+		Accent:             lipgloss.Color("25"),
+		Error:              lipgloss.Color("160"),
+		Warning:            lipgloss.Color("166"),
+		Success:            lipgloss.Color("28"),
+		Text:               lipgloss.Color("235"),
+		Muted:              lipgloss.Color("241"),
+		SelectedForeground: lipgloss.Color("255"),
+		TitleForeground:    lipgloss.Color("255"),
+	},
+	ThemeHighContrast: {
+		Name:               ThemeHighContrast,
+		Primary:            lipgloss.Color("11"),
+		Secondary:          lipgloss.Color("15"),
+		Accent:             lipgloss.Color("14"),
+		Error:              lipgloss.Color("9"),
+		Warning:            lipgloss.Color("11"),
+		Success:            lipgloss.Color("10"),
+		Text:               lipgloss.Color("15"),
+		Muted:              lipgloss.Color("15"),
+		SelectedForeground: lipgloss.Color("0"),
+		TitleForeground:    lipgloss.Color("0"),
+	},
+	ThemeSolarized: {
+		Name:               ThemeSolarized,
+		Primary:            lipgloss.Color("#268BD2"),
+		Secondary:          lipgloss.Color("#586E75"),
+		Accent:             lipgloss.Color("#2AA198"),
+		Error:              lipgloss.Color("#DC322F"),
+		Warning:            lipgloss.Color("#CB4B16"),
+		Success:            lipgloss.Color("#859900"),
+		Text:               lipgloss.Color("#93A1A1"),
+		Muted:              lipgloss.Color("#657B83"),
+		SelectedForeground: lipgloss.Color("#FDF6E3"),
+		TitleForeground:    lipgloss.Color("#FDF6E3"),
+	},
+	ThemeNoColor: {
+		Name:               ThemeNoColor,
+		Primary:            lipgloss.NoColor{},
+		Secondary:          lipgloss.NoColor{},
+		Accent:             lipgloss.NoColor{},
+		Error:              lipgloss.NoColor{},
+		Warning:            lipgloss.NoColor{},
+		Success:            lipgloss.NoColor{},
+		Text:               lipgloss.NoColor{},
+		Muted:              lipgloss.NoColor{},
+		SelectedForeground: lipgloss.NoColor{},
+		TitleForeground:    lipgloss.NoColor{},
+		ReverseSelection:   true,
+	},
+}
+
+// activeTheme is the theme used by NewStyles
+var activeTheme = builtinThemes[ThemeDark]
+
+// SetTheme changes the theme used by NewStyles
+func SetTheme(theme Theme) {
+	activeTheme = theme
+}
+
+// CurrentTheme returns the theme currently used by NewStyles
+func CurrentTheme() Theme {
+	return activeTheme
+}
+
+// noColorRequested reports whether the NO_COLOR convention (https://no-color.org) is in effect
+func noColorRequested() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
+
+// ResolveTheme returns the theme selected by the configuration.
+// NO_COLOR always wins; "auto" picks dark or light from the terminal background;
+// unknown names fall back to automatic detection.
+func ResolveTheme(cfg config.ThemeConfig) Theme {
+	if noColorRequested() {
+		return builtinThemes[ThemeNoColor]
+	}
+	return resolveNamedTheme(cfg.Name, cfg, lipgloss.HasDarkBackground())
+}
+
+// resolveNamedTheme resolves a theme by name without consulting the environment
+func resolveNamedTheme(name string, cfg config.ThemeConfig, darkBackground bool) Theme {
+	if custom, ok := cfg.CustomThemes[name]; ok {
+		return buildCustomTheme(name, custom, darkBackground)
+	}
+
+	if theme, ok := builtinThemes[name]; ok {
+		return theme
+	}
+
+	if darkBackground {
+		return builtinThemes[ThemeDark]
+	}
+	return builtinThemes[ThemeLight]
+}
+
+// buildCustomTheme builds a theme from its base theme and the user's overrides
+func buildCustomTheme(name string, custom config.CustomTheme, darkBackground bool) Theme {
+	base := custom.Base
+	if base == "" {
+		base = ThemeDark
+	}
+
+	theme, ok := builtinThemes[base]
+	if !ok {
+		theme = resolveNamedTheme(ThemeAuto, config.ThemeConfig{}, darkBackground)
+	}
+	theme.Name = name
+
+	applyColor(&theme.Primary, custom.Colors.Primary)
+	applyColor(&theme.Secondary, custom.Colors.Secondary)
+	applyColor(&theme.Accent, custom.Colors.Accent)
+	applyColor(&theme.Error, custom.Colors.Error)
+	applyColor(&theme.Warning, custom.Colors.Warning)
+	applyColor(&theme.Success, custom.Colors.Success)
+	applyColor(&theme.Text, custom.Colors.Text)
+	applyColor(&theme.Muted, custom.Colors.Muted)
+	applyColor(&theme.SelectedForeground, custom.Colors.SelectedForeground)
+	applyColor(&theme.TitleForeground, custom.Colors.TitleForeground)
+
+	theme.overrides = custom.Styles
+	return theme
+}
+
+// applyColor replaces a palette colour when a value is configured
+func applyColor(target *lipgloss.TerminalColor, value string) {
+	if value != "" {
+		*target = lipgloss.Color(value)
+	}
+}
+
+// ApplyTheme activates the theme selected by the configuration and,
+// when NO_COLOR is set, disables colour output entirely
+func ApplyTheme(cfg config.ThemeConfig) Theme {
+	if noColorRequested() {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+	theme := ResolveTheme(cfg)
+	SetTheme(theme)
+	return theme
+}
+
+// applyConfiguredTheme activates the theme from the application config file.
+// It is used by the standalone forms, which do not go through NewModel.
+func applyConfiguredTheme() {
+	themeConfig := config.GetDefaultThemeConfig()
+	if appConfig, err := config.LoadAppConfig(); err == nil {
+		themeConfig = appConfig.Theme
+	}
+	ApplyTheme(themeConfig)
+}
+
+// AvailableThemes returns the names of the built-in and custom themes, sorted
+func AvailableThemes(cfg config.ThemeConfig) []string {
+	names := []string{ThemeAuto, ThemeDark, ThemeLight, ThemeHighContrast, ThemeSolarized}
+
+	var custom []string
+	for name := range cfg.CustomThemes {
+		custom = append(custom, name)
+	}
+	sort.Strings(custom)
+
+	return append(names, custom...)
+}
+
+// applyStyleOverrides applies the theme's per-style overrides to the styles
+func applyStyleOverrides(s *Styles, overrides map[string]config.StyleOverride) {
+	if len(overrides) == 0 {
+		return
+	}
+
+	fields := s.named()
+	for name, override := range overrides {
+		style, ok := fields[name]
+		if !ok {
+			continue
+		}
+		*style = applyStyleOverride(*style, override)
+	}
+}
+
+// applyStyleOverride applies a single override to a style
+func applyStyleOverride(style lipgloss.Style, o config.StyleOverride) lipgloss.Style {
+	if o.Foreground != "" {
+		style = style.Foreground(lipgloss.Color(o.Foreground))
+	}
+	if o.Background != "" {
+		style = style.Background(lipgloss.Color(o.Background))
+	}
+	if o.BorderForeground != "" {
+		style = style.BorderForeground(lipgloss.Color(o.BorderForeground))
+	}
+	if o.Bold != nil {
+		style = style.Bold(*o.Bold)
+	}
+	if o.Italic != nil {
+		style = style.Italic(*o.Italic)
+	}
+	if o.Faint != nil {
+		style = style.Faint(*o.Faint)
+	}
+	if o.Underline != nil {
+		style = style.Underline(*o.Underline)
+	}
+	if o.Reverse != nil {
+		style = style.Reverse(*o.Reverse)
+	}
+	return style
+}
@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestResolveNamedTheme(t *testing.T) {
+	tests := []struct {
+		name           string
+		themeName      string
+		darkBackground bool
+		expected       string
+	}{
+		{"auto on dark background", ThemeAuto, true, ThemeDark},
+		{"auto on light background", ThemeAuto, false, ThemeLight},
+		{"explicit dark", ThemeDark, false, ThemeDark},
+		{"explicit light", ThemeLight, true, ThemeLight},
+		{"high contrast", ThemeHighContrast, true, ThemeHighContrast},
+		{"solarized", ThemeSolarized, true, ThemeSolarized},
+		{"unknown falls back to auto", "does-not-exist", false, ThemeLight},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			theme := resolveNamedTheme(tt.themeName, config.ThemeConfig{}, tt.darkBackground)
+			if theme.Name != tt.expected {
+				t.Errorf("resolveNamedTheme(%q) = %q, want %q", tt.themeName, theme.Name, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCustomTheme(t *testing.T) {
+	bold := true
+	cfg := config.ThemeConfig{
+		Name: "corporate",
+		CustomThemes: map[string]config.CustomTheme{
+			"corporate": {
+				Base:   ThemeLight,
+				Colors: config.ThemeColors{Primary: "#FF00FF"},
+				Styles: map[string]config.StyleOverride{
+					"help_text": {Foreground: "#123456", Bold: &bold},
+				},
+			},
+		},
+	}
+
+	theme := resolveNamedTheme(cfg.Name, cfg, true)
+	if theme.Name != "corporate" {
+		t.Errorf("Expected theme name 'corporate', got %q", theme.Name)
+	}
+	if theme.Primary != lipgloss.Color("#FF00FF") {
+		t.Errorf("Expected overridden primary color, got %v", theme.Primary)
+	}
+	if theme.Secondary != builtinThemes[ThemeLight].Secondary {
+		t.Error("Expected non-overridden colors to come from the base theme")
+	}
+
+	previous := CurrentTheme()
+	defer SetTheme(previous)
+	SetTheme(theme)
+
+	styles := NewStyles(80)
+	if styles.HelpText.GetForeground() != lipgloss.Color("#123456") {
+		t.Errorf("Expected style override to be applied, got %v", styles.HelpText.GetForeground())
+	}
+	if !styles.HelpText.GetBold() {
+		t.Error("Expected bold override to be applied")
+	}
+	if styles.Header.GetForeground() != lipgloss.Color("#FF00FF") {
+		t.Errorf("Expected header to use the custom primary color, got %v", styles.Header.GetForeground())
+	}
+}
+
+func TestStylesNamedCoversEveryStyle(t *testing.T) {
+	s := NewStyles(80)
+	named := s.named()
+
+	// Every field of Styles must be reachable by name for custom themes
+	seen := make(map[*lipgloss.Style]bool)
+	for name, style := range named {
+		if seen[style] {
+			t.Errorf("Style %q is mapped twice", name)
+		}
+		seen[style] = true
+	}
+	if fields := reflect.TypeOf(Styles{}).NumField(); len(named) != fields {
+		t.Errorf("named() exposes %d styles, Styles has %d fields", len(named), fields)
+	}
+}
+
+func TestResolveThemeNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	theme := ResolveTheme(config.ThemeConfig{Name: ThemeSolarized})
+	if theme.Name != ThemeNoColor {
+		t.Errorf("Expected NO_COLOR to select %q, got %q", ThemeNoColor, theme.Name)
+	}
+	if !theme.ReverseSelection {
+		t.Error("Expected no-color theme to use reverse video for the selection")
+	}
+}
+
+func TestAvailableThemes(t *testing.T) {
+	cfg := config.ThemeConfig{
+		CustomThemes: map[string]config.CustomTheme{"zeta": {}, "alpha": {}},
+	}
+	names := AvailableThemes(cfg)
+	if names[0] != ThemeAuto {
+		t.Errorf("Expected %q first, got %q", ThemeAuto, names[0])
+	}
+	if names[len(names)-2] != "alpha" || names[len(names)-1] != "zeta" {
+		t.Errorf("Expected sorted custom themes at the end, got %v", names)
+	}
+}
@@ -2,7 +2,6 @@ package ui
 
 import (
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/Gu1llaum-3/sshm/internal/config"
@@ -12,9 +11,11 @@ import (
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 )
 
+// maxCachedResultAge is the age from which the results of a previous run are not shown anymore
+const maxCachedResultAge = 7 * 24 * time.Hour
+
 // NewModel creates a new TUI model with the given SSH hosts
 func NewModel(hosts []config.SSHHost, configFile string, searchMode bool, currentVersion string) Model {
 	// Load application configuration
@@ -27,24 +28,48 @@ func NewModel(hosts []config.SSHHost, configFile string, searchMode bool, curren
 	}
 
 	// Initialize the history manager
-	historyManager, err := history.NewHistoryManager()
+	historyManager, err := history.NewHistoryManager(configFile)
 	if err != nil {
 		// Log the error but continue without the history functionality
 		fmt.Printf("Warning: Could not initialize history manager: %v\n", err)
 		historyManager = nil
 	}
 
+	// Activate the configured colour theme before building any style
+	ApplyTheme(appConfig.Theme)
+
 	// Create initial styles (will be updated on first WindowSizeMsg)
 	styles := NewStyles(80) // Default width
 
 	// Initialize ping manager with 5 second timeout
 	pingManager := connectivity.NewPingManager(5 * time.Second)
+	pingManager.SetConcurrency(appConfig.Connectivity.Concurrency)
+	pingManager.SetJumpHostConcurrency(appConfig.Connectivity.JumpHostConcurrency)
+
+	// Show the results of the previous run until the hosts are checked again
+	if cachePath, err := connectivity.GetPingCachePath(); err == nil {
+		if err := pingManager.LoadResults(cachePath, configFile, maxCachedResultAge); err != nil {
+			fmt.Printf("Warning: Could not load cached connectivity results: %v\n", err)
+		}
+	}
+
+	// Load the latency history of the hosts
+	monitor := appConfig.Connectivity.Monitor
+	var health *connectivity.HealthHistory
+	if healthPath, err := connectivity.GetHealthHistoryPath(); err == nil {
+		health = connectivity.NewHealthHistory(healthPath, configFile, monitor.HistorySize, monitor.HistoryTTLDuration())
+		if err := health.Load(); err != nil {
+			fmt.Printf("Warning: Could not load health history: %v\n", err)
+		}
+	}
 
 	// Create the model with default sorting by name
 	m := Model{
 		hosts:          hosts,
 		historyManager: historyManager,
 		pingManager:    pingManager,
+		health:         health,
+		monitoring:     monitor.Enabled,
 		sortMode:       SortByName,
 		configFile:     configFile,
 		currentVersion: currentVersion,
@@ -55,6 +80,7 @@ func NewModel(hosts []config.SSHHost, configFile string, searchMode bool, curren
 		ready:          false,
 		viewMode:       ViewList,
 		searchMode:     searchMode,
+		showPreview:    appConfig.Layout.ShowPreview,
 	}
 
 	// Sort hosts according to the default sort mode
@@ -69,52 +95,17 @@ func NewModel(hosts []config.SSHHost, configFile string, searchMode bool, curren
 		ti.Focus()
 	}
 
+	// Resolve the configured table columns
+	m.columns = resolveColumns(appConfig.Table.Columns)
+
 	// Use dynamic column width calculation (will fallback to static if width not available)
-	nameWidth, hostnameWidth, tagsWidth, lastLoginWidth := m.calculateDynamicColumnWidths(sortedHosts)
-
-	// Create table columns
-	columns := []table.Column{
-		{Title: "Name", Width: nameWidth},
-		{Title: "Hostname", Width: hostnameWidth},
-		// {Title: "User", Width: 12},                  // Commented to save space
-		// {Title: "Port", Width: 6},                   // Commented to save space
-		{Title: "Tags", Width: tagsWidth},
-		{Title: "Last Login", Width: lastLoginWidth},
-	}
+	columns := m.buildTableColumns(sortedHosts)
 
 	// Convert hosts to table rows
 	var rows []table.Row
+	visibleColumns := m.visibleColumns()
 	for _, host := range sortedHosts {
-		// Get ping status indicator
-		statusIndicator := m.getPingStatusIndicator(host.Name)
-
-		// Format tags for display
-		var tagsStr string
-		if len(host.Tags) > 0 {
-			// Add the # prefix to each tag and join them with spaces
-			var formattedTags []string
-			for _, tag := range host.Tags {
-				formattedTags = append(formattedTags, "#"+tag)
-			}
-			tagsStr = strings.Join(formattedTags, " ")
-		}
-
-		// Format last login information
-		var lastLoginStr string
-		if historyManager != nil {
-			if lastConnect, exists := historyManager.GetLastConnectionTime(host.Name); exists {
-				lastLoginStr = formatTimeAgo(lastConnect)
-			}
-		}
-
-		rows = append(rows, table.Row{
-			statusIndicator + " " + host.Name,
-			host.Hostname,
-			// host.User,        // Commented to save space
-			// host.Port,        // Commented to save space
-			tagsStr,
-			lastLoginStr,
-		})
+		rows = append(rows, m.buildTableRow(host, visibleColumns))
 	}
 
 	// Create the table with initial height (will be updated on first WindowSizeMsg)
@@ -127,11 +118,7 @@ func NewModel(hosts []config.SSHHost, configFile string, searchMode bool, curren
 
 	// Style the table
 	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color(SecondaryColor)).
-		BorderBottom(true).
-		Bold(false)
+	s.Header = m.styles.TableHeaderUnfocused
 	s.Selected = m.styles.Selected
 
 	t.SetStyles(s)
@@ -156,7 +143,19 @@ func RunInteractiveMode(hosts []config.SSHHost, configFile string, searchMode bo
 
 	// Start the application in alt screen mode for clean output
 	p := tea.NewProgram(m, tea.WithAltScreen())
-	_, err := p.Run()
+	finalModel, err := p.Run()
+
+	// Stop the connectivity checks still running and keep their history
+	if final, ok := finalModel.(Model); ok {
+		final.cancelPings()
+		if final.commandForm != nil {
+			final.commandForm.stop()
+		}
+		if final.forwardManager != nil {
+			final.forwardManager.StopAll()
+		}
+		_ = final.writeChecks()
+	}
 	if err != nil {
 		return fmt.Errorf("error running TUI: %w", err)
 	}
@@ -0,0 +1,342 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/forward"
+	"github.com/Gu1llaum-3/sshm/internal/history"
+	"github.com/Gu1llaum-3/sshm/internal/tunnel"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tunnelsRefreshInterval is the delay between two refreshes of the tunnels
+// view, short enough for the traffic of in-process tunnels to look live
+const tunnelsRefreshInterval = time.Second
+
+// tunnelStartWait is how long starting a tunnel waits for its forwards to be set up
+const tunnelStartWait = 10 * time.Second
+
+// tunnelsLoadedMsg carries the tunnels and their health
+type tunnelsLoadedMsg struct {
+	tunnels []*tunnel.Tunnel
+	health  []tunnel.Health
+	err     error
+}
+
+// tunnelsTickMsg triggers a refresh of the tunnels view
+type tunnelsTickMsg struct{}
+
+// tunnelActionMsg is sent when stopping, restarting or changing a tunnel is done
+type tunnelActionMsg struct {
+	message string
+	err     error
+}
+
+// tunnelsCloseMsg is sent when the tunnels view is closed
+type tunnelsCloseMsg struct{}
+
+// portForwardBackgroundMsg is sent when forwards are to be started as a
+// tunnel in the background instead of in the terminal
+type portForwardBackgroundMsg struct {
+	hostName string
+	profile  history.PortForwardProfile
+}
+
+// portForwardInProcessMsg is sent when forwards are to be run inside sshm,
+// over a connection opened by sshm itself
+type portForwardInProcessMsg struct {
+	hostName string
+	profile  history.PortForwardProfile
+}
+
+// tunnelsModel lists the tunnels running in the background and the ones
+// running inside sshm, with the traffic going through the latter
+type tunnelsModel struct {
+	manager   *tunnel.Manager
+	inProcess *forward.Manager // May be nil when no tunnel was started in sshm
+	styles    Styles
+	width     int
+	height    int
+
+	tunnels  []*tunnel.Tunnel
+	health   []tunnel.Health
+	local    []*forward.Tunnel // Tunnels running in sshm, listed after the others
+	selected int
+	loaded   bool
+	busy     bool   // An action is in progress
+	status   string // Outcome of the last action
+	err      string
+}
+
+// NewTunnelsForm creates the tunnels view
+func NewTunnelsForm(manager *tunnel.Manager, inProcess *forward.Manager, styles Styles, width, height int) *tunnelsModel {
+	return &tunnelsModel{
+		manager:   manager,
+		inProcess: inProcess,
+		styles:    styles,
+		width:     width,
+		height:    height,
+	}
+}
+
+func (m *tunnelsModel) Init() tea.Cmd {
+	return m.load()
+}
+
+// load reads the tunnels and checks their health
+func (m *tunnelsModel) load() tea.Cmd {
+	manager := m.manager
+	return func() tea.Msg {
+		tunnels, err := manager.List()
+		health := make([]tunnel.Health, len(tunnels))
+		for i, t := range tunnels {
+			health[i] = t.CheckHealth(500 * time.Millisecond)
+		}
+		return tunnelsLoadedMsg{tunnels: tunnels, health: health, err: err}
+	}
+}
+
+// tick schedules the next refresh
+func (m *tunnelsModel) tick() tea.Cmd {
+	return tea.Tick(tunnelsRefreshInterval, func(time.Time) tea.Msg {
+		return tunnelsTickMsg{}
+	})
+}
+
+// selectedTunnel returns the background tunnel under the cursor
+func (m *tunnelsModel) selectedTunnel() (*tunnel.Tunnel, bool) {
+	if m.selected < 0 || m.selected >= len(m.tunnels) {
+		return nil, false
+	}
+	return m.tunnels[m.selected], true
+}
+
+// selectedLocal returns the tunnel running in sshm under the cursor
+func (m *tunnelsModel) selectedLocal() (*forward.Tunnel, bool) {
+	i := m.selected - len(m.tunnels)
+	if i < 0 || i >= len(m.local) {
+		return nil, false
+	}
+	return m.local[i], true
+}
+
+// count returns the number of tunnels listed
+func (m *tunnelsModel) count() int {
+	return len(m.tunnels) + len(m.local)
+}
+
+// action runs an action on a tunnel in the background
+func (m *tunnelsModel) action(run func() (string, error)) tea.Cmd {
+	m.busy = true
+	m.status = ""
+	m.err = ""
+	return func() tea.Msg {
+		message, err := run()
+		return tunnelActionMsg{message: message, err: err}
+	}
+}
+
+func (m *tunnelsModel) Update(msg tea.Msg) (*tunnelsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tunnelsLoadedMsg:
+		first := !m.loaded
+		m.loaded = true
+		m.tunnels, m.health = msg.tunnels, msg.health
+		m.local = nil
+		if m.inProcess != nil {
+			m.local = m.inProcess.List()
+		}
+		if msg.err != nil {
+			m.err = msg.err.Error()
+		}
+		if m.selected >= m.count() {
+			m.selected = max(m.count()-1, 0)
+		}
+		if first {
+			return m, m.tick()
+		}
+		return m, nil
+
+	case tunnelsTickMsg:
+		return m, tea.Batch(m.load(), m.tick())
+
+	case tunnelActionMsg:
+		m.busy = false
+		if msg.err != nil {
+			m.err = msg.err.Error()
+		} else {
+			m.status = msg.message
+		}
+		return m, m.load()
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q", "ctrl+c":
+			return m, func() tea.Msg { return tunnelsCloseMsg{} }
+
+		case "up", "k":
+			if m.selected > 0 {
+				m.selected--
+			}
+
+		case "down", "j":
+			if m.selected < m.count()-1 {
+				m.selected++
+			}
+
+		case "s", "d":
+			if t, ok := m.selectedLocal(); ok && !m.busy {
+				manager, id := m.inProcess, t.ID
+				return m, m.action(func() (string, error) {
+					if err := manager.Stop(id); err != nil {
+						return "", err
+					}
+					return fmt.Sprintf("Tunnel %s stopped", id), nil
+				})
+			}
+			if t, ok := m.selectedTunnel(); ok && !m.busy {
+				manager, id := m.manager, t.ID
+				return m, m.action(func() (string, error) {
+					if err := manager.Stop(id); err != nil {
+						return "", err
+					}
+					return fmt.Sprintf("Tunnel %s stopped", id), nil
+				})
+			}
+
+		case "r":
+			if t, ok := m.selectedTunnel(); ok && !m.busy {
+				manager, id := m.manager, t.ID
+				return m, m.action(func() (string, error) {
+					if _, err := manager.Restart(id, tunnelStartWait); err != nil {
+						return "", fmt.Errorf("tunnel %s: %w", id, err)
+					}
+					return fmt.Sprintf("Tunnel %s restarted", id), nil
+				})
+			}
+
+		case "a":
+			if t, ok := m.selectedTunnel(); ok && !m.busy {
+				manager, id, autoRestart := m.manager, t.ID, !t.AutoRestart
+				return m, m.action(func() (string, error) {
+					if err := manager.SetAutoRestart(id, autoRestart); err != nil {
+						return "", err
+					}
+					if autoRestart {
+						return fmt.Sprintf("Tunnel %s will be restarted when it drops", id), nil
+					}
+					return fmt.Sprintf("Tunnel %s will not be restarted", id), nil
+				})
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m *tunnelsModel) View() string {
+	var sections []string
+	sections = append(sections, m.styles.Header.Render("🚇 Tunnels"))
+
+	switch {
+	case !m.loaded:
+		sections = append(sections, m.styles.HelpText.Render("Loading tunnels..."))
+	case m.count() == 0:
+		sections = append(sections, m.styles.HelpText.Render("No tunnel running. Start one from the port forwarding form (f) with Ctrl+B or Ctrl+P, or with b or p on a profile."))
+	}
+
+	if len(m.tunnels) > 0 {
+		header := fmt.Sprintf("  %-22s %-16s %-34s %7s %8s %9s  %s", "TUNNEL", "HOST", "FORWARDS", "PID", "UPTIME", "RESTARTS", "HEALTH")
+		lines := []string{m.styles.Label.Render(header)}
+		for i, t := range m.tunnels {
+			pid := "-"
+			if t.PID != 0 {
+				pid = strconv.Itoa(t.PID)
+			}
+			restarts := strconv.Itoa(t.Restarts)
+			if t.AutoRestart {
+				restarts += " ↻"
+			}
+			line := fmt.Sprintf("%-22s %-16s %-34s %7s %8s %9s  ",
+				truncate(t.ID, 22), truncate(t.HostName, 16), truncate(t.Summary(), 34), pid, tunnel.FormatUptime(t.Uptime()), restarts)
+
+			health := m.health[i]
+			healthText := m.styles.StatusSuccess.Render("● " + health.String())
+			if !health.OK {
+				healthText = m.styles.StatusFailure.Render("● " + health.String())
+			}
+
+			if i == m.selected {
+				lines = append(lines, m.styles.Selected.Render("▶ "+line)+healthText)
+			} else {
+				lines = append(lines, "  "+line+healthText)
+			}
+		}
+		sections = append(sections, lipgloss.JoinVertical(lipgloss.Left, lines...))
+	}
+
+	if len(m.local) > 0 {
+		sections = append(sections, m.viewLocal())
+	}
+
+	switch {
+	case m.busy:
+		sections = append(sections, m.styles.HelpText.Render("Working..."))
+	case m.err != "":
+		sections = append(sections, m.styles.Error.Render("Error: "+m.err))
+	case m.status != "":
+		sections = append(sections, m.styles.StatusSuccess.Render(m.status))
+	}
+
+	sections = append(sections, m.styles.HelpText.Render(" ↑/↓: select • s: stop • r: restart • a: toggle auto-restart (in background) • Esc: back to list"))
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		lipgloss.JoinVertical(lipgloss.Left, sections...),
+	)
+}
+
+// viewLocal renders the tunnels running in sshm, a line per forward with
+// the connections and the traffic going through it
+func (m *tunnelsModel) viewLocal() string {
+	lines := []string{m.styles.Label.Render("Running in sshm (stopped when sshm exits)")}
+	header := fmt.Sprintf("  %-22s %-16s %-34s %9s %8s %8s %6s  %s", "TUNNEL", "HOST", "FORWARD", "CONNS", "IN", "OUT", "ERRORS", "STATUS")
+	lines = append(lines, m.styles.Label.Render(header))
+
+	for i, t := range m.local {
+		status := m.styles.StatusSuccess.Render("● running " + tunnel.FormatUptime(time.Since(t.StartedAt)))
+		if err := t.Err(); err != nil {
+			status = m.styles.StatusFailure.Render("● " + err.Error())
+		}
+
+		for j, stats := range t.Stats() {
+			id, hostName := "", ""
+			if j == 0 {
+				id, hostName = t.ID, t.HostName
+			}
+			line := fmt.Sprintf("%-22s %-16s %-34s %9s %8s %8s %6d  ",
+				truncate(id, 22), truncate(hostName, 16), truncate(t.Forwards[j].String(), 34),
+				fmt.Sprintf("%d/%d", stats.Active, stats.Connections),
+				forward.FormatBytes(stats.BytesIn), forward.FormatBytes(stats.BytesOut), stats.Errors)
+
+			switch {
+			case j > 0:
+				lines = append(lines, "  "+line)
+			case len(m.tunnels)+i == m.selected:
+				lines = append(lines, m.styles.Selected.Render("▶ "+line)+status)
+			default:
+				lines = append(lines, "  "+line+status)
+			}
+			if stats.LastError != "" {
+				lines = append(lines, m.styles.StatusFailure.Render("    last error: "+truncate(stats.LastError, 100)))
+			}
+		}
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
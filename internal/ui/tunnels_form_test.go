@@ -0,0 +1,172 @@
+package ui
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/forward"
+	"github.com/Gu1llaum-3/sshm/internal/history"
+	"github.com/Gu1llaum-3/sshm/internal/tunnel"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestTunnelsForm(t *testing.T) {
+	dir := t.TempDir()
+	failed := tunnel.Tunnel{
+		ID:        "db1-postgres",
+		HostName:  "db1",
+		Forwards:  []history.PortForwardConfig{{Type: "local", LocalPort: "15432", RemoteHost: "localhost", RemotePort: "5432"}},
+		State:     tunnel.StateFailed,
+		LastError: "Could not request local forwarding.",
+	}
+	data, _ := json.Marshal(failed)
+	if err := os.WriteFile(filepath.Join(dir, failed.ID+".json"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := tunnel.NewManager(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewTunnelsForm(manager, nil, NewStyles(160), 160, 30)
+	m, _ = m.Update(m.Init()())
+
+	view := m.View()
+	for _, expected := range []string{"db1-postgres", "-L 15432:localhost:5432", "failed: Could not request local forwarding."} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("Expected the view to contain %q, got:\n%s", expected, view)
+		}
+	}
+
+	// Turn auto-restart on
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	if cmd == nil || !m.busy {
+		t.Fatal("Expected an action to be started")
+	}
+	m, cmd = m.Update(cmd())
+	if m.busy || !strings.Contains(m.status, "will be restarted") {
+		t.Errorf("Expected the action to be reported, got %q (%s)", m.status, m.err)
+	}
+	m, _ = m.Update(cmd())
+	if len(m.tunnels) != 1 || !m.tunnels[0].AutoRestart {
+		t.Errorf("Expected the tunnel to auto-restart, got %+v", m.tunnels)
+	}
+
+	// Stop it
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	m, cmd = m.Update(cmd())
+	m, _ = m.Update(cmd())
+	if len(m.tunnels) != 0 || !strings.Contains(m.View(), "No tunnel running") {
+		t.Errorf("Expected the tunnel to be stopped, got %+v", m.tunnels)
+	}
+
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if _, ok := cmd().(tunnelsCloseMsg); !ok {
+		t.Error("Expected Esc to close the view")
+	}
+}
+
+func TestTunnelsViewFromList(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	m := createTestModel()
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	model := updated.(Model)
+	if model.viewMode != ViewTunnels || model.tunnelsForm == nil || cmd == nil {
+		t.Fatalf("Expected t to open the tunnels view, got mode %d", model.viewMode)
+	}
+
+	updated, _ = model.Update(tunnelsCloseMsg{})
+	if model = updated.(Model); model.viewMode != ViewList || model.tunnelsForm != nil {
+		t.Error("Expected the view to be closed")
+	}
+
+	// Refreshes arriving after the view was closed are dropped
+	if _, cmd := model.Update(tunnelsTickMsg{}); cmd != nil {
+		t.Error("Expected no refresh once the view is closed")
+	}
+}
+
+// loopbackClient stands for an SSH connection whose server is this machine
+type loopbackClient struct {
+	closed chan struct{}
+}
+
+func (c *loopbackClient) Dial(network, address string) (net.Conn, error) {
+	return net.Dial(network, address)
+}
+
+func (c *loopbackClient) Listen(network, address string) (net.Listener, error) {
+	return net.Listen(network, address)
+}
+
+func (c *loopbackClient) ListenUnix(socketPath string) (net.Listener, error) {
+	return net.Listen("unix", socketPath)
+}
+
+func (c *loopbackClient) SendRequest(string, bool, []byte) (bool, []byte, error) {
+	return true, nil, nil
+}
+
+func (c *loopbackClient) Wait() error {
+	<-c.closed
+	return io.EOF
+}
+
+func (c *loopbackClient) Close() error {
+	select {
+	case <-c.closed:
+	default:
+		close(c.closed)
+	}
+	return nil
+}
+
+func TestTunnelsFormInProcess(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := strings.TrimPrefix(listener.Addr().String(), "127.0.0.1:")
+	listener.Close()
+
+	inProcess := forward.NewManager()
+	profile := history.PortForwardProfile{Name: "web", Forwards: []history.PortForwardConfig{
+		{Type: "local", LocalPort: port, RemoteHost: "127.0.0.1", RemotePort: "1"},
+	}}
+	running, err := forward.Start(&loopbackClient{closed: make(chan struct{})}, "db1", profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inProcess.Add(running)
+	defer inProcess.StopAll()
+
+	manager, err := tunnel.NewManager(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewTunnelsForm(manager, inProcess, NewStyles(200), 200, 30)
+	m, _ = m.Update(m.Init()())
+
+	view := m.View()
+	for _, expected := range []string{"Running in sshm", "db1-web", "-L " + port + ":127.0.0.1:1", "0/0", "● running"} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("Expected the view to contain %q, got:\n%s", expected, view)
+		}
+	}
+
+	// Stop it
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	m, cmd = m.Update(cmd())
+	m, _ = m.Update(cmd())
+	if running.Running() || len(m.local) != 0 || !strings.Contains(m.status, "db1-web stopped") {
+		t.Errorf("Expected the tunnel to be stopped, got %q (%s)", m.status, m.err)
+	}
+}
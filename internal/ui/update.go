@@ -22,25 +22,117 @@ type (
 	errorMsg        string
 )
 
-// startPingAllCmd creates a command to ping all hosts concurrently
-func (m Model) startPingAllCmd() tea.Cmd {
-	if m.pingManager == nil {
+// pingStreamMsg carries a result of a check of all hosts, along with the
+// channel streaming the next ones. done is set once the channel is closed.
+type pingStreamMsg struct {
+	result  *connectivity.HostPingResult
+	results <-chan *connectivity.HostPingResult
+	done    bool
+}
+
+// authProbeMsg carries the outcome of the authentication probe of the host shown in the info view
+type authProbeMsg struct {
+	result *connectivity.AuthProbeResult
+}
+
+// startupRefreshMsg triggers the checks run when the TUI starts
+type startupRefreshMsg struct{}
+
+// startPingAllCmd checks all hosts, the rows on screen first, cancelling
+// the checks still running from a previous run
+func (m *Model) startPingAllCmd() tea.Cmd {
+	return m.startPingCmd(m.pingOrder())
+}
+
+// startPingCmd checks the given hosts in order, cancelling the checks still
+// running from a previous run
+func (m *Model) startPingCmd(hosts []config.SSHHost) tea.Cmd {
+	if m.pingManager == nil || len(hosts) == 0 {
+		return nil
+	}
+	m.cancelPings()
+
+	// Jump hosts named in ProxyJump directives are resolved from the configured hosts
+	m.pingManager.SetHosts(m.hosts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.pingCancel = cancel
+	m.pingResults = m.pingManager.PingAllHosts(ctx, hosts)
+	return waitForPingResult(m.pingResults)
+}
+
+// startupRefreshCmd checks the hosts according to the refresh_on_startup
+// policy: every host, only those without a recent result, or none
+func (m *Model) startupRefreshCmd() tea.Cmd {
+	if m.appConfig == nil || m.pingManager == nil {
 		return nil
 	}
 
-	return tea.Batch(
-		// Create individual ping commands for each host
-		func() tea.Cmd {
-			var cmds []tea.Cmd
-			for _, host := range m.hosts {
-				cmds = append(cmds, pingSingleHostCmd(m.pingManager, host))
+	switch m.appConfig.Connectivity.RefreshOnStartup {
+	case config.RefreshAlways:
+		return m.startPingAllCmd()
+	case config.RefreshStale:
+		return m.startPingCmd(m.staleHosts(m.pingOrder()))
+	}
+	return nil
+}
+
+// staleHosts returns the hosts without a result or whose result is stale
+func (m *Model) staleHosts(hosts []config.SSHHost) []config.SSHHost {
+	var stale []config.SSHHost
+	for _, host := range hosts {
+		if result, ok := m.pingManager.GetResult(host.Name); !ok || m.isStale(result) {
+			stale = append(stale, host)
+		}
+	}
+	return stale
+}
+
+// cancelPings stops the checks of all hosts still running
+func (m *Model) cancelPings() {
+	if m.pingCancel != nil {
+		m.pingCancel()
+	}
+	m.pingCancel = nil
+	m.pingResults = nil
+}
+
+// pingOrder returns the hosts to check by priority: the rows around the
+// cursor, which include the rows on screen, then the rest of the list,
+// then the hosts hidden by the search
+func (m *Model) pingOrder() []config.SSHHost {
+	ordered := make([]config.SSHHost, 0, len(m.hosts))
+	seen := make(map[string]bool, len(m.hosts))
+	add := func(hosts []config.SSHHost) {
+		for _, host := range hosts {
+			if !seen[host.Name] {
+				seen[host.Name] = true
+				ordered = append(ordered, host)
 			}
-			return tea.Batch(cmds...)
-		}(),
-	)
+		}
+	}
+
+	cursor, height := m.table.Cursor(), m.table.Height()
+	start := max(cursor-height, 0)
+	end := min(cursor+height+1, len(m.filteredHosts))
+	if start < end {
+		add(m.filteredHosts[start:end])
+	}
+	add(m.filteredHosts)
+	add(m.hosts)
+	return ordered
 }
 
-// listenForPingResultsCmd is no longer needed since we use individual ping commands
+// waitForPingResult waits for the next result streamed by a check of all hosts
+func waitForPingResult(results <-chan *connectivity.HostPingResult) tea.Cmd {
+	return func() tea.Msg {
+		result, ok := <-results
+		if !ok {
+			return pingStreamMsg{results: results, done: true}
+		}
+		return pingStreamMsg{result: result, results: results}
+	}
+}
 
 // pingSingleHostCmd creates a command to ping a single host
 func pingSingleHostCmd(pingManager *connectivity.PingManager, host config.SSHHost) tea.Cmd {
@@ -67,6 +159,14 @@ func checkVersionCmd(currentVersion string) tea.Cmd {
 	}
 }
 
+// saveAppConfig persists the application configuration, showing an error if it cannot be saved
+func (m *Model) saveAppConfig() tea.Cmd {
+	if err := config.SaveAppConfig(m.appConfig); err != nil {
+		return m.showError(fmt.Sprintf("Could not save settings: %v", err))
+	}
+	return nil
+}
+
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
 	var cmds []tea.Cmd
@@ -79,6 +179,16 @@ func (m Model) Init() tea.Cmd {
 		cmds = append(cmds, checkVersionCmd(m.currentVersion))
 	}
 
+	// Check the hosts whose cached result is outdated, depending on the policy
+	if m.appConfig != nil && m.appConfig.Connectivity.RefreshOnStartup != config.RefreshNever {
+		cmds = append(cmds, func() tea.Msg { return startupRefreshMsg{} })
+	}
+
+	// Start the background health monitor if enabled
+	if m.monitoring {
+		cmds = append(cmds, m.monitorTickCmd())
+	}
+
 	return tea.Batch(cmds...)
 }
 
@@ -135,6 +245,25 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.fileSelectorForm.height = m.height
 			m.fileSelectorForm.styles = m.styles
 		}
+		if m.columnsForm != nil {
+			m.columnsForm.width = m.width
+			m.columnsForm.height = m.height
+			m.columnsForm.styles = m.styles
+		}
+		if m.palette != nil {
+			m.palette.width = m.width
+			m.palette.height = m.height
+			m.palette.styles = m.styles
+		}
+		if m.commandForm != nil {
+			m.commandForm.styles = m.styles
+			m.commandForm.setSize(m.width, m.height)
+		}
+		if m.tunnelsForm != nil {
+			m.tunnelsForm.width = m.width
+			m.tunnelsForm.height = m.height
+			m.tunnelsForm.styles = m.styles
+		}
 		return m, nil
 
 	case pingResultMsg:
@@ -142,6 +271,46 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg != nil {
 			// Update the table to reflect the new ping status
 			m.updateTableRows()
+			cmd := m.recordHealth(msg)
+			return m, cmd
+		}
+		return m, nil
+
+	case authProbeMsg:
+		// The info view may have been closed or show another host since
+		if m.infoForm != nil && m.infoForm.hostName == msg.result.HostName {
+			m.infoForm.auth = msg.result
+			m.infoForm.authProbing = false
+		}
+		return m, nil
+
+	case pingStreamMsg:
+		if msg.done {
+			// Only the current run owns the cancel function
+			if msg.results != m.pingResults {
+				return m, nil
+			}
+			m.cancelPings()
+			if m.sortMode == SortByLatency {
+				m.refreshSort()
+			}
+			cmd := m.saveChecks()
+			return m, cmd
+		}
+		m.updateTableRows()
+		cmd := m.recordHealth(msg.result)
+		return m, tea.Batch(cmd, waitForPingResult(msg.results))
+
+	case startupRefreshMsg:
+		cmd := m.startupRefreshCmd()
+		return m, cmd
+
+	case monitorTickMsg:
+		return m.handleMonitorTick(msg)
+
+	case alertClearMsg:
+		if msg.seq == m.alertSeq {
+			m.alertMessage = ""
 		}
 		return m, nil
 
@@ -340,7 +509,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.err != nil {
 			// Show error in form
 			if m.portForwardForm != nil {
-				m.portForwardForm.err = msg.err.Error()
+				m.portForwardForm.setError(msg.err)
 			}
 			return m, nil
 		} else {
@@ -348,17 +517,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if len(msg.sshArgs) > 0 {
 				sshCmd := exec.Command("ssh", msg.sshArgs...)
 
+				var hostName string
+				if m.portForwardForm != nil {
+					hostName = m.portForwardForm.hostName
+				}
+
 				// Record the connection in history
-				if m.historyManager != nil && m.portForwardForm != nil {
-					err := m.historyManager.RecordConnection(m.portForwardForm.hostName)
+				if m.historyManager != nil && hostName != "" {
+					err := m.historyManager.RecordConnection(hostName)
 					if err != nil {
 						fmt.Printf("Warning: Could not record connection history: %v\n", err)
 					}
 				}
 
-				return m, tea.ExecProcess(sshCmd, func(err error) tea.Msg {
-					return tea.Quit()
-				})
+				m.cancelPings()
+				return m, runSession(sshCmd, hostName, true)
 			}
 
 			// If no SSH args, just return to list view
@@ -382,6 +555,143 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.table.Focus()
 		return m, nil
 
+	case columnsFormCloseMsg:
+		// Close the column picker: apply and persist the new layout if confirmed
+		m.viewMode = ViewList
+		m.columnsForm = nil
+		m.table.Focus()
+		if !msg.changed {
+			return m, nil
+		}
+
+		m.columns = msg.columns
+		m.updateTableRows()
+
+		if m.appConfig != nil {
+			m.appConfig.Table.Columns = columnsToConfig(m.columns)
+			return m, m.saveAppConfig()
+		}
+		return m, nil
+
+	case commandOutputMsg, commandDoneMsg:
+		if m.commandForm != nil {
+			var newForm *commandModel
+			newForm, cmd = m.commandForm.Update(msg)
+			m.commandForm = newForm
+			return m, cmd
+		}
+		// The view was closed: drain the output of the stopped command
+		if output, ok := msg.(commandOutputMsg); ok {
+			return m, waitForCommandOutput(output.stream)
+		}
+		return m, nil
+
+	case tunnelsLoadedMsg, tunnelsTickMsg, tunnelActionMsg:
+		// Refreshes stop once the tunnels view is closed
+		if m.tunnelsForm != nil {
+			var newForm *tunnelsModel
+			newForm, cmd = m.tunnelsForm.Update(msg)
+			m.tunnelsForm = newForm
+			return m, cmd
+		}
+		return m, nil
+
+	case tunnelsCloseMsg:
+		// Close the tunnels view: return to list view
+		m.viewMode = ViewList
+		m.tunnelsForm = nil
+		m.table.Focus()
+		return m, nil
+
+	case portForwardDiscoveredMsg:
+		// Dropped if the form was closed while the ports were being listed
+		if m.portForwardForm != nil {
+			var newForm *portForwardModel
+			newForm, cmd = m.portForwardForm.Update(msg)
+			m.portForwardForm = newForm
+			return m, cmd
+		}
+		return m, nil
+
+	case portForwardConfigSavedMsg:
+		if m.portForwardForm == nil {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.portForwardForm.setError(msg.err)
+			return m, nil
+		}
+		m.portForwardForm.err = ""
+		m.portForwardForm.status = msg.message
+		if err := m.reloadHosts(); err != nil {
+			return m, m.showError(fmt.Sprintf("Could not reload SSH config: %v", err))
+		}
+		return m, nil
+
+	case portForwardBackgroundMsg:
+		// Start the forwards as a tunnel, then show the tunnels
+		return m, m.startTunnelCmd(msg.hostName, msg.profile)
+
+	case portForwardInProcessMsg:
+		// Run the forwards in sshm, then show the tunnels and their traffic
+		return m, m.startInProcessCmd(msg.hostName, msg.profile)
+
+	case tunnelStartedMsg:
+		if msg.err != nil {
+			if m.portForwardForm != nil {
+				m.portForwardForm.setError(msg.err)
+				return m, nil
+			}
+			return m, m.showError(msg.err.Error())
+		}
+		m.portForwardForm = nil
+		if msg.inProcess {
+			return m.openTunnels(fmt.Sprintf("Tunnel %s running in sshm", msg.id))
+		}
+		return m.openTunnels(fmt.Sprintf("Tunnel %s started", msg.id))
+
+	case commandCloseMsg:
+		// Close the command view: return to list view
+		m.viewMode = ViewList
+		m.commandForm = nil
+		m.table.Focus()
+		return m, nil
+
+	case paletteCloseMsg:
+		// Close the command palette: return to list view
+		m.viewMode = ViewList
+		m.palette = nil
+		m.table.Focus()
+		return m, nil
+
+	case paletteArgsMsg:
+		// The chosen command takes an argument: list the possible values
+		if m.palette != nil {
+			args, err := msg.command.args(&m)
+			m.palette.setArgs(msg.command, args, err)
+		}
+		return m, nil
+
+	case paletteRunMsg:
+		// Close the command palette and run the chosen command
+		m.viewMode = ViewList
+		m.palette = nil
+		if !m.searchMode {
+			m.table.Focus()
+		}
+		return m.runPaletteCommand(msg.id, msg.arg)
+
+	case sessionFinishedMsg:
+		// The SSH session ended: exit, or return to the host list if configured
+		return m.handleSessionFinished(msg)
+
+	case editorFinishedMsg:
+		// The config file may have changed in the editor
+		if msg.err != nil {
+			return m, m.showError(fmt.Sprintf("Editor exited with an error: %v", msg.err))
+		}
+		return m.reload()
+
 	case tea.KeyMsg:
 		// Handle view-specific key presses
 		switch m.viewMode {
@@ -427,6 +737,34 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.helpForm = newForm
 				return m, cmd
 			}
+		case ViewPalette:
+			if m.palette != nil {
+				var newPalette *commandPaletteModel
+				newPalette, cmd = m.palette.Update(msg)
+				m.palette = newPalette
+				return m, cmd
+			}
+		case ViewColumns:
+			if m.columnsForm != nil {
+				var newForm *columnsFormModel
+				newForm, cmd = m.columnsForm.Update(msg)
+				m.columnsForm = newForm
+				return m, cmd
+			}
+		case ViewCommand:
+			if m.commandForm != nil {
+				var newForm *commandModel
+				newForm, cmd = m.commandForm.Update(msg)
+				m.commandForm = newForm
+				return m, cmd
+			}
+		case ViewTunnels:
+			if m.tunnelsForm != nil {
+				var newForm *tunnelsModel
+				newForm, cmd = m.tunnelsForm.Update(msg)
+				m.tunnelsForm = newForm
+				return m, cmd
+			}
 		case ViewFileSelector:
 			if m.fileSelectorForm != nil {
 				var newForm *fileSelectorModel
@@ -551,198 +889,107 @@ func (m Model) handleListViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		} else {
 			// Connect to the selected host
-			selected := m.table.SelectedRow()
-			if len(selected) > 0 {
-				hostName := extractHostNameFromTableRow(selected[0]) // Extract hostname from first column
-
-				// Record the connection in history
-				if m.historyManager != nil {
-					err := m.historyManager.RecordConnection(hostName)
-					if err != nil {
-						// Log the error but don't prevent the connection
-						fmt.Printf("Warning: Could not record connection history: %v\n", err)
-					}
-				}
-
-				// Build the SSH command with the appropriate config file
-				var sshCmd *exec.Cmd
-				if m.configFile != "" {
-					sshCmd = exec.Command("ssh", "-F", m.configFile, hostName)
-				} else {
-					sshCmd = exec.Command("ssh", hostName)
-				}
-
-				return m, tea.ExecProcess(sshCmd, func(err error) tea.Msg {
-					return tea.Quit()
-				})
-			}
+			return m.connectToSelectedHost()
+		}
+	case "ctrl+p":
+		if !m.deleteMode {
+			// Open the command palette
+			return m.openCommandPalette()
 		}
 	case "e":
 		if !m.searchMode && !m.deleteMode {
 			// Edit the selected host
-			selected := m.table.SelectedRow()
-			if len(selected) > 0 {
-				hostName := extractHostNameFromTableRow(selected[0]) // Extract hostname from first column
-				editForm, err := NewEditForm(hostName, m.styles, m.width, m.height, m.configFile)
-				if err != nil {
-					// Handle error - could show in UI
-					return m, nil
-				}
-				m.editForm = editForm
-				m.viewMode = ViewEdit
-				return m, textinput.Blink
-			}
+			return m.openEditForm()
 		}
 	case "m":
 		if !m.searchMode && !m.deleteMode {
 			// Move the selected host to another config file
-			selected := m.table.SelectedRow()
-			if len(selected) > 0 {
-				hostName := extractHostNameFromTableRow(selected[0]) // Extract hostname from first column
-				moveForm, err := NewMoveForm(hostName, m.styles, m.width, m.height, m.configFile)
-				if err != nil {
-					// Show error message to user
-					m.errorMessage = err.Error()
-					m.showingError = true
-					return m, func() tea.Msg {
-						time.Sleep(3 * time.Second) // Show error for 3 seconds
-						return errorMsg("clear")
-					}
-				}
-				m.moveForm = moveForm
-				m.viewMode = ViewMove
-				return m, textinput.Blink
-			}
+			return m.openMoveForm()
 		}
 	case "i":
 		if !m.searchMode && !m.deleteMode {
 			// Show info for the selected host
-			selected := m.table.SelectedRow()
-			if len(selected) > 0 {
-				hostName := extractHostNameFromTableRow(selected[0]) // Extract hostname from first column
-				infoForm, err := NewInfoForm(hostName, m.styles, m.width, m.height, m.configFile)
-				if err != nil {
-					// Handle error - could show in UI
-					return m, nil
-				}
-				m.infoForm = infoForm
-				m.viewMode = ViewInfo
-				return m, nil
-			}
+			return m.openInfoForm()
 		}
 	case "a":
 		if !m.searchMode && !m.deleteMode {
-			// Check if there are multiple config files starting from the current base config
-			var configFiles []string
-			var err error
-
-			if m.configFile != "" {
-				// Use the specified config file as base
-				configFiles, err = config.GetAllConfigFilesFromBase(m.configFile)
-			} else {
-				// Use the default config file as base
-				configFiles, err = config.GetAllConfigFiles()
-			}
-
-			if err != nil || len(configFiles) <= 1 {
-				// Only one config file (or error), go directly to add form
-				var configFile string
-				if len(configFiles) == 1 {
-					configFile = configFiles[0]
-				} else {
-					configFile = m.configFile
-				}
-				m.addForm = NewAddForm("", m.styles, m.width, m.height, configFile)
-				m.viewMode = ViewAdd
-			} else {
-				// Multiple config files, show file selector
-				fileSelectorForm, err := NewFileSelectorFromBase("Select config file to add host to:", m.styles, m.width, m.height, m.configFile)
-				if err != nil {
-					// Fallback to default behavior if file selector fails
-					m.addForm = NewAddForm("", m.styles, m.width, m.height, m.configFile)
-					m.viewMode = ViewAdd
-				} else {
-					m.fileSelectorForm = fileSelectorForm
-					m.viewMode = ViewFileSelector
-				}
-			}
-			return m, textinput.Blink
+			// Add a new host
+			return m.openAddForm()
 		}
 	case "d":
 		if !m.searchMode && !m.deleteMode {
 			// Delete the selected host
-			cursor := m.table.Cursor()
-			if cursor >= 0 && cursor < len(m.filteredHosts) {
-				// Get the host at the cursor position (which corresponds to filteredHosts index)
-				targetHost := &m.filteredHosts[cursor]
-
-				m.deleteMode = true
-				m.deleteHost = targetHost
-				m.table.Blur()
-				return m, nil
-			}
+			return m.startDeleteSelectedHost()
 		}
 	case "p":
 		if !m.searchMode && !m.deleteMode {
 			// Ping all hosts
-			return m, m.startPingAllCmd()
+			cmd := m.startPingAllCmd()
+			return m, cmd
 		}
 	case "f":
 		if !m.searchMode && !m.deleteMode {
 			// Port forwarding for the selected host
-			selected := m.table.SelectedRow()
-			if len(selected) > 0 {
-				hostName := extractHostNameFromTableRow(selected[0]) // Extract hostname from first column
-				m.portForwardForm = NewPortForwardForm(hostName, m.styles, m.width, m.height, m.configFile, m.historyManager)
-				m.viewMode = ViewPortForward
-				return m, textinput.Blink
-			}
+			return m.openPortForwardForm()
+		}
+	case "x":
+		if !m.searchMode && !m.deleteMode {
+			// Run a command on the selected host
+			return m.openCommandForm()
+		}
+	case "t":
+		if !m.searchMode && !m.deleteMode {
+			// Tunnels running in the background
+			return m.openTunnels("")
 		}
 	case "h":
 		if !m.searchMode && !m.deleteMode {
 			// Show help
-			m.helpForm = NewHelpForm(m.styles, m.width, m.height)
-			m.viewMode = ViewHelp
-			return m, nil
+			return m.openHelp()
+		}
+	case "c":
+		if !m.searchMode && !m.deleteMode {
+			// Choose which table columns are shown
+			return m.openColumnsForm()
+		}
+	case "v":
+		if !m.searchMode && !m.deleteMode {
+			// Toggle the preview pane
+			return m.togglePreview()
+		}
+	case "K":
+		if !m.searchMode && !m.deleteMode {
+			return m.toggleChangedHostKeysFilter()
 		}
 	case "s":
 		if !m.searchMode && !m.deleteMode {
-			// Cycle through sort modes (only 2 modes now)
-			m.sortMode = (m.sortMode + 1) % 2
-			// Re-apply the current filter with the new sort mode
-			if m.searchInput.Value() != "" {
-				m.filteredHosts = m.filterHosts(m.searchInput.Value())
-			} else {
-				m.filteredHosts = m.sortHosts(m.hosts)
-			}
-			m.updateTableRows()
-			return m, nil
+			// Cycle through sort modes
+			return m.setSortMode((m.sortMode + 1) % sortModeCount)
 		}
 	case "r":
 		if !m.searchMode && !m.deleteMode {
 			// Switch to sort by recent (last used)
-			m.sortMode = SortByLastUsed
-			// Re-apply the current filter with the new sort mode
-			if m.searchInput.Value() != "" {
-				m.filteredHosts = m.filterHosts(m.searchInput.Value())
-			} else {
-				m.filteredHosts = m.sortHosts(m.hosts)
-			}
-			m.updateTableRows()
-			return m, nil
+			return m.setSortMode(SortByLastUsed)
 		}
 	case "n":
 		if !m.searchMode && !m.deleteMode {
 			// Switch to sort by name
-			m.sortMode = SortByName
-			// Re-apply the current filter with the new sort mode
-			if m.searchInput.Value() != "" {
-				m.filteredHosts = m.filterHosts(m.searchInput.Value())
-			} else {
-				m.filteredHosts = m.sortHosts(m.hosts)
-			}
-			m.updateTableRows()
-			return m, nil
+			return m.setSortMode(SortByName)
+		}
+	case "l":
+		if !m.searchMode && !m.deleteMode {
+			// Switch to sort by latency
+			return m.setSortMode(SortByLatency)
+		}
+	case "M":
+		if !m.searchMode && !m.deleteMode {
+			// Start or stop the background health monitor
+			return m.toggleMonitor()
+		}
+	case "P":
+		if !m.searchMode && !m.deleteMode {
+			// Pin or unpin the selected host
+			return m.togglePinned()
 		}
 	}
 
@@ -0,0 +1,202 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+	"github.com/Gu1llaum-3/sshm/internal/history"
+)
+
+func TestPingOrderPrioritisesVisibleRows(t *testing.T) {
+	m := createTestModel()
+
+	// The search hides server1, the cursor is on db-server
+	m.filteredHosts = m.hosts[1:]
+	m.updateTableRows()
+	m.table.SetHeight(2) // One row on screen
+	m.table.SetCursor(3)
+
+	var got []string
+	for _, host := range m.pingOrder() {
+		got = append(got, host.Name)
+	}
+
+	want := []string{"web-server", "db-server", "server2", "server3", "server1"}
+	if len(got) != len(want) {
+		t.Fatalf("pingOrder() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("pingOrder() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestPingStreamDoneEndsCurrentRun(t *testing.T) {
+	m := createTestModel()
+
+	cancelled := false
+	current := make(chan *connectivity.HostPingResult)
+	m.pingCancel = func() { cancelled = true }
+	m.pingResults = current
+
+	// A previous run ending does not end the current one
+	newModel, _ := m.Update(pingStreamMsg{results: make(chan *connectivity.HostPingResult), done: true})
+	m = newModel.(Model)
+	if cancelled || m.pingResults == nil {
+		t.Fatal("Expected the current run to keep going")
+	}
+
+	newModel, _ = m.Update(pingStreamMsg{results: current, done: true})
+	m = newModel.(Model)
+	if !cancelled || m.pingCancel != nil || m.pingResults != nil {
+		t.Error("Expected the current run to be released once done")
+	}
+}
+
+func TestStartPingAllCancelsPreviousRun(t *testing.T) {
+	m := createTestModel()
+	m.pingManager = connectivity.NewPingManager(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.pingCancel = cancel
+
+	if cmd := m.startPingAllCmd(); cmd == nil {
+		t.Fatal("Expected a command")
+	}
+	if ctx.Err() == nil {
+		t.Error("Expected the previous run to be cancelled")
+	}
+	m.cancelPings()
+}
+
+// loadTestPingResults loads check results into a ping manager through its cache file
+func loadTestPingResults(t *testing.T, pm *connectivity.PingManager, results string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ping_cache.json")
+	configFile := filepath.Join(dir, "config")
+	cache := fmt.Sprintf(`{"config_files": {%q: %s}}`, history.CanonicalConfigFile(configFile), results)
+	if err := os.WriteFile(path, []byte(cache), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := pm.LoadResults(path, configFile, 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testPingCache returns the cached results of a config file with an online result for each host,
+// checked at the given time with the given latency in milliseconds
+func testPingCache(checkedAt time.Time, latencies map[string]int) string {
+	var results []string
+	for name, latency := range latencies {
+		results = append(results, fmt.Sprintf(`{"host_name": %q, "kind": %d, "duration": %d, "checked_at": %q}`,
+			name, connectivity.KindAuthRequired, time.Duration(latency)*time.Millisecond, checkedAt.Format(time.RFC3339Nano)))
+	}
+	return `[` + strings.Join(results, ",") + `]`
+}
+
+func TestStaleHosts(t *testing.T) {
+	m := createTestModel()
+	m.pingManager = connectivity.NewPingManager(time.Second)
+	m.appConfig = &config.AppConfig{Connectivity: config.ConnectivityConfig{StaleAfter: "10m"}}
+
+	loadTestPingResults(t, m.pingManager, testPingCache(time.Now().Add(-time.Minute), map[string]int{"server1": 10}))
+	loadTestPingResults(t, m.pingManager, testPingCache(time.Now().Add(-time.Hour), map[string]int{"server2": 10}))
+
+	var got []string
+	for _, host := range m.staleHosts(m.hosts) {
+		got = append(got, host.Name)
+	}
+	if want := "server2 server3 web-server db-server"; strings.Join(got, " ") != want {
+		t.Errorf("staleHosts() = %v, want %s", got, want)
+	}
+
+	result, _ := m.pingManager.GetResult("server2")
+	if !m.isStale(result) {
+		t.Error("Expected the result of server2 to be stale")
+	}
+}
+
+func TestSortHostsByLatency(t *testing.T) {
+	m := createTestModel()
+	m.pingManager = connectivity.NewPingManager(time.Second)
+	loadTestPingResults(t, m.pingManager, testPingCache(time.Now(), map[string]int{"server3": 30, "web-server": 5}))
+
+	m.sortMode = SortByLatency
+	var got []string
+	for _, host := range m.sortHosts(m.hosts) {
+		got = append(got, host.Name)
+	}
+
+	if want := "web-server server3 db-server server1 server2"; strings.Join(got, " ") != want {
+		t.Errorf("sortHosts() = %v, want %s", got, want)
+	}
+}
+
+func TestAuthProbeShownInInfoView(t *testing.T) {
+	m := createTestModel()
+	m.infoForm = &infoFormModel{host: &m.hosts[0], hostName: "server1", styles: m.styles, authProbing: true}
+
+	// The outcome of a probe of another host is ignored
+	updated, _ := m.Update(authProbeMsg{result: &connectivity.AuthProbeResult{HostName: "server2", Status: connectivity.AuthPasswordOnly}})
+	m = updated.(Model)
+	if m.infoForm.auth != nil || !m.infoForm.authProbing {
+		t.Fatal("Expected the probe of another host to be ignored")
+	}
+
+	result := &connectivity.AuthProbeResult{HostName: "server1", Status: connectivity.AuthKeyAccepted, Methods: []string{"publickey"}, AcceptedKey: "~/.ssh/id_ed25519"}
+	updated, _ = m.Update(authProbeMsg{result: result})
+	m = updated.(Model)
+	if m.infoForm.auth != result || m.infoForm.authProbing {
+		t.Fatal("Expected the probe outcome to be shown")
+	}
+	if view := m.infoForm.renderAuth(); !strings.Contains(view, "key ok") || !strings.Contains(view, "~/.ssh/id_ed25519") {
+		t.Errorf("renderAuth() = %q, want the status and the accepted key", view)
+	}
+}
+
+func TestSessionsShownInInfoView(t *testing.T) {
+	m := createTestModel()
+	newTestHistoryManager(t)
+	m.configFile = filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(m.configFile, []byte("Host server1\n    HostName server1.example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	hm, err := history.NewHistoryManager(m.configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.historyManager = hm
+
+	// Sessions with the server1 of another config file are not shown
+	if err := hm.RecordSession(history.NewSession("server1", history.SessionInteractive, "")); err != nil {
+		t.Fatal(err)
+	}
+	for _, exitCode := range []int{0, 255} {
+		session := history.NewSession("server1", history.SessionCommand, m.configFile)
+		session.Command = "uptime"
+		session.ExitCode = exitCode
+		if err := m.historyManager.RecordSession(session); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	updated, _ := m.openInfoForm()
+	m = updated.(Model)
+	if m.infoForm == nil || len(m.infoForm.sessions) != 2 {
+		t.Fatalf("Expected the sessions of the host in the info view, got %+v", m.infoForm)
+	}
+	view := m.infoForm.renderSessions()
+	if !strings.Contains(view, "Sessions") || !strings.Contains(view, "exit 255") || !strings.Contains(view, "uptime") {
+		t.Errorf("renderSessions() = %q, want the sessions", view)
+	}
+}
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"github.com/Gu1llaum-3/sshm/internal/config"
 	"github.com/Gu1llaum-3/sshm/internal/connectivity"
 	"strings"
 	"time"
@@ -71,20 +72,47 @@ func formatConfigFile(filePath string) string {
 	return filePath
 }
 
-// getPingStatusIndicator returns a colored circle indicator based on ping status
+// getPingStatusIndicator returns an indicator of the ping status of a host.
+// Failed checks get an icon telling why the host could not be reached.
 func (m *Model) getPingStatusIndicator(hostName string) string {
 	if m.pingManager == nil {
 		return "⚫" // Gray circle for unknown
 	}
 
-	status := m.pingManager.GetStatus(hostName)
-	switch status {
-	case connectivity.StatusOnline:
-		return "🟢" // Green circle for online
-	case connectivity.StatusOffline:
-		return "🔴" // Red circle for offline
-	case connectivity.StatusConnecting:
+	result, ok := m.pingManager.GetResult(hostName)
+	if !ok {
+		return "⚫" // Gray circle for unknown
+	}
+	if result.Status == connectivity.StatusConnecting {
 		return "🟡" // Yellow circle for connecting
+	}
+	if result.Status == connectivity.StatusOnline && result.HostKeyStatus == connectivity.HostKeyUnknown {
+		return "🆕" // Reachable, but ssh will ask to trust its key
+	}
+	return pingKindIndicator(result.Kind)
+}
+
+// pingKindIndicator returns the icon of a connectivity check outcome
+func pingKindIndicator(kind connectivity.ResultKind) string {
+	switch kind {
+	case connectivity.KindSSHReady, connectivity.KindAuthRequired:
+		return "🟢" // Green circle for online
+	case connectivity.KindHostKeyChanged:
+		return "🔑" // The server answered with an unexpected key
+	case connectivity.KindHandshakeFailed:
+		return "🟠" // SSH server found but the key exchange failed
+	case connectivity.KindDNSFailure:
+		return "❓" // Unknown hostname
+	case connectivity.KindTCPRefused:
+		return "🔴" // Red circle for a closed port
+	case connectivity.KindTCPTimeout:
+		return "⌛" // No answer in time
+	case connectivity.KindNotSSH:
+		return "🚫" // Something else than SSH listens on the port
+	case connectivity.KindProxyFailed:
+		return "🔗" // Broken jump host or ProxyCommand
+	case connectivity.KindUnreachable:
+		return "⛔" // Network unreachable
 	default:
 		return "⚫" // Gray circle for unknown
 	}
@@ -103,3 +131,52 @@ func extractHostNameFromTableRow(firstColumn string) string {
 	// Fallback: if there's no space, return the whole string
 	return firstColumn
 }
+
+// formatAge formats the age of a result compactly, e.g. "45s", "12m", "3h" or "2d"
+func formatAge(t time.Time) string {
+	age := time.Since(t)
+	switch {
+	case age < time.Minute:
+		return fmt.Sprintf("%ds", int(age.Seconds()))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(age.Hours()/24))
+	}
+}
+
+// isStale reports whether a check result is older than the configured
+// stale_after age, and is shown dimmed with its age
+func (m *Model) isStale(result *connectivity.HostPingResult) bool {
+	if result == nil || result.CheckedAt.IsZero() {
+		return false
+	}
+	staleAfter := config.GetDefaultConnectivityConfig().StaleAfterDuration()
+	if m.appConfig != nil {
+		staleAfter = m.appConfig.Connectivity.StaleAfterDuration()
+	}
+	return time.Since(result.CheckedAt) > staleAfter
+}
+
+// formatHostKey formats the type, fingerprint and status of the host key
+// presented during a connectivity check
+func formatHostKey(result *connectivity.HostPingResult) string {
+	if result.HostKeyStatus == connectivity.HostKeyUnchecked {
+		return "Not checked"
+	}
+	return fmt.Sprintf("%s %s (%s)", result.HostKeyType, result.HostKeyFingerprint, result.HostKeyStatus)
+}
+
+// truncate shortens value to width characters, ending it with "…" if it was cut
+func truncate(value string, width int) string {
+	runes := []rune(value)
+	if len(runes) <= width {
+		return value
+	}
+	if width <= 1 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-1]) + "…"
+}
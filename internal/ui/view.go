@@ -43,6 +43,22 @@ func (m Model) View() string {
 		if m.fileSelectorForm != nil {
 			return m.fileSelectorForm.View()
 		}
+	case ViewColumns:
+		if m.columnsForm != nil {
+			return m.columnsForm.View()
+		}
+	case ViewPalette:
+		if m.palette != nil {
+			return m.palette.View()
+		}
+	case ViewCommand:
+		if m.commandForm != nil {
+			return m.commandForm.View()
+		}
+	case ViewTunnels:
+		if m.tunnelsForm != nil {
+			return m.tunnelsForm.View()
+		}
 	case ViewList:
 		return m.renderListView()
 	}
@@ -64,26 +80,17 @@ func (m Model) renderListView() string {
 			m.updateInfo.CurrentVer,
 			m.updateInfo.LatestVer)
 
-		updateStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("10")). // Green color
-			Bold(true).
-			Align(lipgloss.Center) // Center the notification
-
-		components = append(components, updateStyle.Render(updateText))
+		components = append(components, m.styles.UpdateBanner.Render(updateText))
 	}
 
 	// Add error message if there's one to show
 	if m.showingError && m.errorMessage != "" {
-		errorStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("9")). // Red color
-			Background(lipgloss.Color("1")). // Dark red background
-			Bold(true).
-			Padding(0, 1).
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("9")).
-			Align(lipgloss.Center)
+		components = append(components, m.styles.ErrorBanner.Render("❌ "+m.errorMessage))
+	}
 
-		components = append(components, errorStyle.Render("❌ "+m.errorMessage))
+	// Add the alert flashed by the health monitor
+	if m.alertMessage != "" {
+		components = append(components, m.styles.UpdateBanner.Render(m.alertMessage))
 	}
 
 	// Add the search bar with the appropriate style based on focus
@@ -95,12 +102,35 @@ func (m Model) renderListView() string {
 	}
 
 	// Add the table with the appropriate style based on focus
+	var tableView string
 	if m.searchMode {
 		// The table is not focused, use the unfocused style
-		components = append(components, m.styles.TableUnfocused.Render(m.table.View()))
+		tableView = m.styles.TableUnfocused.Render(m.table.View())
 	} else {
 		// The table is focused, use the focused style with the primary color
-		components = append(components, m.styles.TableFocused.Render(m.table.View()))
+		tableView = m.styles.TableFocused.Render(m.table.View())
+	}
+
+	// Add the preview pane next to the table, using the space the table leaves
+	if m.previewVisible() {
+		// App padding (2) and the gap between the table and the preview (1)
+		previewWidth := m.width - lipgloss.Width(tableView) - 3
+		if previewWidth > m.previewWidth() {
+			previewWidth = m.previewWidth()
+		}
+		if previewWidth >= previewMinWidth/2 {
+			tableView = lipgloss.JoinHorizontal(lipgloss.Top,
+				tableView,
+				" ",
+				m.renderPreview(previewWidth, lipgloss.Height(tableView)),
+			)
+		}
+	}
+	components = append(components, tableView)
+
+	// Add the outcome of the last session
+	if m.lastSession != nil {
+		components = append(components, m.renderSessionStatus())
 	}
 
 	// Add the help text
@@ -153,10 +183,10 @@ func (m Model) renderDeleteConfirmation() string {
 	help := "Enter: confirm • Esc: cancel"
 
 	// Individual styles (do not affect width via internal centering)
-	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("196"))
+	titleStyle := m.styles.DangerTitle
 	questionStyle := lipgloss.NewStyle()
-	actionStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
-	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	actionStyle := m.styles.DangerText
+	helpStyle := m.styles.HelpText
 
 	lines := []string{
 		titleStyle.Render(title),
@@ -185,9 +215,7 @@ func (m Model) renderDeleteConfirmation() string {
 	raw := strings.Join(lines, "\n")
 
 	// Container style: wider horizontal padding, stable border
-	box := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("196")).
+	box := m.styles.DangerBorder.
 		PaddingTop(1).PaddingBottom(1).PaddingLeft(2).PaddingRight(2).
 		Width(maxw + 4) // +4 = internal margin (2 spaces of left/right padding)
 
@@ -211,12 +239,10 @@ func (m Model) renderUpdateNotification() string {
 	}
 
 	// Style the notification with a bright color to make it stand out
-	notificationStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#00FF00")). // Bright green
-		Bold(true).
+	notificationStyle := m.styles.UpdateBanner.
 		Padding(0, 1).
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("#00AA00")) // Darker green border
+		BorderForeground(CurrentTheme().Success)
 
 	return notificationStyle.Render(message)
 }